@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"temp-mailbox-service/internal/application"
+	"temp-mailbox-service/internal/infrastructure/auth"
+	"temp-mailbox-service/internal/infrastructure/config"
+	"temp-mailbox-service/internal/infrastructure/database"
+	"temp-mailbox-service/internal/infrastructure/mailer"
+	"temp-mailbox-service/internal/infrastructure/persistence"
+)
+
+// 创建初始管理员，或将已注册的用户提升为管理员
+//
+//	go run ./cmd/admin -email admin@example.com -username admin -password 'secret123'
+func main() {
+	configFile := flag.String("config", ".env.dev", "配置文件路径")
+	email := flag.String("email", "", "管理员邮箱（必填）")
+	username := flag.String("username", "admin", "新建管理员时使用的用户名")
+	password := flag.String("password", "", "新建管理员时使用的密码")
+	flag.Parse()
+
+	if *email == "" {
+		flag.Usage()
+		log.Fatal("必须指定 -email")
+	}
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		log.Fatal("加载配置失败:", err)
+	}
+
+	if err := database.InitDatabase(&cfg.Database); err != nil {
+		log.Fatal("初始化数据库失败:", err)
+	}
+	defer database.CloseDatabase()
+
+	if err := database.Migrate(); err != nil {
+		log.Fatal("数据库迁移失败:", err)
+	}
+
+	jwtService := auth.NewJWTService(
+		cfg.JWT.Secret,
+		cfg.JWT.AccessTokenTTL,
+		cfg.JWT.RefreshTokenTTL,
+		cfg.JWT.Issuer,
+	)
+	userRepo := persistence.NewUserRepository()
+	revocationService := application.NewRevocationService(
+		persistence.NewRevocationRepository(),
+		jwtService.AccessTokenTTL(),
+		0,
+	)
+	sessionService := application.NewSessionService(persistence.NewSessionRepository(), userRepo, jwtService, revocationService)
+	mailSender, err := mailer.NewSender(&cfg.Mailer)
+	if err != nil {
+		log.Fatal("初始化邮件发送失败:", err)
+	}
+	lockoutService := application.NewLockoutService(persistence.NewLockoutRepository(), &cfg.Auth.Lockout)
+	mfaService := application.NewMFAService(userRepo, auth.NewSecretBox(cfg.JWT.Secret, "totp"), auth.NewSigner(cfg.JWT.Secret, "mfa-pending"), &cfg.Auth.MFA)
+	userService := application.NewUserService(userRepo, sessionService, lockoutService, mfaService, mailSender, auth.NewSigner(cfg.JWT.Secret, "email-verification"), &cfg.Auth)
+	adminService := application.NewAdminService(userRepo, userService, sessionService)
+
+	adminUser, created, err := adminService.BootstrapAdmin(context.Background(), *email, *username, *password)
+	if err != nil {
+		log.Fatal("初始化管理员失败:", err)
+	}
+
+	if created {
+		fmt.Printf("已创建管理员 %s (%s)\n", adminUser.Username, adminUser.Email)
+	} else {
+		fmt.Printf("已将用户 %s (%s) 设为管理员\n", adminUser.Username, adminUser.Email)
+	}
+}
@@ -1,17 +1,31 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"temp-mailbox-service/internal/api"
 	"temp-mailbox-service/internal/application"
+	"temp-mailbox-service/internal/domain/apikey"
 	"temp-mailbox-service/internal/infrastructure/auth"
 	"temp-mailbox-service/internal/infrastructure/config"
 	"temp-mailbox-service/internal/infrastructure/database"
+	"temp-mailbox-service/internal/infrastructure/dns"
+	"temp-mailbox-service/internal/infrastructure/mailauth"
+	"temp-mailbox-service/internal/infrastructure/mailer"
 	"temp-mailbox-service/internal/infrastructure/middleware"
 	"temp-mailbox-service/internal/infrastructure/persistence"
+	"temp-mailbox-service/internal/infrastructure/ratelimit"
+	"temp-mailbox-service/internal/infrastructure/scheduler"
+	"temp-mailbox-service/internal/infrastructure/smtp"
+	"temp-mailbox-service/internal/infrastructure/spam"
+	"temp-mailbox-service/internal/infrastructure/storage"
 
 	"github.com/gin-gonic/gin"
 )
@@ -54,16 +68,140 @@ func main() {
 	)
 
 	userRepo := persistence.NewUserRepository()
-	userService := application.NewUserService(userRepo, jwtService)
+	sessionRepo := persistence.NewSessionRepository()
+	revocationService := application.NewRevocationService(
+		persistence.NewRevocationRepository(),
+		jwtService.AccessTokenTTL(),
+		time.Duration(cfg.JWT.RevocationSync)*time.Second,
+	)
+	sessionService := application.NewSessionService(sessionRepo, userRepo, jwtService, revocationService)
+	// 初始化外发邮件
+	mailSender, err := mailer.NewSender(&cfg.Mailer)
+	if err != nil {
+		log.Fatal("初始化邮件发送失败:", err)
+	}
+	emailVerifier := auth.NewSigner(cfg.JWT.Secret, "email-verification")
+	lockoutService := application.NewLockoutService(persistence.NewLockoutRepository(), &cfg.Auth.Lockout)
+	mfaService := application.NewMFAService(userRepo, auth.NewSecretBox(cfg.JWT.Secret, "totp"), auth.NewSigner(cfg.JWT.Secret, "mfa-pending"), &cfg.Auth.MFA)
+	userService := application.NewUserService(userRepo, sessionService, lockoutService, mfaService, mailSender, emailVerifier, &cfg.Auth)
+	adminService := application.NewAdminService(userRepo, userService, sessionService)
+	apiKeyService := application.NewAPIKeyService(persistence.NewAPIKeyRepository(), userRepo)
+	oidcService := application.NewOIDCService(userRepo, userService, auth.NewSecretBox(cfg.JWT.Secret, "oidc-state"), &cfg.Auth.OIDC)
+
+	// 创建或提升配置中的初始管理员
+	if cfg.Auth.Admin.Email != "" {
+		adminUser, created, err := adminService.BootstrapAdmin(context.Background(), cfg.Auth.Admin.Email, cfg.Auth.Admin.Username, cfg.Auth.Admin.Password)
+		if err != nil {
+			log.Fatal("初始化管理员失败:", err)
+		}
+		if created {
+			fmt.Printf("已创建初始管理员 %s\n", adminUser.Email)
+		}
+	}
+
+	mailboxRepo := persistence.NewMailboxRepository()
+	domainRepo := persistence.NewDomainRepository()
+	dnsProvider, err := dns.NewDNSProvider(&cfg.DNS)
+	if err != nil {
+		log.Fatal("初始化DNS服务商失败:", err)
+	}
+	domainService := application.NewDomainService(domainRepo, mailboxRepo, userRepo, dns.NewResolver(), dnsProvider, &cfg.DNS, cfg.SMTP.Hostname)
+	mailboxService := application.NewMailboxService(mailboxRepo, domainRepo, userRepo, &cfg.Mailbox)
+
+	// 登记配置中的系统域名
+	if err := domainService.EnsureSystemDomains(context.Background(), cfg.Mailbox.Domains); err != nil {
+		log.Fatal("登记系统域名失败:", err)
+	}
+
+	// 初始化附件对象存储
+	blobStore, err := storage.NewBlobStore(&cfg.Storage)
+	if err != nil {
+		log.Fatal("初始化对象存储失败:", err)
+	}
+
+	// 收信来源验证（SPF/DKIM/DMARC）
+	var authVerifier *mailauth.Verifier
+	if cfg.SMTP.AuthCheck {
+		authVerifier = mailauth.NewVerifier(dns.NewResolver(), time.Duration(cfg.SMTP.AuthTimeout)*time.Second)
+	}
+
+	// 收信垃圾邮件评分
+	var spamEngine *spam.Engine
+	if cfg.Spam.Enabled {
+		rules, err := spam.LoadRules(cfg.Spam.RulesFile)
+		if err != nil {
+			log.Fatal("加载垃圾邮件规则失败:", err)
+		}
+		spamEngine, err = spam.NewEngine(rules, dns.NewResolver(), time.Duration(cfg.Spam.Timeout)*time.Second)
+		if err != nil {
+			log.Fatal("初始化垃圾邮件评分失败:", err)
+		}
+	}
+
+	messageRepo := persistence.NewMessageRepository()
+	filterRepo := persistence.NewMailFilterRepository()
+	filterService := application.NewFilterService(mailboxRepo, messageRepo, filterRepo, &cfg.Sieve)
+	// 未开启 sieve.allow_redirect 时不传入转发器，已保存的 redirect 脚本会改为保存到收件箱
+	var forwarder mailer.Sender
+	if cfg.Sieve.AllowRedirect {
+		forwarder = mailSender
+	}
+	deliveryService := application.NewDeliveryService(mailboxRepo, messageRepo, domainRepo, blobStore, authVerifier, spamEngine, filterService, forwarder)
+	messageService := application.NewMessageService(mailboxRepo, messageRepo, blobStore, filterService)
+
+	// 注册后台任务
+	jobRepo := persistence.NewJobRepository()
+	jobScheduler := scheduler.New(application.NewJobRecorder(jobRepo))
+	maintenanceService := application.NewMaintenanceService(mailboxRepo, messageRepo, filterRepo, jobRepo, sessionRepo, revocationService, lockoutService, domainService, blobStore, &cfg.Scheduler)
+	if err := application.RegisterMaintenanceJobs(jobScheduler, maintenanceService, &cfg.Scheduler); err != nil {
+		log.Fatal("注册后台任务失败:", err)
+	}
+	jobService := application.NewJobService(jobRepo, jobScheduler)
+
+	// 启动SMTP接收服务
+	if cfg.SMTP.Enabled {
+		smtpServer := smtp.NewServer(&cfg.SMTP, deliveryService)
+		defer smtpServer.Close()
+		go func() {
+			fmt.Printf("SMTP服务启动在地址 %s\n", cfg.GetSMTPAddress())
+			if err := smtpServer.ListenAndServe(cfg.GetSMTPAddress()); err != nil && err != smtp.ErrServerClosed {
+				log.Fatal("SMTP服务启动失败:", err)
+			}
+		}()
+	}
 
 	// 创建API处理器
 	userHandler := api.NewUserHandler(userService)
+	mailboxHandler := api.NewMailboxHandler(mailboxService)
+	messageHandler := api.NewMessageHandler(messageService)
+	filterHandler := api.NewFilterHandler(filterService)
+	domainHandler := api.NewDomainHandler(domainService)
+	jobHandler := api.NewJobHandler(jobService)
+	adminHandler := api.NewAdminHandler(adminService)
+	lockoutHandler := api.NewLockoutHandler(lockoutService)
+	mfaHandler := api.NewMFAHandler(mfaService)
+	apiKeyHandler := api.NewAPIKeyHandler(apiKeyService)
+	sessionHandler := api.NewSessionHandler(sessionService)
+	oidcHandler := api.NewOIDCHandler(oidcService, cfg.GetOIDCCallbackURL())
+
+	// 接口限流，认证路由组挂在认证中间件之后以便按用户限流
+	var rateLimit []gin.HandlerFunc
+	if cfg.RateLimit.Enabled {
+		rateLimitStore, err := ratelimit.NewStore(&cfg.RateLimit)
+		if err != nil {
+			log.Fatal("初始化限流存储失败:", err)
+		}
+		rateLimit = append(rateLimit, middleware.RateLimiterMiddleware(rateLimitStore, ratelimit.NewRules(&cfg.RateLimit)))
+	}
 
 	// 设置Gin模式
 	gin.SetMode(cfg.Server.Mode)
 
 	// 创建Gin路由器
 	r := gin.Default()
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatal("配置可信代理失败:", err)
+	}
 
 	// 全局中间件
 	r.Use(middleware.CORS())
@@ -83,15 +221,59 @@ func main() {
 	api := r.Group("/api")
 	{
 		// 公开的认证路由
-		userHandler.RegisterRoutes(api)
+		userHandler.RegisterRoutes(api.Group("", rateLimit...))
+		oidcHandler.RegisterRoutes(api.Group("/auth/oidc", rateLimit...))
+
+		// 需要认证的认证路由
+		authRequired := api.Group("/auth")
+		authRequired.Use(middleware.JWTAuth(jwtService, revocationService))
+		authRequired.Use(rateLimit...)
+		{
+			authRequired.POST("/logout", userHandler.Logout)
+			authRequired.POST("/logout-all", userHandler.LogoutAll)
+		}
 
 		// 需要认证的用户路由
 		userAuth := api.Group("/user")
-		userAuth.Use(middleware.JWTAuth(jwtService))
+		userAuth.Use(middleware.JWTAuth(jwtService, revocationService))
+		userAuth.Use(rateLimit...)
 		{
 			userAuth.GET("/profile", userHandler.GetProfile)
 			userAuth.PUT("/profile", userHandler.UpdateProfile)
 			userAuth.POST("/change-password", userHandler.ChangePassword)
+			mfaHandler.RegisterRoutes(userAuth.Group("/mfa"))
+			apiKeyHandler.RegisterRoutes(userAuth.Group("/api-keys"))
+			sessionHandler.RegisterRoutes(userAuth.Group("/sessions"))
+		}
+
+		// 需要认证的临时邮箱路由，同时接受API密钥
+		mailboxAuth := api.Group("/mailboxes")
+		mailboxAuth.Use(middleware.JWTOrAPIKeyAuth(jwtService, revocationService, apiKeyService))
+		mailboxAuth.Use(middleware.RequireAPIKeyScope(apikey.ScopeMailboxRead, apikey.ScopeMailboxWrite))
+		mailboxAuth.Use(rateLimit...)
+		{
+			mailboxHandler.RegisterRoutes(mailboxAuth)
+			messageHandler.RegisterRoutes(mailboxAuth)
+			filterHandler.RegisterRoutes(mailboxAuth)
+		}
+
+		// 需要认证的域名管理路由：用户添加并验证的域名只能由本人创建邮箱，
+		// 所有用户共享的系统域名只能通过配置文件 mailbox.domains 登记
+		domainAuth := api.Group("/domains")
+		domainAuth.Use(middleware.JWTAuth(jwtService, revocationService))
+		domainAuth.Use(rateLimit...)
+		{
+			domainHandler.RegisterRoutes(domainAuth)
+		}
+
+		// 管理员路由
+		admin := api.Group("/admin")
+		admin.Use(middleware.JWTAuth(jwtService, revocationService), middleware.AdminMiddleware())
+		admin.Use(rateLimit...)
+		{
+			jobHandler.RegisterRoutes(admin.Group("/jobs"))
+			adminHandler.RegisterRoutes(admin.Group("/users"))
+			lockoutHandler.RegisterRoutes(admin.Group("/lockouts"))
 		}
 
 		// 测试端点
@@ -102,10 +284,39 @@ func main() {
 		})
 	}
 
+	// 启动后台任务调度
+	if cfg.Scheduler.Enabled {
+		jobScheduler.Start()
+	}
+
 	// 启动服务器
 	addr := cfg.GetServerAddress()
-	fmt.Printf("服务器启动在地址 %s\n", addr)
-	if err := r.Run(addr); err != nil {
-		log.Fatal("服务器启动失败:", err)
+	srv := &http.Server{
+		Addr:         addr,
+		Handler:      r,
+		ReadTimeout:  time.Duration(cfg.Server.ReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(cfg.Server.WriteTimeout) * time.Second,
+	}
+	go func() {
+		fmt.Printf("服务器启动在地址 %s\n", addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("服务器启动失败:", err)
+		}
+	}()
+
+	// 等待退出信号后优雅关闭
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+	fmt.Println("正在关闭服务...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownTimeout)*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Println("HTTP服务关闭失败:", err)
+	}
+	if err := jobScheduler.Stop(shutdownCtx); err != nil {
+		log.Println("后台任务停止失败:", err)
 	}
+	fmt.Println("服务已关闭")
 } 
\ No newline at end of file
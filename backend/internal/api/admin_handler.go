@@ -0,0 +1,324 @@
+package api
+
+import (
+	"net/http"
+
+	"temp-mailbox-service/internal/application"
+	"temp-mailbox-service/internal/domain/user"
+	"temp-mailbox-service/internal/infrastructure/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// AdminHandler 用户管理处理器
+type AdminHandler struct {
+	adminService application.AdminService
+	validator    *validator.Validate
+}
+
+// NewAdminHandler 创建用户管理处理器实例
+func NewAdminHandler(adminService application.AdminService) *AdminHandler {
+	return &AdminHandler{
+		adminService: adminService,
+		validator:    validator.New(),
+	}
+}
+
+// RegisterRoutes 注册路由（调用方负责挂载认证和管理员中间件）
+func (h *AdminHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("", h.ListUsers)
+	r.GET("/:id", h.GetUser)
+	r.PUT("/:id/role", h.UpdateUserRole)
+	r.POST("/:id/activate", h.ActivateUser)
+	r.POST("/:id/deactivate", h.DeactivateUser)
+	r.POST("/:id/reset-password", h.ForcePasswordReset)
+	r.DELETE("/:id", h.DeleteUser)
+	r.POST("/:id/restore", h.RestoreUser)
+}
+
+// ListUsers 搜索用户列表，支持 q（邮箱/用户名）、role、status 筛选
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	page, pageSize, ok := parsePagination(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    6301,
+			"message": "分页参数错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	filter := &user.SearchFilter{
+		Keyword: c.Query("q"),
+		Role:    c.Query("role"),
+		Status:  c.Query("status"),
+	}
+
+	listResp, err := h.adminService.ListUsers(c.Request.Context(), filter, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    6302,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "获取用户列表成功",
+		"data":    listResp,
+	})
+}
+
+// GetUser 获取用户详情
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    6401,
+			"message": "用户ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	userResp, err := h.adminService.GetUser(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    6402,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "获取用户详情成功",
+		"data":    userResp,
+	})
+}
+
+// UpdateUserRole 修改用户角色
+func (h *AdminHandler) UpdateUserRole(c *gin.Context) {
+	operatorID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    6201,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    6202,
+			"message": "用户ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	var req user.UpdateRoleRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    6203,
+			"message": "请求参数格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    6204,
+			"message": "请求参数验证失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	userResp, err := h.adminService.UpdateUserRole(c.Request.Context(), operatorID, userID, &req)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    6205,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "修改用户角色成功",
+		"data":    userResp,
+	})
+}
+
+// ActivateUser 激活用户
+func (h *AdminHandler) ActivateUser(c *gin.Context) {
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    6501,
+			"message": "用户ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	userResp, err := h.adminService.ActivateUser(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    6502,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "激活用户成功",
+		"data":    userResp,
+	})
+}
+
+// DeactivateUser 停用用户
+func (h *AdminHandler) DeactivateUser(c *gin.Context) {
+	operatorID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    6601,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    6602,
+			"message": "用户ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	userResp, err := h.adminService.DeactivateUser(c.Request.Context(), operatorID, userID)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    6603,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "停用用户成功",
+		"data":    userResp,
+	})
+}
+
+// ForcePasswordReset 强制用户重置密码
+func (h *AdminHandler) ForcePasswordReset(c *gin.Context) {
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    6701,
+			"message": "用户ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	if err := h.adminService.ForcePasswordReset(c.Request.Context(), userID); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    6702,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "已向用户发送密码重置邮件",
+		"data":    nil,
+	})
+}
+
+// DeleteUser 删除用户（软删除）
+func (h *AdminHandler) DeleteUser(c *gin.Context) {
+	operatorID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    6801,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    6802,
+			"message": "用户ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	if err := h.adminService.DeleteUser(c.Request.Context(), operatorID, userID); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    6803,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "删除用户成功",
+		"data":    nil,
+	})
+}
+
+// RestoreUser 恢复已删除的用户
+func (h *AdminHandler) RestoreUser(c *gin.Context) {
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    6901,
+			"message": "用户ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	userResp, err := h.adminService.RestoreUser(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    6902,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "恢复用户成功",
+		"data":    userResp,
+	})
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"net/http"
+
+	"temp-mailbox-service/internal/application"
+	"temp-mailbox-service/internal/domain/apikey"
+	"temp-mailbox-service/internal/infrastructure/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// APIKeyHandler API密钥处理器
+type APIKeyHandler struct {
+	apiKeyService application.APIKeyService
+	validator     *validator.Validate
+}
+
+// NewAPIKeyHandler 创建API密钥处理器实例
+func NewAPIKeyHandler(apiKeyService application.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+		validator:     validator.New(),
+	}
+}
+
+// RegisterRoutes 注册路由（调用方负责挂载认证中间件）
+func (h *APIKeyHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("", h.ListKeys)
+	r.POST("", h.CreateKey)
+	r.DELETE("/:id", h.RevokeKey)
+}
+
+// ListKeys 获取当前用户的API密钥
+func (h *APIKeyHandler) ListKeys(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    7701,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	keys, err := h.apiKeyService.ListKeys(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    7702,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "获取API密钥列表成功",
+		"data":    keys,
+	})
+}
+
+// CreateKey 创建API密钥
+func (h *APIKeyHandler) CreateKey(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    7801,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	var req apikey.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    7802,
+			"message": "请求参数格式错误",
+			"data":    nil,
+		})
+		return
+	}
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    7803,
+			"message": "请求参数验证失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	created, err := h.apiKeyService.CreateKey(c.Request.Context(), userID, &req)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    7804,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "API密钥创建成功，请立即保存，密钥不会再次显示",
+		"data":    created,
+	})
+}
+
+// RevokeKey 吊销API密钥
+func (h *APIKeyHandler) RevokeKey(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    7901,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    7902,
+			"message": "API密钥ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	if err := h.apiKeyService.RevokeKey(c.Request.Context(), userID, id); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    7903,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "API密钥已吊销",
+		"data":    nil,
+	})
+}
@@ -0,0 +1,389 @@
+package api
+
+import (
+	"net/http"
+
+	"temp-mailbox-service/internal/application"
+	"temp-mailbox-service/internal/domain/maildomain"
+	"temp-mailbox-service/internal/infrastructure/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// DomainHandler 托管域名处理器
+type DomainHandler struct {
+	domainService application.DomainService
+	validator     *validator.Validate
+}
+
+// NewDomainHandler 创建托管域名处理器实例
+func NewDomainHandler(domainService application.DomainService) *DomainHandler {
+	return &DomainHandler{
+		domainService: domainService,
+		validator:     validator.New(),
+	}
+}
+
+// RegisterRoutes 注册路由（调用方负责挂载认证中间件）
+func (h *DomainHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST("", h.AddDomain)
+	r.GET("", h.ListDomains)
+	r.GET("/available", h.ListAvailableDomains)
+	r.GET("/:id", h.GetDomain)
+	r.POST("/:id/verify", h.VerifyDomain)
+	r.POST("/:id/disable", h.DisableDomain)
+	r.POST("/:id/enable", h.EnableDomain)
+	r.POST("/:id/dns", h.ProvisionDNS)
+	r.DELETE("/:id", h.DeleteDomain)
+}
+
+// AddDomain 添加域名
+func (h *DomainHandler) AddDomain(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    4001,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	var req maildomain.CreateDomainRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    4002,
+			"message": "请求参数格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    4003,
+			"message": "请求参数验证失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	domainResp, err := h.domainService.AddDomain(c.Request.Context(), userID, &req)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    4004,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "添加域名成功，请按提示配置DNS记录后进行验证",
+		"data":    domainResp,
+	})
+}
+
+// ListDomains 获取域名列表
+func (h *DomainHandler) ListDomains(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    4101,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	page, pageSize, ok := parsePagination(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    4102,
+			"message": "分页参数错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	listResp, err := h.domainService.ListDomains(c.Request.Context(), userID, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    4103,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "获取域名列表成功",
+		"data":    listResp,
+	})
+}
+
+// ListAvailableDomains 获取当前用户可用于创建邮箱的域名
+func (h *DomainHandler) ListAvailableDomains(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    4201,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	names, err := h.domainService.ListAvailableDomains(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    4202,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "获取可用域名成功",
+		"data":    names,
+	})
+}
+
+// GetDomain 获取域名详情
+func (h *DomainHandler) GetDomain(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    4301,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	domainID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    4302,
+			"message": "域名ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	domainResp, err := h.domainService.GetDomain(c.Request.Context(), userID, domainID)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    4303,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "获取域名详情成功",
+		"data":    domainResp,
+	})
+}
+
+// VerifyDomain 验证域名的DNS记录
+func (h *DomainHandler) VerifyDomain(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    4401,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	domainID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    4402,
+			"message": "域名ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	domainResp, err := h.domainService.VerifyDomain(c.Request.Context(), userID, domainID)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    4403,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "域名验证成功",
+		"data":    domainResp,
+	})
+}
+
+// DisableDomain 停用域名
+func (h *DomainHandler) DisableDomain(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    4501,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	domainID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    4502,
+			"message": "域名ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	domainResp, err := h.domainService.DisableDomain(c.Request.Context(), userID, domainID)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    4503,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "停用域名成功",
+		"data":    domainResp,
+	})
+}
+
+// EnableDomain 启用域名
+func (h *DomainHandler) EnableDomain(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    4601,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	domainID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    4602,
+			"message": "域名ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	domainResp, err := h.domainService.EnableDomain(c.Request.Context(), userID, domainID)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    4603,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "启用域名成功",
+		"data":    domainResp,
+	})
+}
+
+// ProvisionDNS 通过DNS服务商自动配置域名记录
+func (h *DomainHandler) ProvisionDNS(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    4801,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	domainID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    4802,
+			"message": "域名ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	domainResp, err := h.domainService.ProvisionDNS(c.Request.Context(), userID, domainID)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    4803,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "配置DNS记录成功",
+		"data":    domainResp,
+	})
+}
+
+// DeleteDomain 删除域名
+func (h *DomainHandler) DeleteDomain(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    4701,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	domainID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    4702,
+			"message": "域名ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	if err := h.domainService.DeleteDomain(c.Request.Context(), userID, domainID); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    4703,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "删除域名成功",
+		"data":    nil,
+	})
+}
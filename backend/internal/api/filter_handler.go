@@ -0,0 +1,472 @@
+package api
+
+import (
+	"net/http"
+
+	"temp-mailbox-service/internal/application"
+	"temp-mailbox-service/internal/domain/mailfilter"
+	"temp-mailbox-service/internal/infrastructure/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// FilterHandler 邮箱过滤规则和 Sieve 脚本处理器
+type FilterHandler struct {
+	filterService application.FilterService
+	validator     *validator.Validate
+}
+
+// NewFilterHandler 创建邮箱过滤规则处理器实例
+func NewFilterHandler(filterService application.FilterService) *FilterHandler {
+	return &FilterHandler{
+		filterService: filterService,
+		validator:     validator.New(),
+	}
+}
+
+// RegisterRoutes 注册路由（挂载在 /api/mailboxes 分组下，调用方负责挂载认证中间件）
+func (h *FilterHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/:id/rules", h.ListRules)
+	r.POST("/:id/rules", h.CreateRule)
+	r.GET("/:id/rules/decisions", h.ListDecisions)
+	r.PUT("/:id/rules/:rid", h.UpdateRule)
+	r.DELETE("/:id/rules/:rid", h.DeleteRule)
+	r.GET("/:id/sieve", h.GetScript)
+	r.PUT("/:id/sieve", h.SaveScript)
+	r.DELETE("/:id/sieve", h.DeleteScript)
+	r.POST("/:id/sieve/dry-run", h.DryRunScript)
+}
+
+// ListRules 获取邮箱的过滤规则
+func (h *FilterHandler) ListRules(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    8401,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	mailboxID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    8402,
+			"message": "邮箱ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	rules, err := h.filterService.ListRules(c.Request.Context(), userID, mailboxID)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    8403,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "获取过滤规则成功",
+		"data":    rules,
+	})
+}
+
+// CreateRule 添加过滤规则
+func (h *FilterHandler) CreateRule(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    8501,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	mailboxID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    8502,
+			"message": "邮箱ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	var req mailfilter.RuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    8503,
+			"message": "请求参数格式错误",
+			"data":    nil,
+		})
+		return
+	}
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    8504,
+			"message": "请求参数验证失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	rule, err := h.filterService.CreateRule(c.Request.Context(), userID, mailboxID, &req)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    8505,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "过滤规则创建成功",
+		"data":    rule,
+	})
+}
+
+// UpdateRule 更新过滤规则
+func (h *FilterHandler) UpdateRule(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    8601,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	mailboxID, ok := parseIDParam(c, "id")
+	ruleID, rok := parseIDParam(c, "rid")
+	if !ok || !rok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    8602,
+			"message": "邮箱或规则ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	var req mailfilter.RuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    8603,
+			"message": "请求参数格式错误",
+			"data":    nil,
+		})
+		return
+	}
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    8604,
+			"message": "请求参数验证失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	rule, err := h.filterService.UpdateRule(c.Request.Context(), userID, mailboxID, ruleID, &req)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    8605,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "过滤规则更新成功",
+		"data":    rule,
+	})
+}
+
+// DeleteRule 删除过滤规则
+func (h *FilterHandler) DeleteRule(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    8701,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	mailboxID, ok := parseIDParam(c, "id")
+	ruleID, rok := parseIDParam(c, "rid")
+	if !ok || !rok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    8702,
+			"message": "邮箱或规则ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	if err := h.filterService.DeleteRule(c.Request.Context(), userID, mailboxID, ruleID); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    8703,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "过滤规则已删除",
+		"data":    nil,
+	})
+}
+
+// ListDecisions 获取邮箱的过滤记录，说明哪些邮件被拒收、丢弃或查看后删除
+func (h *FilterHandler) ListDecisions(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    8801,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	mailboxID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    8802,
+			"message": "邮箱ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	page, pageSize, ok := parsePagination(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    8803,
+			"message": "分页参数错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	decisions, err := h.filterService.ListDecisions(c.Request.Context(), userID, mailboxID, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    8804,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "获取过滤记录成功",
+		"data":    decisions,
+	})
+}
+
+// GetScript 获取邮箱的 Sieve 脚本，未设置时 data 为 null
+func (h *FilterHandler) GetScript(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    8901,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	mailboxID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    8902,
+			"message": "邮箱ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	script, err := h.filterService.GetScript(c.Request.Context(), userID, mailboxID)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    8903,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "获取Sieve脚本成功",
+		"data":    script,
+	})
+}
+
+// SaveScript 上传 Sieve 脚本，脚本无效时返回包含行号的错误信息
+func (h *FilterHandler) SaveScript(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    9001,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	mailboxID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    9002,
+			"message": "邮箱ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	var req mailfilter.ScriptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    9003,
+			"message": "请求参数格式错误",
+			"data":    nil,
+		})
+		return
+	}
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    9004,
+			"message": "请求参数验证失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	script, err := h.filterService.SaveScript(c.Request.Context(), userID, mailboxID, &req)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    9005,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "Sieve脚本保存成功",
+		"data":    script,
+	})
+}
+
+// DeleteScript 删除邮箱的 Sieve 脚本
+func (h *FilterHandler) DeleteScript(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    9101,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	mailboxID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    9102,
+			"message": "邮箱ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	if err := h.filterService.DeleteScript(c.Request.Context(), userID, mailboxID); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    9103,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "Sieve脚本已删除",
+		"data":    nil,
+	})
+}
+
+// DryRunScript 使用已收到的邮件试运行 Sieve 脚本，返回脚本产生的动作
+func (h *FilterHandler) DryRunScript(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    9201,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	mailboxID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    9202,
+			"message": "邮箱ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	var req mailfilter.DryRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    9203,
+			"message": "请求参数格式错误",
+			"data":    nil,
+		})
+		return
+	}
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    9204,
+			"message": "请求参数验证失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	result, err := h.filterService.DryRunScript(c.Request.Context(), userID, mailboxID, &req)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    9205,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "试运行完成",
+		"data":    result,
+	})
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"net/http"
+
+	"temp-mailbox-service/internal/application"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JobHandler 后台任务管理处理器
+type JobHandler struct {
+	jobService application.JobService
+}
+
+// NewJobHandler 创建后台任务管理处理器实例
+func NewJobHandler(jobService application.JobService) *JobHandler {
+	return &JobHandler{
+		jobService: jobService,
+	}
+}
+
+// RegisterRoutes 注册路由（调用方负责挂载认证和管理员中间件）
+func (h *JobHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("", h.ListJobs)
+	r.GET("/:name/runs", h.ListRuns)
+	r.POST("/:name/run", h.TriggerJob)
+}
+
+// ListJobs 获取任务列表
+func (h *JobHandler) ListJobs(c *gin.Context) {
+	jobs, err := h.jobService.ListJobs(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    5001,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "获取任务列表成功",
+		"data":    jobs,
+	})
+}
+
+// ListRuns 获取任务执行记录
+func (h *JobHandler) ListRuns(c *gin.Context) {
+	page, pageSize, ok := parsePagination(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    5101,
+			"message": "分页参数错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	listResp, err := h.jobService.ListRuns(c.Request.Context(), c.Param("name"), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    5102,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "获取执行记录成功",
+		"data":    listResp,
+	})
+}
+
+// TriggerJob 手动触发任务
+func (h *JobHandler) TriggerJob(c *gin.Context) {
+	if err := h.jobService.TriggerJob(c.Request.Context(), c.Param("name")); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    5201,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"code":    0,
+		"message": "任务已开始执行",
+		"data":    nil,
+	})
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+
+	"temp-mailbox-service/internal/application"
+	"temp-mailbox-service/internal/domain/lockout"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LockoutHandler 登录锁定管理处理器
+type LockoutHandler struct {
+	lockoutService application.LockoutService
+}
+
+// NewLockoutHandler 创建登录锁定管理处理器实例
+func NewLockoutHandler(lockoutService application.LockoutService) *LockoutHandler {
+	return &LockoutHandler{
+		lockoutService: lockoutService,
+	}
+}
+
+// RegisterRoutes 注册路由（调用方负责挂载认证和管理员中间件）
+func (h *LockoutHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("", h.ListLockouts)
+	r.DELETE("/:id", h.ClearLockout)
+}
+
+// ListLockouts 获取登录失败记录，支持 kind（account/ip）和 locked=true 筛选
+func (h *LockoutHandler) ListLockouts(c *gin.Context) {
+	page, pageSize, ok := parsePagination(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    7001,
+			"message": "分页参数错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	filter := &lockout.Filter{
+		Kind:   lockout.Kind(c.Query("kind")),
+		Locked: c.Query("locked") == "true",
+	}
+
+	listResp, err := h.lockoutService.ListLockouts(c.Request.Context(), filter, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    7002,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "获取登录失败记录成功",
+		"data":    listResp,
+	})
+}
+
+// ClearLockout 清除登录失败记录并解除锁定
+func (h *LockoutHandler) ClearLockout(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    7101,
+			"message": "记录ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	if err := h.lockoutService.ClearLockout(c.Request.Context(), id); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    7102,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "解除锁定成功",
+		"data":    nil,
+	})
+}
@@ -0,0 +1,297 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"temp-mailbox-service/internal/application"
+	"temp-mailbox-service/internal/domain/mailbox"
+	"temp-mailbox-service/internal/infrastructure/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	// defaultPageSize 默认分页大小
+	defaultPageSize = 20
+	// maxPageSize 最大分页大小
+	maxPageSize = 100
+)
+
+// MailboxHandler 临时邮箱处理器
+type MailboxHandler struct {
+	mailboxService application.MailboxService
+	validator      *validator.Validate
+}
+
+// NewMailboxHandler 创建临时邮箱处理器实例
+func NewMailboxHandler(mailboxService application.MailboxService) *MailboxHandler {
+	return &MailboxHandler{
+		mailboxService: mailboxService,
+		validator:      validator.New(),
+	}
+}
+
+// RegisterRoutes 注册路由（调用方负责挂载认证中间件）
+func (h *MailboxHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST("", h.CreateMailbox)
+	r.GET("", h.ListMailboxes)
+	r.GET("/:id", h.GetMailbox)
+	r.PATCH("/:id", h.ExtendMailbox)
+	r.DELETE("/:id", h.DeleteMailbox)
+}
+
+// CreateMailbox 创建临时邮箱
+func (h *MailboxHandler) CreateMailbox(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    2001,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	var req mailbox.CreateMailboxRequest
+
+	// 允许空请求体，此时全部使用默认值
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    2002,
+				"message": "请求参数格式错误",
+				"data":    nil,
+			})
+			return
+		}
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    2003,
+			"message": "请求参数验证失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	mailboxResp, err := h.mailboxService.CreateMailbox(c.Request.Context(), userID, &req)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    2004,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "创建邮箱成功",
+		"data":    mailboxResp,
+	})
+}
+
+// ListMailboxes 获取邮箱列表
+func (h *MailboxHandler) ListMailboxes(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    2101,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	page, pageSize, ok := parsePagination(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    2102,
+			"message": "分页参数错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	listResp, err := h.mailboxService.ListMailboxes(c.Request.Context(), userID, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    2103,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "获取邮箱列表成功",
+		"data":    listResp,
+	})
+}
+
+// GetMailbox 获取邮箱详情
+func (h *MailboxHandler) GetMailbox(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    2201,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	mailboxID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    2202,
+			"message": "邮箱ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	mailboxResp, err := h.mailboxService.GetMailbox(c.Request.Context(), userID, mailboxID)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    2203,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "获取邮箱详情成功",
+		"data":    mailboxResp,
+	})
+}
+
+// ExtendMailbox 延长邮箱有效期
+func (h *MailboxHandler) ExtendMailbox(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    2301,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	mailboxID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    2302,
+			"message": "邮箱ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	var req mailbox.ExtendMailboxRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    2303,
+			"message": "请求参数格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    2304,
+			"message": "请求参数验证失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	mailboxResp, err := h.mailboxService.ExtendMailbox(c.Request.Context(), userID, mailboxID, &req)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    2305,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "延长邮箱有效期成功",
+		"data":    mailboxResp,
+	})
+}
+
+// DeleteMailbox 删除邮箱
+func (h *MailboxHandler) DeleteMailbox(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    2401,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	mailboxID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    2402,
+			"message": "邮箱ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	if err := h.mailboxService.DeleteMailbox(c.Request.Context(), userID, mailboxID); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    2403,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "删除邮箱成功",
+		"data":    nil,
+	})
+}
+
+// parsePagination 解析分页参数 page 和 page_size
+func parsePagination(c *gin.Context) (int, int, bool) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		return 0, 0, false
+	}
+
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultPageSize)))
+	if err != nil || pageSize < 1 {
+		return 0, 0, false
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	return page, pageSize, true
+}
+
+// parseIDParam 解析路径中的数字ID参数
+func parseIDParam(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 64)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
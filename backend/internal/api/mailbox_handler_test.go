@@ -0,0 +1,222 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"temp-mailbox-service/internal/domain/mailbox"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubMailboxService 记录调用参数的邮箱服务
+type stubMailboxService struct {
+	err error
+
+	userID    uint
+	mailboxID uint
+	page      int
+	pageSize  int
+	create    *mailbox.CreateMailboxRequest
+	extend    *mailbox.ExtendMailboxRequest
+	deleted   bool
+}
+
+func (s *stubMailboxService) CreateMailbox(ctx context.Context, userID uint, req *mailbox.CreateMailboxRequest) (*mailbox.MailboxResponse, error) {
+	s.userID, s.create = userID, req
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &mailbox.MailboxResponse{ID: 1, Address: "alice@test.local"}, nil
+}
+
+func (s *stubMailboxService) ListMailboxes(ctx context.Context, userID uint, page, pageSize int) (*mailbox.MailboxListResponse, error) {
+	s.userID, s.page, s.pageSize = userID, page, pageSize
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &mailbox.MailboxListResponse{Page: page, PageSize: pageSize}, nil
+}
+
+func (s *stubMailboxService) GetMailbox(ctx context.Context, userID, mailboxID uint) (*mailbox.MailboxResponse, error) {
+	s.userID, s.mailboxID = userID, mailboxID
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &mailbox.MailboxResponse{ID: mailboxID}, nil
+}
+
+func (s *stubMailboxService) ExtendMailbox(ctx context.Context, userID, mailboxID uint, req *mailbox.ExtendMailboxRequest) (*mailbox.MailboxResponse, error) {
+	s.userID, s.mailboxID, s.extend = userID, mailboxID, req
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &mailbox.MailboxResponse{ID: mailboxID}, nil
+}
+
+func (s *stubMailboxService) DeleteMailbox(ctx context.Context, userID, mailboxID uint) error {
+	s.userID, s.mailboxID, s.deleted = userID, mailboxID, s.err == nil
+	return s.err
+}
+
+// apiResponse 统一响应格式
+type apiResponse struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// newMailboxRouter 挂载邮箱路由，userID 非0时模拟认证中间件写入用户ID
+func newMailboxRouter(svc *stubMailboxService, userID uint) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	group := r.Group("/api/mailboxes")
+	if userID != 0 {
+		group.Use(func(c *gin.Context) {
+			c.Set("user_id", userID)
+			c.Next()
+		})
+	}
+	NewMailboxHandler(svc).RegisterRoutes(group)
+	return r
+}
+
+func doRequest(t *testing.T, r *gin.Engine, method, path, body string) (int, apiResponse) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var resp apiResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+	return w.Code, resp
+}
+
+func TestMailboxHandler_Create(t *testing.T) {
+	svc := &stubMailboxService{}
+	r := newMailboxRouter(svc, 7)
+
+	status, resp := doRequest(t, r, http.MethodPost, "/api/mailboxes", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 0, resp.Code, "允许空请求体")
+	assert.Equal(t, uint(7), svc.userID)
+	assert.Equal(t, &mailbox.CreateMailboxRequest{}, svc.create)
+
+	status, resp = doRequest(t, r, http.MethodPost, "/api/mailboxes", `{"local_part":"alice","ttl":30}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 0, resp.Code)
+	assert.Equal(t, &mailbox.CreateMailboxRequest{LocalPart: "alice", TTL: 30}, svc.create)
+	assert.Contains(t, string(resp.Data), "alice@test.local")
+
+	tests := []struct {
+		name string
+		body string
+		code int
+	}{
+		{"请求体格式错误", `{"local_part":`, 2002},
+		{"邮箱名过短", `{"local_part":"ab"}`, 2003},
+		{"域名格式错误", `{"domain":"not a domain"}`, 2003},
+		{"有效期为负数", `{"ttl":-1}`, 2003},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := doRequest(t, r, http.MethodPost, "/api/mailboxes", tt.body)
+			assert.Equal(t, http.StatusBadRequest, status)
+			assert.Equal(t, tt.code, resp.Code)
+		})
+	}
+
+	svc.err = errors.New("邮箱地址已被占用")
+	status, resp = doRequest(t, r, http.MethodPost, "/api/mailboxes", `{"local_part":"alice"}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 2004, resp.Code)
+	assert.Equal(t, "邮箱地址已被占用", resp.Message)
+}
+
+func TestMailboxHandler_RequiresUser(t *testing.T) {
+	svc := &stubMailboxService{}
+	r := newMailboxRouter(svc, 0)
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		code   int
+	}{
+		{http.MethodPost, "/api/mailboxes", "", 2001},
+		{http.MethodGet, "/api/mailboxes", "", 2101},
+		{http.MethodGet, "/api/mailboxes/1", "", 2201},
+		{http.MethodPatch, "/api/mailboxes/1", `{"ttl":60}`, 2301},
+		{http.MethodDelete, "/api/mailboxes/1", "", 2401},
+	}
+	for _, tt := range tests {
+		status, resp := doRequest(t, r, tt.method, tt.path, tt.body)
+		assert.Equal(t, http.StatusUnauthorized, status, tt.method+" "+tt.path)
+		assert.Equal(t, tt.code, resp.Code, tt.method+" "+tt.path)
+	}
+	assert.Zero(t, svc.userID, "未认证时不调用服务")
+}
+
+func TestMailboxHandler_List(t *testing.T) {
+	svc := &stubMailboxService{}
+	r := newMailboxRouter(svc, 7)
+
+	_, resp := doRequest(t, r, http.MethodGet, "/api/mailboxes", "")
+	assert.Equal(t, 0, resp.Code)
+	assert.Equal(t, 1, svc.page)
+	assert.Equal(t, defaultPageSize, svc.pageSize)
+
+	_, resp = doRequest(t, r, http.MethodGet, "/api/mailboxes?page=3&page_size=500", "")
+	assert.Equal(t, 0, resp.Code)
+	assert.Equal(t, 3, svc.page)
+	assert.Equal(t, maxPageSize, svc.pageSize, "分页大小不超过上限")
+
+	for _, query := range []string{"page=0", "page=abc", "page_size=0"} {
+		status, resp := doRequest(t, r, http.MethodGet, "/api/mailboxes?"+query, "")
+		assert.Equal(t, http.StatusBadRequest, status, query)
+		assert.Equal(t, 2102, resp.Code, query)
+	}
+}
+
+func TestMailboxHandler_GetExtendDelete(t *testing.T) {
+	svc := &stubMailboxService{}
+	r := newMailboxRouter(svc, 7)
+
+	_, resp := doRequest(t, r, http.MethodGet, "/api/mailboxes/42", "")
+	assert.Equal(t, 0, resp.Code)
+	assert.Equal(t, uint(42), svc.mailboxID)
+
+	for _, id := range []string{"abc", "0", "-1"} {
+		status, resp := doRequest(t, r, http.MethodGet, "/api/mailboxes/"+id, "")
+		assert.Equal(t, http.StatusBadRequest, status, id)
+		assert.Equal(t, 2202, resp.Code, id)
+	}
+
+	_, resp = doRequest(t, r, http.MethodPatch, "/api/mailboxes/42", `{"ttl":120}`)
+	assert.Equal(t, 0, resp.Code)
+	assert.Equal(t, &mailbox.ExtendMailboxRequest{TTL: 120}, svc.extend)
+
+	status, resp := doRequest(t, r, http.MethodPatch, "/api/mailboxes/42", `{}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, 2304, resp.Code, "延期必须指定有效期")
+
+	_, resp = doRequest(t, r, http.MethodDelete, "/api/mailboxes/42", "")
+	assert.Equal(t, 0, resp.Code)
+	assert.True(t, svc.deleted)
+	assert.Equal(t, uint(7), svc.userID)
+
+	svc.err = errors.New("邮箱不存在")
+	_, resp = doRequest(t, r, http.MethodDelete, "/api/mailboxes/43", "")
+	assert.Equal(t, 2403, resp.Code)
+	assert.Equal(t, "邮箱不存在", resp.Message)
+}
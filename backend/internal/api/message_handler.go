@@ -0,0 +1,265 @@
+package api
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+
+	"temp-mailbox-service/internal/application"
+	"temp-mailbox-service/internal/domain/apikey"
+	"temp-mailbox-service/internal/infrastructure/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MessageHandler 邮件处理器
+type MessageHandler struct {
+	messageService application.MessageService
+}
+
+// NewMessageHandler 创建邮件处理器实例
+func NewMessageHandler(messageService application.MessageService) *MessageHandler {
+	return &MessageHandler{
+		messageService: messageService,
+	}
+}
+
+// RegisterRoutes 注册路由（挂载在 /api/mailboxes 分组下，调用方负责挂载认证中间件）
+func (h *MessageHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/:id/messages", h.ListMessages)
+	r.GET("/:id/messages/:mid", h.GetMessage)
+	r.GET("/:id/messages/:mid/raw", h.GetRawMessage)
+	r.GET("/:id/messages/:mid/report", h.GetReport)
+	r.GET("/:id/messages/:mid/attachments/:aid", h.DownloadAttachment)
+}
+
+// ListMessages 获取邮件列表
+func (h *MessageHandler) ListMessages(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    3001,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	mailboxID, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    3002,
+			"message": "邮箱ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	page, pageSize, ok := parsePagination(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    3003,
+			"message": "分页参数错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	listResp, err := h.messageService.ListMessages(c.Request.Context(), userID, mailboxID, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    3004,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "获取邮件列表成功",
+		"data":    listResp,
+	})
+}
+
+// GetMessage 获取邮件详情
+func (h *MessageHandler) GetMessage(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    3101,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	mailboxID, messageID, ok := parseMessagePath(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    3102,
+			"message": "邮箱或邮件ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	// 标记已读和查看后删除会修改数据，只有读权限的API密钥不触发
+	markRead := true
+	if principal, ok := middleware.GetAPIKeyPrincipal(c); ok && !principal.HasScope(apikey.ScopeMailboxWrite) {
+		markRead = false
+	}
+
+	messageResp, err := h.messageService.GetMessage(c.Request.Context(), userID, mailboxID, messageID, markRead)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    3103,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "获取邮件详情成功",
+		"data":    messageResp,
+	})
+}
+
+// GetRawMessage 获取邮件原始内容（RFC 5322格式）
+func (h *MessageHandler) GetRawMessage(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    3201,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	mailboxID, messageID, ok := parseMessagePath(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    3202,
+			"message": "邮箱或邮件ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	raw, err := h.messageService.GetRawMessage(c.Request.Context(), userID, mailboxID, messageID)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    3203,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", mime.FormatMediaType("inline", map[string]string{
+		"filename": fmt.Sprintf("message-%d.eml", messageID),
+	}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, "message/rfc822", raw)
+}
+
+// GetReport 获取邮件投递质量报告
+func (h *MessageHandler) GetReport(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    3401,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	mailboxID, messageID, ok := parseMessagePath(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    3402,
+			"message": "邮箱或邮件ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	report, err := h.messageService.GetReport(c.Request.Context(), userID, mailboxID, messageID)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    3403,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "获取投递报告成功",
+		"data":    report,
+	})
+}
+
+// DownloadAttachment 下载附件
+func (h *MessageHandler) DownloadAttachment(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    3301,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	mailboxID, messageID, ok := parseMessagePath(c)
+	attachmentID, aok := parseIDParam(c, "aid")
+	if !ok || !aok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    3302,
+			"message": "邮箱、邮件或附件ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	attachment, reader, err := h.messageService.OpenAttachment(c.Request.Context(), userID, mailboxID, messageID, attachmentID)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    3303,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	defer reader.Close()
+
+	contentType := attachment.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	// 始终以附件形式下载，避免浏览器在本站域名下直接渲染邮件中的HTML等内容
+	c.DataFromReader(http.StatusOK, int64(attachment.Size), contentType, reader, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// parseMessagePath 解析路径中的邮箱ID和邮件ID
+func parseMessagePath(c *gin.Context) (uint, uint, bool) {
+	mailboxID, ok := parseIDParam(c, "id")
+	if !ok {
+		return 0, 0, false
+	}
+	messageID, ok := parseIDParam(c, "mid")
+	if !ok {
+		return 0, 0, false
+	}
+	return mailboxID, messageID, true
+}
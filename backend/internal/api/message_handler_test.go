@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"temp-mailbox-service/internal/domain/apikey"
+	"temp-mailbox-service/internal/domain/message"
+	"temp-mailbox-service/internal/infrastructure/auth"
+	"temp-mailbox-service/internal/infrastructure/mailreport"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubMessageService 返回固定内容的邮件服务
+type stubMessageService struct {
+	err         error
+	contentType string
+
+	userID       uint
+	mailboxID    uint
+	messageID    uint
+	attachmentID uint
+	markRead     bool
+}
+
+func (s *stubMessageService) ListMessages(ctx context.Context, userID, mailboxID uint, page, pageSize int) (*message.MessageListResponse, error) {
+	s.userID, s.mailboxID = userID, mailboxID
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &message.MessageListResponse{Page: page, PageSize: pageSize}, nil
+}
+
+func (s *stubMessageService) GetMessage(ctx context.Context, userID, mailboxID, messageID uint, markRead bool) (*message.MessageResponse, error) {
+	s.userID, s.mailboxID, s.messageID, s.markRead = userID, mailboxID, messageID, markRead
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &message.MessageResponse{ID: messageID, Subject: "hello"}, nil
+}
+
+func (s *stubMessageService) GetRawMessage(ctx context.Context, userID, mailboxID, messageID uint) ([]byte, error) {
+	s.userID, s.mailboxID, s.messageID = userID, mailboxID, messageID
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []byte("Subject: hello\r\n\r\nbody\r\n"), nil
+}
+
+func (s *stubMessageService) OpenAttachment(ctx context.Context, userID, mailboxID, messageID, attachmentID uint) (*message.Attachment, io.ReadCloser, error) {
+	s.userID, s.mailboxID, s.messageID, s.attachmentID = userID, mailboxID, messageID, attachmentID
+	if s.err != nil {
+		return nil, nil, s.err
+	}
+	content := "<script>alert(1)</script>"
+	return &message.Attachment{
+		ID:          attachmentID,
+		MessageID:   messageID,
+		Filename:    "page.html",
+		ContentType: s.contentType,
+		Size:        len(content),
+	}, io.NopCloser(strings.NewReader(content)), nil
+}
+
+func (s *stubMessageService) GetReport(ctx context.Context, userID, mailboxID, messageID uint) (*mailreport.Report, error) {
+	s.userID, s.mailboxID, s.messageID = userID, mailboxID, messageID
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &mailreport.Report{}, nil
+}
+
+// newMessageRouter 挂载邮件路由并模拟认证中间件写入用户ID，principal 非空时模拟API密钥认证
+func newMessageRouter(svc *stubMessageService, userID uint, principal *auth.APIKeyPrincipal) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	group := r.Group("/api/mailboxes")
+	group.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+		if principal != nil {
+			c.Set("api_key", principal)
+		}
+		c.Next()
+	})
+	NewMessageHandler(svc).RegisterRoutes(group)
+	return r
+}
+
+func TestMessageHandler_GetMessage(t *testing.T) {
+	svc := &stubMessageService{}
+	r := newMessageRouter(svc, 7, nil)
+
+	_, resp := doRequest(t, r, http.MethodGet, "/api/mailboxes/3/messages/5", "")
+	assert.Equal(t, 0, resp.Code)
+	assert.Contains(t, string(resp.Data), `"subject":"hello"`)
+	assert.Equal(t, []uint{7, 3, 5}, []uint{svc.userID, svc.mailboxID, svc.messageID})
+	assert.True(t, svc.markRead)
+
+	status, resp := doRequest(t, r, http.MethodGet, "/api/mailboxes/3/messages/abc", "")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, 3102, resp.Code)
+
+	status, resp = doRequest(t, r, http.MethodGet, "/api/mailboxes/3/messages?page=0", "")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, 3003, resp.Code)
+
+	svc.err = errors.New("邮件不存在")
+	_, resp = doRequest(t, r, http.MethodGet, "/api/mailboxes/3/messages/6", "")
+	assert.Equal(t, 3103, resp.Code)
+	assert.Equal(t, "邮件不存在", resp.Message)
+}
+
+func TestMessageHandler_GetMessageWithAPIKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		scopes   []string
+		markRead bool
+	}{
+		{"只读密钥不修改邮件状态", []string{apikey.ScopeMailboxRead}, false},
+		{"读写密钥标记已读", []string{apikey.ScopeMailboxRead, apikey.ScopeMailboxWrite}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &stubMessageService{}
+			r := newMessageRouter(svc, 7, &auth.APIKeyPrincipal{UserID: 7, Scopes: tt.scopes})
+
+			_, resp := doRequest(t, r, http.MethodGet, "/api/mailboxes/3/messages/5", "")
+			assert.Equal(t, 0, resp.Code)
+			assert.Equal(t, tt.markRead, svc.markRead)
+		})
+	}
+}
+
+func TestMessageHandler_RawMessage(t *testing.T) {
+	svc := &stubMessageService{}
+	r := newMessageRouter(svc, 7, nil)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/mailboxes/3/messages/5/raw", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "message/rfc822", w.Header().Get("Content-Type"))
+	assert.Equal(t, "inline; filename=message-5.eml", w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "Subject: hello\r\n\r\nbody\r\n", w.Body.String())
+
+	svc.err = errors.New("邮件不存在")
+	_, resp := doRequest(t, r, http.MethodGet, "/api/mailboxes/3/messages/5/raw", "")
+	assert.Equal(t, 3203, resp.Code)
+}
+
+func TestMessageHandler_DownloadAttachment(t *testing.T) {
+	svc := &stubMessageService{contentType: "text/html"}
+	r := newMessageRouter(svc, 7, nil)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/mailboxes/3/messages/5/attachments/9", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, uint(9), svc.attachmentID)
+	assert.Equal(t, "text/html", w.Header().Get("Content-Type"))
+	assert.Equal(t, "attachment; filename=page.html", w.Header().Get("Content-Disposition"), "始终以附件形式下载")
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "<script>alert(1)</script>", w.Body.String())
+
+	svc.contentType = ""
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/mailboxes/3/messages/5/attachments/9", nil))
+	assert.Equal(t, "application/octet-stream", w.Header().Get("Content-Type"))
+
+	status, resp := doRequest(t, r, http.MethodGet, "/api/mailboxes/3/messages/5/attachments/0", "")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, 3302, resp.Code)
+
+	svc.err = errors.New("附件不存在")
+	_, resp = doRequest(t, r, http.MethodGet, "/api/mailboxes/3/messages/5/attachments/9", "")
+	assert.Equal(t, 3303, resp.Code)
+	assert.Equal(t, "附件不存在", resp.Message)
+}
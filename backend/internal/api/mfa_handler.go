@@ -0,0 +1,203 @@
+package api
+
+import (
+	"net/http"
+
+	"temp-mailbox-service/internal/application"
+	"temp-mailbox-service/internal/domain/user"
+	"temp-mailbox-service/internal/infrastructure/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// MFAHandler 两步验证处理器
+type MFAHandler struct {
+	mfaService application.MFAService
+	validator  *validator.Validate
+}
+
+// NewMFAHandler 创建两步验证处理器实例
+func NewMFAHandler(mfaService application.MFAService) *MFAHandler {
+	return &MFAHandler{
+		mfaService: mfaService,
+		validator:  validator.New(),
+	}
+}
+
+// RegisterRoutes 注册路由（调用方负责挂载认证中间件）
+func (h *MFAHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST("/totp/setup", h.SetupTOTP)
+	r.POST("/totp/confirm", h.ConfirmTOTP)
+	r.POST("/totp/disable", h.DisableTOTP)
+	r.POST("/recovery-codes", h.RegenerateRecoveryCodes)
+}
+
+// SetupTOTP 生成两步验证密钥和 otpauth 链接
+func (h *MFAHandler) SetupTOTP(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    7301,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	setupResp, err := h.mfaService.SetupTOTP(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    7302,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "请使用身份验证器扫描并提交验证码完成启用",
+		"data":    setupResp,
+	})
+}
+
+// ConfirmTOTP 确认验证码并启用两步验证，返回恢复码
+func (h *MFAHandler) ConfirmTOTP(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    7401,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	var req user.ConfirmTOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    7402,
+			"message": "请求参数格式错误",
+			"data":    nil,
+		})
+		return
+	}
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    7403,
+			"message": "请求参数验证失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	codesResp, err := h.mfaService.ConfirmTOTP(c.Request.Context(), userID, &req)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    7404,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "两步验证已启用，请妥善保存恢复码",
+		"data":    codesResp,
+	})
+}
+
+// DisableTOTP 关闭两步验证
+func (h *MFAHandler) DisableTOTP(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    7501,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	var req user.DisableTOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    7502,
+			"message": "请求参数格式错误",
+			"data":    nil,
+		})
+		return
+	}
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    7503,
+			"message": "请求参数验证失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	if err := h.mfaService.DisableTOTP(c.Request.Context(), userID, &req); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    7504,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "两步验证已关闭",
+		"data":    nil,
+	})
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    7601,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	var req user.RegenerateRecoveryCodesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    7602,
+			"message": "请求参数格式错误",
+			"data":    nil,
+		})
+		return
+	}
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    7603,
+			"message": "请求参数验证失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	codesResp, err := h.mfaService.RegenerateRecoveryCodes(c.Request.Context(), userID, &req)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    7604,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "恢复码已重新生成，旧恢复码已失效",
+		"data":    codesResp,
+	})
+}
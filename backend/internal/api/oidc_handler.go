@@ -0,0 +1,119 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"temp-mailbox-service/internal/application"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// oidcStateCookie 保存第三方登录状态的 Cookie 名称
+	oidcStateCookie = "oidc_state"
+	// oidcCookiePath Cookie 只在第三方登录路由下发送
+	oidcCookiePath = "/api/auth/oidc"
+)
+
+// OIDCHandler 第三方登录处理器
+type OIDCHandler struct {
+	oidcService application.OIDCService
+	callbackURL string
+}
+
+// NewOIDCHandler 创建第三方登录处理器实例，callbackURL 为登录完成后跳转的前端地址
+func NewOIDCHandler(oidcService application.OIDCService, callbackURL string) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService: oidcService,
+		callbackURL: callbackURL,
+	}
+}
+
+// RegisterRoutes 注册路由
+func (h *OIDCHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/providers", h.ListProviders)
+	r.GET("/:provider/authorize", h.Authorize)
+	r.GET("/:provider/callback", h.Callback)
+}
+
+// ListProviders 获取可用的身份提供方
+func (h *OIDCHandler) ListProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "获取身份提供方成功",
+		"data": gin.H{
+			"providers": h.oidcService.Providers(),
+		},
+	})
+}
+
+// Authorize 发起第三方登录，保存登录状态后重定向到身份提供方
+func (h *OIDCHandler) Authorize(c *gin.Context) {
+	authorization, err := h.oidcService.BeginLogin(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		h.redirectError(c, 8201, err.Error())
+		return
+	}
+
+	h.setStateCookie(c, authorization.State, int(authorization.ExpiresIn.Seconds()))
+	c.Redirect(http.StatusFound, authorization.URL)
+}
+
+// Callback 身份提供方回调，完成登录后将令牌放在URL片段中重定向到前端
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	stateCookie, _ := c.Cookie(oidcStateCookie)
+	// 登录状态只能使用一次
+	h.setStateCookie(c, "", -1)
+
+	if errCode := c.Query("error"); errCode != "" {
+		message := "第三方登录已取消"
+		if errCode != "access_denied" {
+			message = "第三方登录失败: " + errCode
+		}
+		h.redirectError(c, 8301, message)
+		return
+	}
+
+	loginResp, err := h.oidcService.CompleteLogin(
+		c.Request.Context(),
+		c.Param("provider"),
+		c.Query("code"),
+		c.Query("state"),
+		stateCookie,
+		clientInfo(c),
+	)
+	if err != nil {
+		h.redirectError(c, 8302, err.Error())
+		return
+	}
+
+	fragment := url.Values{}
+	if loginResp.MFARequired {
+		fragment.Set("mfa_required", "true")
+		fragment.Set("mfa_token", loginResp.MFAToken)
+		fragment.Set("mfa_expires_in", strconv.Itoa(loginResp.MFAExpiresIn))
+	} else {
+		fragment.Set("access_token", loginResp.Token.AccessToken)
+		fragment.Set("refresh_token", loginResp.Token.RefreshToken)
+		fragment.Set("token_type", loginResp.Token.TokenType)
+		fragment.Set("expires_in", strconv.FormatInt(loginResp.Token.ExpiresIn, 10))
+	}
+	c.Redirect(http.StatusFound, h.callbackURL+"#"+fragment.Encode())
+}
+
+// redirectError 将错误信息放在URL片段中重定向到前端
+func (h *OIDCHandler) redirectError(c *gin.Context, code int, message string) {
+	fragment := url.Values{}
+	fragment.Set("code", strconv.Itoa(code))
+	fragment.Set("message", message)
+	c.Redirect(http.StatusFound, h.callbackURL+"#"+fragment.Encode())
+}
+
+// setStateCookie 设置登录状态 Cookie，身份提供方回调是跨站的顶层跳转，需要 SameSite=Lax
+func (h *OIDCHandler) setStateCookie(c *gin.Context, value string, maxAge int) {
+	secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, value, maxAge, oidcCookiePath, "", secure, true)
+}
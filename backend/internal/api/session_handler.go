@@ -0,0 +1,100 @@
+package api
+
+import (
+	"net/http"
+
+	"temp-mailbox-service/internal/application"
+	"temp-mailbox-service/internal/infrastructure/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionHandler 登录设备管理处理器
+type SessionHandler struct {
+	sessionService application.SessionService
+}
+
+// NewSessionHandler 创建登录设备管理处理器实例
+func NewSessionHandler(sessionService application.SessionService) *SessionHandler {
+	return &SessionHandler{
+		sessionService: sessionService,
+	}
+}
+
+// RegisterRoutes 注册路由（调用方负责挂载认证中间件）
+func (h *SessionHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("", h.ListSessions)
+	r.DELETE("/:id", h.RevokeSession)
+}
+
+// ListSessions 获取当前用户的登录设备
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    8001,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	var currentSessionID string
+	if claims, ok := middleware.GetJWTClaims(c); ok {
+		currentSessionID = claims.SessionID
+	}
+
+	sessions, err := h.sessionService.ListSessions(c.Request.Context(), userID, currentSessionID)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    8002,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "获取登录设备成功",
+		"data":    sessions,
+	})
+}
+
+// RevokeSession 注销指定登录设备，注销当前设备等同于退出登录
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    8101,
+			"message": "获取用户信息失败",
+			"data":    nil,
+		})
+		return
+	}
+
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    8102,
+			"message": "会话ID格式错误",
+			"data":    nil,
+		})
+		return
+	}
+
+	if err := h.sessionService.RevokeSession(c.Request.Context(), userID, id); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    8103,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "已注销该设备",
+		"data":    nil,
+	})
+}
@@ -1,9 +1,13 @@
 package api
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"temp-mailbox-service/internal/application"
+	"temp-mailbox-service/internal/domain/session"
 	"temp-mailbox-service/internal/domain/user"
 	"temp-mailbox-service/internal/infrastructure/middleware"
 
@@ -31,6 +35,12 @@ func (h *UserHandler) RegisterRoutes(r *gin.RouterGroup) {
 	{
 		auth.POST("/register", h.Register)
 		auth.POST("/login", h.Login)
+		auth.POST("/mfa", h.CompleteMFALogin)
+		auth.POST("/refresh", h.Refresh)
+		auth.POST("/forgot-password", h.ForgotPassword)
+		auth.POST("/reset-password", h.ResetPassword)
+		auth.POST("/verify-email", h.VerifyEmail)
+		auth.POST("/resend-verification", h.ResendVerification)
 	}
 }
 
@@ -59,7 +69,7 @@ func (h *UserHandler) Register(c *gin.Context) {
 	}
 	
 	// 调用用户服务注册
-	loginResp, err := h.userService.RegisterUser(c.Request.Context(), &req)
+	loginResp, err := h.userService.RegisterUser(c.Request.Context(), &req, clientInfo(c))
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"code": 1003,
@@ -101,7 +111,10 @@ func (h *UserHandler) Login(c *gin.Context) {
 	}
 	
 	// 调用用户服务登录
-	loginResp, err := h.userService.LoginUser(c.Request.Context(), &req)
+	loginResp, err := h.userService.LoginUser(c.Request.Context(), &req, clientInfo(c))
+	if respondThrottled(c, 1104, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"code": 1103,
@@ -111,6 +124,54 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 	
+	message := "登录成功"
+	if loginResp.MFARequired {
+		message = "请输入两步验证码"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"message": message,
+		"data": loginResp,
+	})
+}
+
+// CompleteMFALogin 提交两步验证码完成登录
+func (h *UserHandler) CompleteMFALogin(c *gin.Context) {
+	var req user.MFALoginRequest
+	
+	// 绑定JSON请求体
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 7201,
+			"message": "请求参数格式错误",
+			"data": nil,
+		})
+		return
+	}
+	
+	// 验证请求参数
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 7202,
+			"message": "请求参数验证失败",
+			"data": nil,
+		})
+		return
+	}
+	
+	loginResp, err := h.userService.CompleteMFALogin(c.Request.Context(), &req, clientInfo(c))
+	if respondThrottled(c, 7204, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": 7203,
+			"message": err.Error(),
+			"data": nil,
+		})
+		return
+	}
+	
 	c.JSON(http.StatusOK, gin.H{
 		"code": 0,
 		"message": "登录成功",
@@ -118,6 +179,278 @@ func (h *UserHandler) Login(c *gin.Context) {
 	})
 }
 
+// Refresh 刷新令牌
+func (h *UserHandler) Refresh(c *gin.Context) {
+	var req session.RefreshRequest
+	
+	// 绑定JSON请求体
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 1501,
+			"message": "请求参数格式错误",
+			"data": nil,
+		})
+		return
+	}
+	
+	// 验证请求参数
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 1502,
+			"message": "请求参数验证失败",
+			"data": nil,
+		})
+		return
+	}
+	
+	// 调用用户服务刷新令牌
+	tokenPair, err := h.userService.RefreshToken(c.Request.Context(), &req, clientInfo(c))
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": 1503,
+			"message": err.Error(),
+			"data": nil,
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"message": "令牌刷新成功",
+		"data": tokenPair,
+	})
+}
+
+// ForgotPassword 忘记密码
+func (h *UserHandler) ForgotPassword(c *gin.Context) {
+	var req user.ForgotPasswordRequest
+	
+	// 绑定JSON请求体
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 1801,
+			"message": "请求参数格式错误",
+			"data": nil,
+		})
+		return
+	}
+	
+	// 验证请求参数
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 1802,
+			"message": "请求参数验证失败",
+			"data": nil,
+		})
+		return
+	}
+	
+	// 调用用户服务发送重置邮件
+	if err := h.userService.ForgotPassword(c.Request.Context(), &req); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": 1803,
+			"message": err.Error(),
+			"data": nil,
+		})
+		return
+	}
+	
+	// 无论邮箱是否注册都返回相同的响应
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"message": "如果该邮箱已注册，重置密码的邮件将很快送达",
+		"data": nil,
+	})
+}
+
+// ResetPassword 重置密码
+func (h *UserHandler) ResetPassword(c *gin.Context) {
+	var req user.ResetPasswordRequest
+	
+	// 绑定JSON请求体
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 1901,
+			"message": "请求参数格式错误",
+			"data": nil,
+		})
+		return
+	}
+	
+	// 验证请求参数
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 1902,
+			"message": "请求参数验证失败",
+			"data": nil,
+		})
+		return
+	}
+	
+	// 调用用户服务重置密码
+	if err := h.userService.ResetPassword(c.Request.Context(), &req); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": 1903,
+			"message": err.Error(),
+			"data": nil,
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"message": "密码重置成功，请使用新密码登录",
+		"data": nil,
+	})
+}
+
+// VerifyEmail 验证邮箱
+func (h *UserHandler) VerifyEmail(c *gin.Context) {
+	var req user.VerifyEmailRequest
+	
+	// 绑定JSON请求体
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 6001,
+			"message": "请求参数格式错误",
+			"data": nil,
+		})
+		return
+	}
+	
+	// 验证请求参数
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 6002,
+			"message": "请求参数验证失败",
+			"data": nil,
+		})
+		return
+	}
+	
+	// 调用用户服务验证邮箱
+	userResp, err := h.userService.VerifyEmail(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": 6003,
+			"message": err.Error(),
+			"data": nil,
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"message": "邮箱验证成功",
+		"data": userResp,
+	})
+}
+
+// ResendVerification 重新发送验证邮件
+func (h *UserHandler) ResendVerification(c *gin.Context) {
+	var req user.ResendVerificationRequest
+	
+	// 绑定JSON请求体
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 6101,
+			"message": "请求参数格式错误",
+			"data": nil,
+		})
+		return
+	}
+	
+	// 验证请求参数
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 6102,
+			"message": "请求参数验证失败",
+			"data": nil,
+		})
+		return
+	}
+	
+	// 调用用户服务重新发送验证邮件
+	if err := h.userService.ResendVerification(c.Request.Context(), &req); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": 6103,
+			"message": err.Error(),
+			"data": nil,
+		})
+		return
+	}
+	
+	// 无论邮箱是否注册都返回相同的响应
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"message": "如果该邮箱已注册且尚未验证，验证邮件将很快送达",
+		"data": nil,
+	})
+}
+
+// Logout 退出登录（需要认证）
+func (h *UserHandler) Logout(c *gin.Context) {
+	// 从上下文获取令牌声明
+	claims, ok := middleware.GetJWTClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 1601,
+			"message": "获取用户信息失败",
+			"data": nil,
+		})
+		return
+	}
+	
+	// 调用用户服务退出登录
+	if err := h.userService.Logout(c.Request.Context(), claims); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": 1602,
+			"message": err.Error(),
+			"data": nil,
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"message": "退出登录成功",
+		"data": nil,
+	})
+}
+
+// LogoutAll 退出所有设备（需要认证）
+func (h *UserHandler) LogoutAll(c *gin.Context) {
+	// 从上下文获取用户ID
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": 1701,
+			"message": "获取用户信息失败",
+			"data": nil,
+		})
+		return
+	}
+	
+	// 调用用户服务注销全部会话
+	count, err := h.userService.LogoutAll(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": 1702,
+			"message": err.Error(),
+			"data": nil,
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"message": "已退出所有设备",
+		"data": gin.H{
+			"revoked_sessions": count,
+		},
+	})
+}
+
 // GetProfile 获取用户资料
 func (h *UserHandler) GetProfile(c *gin.Context) {
 	// 从上下文获取用户ID
@@ -237,8 +570,13 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 	
-	// 调用用户服务修改密码
-	if err := h.userService.ChangePassword(c.Request.Context(), userID, &req); err != nil {
+	// 调用用户服务修改密码，保留当前会话
+	var currentSessionID string
+	if claims, ok := middleware.GetJWTClaims(c); ok {
+		currentSessionID = claims.SessionID
+	}
+	revoked, err := h.userService.ChangePassword(c.Request.Context(), userID, &req, currentSessionID)
+	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"code": 1404,
 			"message": err.Error(),
@@ -250,6 +588,34 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"code": 0,
 		"message": "密码修改成功",
-		"data": nil,
+		"data": gin.H{
+			"revoked_sessions": revoked,
+		},
 	})
-} 
\ No newline at end of file
+}
+
+// respondThrottled 登录被锁定或需要等待时写入带 Retry-After 的响应，返回是否已处理
+func respondThrottled(c *gin.Context, code int, err error) bool {
+	var throttled *application.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		return false
+	}
+	retryAfter := int((throttled.RetryAfter + time.Second - 1) / time.Second)
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusOK, gin.H{
+		"code": code,
+		"message": err.Error(),
+		"data": gin.H{
+			"retry_after": retryAfter,
+		},
+	})
+	return true
+}
+
+// clientInfo 从请求中提取客户端信息
+func clientInfo(c *gin.Context) *application.ClientInfo {
+	return &application.ClientInfo{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
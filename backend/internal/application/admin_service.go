@@ -0,0 +1,309 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"temp-mailbox-service/internal/domain/user"
+	"temp-mailbox-service/internal/infrastructure/auth"
+)
+
+// AdminService 管理员服务接口
+type AdminService interface {
+	BootstrapAdmin(ctx context.Context, email, username, password string) (*user.UserResponse, bool, error)
+	UpdateUserRole(ctx context.Context, operatorID, userID uint, req *user.UpdateRoleRequest) (*user.UserResponse, error)
+
+	// 用户管理
+	ListUsers(ctx context.Context, filter *user.SearchFilter, page, pageSize int) (*user.UserListResponse, error)
+	GetUser(ctx context.Context, userID uint) (*user.UserResponse, error)
+	ActivateUser(ctx context.Context, userID uint) (*user.UserResponse, error)
+	DeactivateUser(ctx context.Context, operatorID, userID uint) (*user.UserResponse, error)
+	ForcePasswordReset(ctx context.Context, userID uint) error
+	DeleteUser(ctx context.Context, operatorID, userID uint) error
+	RestoreUser(ctx context.Context, userID uint) (*user.UserResponse, error)
+}
+
+// adminService 管理员服务实现
+type adminService struct {
+	userRepo       user.Repository
+	userService    UserService
+	sessionService SessionService
+}
+
+// NewAdminService 创建新的管理员服务实例
+func NewAdminService(userRepo user.Repository, userService UserService, sessionService SessionService) AdminService {
+	return &adminService{
+		userRepo:       userRepo,
+		userService:    userService,
+		sessionService: sessionService,
+	}
+}
+
+// BootstrapAdmin 创建初始管理员，邮箱已注册时将该用户提升为管理员（不修改其密码）
+// 第二个返回值表示是否新建了用户
+func (s *adminService) BootstrapAdmin(ctx context.Context, email, username, password string) (*user.UserResponse, bool, error) {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return nil, false, fmt.Errorf("管理员邮箱不能为空")
+	}
+
+	existing, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, false, fmt.Errorf("获取用户失败: %w", err)
+	}
+
+	if existing != nil {
+		if !existing.IsAdmin() {
+			if err := s.userRepo.UpdateRole(ctx, existing.ID, user.RoleAdmin); err != nil {
+				return nil, false, fmt.Errorf("更新用户角色失败: %w", err)
+			}
+		}
+		if !existing.EmailVerified {
+			if err := s.userRepo.MarkEmailVerified(ctx, existing.ID); err != nil {
+				return nil, false, fmt.Errorf("标记邮箱验证失败: %w", err)
+			}
+		}
+		if !existing.IsActive {
+			if err := s.userRepo.Activate(ctx, existing.ID); err != nil {
+				return nil, false, fmt.Errorf("激活用户失败: %w", err)
+			}
+		}
+
+		updated, err := s.userRepo.GetByID(ctx, existing.ID)
+		if err != nil {
+			return nil, false, fmt.Errorf("获取用户失败: %w", err)
+		}
+		return updated.ToResponse(), false, nil
+	}
+
+	if username == "" {
+		return nil, false, fmt.Errorf("管理员用户名不能为空")
+	}
+	exists, err := s.userRepo.ExistsByUsername(ctx, username)
+	if err != nil {
+		return nil, false, fmt.Errorf("检查用户名失败: %w", err)
+	}
+	if exists {
+		return nil, false, fmt.Errorf("用户名已存在")
+	}
+
+	if err := auth.IsValidPassword(password); err != nil {
+		return nil, false, fmt.Errorf("密码不符合要求: %w", err)
+	}
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		return nil, false, fmt.Errorf("密码哈希失败: %w", err)
+	}
+
+	// 初始管理员由运维直接配置，无需再验证邮箱
+	now := time.Now()
+	admin := &user.User{
+		Username:        username,
+		Email:           email,
+		Password:        hashedPassword,
+		Role:            user.RoleAdmin,
+		IsActive:        true,
+		EmailVerified:   true,
+		EmailVerifiedAt: &now,
+	}
+	if err := s.userRepo.Create(ctx, admin); err != nil {
+		return nil, false, fmt.Errorf("创建管理员失败: %w", err)
+	}
+
+	return admin.ToResponse(), true, nil
+}
+
+// UpdateUserRole 修改用户角色，角色变更后注销该用户的全部会话，使新角色立即生效
+func (s *adminService) UpdateUserRole(ctx context.Context, operatorID, userID uint, req *user.UpdateRoleRequest) (*user.UserResponse, error) {
+	if !user.IsValidRole(req.Role) {
+		return nil, fmt.Errorf("无效的角色")
+	}
+	if operatorID == userID {
+		return nil, fmt.Errorf("不能修改自己的角色")
+	}
+
+	u, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.Role == req.Role {
+		return u.ToResponse(), nil
+	}
+
+	if err := s.ensureNotLastAdmin(ctx, u); err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.UpdateRole(ctx, userID, req.Role); err != nil {
+		return nil, fmt.Errorf("更新用户角色失败: %w", err)
+	}
+	if _, err := s.sessionService.LogoutAll(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	u.Role = req.Role
+	return u.ToResponse(), nil
+}
+
+// ListUsers 按条件分页搜索用户
+func (s *adminService) ListUsers(ctx context.Context, filter *user.SearchFilter, page, pageSize int) (*user.UserListResponse, error) {
+	if filter.Role != "" && !user.IsValidRole(filter.Role) {
+		return nil, fmt.Errorf("无效的角色")
+	}
+	if !user.IsValidStatus(filter.Status) {
+		return nil, fmt.Errorf("无效的用户状态")
+	}
+	filter.Keyword = strings.TrimSpace(filter.Keyword)
+
+	total, err := s.userRepo.CountSearch(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("统计用户数量失败: %w", err)
+	}
+
+	users, err := s.userRepo.Search(ctx, filter, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户列表失败: %w", err)
+	}
+
+	items := make([]*user.UserResponse, 0, len(users))
+	for _, u := range users {
+		items = append(items, u.ToResponse())
+	}
+
+	return &user.UserListResponse{
+		Items:    items,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// GetUser 获取用户详情（包含已删除的用户）
+func (s *adminService) GetUser(ctx context.Context, userID uint) (*user.UserResponse, error) {
+	u, err := s.userRepo.GetByIDWithDeleted(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户失败: %w", err)
+	}
+	if u == nil {
+		return nil, fmt.Errorf("用户不存在")
+	}
+	return u.ToResponse(), nil
+}
+
+// ActivateUser 激活用户
+func (s *adminService) ActivateUser(ctx context.Context, userID uint) (*user.UserResponse, error) {
+	u, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !u.IsActive {
+		if err := s.userRepo.Activate(ctx, userID); err != nil {
+			return nil, fmt.Errorf("激活用户失败: %w", err)
+		}
+		u.IsActive = true
+	}
+	return u.ToResponse(), nil
+}
+
+// DeactivateUser 停用用户，并立即注销其全部会话使已签发的令牌失效
+func (s *adminService) DeactivateUser(ctx context.Context, operatorID, userID uint) (*user.UserResponse, error) {
+	if operatorID == userID {
+		return nil, fmt.Errorf("不能停用自己的账户")
+	}
+
+	u, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.IsActive {
+		if err := s.userRepo.Deactivate(ctx, userID); err != nil {
+			return nil, fmt.Errorf("停用用户失败: %w", err)
+		}
+		u.IsActive = false
+	}
+
+	// 已停用的用户也执行一次，确保没有遗留的有效会话
+	if _, err := s.sessionService.LogoutAll(ctx, userID); err != nil {
+		return nil, err
+	}
+	return u.ToResponse(), nil
+}
+
+// ForcePasswordReset 强制用户重置密码
+func (s *adminService) ForcePasswordReset(ctx context.Context, userID uint) error {
+	return s.userService.ForcePasswordReset(ctx, userID)
+}
+
+// DeleteUser 软删除用户，并立即注销其全部会话
+func (s *adminService) DeleteUser(ctx context.Context, operatorID, userID uint) error {
+	if operatorID == userID {
+		return fmt.Errorf("不能删除自己的账户")
+	}
+
+	u, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.ensureNotLastAdmin(ctx, u); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.Delete(ctx, userID); err != nil {
+		return fmt.Errorf("删除用户失败: %w", err)
+	}
+	if _, err := s.sessionService.LogoutAll(ctx, userID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// RestoreUser 恢复已软删除的用户
+func (s *adminService) RestoreUser(ctx context.Context, userID uint) (*user.UserResponse, error) {
+	u, err := s.userRepo.GetByIDWithDeleted(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户失败: %w", err)
+	}
+	if u == nil {
+		return nil, fmt.Errorf("用户不存在")
+	}
+	if !u.DeletedAt.Valid {
+		return nil, fmt.Errorf("用户未被删除")
+	}
+
+	if err := s.userRepo.Restore(ctx, userID); err != nil {
+		return nil, fmt.Errorf("恢复用户失败: %w", err)
+	}
+
+	return s.GetUser(ctx, userID)
+}
+
+// getUser 获取未删除的用户
+func (s *adminService) getUser(ctx context.Context, userID uint) (*user.User, error) {
+	u, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户失败: %w", err)
+	}
+	if u == nil {
+		return nil, fmt.Errorf("用户不存在")
+	}
+	return u, nil
+}
+
+// ensureNotLastAdmin 移除管理员前检查，至少保留一个管理员
+func (s *adminService) ensureNotLastAdmin(ctx context.Context, u *user.User) error {
+	if !u.IsAdmin() {
+		return nil
+	}
+	admins, err := s.userRepo.CountByRole(ctx, user.RoleAdmin)
+	if err != nil {
+		return fmt.Errorf("统计管理员失败: %w", err)
+	}
+	if admins <= 1 {
+		return fmt.Errorf("不能移除最后一个管理员")
+	}
+	return nil
+}
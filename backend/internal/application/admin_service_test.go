@@ -0,0 +1,115 @@
+package application
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"temp-mailbox-service/internal/domain/user"
+	"temp-mailbox-service/internal/infrastructure/auth"
+	"temp-mailbox-service/internal/infrastructure/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// authorizeAdmin 使用 JWTAuth 和 AdminMiddleware 校验访问令牌，返回HTTP状态码
+func (f *sessionFixture) authorizeAdmin(accessToken string) int {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", middleware.JWTAuth(f.jwt, f.revocations), middleware.AdminMiddleware(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestUpdateUserRole_InvalidatesTokens(t *testing.T) {
+	f := setupSessions(t)
+	ctx := context.Background()
+	admins := NewAdminService(f.userRepo, nil, f.sessions)
+	root := f.createUser(t, "root", user.RoleAdmin)
+	other := f.createUser(t, "other", user.RoleAdmin)
+	bob := f.createUser(t, "bob", user.RoleUser)
+
+	t.Run("提升为管理员", func(t *testing.T) {
+		old := f.login(t, bob)
+		require.Equal(t, http.StatusForbidden, f.authorizeAdmin(old.AccessToken))
+
+		_, err := admins.UpdateUserRole(ctx, root.ID, bob.ID, &user.UpdateRoleRequest{Role: user.RoleAdmin})
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusUnauthorized, f.authorizeAdmin(old.AccessToken), "角色变更前签发的令牌应失效")
+		_, err = f.refresh(old.RefreshToken)
+		assert.Error(t, err, "角色变更后不能继续刷新旧会话")
+
+		bob.Role = user.RoleAdmin
+		assert.Equal(t, http.StatusOK, f.authorizeAdmin(f.login(t, bob).AccessToken))
+	})
+
+	t.Run("撤销管理员", func(t *testing.T) {
+		old := f.login(t, other)
+		require.Equal(t, http.StatusOK, f.authorizeAdmin(old.AccessToken))
+
+		_, err := admins.UpdateUserRole(ctx, root.ID, other.ID, &user.UpdateRoleRequest{Role: user.RoleUser})
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusUnauthorized, f.authorizeAdmin(old.AccessToken), "被撤销的管理员令牌不能继续访问管理接口")
+		_, err = f.refresh(old.RefreshToken)
+		assert.Error(t, err)
+	})
+
+	t.Run("不能修改自己的角色", func(t *testing.T) {
+		_, err := admins.UpdateUserRole(ctx, root.ID, root.ID, &user.UpdateRoleRequest{Role: user.RoleUser})
+		assert.Error(t, err)
+	})
+}
+
+func TestDeactivateUser_InvalidatesTokens(t *testing.T) {
+	f := setupSessions(t)
+	ctx := context.Background()
+	admins := NewAdminService(f.userRepo, nil, f.sessions)
+	root := f.createUser(t, "root", user.RoleAdmin)
+	bob := f.createUser(t, "bob", user.RoleUser)
+	first := f.login(t, bob)
+	second := f.login(t, bob)
+
+	_, err := admins.DeactivateUser(ctx, root.ID, root.ID)
+	assert.Error(t, err, "不能停用自己的账户")
+
+	resp, err := admins.DeactivateUser(ctx, root.ID, bob.ID)
+	require.NoError(t, err)
+	assert.False(t, resp.IsActive)
+
+	for _, pair := range []*auth.TokenPair{first, second} {
+		assert.Equal(t, http.StatusUnauthorized, f.authorize(pair.AccessToken), "停用后已签发的访问令牌应失效")
+		_, err = f.refresh(pair.RefreshToken)
+		assert.Error(t, err, "停用后不能刷新令牌")
+	}
+
+	// 重新激活不会恢复已注销的会话
+	_, err = admins.ActivateUser(ctx, bob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, f.authorize(first.AccessToken))
+	_, err = f.refresh(second.RefreshToken)
+	assert.Error(t, err)
+}
+
+func TestRefreshSession_RejectsInactiveUser(t *testing.T) {
+	f := setupSessions(t)
+	ctx := context.Background()
+	bob := f.createUser(t, "bob", user.RoleUser)
+	pair := f.login(t, bob)
+
+	// 绕过管理接口直接停用，刷新时仍然检查账户状态
+	require.NoError(t, f.userRepo.Deactivate(ctx, bob.ID))
+	_, err := f.refresh(pair.RefreshToken)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "停用")
+	assert.Equal(t, http.StatusUnauthorized, f.authorize(pair.AccessToken), "刷新时发现账户停用应注销会话")
+}
@@ -0,0 +1,174 @@
+package application
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"temp-mailbox-service/internal/domain/apikey"
+	"temp-mailbox-service/internal/domain/user"
+	"temp-mailbox-service/internal/infrastructure/auth"
+)
+
+const (
+	// maxAPIKeysPerUser 每个用户最多持有的有效API密钥数量
+	maxAPIKeysPerUser = 20
+	// apiKeyTouchInterval 最近使用时间的最小更新间隔，避免每个请求都写数据库
+	apiKeyTouchInterval = time.Minute
+)
+
+// APIKeyService API密钥服务接口，同时实现 auth.APIKeyAuthenticator 供认证中间件使用
+type APIKeyService interface {
+	CreateKey(ctx context.Context, userID uint, req *apikey.CreateAPIKeyRequest) (*apikey.CreatedAPIKeyResponse, error)
+	ListKeys(ctx context.Context, userID uint) ([]*apikey.APIKeyResponse, error)
+	RevokeKey(ctx context.Context, userID, keyID uint) error
+	AuthenticateAPIKey(ctx context.Context, key, clientIP string) (*auth.APIKeyPrincipal, error)
+}
+
+// apiKeyService API密钥服务实现
+type apiKeyService struct {
+	keyRepo  apikey.Repository
+	userRepo user.Repository
+}
+
+// NewAPIKeyService 创建API密钥服务实例
+func NewAPIKeyService(keyRepo apikey.Repository, userRepo user.Repository) APIKeyService {
+	return &apiKeyService{
+		keyRepo:  keyRepo,
+		userRepo: userRepo,
+	}
+}
+
+// CreateKey 创建API密钥，完整密钥只在响应中返回一次
+func (s *apiKeyService) CreateKey(ctx context.Context, userID uint, req *apikey.CreateAPIKeyRequest) (*apikey.CreatedAPIKeyResponse, error) {
+	count, err := s.keyRepo.CountActiveByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("统计API密钥失败: %w", err)
+	}
+	if count >= maxAPIKeysPerUser {
+		return nil, fmt.Errorf("最多只能创建%d个API密钥，请先吊销不再使用的密钥", maxAPIKeysPerUser)
+	}
+
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	key, prefix, secret, err := auth.GenerateAPIKey()
+	if err != nil {
+		return nil, fmt.Errorf("生成API密钥失败: %w", err)
+	}
+
+	now := time.Now()
+	k := &apikey.APIKey{
+		UserID:     userID,
+		Name:       strings.TrimSpace(req.Name),
+		Prefix:     prefix,
+		SecretHash: hashToken(secret),
+		Scopes:     strings.Join(scopes, " "),
+	}
+	if req.ExpiresIn > 0 {
+		expiresAt := now.AddDate(0, 0, req.ExpiresIn)
+		k.ExpiresAt = &expiresAt
+	}
+	if err := s.keyRepo.Create(ctx, k); err != nil {
+		return nil, fmt.Errorf("创建API密钥失败: %w", err)
+	}
+
+	return &apikey.CreatedAPIKeyResponse{
+		APIKeyResponse: k.ToResponse(now),
+		Key:            key,
+	}, nil
+}
+
+// ListKeys 获取用户未吊销的API密钥
+func (s *apiKeyService) ListKeys(ctx context.Context, userID uint) ([]*apikey.APIKeyResponse, error) {
+	keys, err := s.keyRepo.ListActiveByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取API密钥列表失败: %w", err)
+	}
+
+	now := time.Now()
+	items := make([]*apikey.APIKeyResponse, 0, len(keys))
+	for _, k := range keys {
+		items = append(items, k.ToResponse(now))
+	}
+	return items, nil
+}
+
+// RevokeKey 吊销用户自己的API密钥
+func (s *apiKeyService) RevokeKey(ctx context.Context, userID, keyID uint) error {
+	revoked, err := s.keyRepo.Revoke(ctx, keyID, userID)
+	if err != nil {
+		return fmt.Errorf("吊销API密钥失败: %w", err)
+	}
+	if !revoked {
+		return fmt.Errorf("API密钥不存在")
+	}
+	return nil
+}
+
+// AuthenticateAPIKey 校验API密钥，返回对应用户身份；用户被停用或删除后密钥随之失效
+func (s *apiKeyService) AuthenticateAPIKey(ctx context.Context, key, clientIP string) (*auth.APIKeyPrincipal, error) {
+	prefix, secret, ok := auth.ParseAPIKey(key)
+	if !ok {
+		return nil, auth.ErrAPIKeyInvalid
+	}
+
+	k, err := s.keyRepo.GetByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("获取API密钥失败: %w", err)
+	}
+	if k == nil || subtle.ConstantTimeCompare([]byte(k.SecretHash), []byte(hashToken(secret))) != 1 {
+		return nil, auth.ErrAPIKeyInvalid
+	}
+	now := time.Now()
+	if k.IsExpired(now) {
+		return nil, auth.ErrAPIKeyExpired
+	}
+
+	u, err := s.userRepo.GetByID(ctx, k.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户失败: %w", err)
+	}
+	if u == nil || !u.IsActive {
+		return nil, auth.ErrAPIKeyInvalid
+	}
+
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= apiKeyTouchInterval || k.LastUsedIP != clientIP {
+		if err := s.keyRepo.TouchLastUsed(ctx, k.ID, now, clientIP); err != nil {
+			log.Printf("更新API密钥 %d 使用时间失败: %v", k.ID, err)
+		}
+	}
+
+	return &auth.APIKeyPrincipal{
+		KeyID:    k.ID,
+		UserID:   u.ID,
+		Username: u.Username,
+		Email:    u.Email,
+		Role:     u.Role,
+		Scopes:   k.ScopeList(),
+	}, nil
+}
+
+// normalizeScopes 校验并去重授权范围
+func normalizeScopes(scopes []string) ([]string, error) {
+	seen := make(map[string]bool, len(scopes))
+	result := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !apikey.IsValidScope(scope) {
+			return nil, fmt.Errorf("无效的授权范围: %s", scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("至少需要一个授权范围")
+	}
+	return result, nil
+}
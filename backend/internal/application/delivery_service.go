@@ -0,0 +1,535 @@
+package application
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+
+	"temp-mailbox-service/internal/domain/mailbox"
+	"temp-mailbox-service/internal/domain/maildomain"
+	"temp-mailbox-service/internal/domain/mailfilter"
+	"temp-mailbox-service/internal/domain/message"
+	"temp-mailbox-service/internal/infrastructure/mailauth"
+	"temp-mailbox-service/internal/infrastructure/mailer"
+	"temp-mailbox-service/internal/infrastructure/mailparser"
+	"temp-mailbox-service/internal/infrastructure/sieve"
+	"temp-mailbox-service/internal/infrastructure/smtp"
+	"temp-mailbox-service/internal/infrastructure/spam"
+	"temp-mailbox-service/internal/infrastructure/storage"
+)
+
+// DeliveryService 邮件投递服务接口，作为SMTP服务器的后端
+type DeliveryService interface {
+	smtp.Backend
+}
+
+// deliveryService 邮件投递服务实现
+type deliveryService struct {
+	mailboxRepo mailbox.Repository
+	messageRepo message.Repository
+	domainRepo  maildomain.Repository
+	blobStore   storage.BlobStore
+	verifier    *mailauth.Verifier
+	spamEngine  *spam.Engine
+	filters     FilterService
+	forwarder   mailer.Sender
+}
+
+// NewDeliveryService 创建新的邮件投递服务实例，verifier 为 nil 时不进行来源验证，spamEngine 为 nil 时不进行垃圾邮件评分，
+// forwarder 用于执行 Sieve 脚本的 redirect
+func NewDeliveryService(mailboxRepo mailbox.Repository, messageRepo message.Repository, domainRepo maildomain.Repository, blobStore storage.BlobStore, verifier *mailauth.Verifier, spamEngine *spam.Engine, filters FilterService, forwarder mailer.Sender) DeliveryService {
+	return &deliveryService{
+		mailboxRepo: mailboxRepo,
+		messageRepo: messageRepo,
+		domainRepo:  domainRepo,
+		blobStore:   blobStore,
+		verifier:    verifier,
+		spamEngine:  spamEngine,
+		filters:     filters,
+		forwarder:   forwarder,
+	}
+}
+
+// CheckRecipient 只接收已验证域名下存在且未过期的邮箱，并执行只依赖信封发件人的拒收规则
+func (s *deliveryService) CheckRecipient(ctx context.Context, env *smtp.Envelope, rcpt string) error {
+	m, err := s.lookupMailbox(ctx, rcpt)
+	if err != nil {
+		return err
+	}
+
+	in := &mailfilter.Input{Sender: env.From}
+	verdict, err := s.filters.Evaluate(ctx, m.ID, mailfilter.StageRCPT, in)
+	if err != nil {
+		return err
+	}
+	if verdict.Rule != nil {
+		s.recordDecision(ctx, m.ID, verdict.Rule, mailfilter.StageRCPT, env, in, 0)
+		return smtp.ErrRejectedByPolicy
+	}
+	return nil
+}
+
+// Deliver 解析邮件并保存到每个收件人邮箱。所有收件人的邮件在同一事务中保存，
+// 保存失败时返回临时错误，发件方重试不会导致部分收件人收到重复邮件；
+// Sieve 转发在保存成功之后执行，避免重试时重复转发
+func (s *deliveryService) Deliver(ctx context.Context, env *smtp.Envelope, data []byte) error {
+	parsed, err := mailparser.Parse(data)
+	if err != nil {
+		// 无法解析的邮件仍然保存原始内容，方便用户查看
+		log.Printf("解析邮件失败，仅保存原始内容: %v", err)
+		parsed = &mailparser.Message{}
+	}
+
+	// 来源验证与收件人无关，每封邮件只验证一次
+	var authResults *mailauth.Results
+	if s.verifier != nil {
+		authResults = s.verifier.Verify(ctx, env.RemoteIP(), env.Helo, env.From, data)
+	}
+
+	// 垃圾邮件评分在保存附件之前进行，被拒收的邮件不占用存储
+	var spamResult *spam.Result
+	if s.spamEngine != nil {
+		spamResult = s.spamEngine.Evaluate(ctx, &spam.Message{
+			RemoteIP: env.RemoteIP(),
+			Helo:     env.Helo,
+			MailFrom: env.From,
+			Header:   parsed.Header,
+			Text:     parsed.Text,
+			HTML:     parsed.HTML,
+			Auth:     authResults,
+		})
+		if spamResult.Action == spam.ActionReject {
+			log.Printf("拒收垃圾邮件: from=%s ip=%s score=%.2f", env.From, env.RemoteIP(), spamResult.Score)
+			return smtp.ErrMessageRejected
+		}
+	}
+
+	attachments, err := s.storeAttachments(ctx, parsed.Attachments)
+	if err != nil {
+		return err
+	}
+	rawKey, err := s.storeRaw(ctx, data)
+	if err != nil {
+		return err
+	}
+
+	remoteIP := ""
+	if ip := env.RemoteIP(); ip != nil {
+		remoteIP = ip.String()
+	}
+	receivedAt := time.Now()
+	filterInput := &mailfilter.Input{
+		Sender:  env.From,
+		From:    headerFromAddress(parsed),
+		Subject: parsed.Subject,
+	}
+
+	var pending []*message.Message
+	var deleteAfterRead []*mailfilter.Rule
+	var forwards []*pendingForward
+	delivered, rejected := 0, 0
+	rejectReason := ""
+	// 同一地址可能以不同大小写或重复的 RCPT 出现，每个邮箱只保存一份
+	for _, rcpt := range uniqueRecipients(env.Recipients) {
+		m, err := s.lookupMailbox(ctx, rcpt)
+		if err != nil {
+			// 邮箱可能在RCPT之后过期或被删除，跳过该收件人；
+			// 查询失败返回临时错误，由发件方重试，避免邮件丢失
+			var smtpErr *smtp.Error
+			if errors.As(err, &smtpErr) {
+				continue
+			}
+			return err
+		}
+
+		verdict, err := s.filters.Evaluate(ctx, m.ID, mailfilter.StageData, filterInput)
+		if err != nil {
+			return err
+		}
+		if verdict.Rule != nil {
+			s.recordDecision(ctx, m.ID, verdict.Rule, mailfilter.StageData, env, filterInput, 0)
+			if verdict.Rule.Action == mailfilter.ActionReject {
+				rejected++
+			}
+			continue
+		}
+
+		// 过滤规则之后执行 Sieve 脚本，决定保存到哪些文件夹
+		folders, redirects, err := s.runScript(ctx, m, env, parsed, data, filterInput)
+		if err != nil {
+			var rejection *scriptRejection
+			if !errors.As(err, &rejection) {
+				return err
+			}
+			rejected++
+			rejectReason = rejection.reason
+			continue
+		}
+
+		msg := &message.Message{
+			MailboxID:    m.ID,
+			EnvelopeFrom: truncate(env.From, 320),
+			EnvelopeTo:   m.Address,
+			RemoteIP:     remoteIP,
+			Helo:         truncate(env.Helo, 255),
+			From:         truncate(parsed.From, 512),
+			To:           parsed.To,
+			Cc:           parsed.Cc,
+			ReplyTo:      truncate(parsed.ReplyTo, 512),
+			Subject:      truncate(parsed.Subject, 998),
+			MessageID:    truncate(parsed.MessageID, 998),
+			SentAt:       parsed.Date,
+			TextBody:     parsed.Text,
+			HTMLBody:     parsed.HTML,
+			Size:         len(data),
+			RawKey:       rawKey,
+			Attachments:  copyAttachments(attachments),
+			ReceivedAt:   receivedAt,
+		}
+		if parsed.Header != nil {
+			if err := msg.SetHeaders(parsed.Header); err != nil {
+				return fmt.Errorf("编码邮件头失败: %w", err)
+			}
+		}
+		if authResults != nil {
+			if err := msg.SetAuthResults(authResults); err != nil {
+				return fmt.Errorf("编码来源验证结果失败: %w", err)
+			}
+		}
+		if spamResult != nil {
+			msg.IsSpam = spamResult.Action == spam.ActionTag
+			msg.SpamScore = spamResult.Score
+			if err := msg.SetSpamResult(spamResult); err != nil {
+				return fmt.Errorf("编码垃圾邮件评分结果失败: %w", err)
+			}
+		}
+		msg.DeleteAfterRead = verdict.DeleteAfterRead != nil
+
+		// fileinto 多个文件夹时每个文件夹保存一份
+		for _, folder := range folders {
+			saved := *msg
+			saved.Folder = folder
+			saved.Attachments = copyAttachments(attachments)
+			pending = append(pending, &saved)
+			deleteAfterRead = append(deleteAfterRead, verdict.DeleteAfterRead)
+		}
+		if len(redirects) > 0 {
+			forwards = append(forwards, &pendingForward{mailbox: m, msg: msg, folders: folders, actions: redirects})
+		}
+		delivered++
+	}
+
+	if len(pending) > 0 {
+		if err := s.messageRepo.CreateBatch(ctx, pending); err != nil {
+			return fmt.Errorf("保存邮件失败: %w", err)
+		}
+	}
+	for i, saved := range pending {
+		if deleteAfterRead[i] != nil {
+			s.recordDecision(ctx, saved.MailboxID, deleteAfterRead[i], mailfilter.StageData, env, filterInput, saved.ID)
+		}
+	}
+	for _, f := range forwards {
+		s.forward(ctx, f, env, parsed, data, filterInput)
+	}
+
+	// DATA 阶段只能整体拒收，部分收件人拒收时对这些收件人按丢弃处理
+	if delivered == 0 && rejected > 0 {
+		if rejectReason != "" && rejected == 1 {
+			return &smtp.Error{Code: 550, EnhancedCode: "5.7.1", Message: rejectReason}
+		}
+		return smtp.ErrRejectedByPolicy
+	}
+	return nil
+}
+
+// scriptRejection Sieve 脚本执行了 reject
+type scriptRejection struct {
+	reason string
+}
+
+func (e *scriptRejection) Error() string {
+	return "rejected by sieve script: " + e.reason
+}
+
+// pendingForward 等待邮件保存后执行的 Sieve 转发
+type pendingForward struct {
+	mailbox *mailbox.Mailbox
+	msg     *message.Message // 转发失败时保存到收件箱的邮件模板
+	folders []string
+	actions []sieve.Action
+}
+
+// runScript 执行邮箱的 Sieve 脚本，返回需要保存邮件的文件夹和需要转发的 redirect 动作；
+// 脚本执行 reject 时返回 *scriptRejection，脚本出错时按隐式保留处理
+func (s *deliveryService) runScript(ctx context.Context, m *mailbox.Mailbox, env *smtp.Envelope, parsed *mailparser.Message, data []byte, in *mailfilter.Input) ([]string, []sieve.Action, error) {
+	inbox := []string{""}
+	result, err := s.filters.RunScript(ctx, m.ID, &sieve.Message{
+		Header:       parsed.Header,
+		Size:         len(data),
+		EnvelopeFrom: env.From,
+		EnvelopeTo:   m.Address,
+	})
+	var scriptErr *sieve.Error
+	if errors.As(err, &scriptErr) {
+		s.recordScriptDecision(ctx, m.ID, sieve.Action{Type: sieve.ActionKeep, Line: scriptErr.Line}, env, in, "脚本执行出错: "+scriptErr.Message)
+		return inbox, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if result == nil {
+		return inbox, nil, nil
+	}
+
+	for _, action := range result.Actions {
+		switch action.Type {
+		case sieve.ActionReject:
+			s.recordScriptDecision(ctx, m.ID, action, env, in, action.Argument)
+			return nil, nil, &scriptRejection{reason: rejectionText(action.Argument)}
+		case sieve.ActionDiscard:
+			s.recordScriptDecision(ctx, m.ID, action, env, in, "")
+		}
+	}
+
+	var redirects []sieve.Action
+	for _, action := range result.Actions {
+		if action.Type == sieve.ActionRedirect {
+			redirects = append(redirects, action)
+		}
+	}
+	return uniqueFolders(result.Folders()), redirects, nil
+}
+
+// forward 执行 Sieve 转发并记录结果。转发失败且邮件没有保存到收件箱时补存一份，避免邮件丢失
+func (s *deliveryService) forward(ctx context.Context, f *pendingForward, env *smtp.Envelope, parsed *mailparser.Message, data []byte, in *mailfilter.Input) {
+	to := make([]string, 0, len(f.actions))
+	for _, action := range f.actions {
+		to = append(to, action.Argument)
+	}
+
+	err := s.redirect(ctx, f.mailbox, parsed, data, to)
+	if err != nil {
+		log.Printf("Sieve转发失败，保存到收件箱: mailbox=%s: %v", f.mailbox.Address, err)
+		err = fmt.Errorf("转发失败，已保存到收件箱: %v", err)
+		if !containsFolder(f.folders, "") {
+			saved := *f.msg
+			saved.Folder = ""
+			saved.Attachments = copyAttachments(f.msg.Attachments)
+			if createErr := s.messageRepo.Create(ctx, &saved); createErr != nil {
+				log.Printf("保存转发失败的邮件到收件箱失败: mailbox=%s: %v", f.mailbox.Address, createErr)
+				err = fmt.Errorf("转发失败，保存到收件箱也失败: %v", createErr)
+			}
+		}
+	}
+	for _, action := range f.actions {
+		detail := action.Argument
+		if err != nil {
+			detail += "（" + err.Error() + "）"
+		}
+		s.recordScriptDecision(ctx, f.mailbox.ID, action, env, in, detail)
+	}
+}
+
+// redirect 原样转发邮件，开头添加 Delivered-To 以检测转发循环
+func (s *deliveryService) redirect(ctx context.Context, m *mailbox.Mailbox, parsed *mailparser.Message, data []byte, to []string) error {
+	if s.forwarder == nil {
+		return fmt.Errorf("服务器未启用 redirect 转发")
+	}
+	if parsed.Header != nil {
+		for _, value := range parsed.Header["Delivered-To"] {
+			if strings.EqualFold(strings.TrimSpace(value), m.Address) {
+				return fmt.Errorf("检测到转发循环")
+			}
+		}
+	}
+	forwarded := append([]byte("Delivered-To: "+m.Address+"\r\n"), data...)
+	return s.forwarder.Forward(ctx, to, forwarded)
+}
+
+// recordScriptDecision 记录 Sieve 脚本的拒收、丢弃、转发以及执行出错
+func (s *deliveryService) recordScriptDecision(ctx context.Context, mailboxID uint, action sieve.Action, env *smtp.Envelope, in *mailfilter.Input, detail string) {
+	d := &mailfilter.Decision{
+		MailboxID: mailboxID,
+		RuleName:  fmt.Sprintf("Sieve脚本第%d行", action.Line),
+		Stage:     mailfilter.StageData,
+		Action:    action.Type,
+		Sender:    env.From,
+		From:      in.From,
+		Subject:   in.Subject,
+		Detail:    truncate(detail, 500),
+	}
+	if ip := env.RemoteIP(); ip != nil {
+		d.RemoteIP = ip.String()
+	}
+	s.filters.RecordDecision(ctx, d)
+}
+
+// rejectionText 将拒收原因转换为单行的SMTP响应文本
+func rejectionText(reason string) string {
+	text := strings.Join(strings.Fields(reason), " ")
+	if text == "" {
+		return smtp.ErrRejectedByPolicy.Message
+	}
+	return truncate(text, 200)
+}
+
+// containsFolder 检查文件夹列表是否包含指定文件夹
+func containsFolder(folders []string, folder string) bool {
+	for _, f := range folders {
+		if f == folder {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueFolders 去除重复的文件夹，保持原有顺序
+func uniqueFolders(folders []string) []string {
+	seen := make(map[string]bool)
+	unique := folders[:0]
+	for _, folder := range folders {
+		if !seen[folder] {
+			seen[folder] = true
+			unique = append(unique, folder)
+		}
+	}
+	return unique
+}
+
+// uniqueRecipients 统一收件人地址的大小写和空白并去除重复，保持原有顺序
+func uniqueRecipients(recipients []string) []string {
+	seen := make(map[string]bool)
+	unique := make([]string, 0, len(recipients))
+	for _, rcpt := range recipients {
+		address := strings.ToLower(strings.TrimSpace(rcpt))
+		if !seen[address] {
+			seen[address] = true
+			unique = append(unique, address)
+		}
+	}
+	return unique
+}
+
+// recordDecision 记录过滤规则的处理结果
+func (s *deliveryService) recordDecision(ctx context.Context, mailboxID uint, rule *mailfilter.Rule, stage string, env *smtp.Envelope, in *mailfilter.Input, messageID uint) {
+	d := &mailfilter.Decision{
+		MailboxID: mailboxID,
+		RuleID:    rule.ID,
+		RuleName:  rule.Name,
+		Stage:     stage,
+		Action:    rule.Action,
+		Sender:    env.From,
+		From:      in.From,
+		Subject:   in.Subject,
+		MessageID: messageID,
+	}
+	if ip := env.RemoteIP(); ip != nil {
+		d.RemoteIP = ip.String()
+	}
+	s.filters.RecordDecision(ctx, d)
+}
+
+// headerFromAddress 获取邮件头 From 中第一个发件人的地址，无法解析时返回原始值
+func headerFromAddress(parsed *mailparser.Message) string {
+	if parsed.Header == nil {
+		return ""
+	}
+	value := parsed.Header.Get("From")
+	if addresses, err := mail.ParseAddressList(value); err == nil && len(addresses) > 0 {
+		return addresses[0].Address
+	}
+	return strings.TrimSpace(value)
+}
+
+// storeRaw 将原始邮件写入对象存储，所有收件人共享同一份内容，返回对象存储键
+func (s *deliveryService) storeRaw(ctx context.Context, data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	key := message.RawStorageKey(hex.EncodeToString(sum[:]))
+	if err := s.putBlob(ctx, key, data); err != nil {
+		return "", fmt.Errorf("保存原始邮件失败: %w", err)
+	}
+	return key, nil
+}
+
+// storeAttachments 将附件内容写入对象存储，返回附件元数据模板
+func (s *deliveryService) storeAttachments(ctx context.Context, parts []*mailparser.Attachment) ([]message.Attachment, error) {
+	attachments := make([]message.Attachment, 0, len(parts))
+	for _, part := range parts {
+		sum := sha256.Sum256(part.Data)
+		checksum := hex.EncodeToString(sum[:])
+		key := message.AttachmentStorageKey(checksum)
+		if err := s.putBlob(ctx, key, part.Data); err != nil {
+			return nil, fmt.Errorf("保存附件失败: %w", err)
+		}
+
+		attachments = append(attachments, message.Attachment{
+			Filename:    part.Filename,
+			ContentType: part.ContentType,
+			ContentID:   part.ContentID,
+			Inline:      part.Inline,
+			Size:        len(part.Data),
+			Checksum:    checksum,
+			StorageKey:  key,
+		})
+	}
+	return attachments, nil
+}
+
+// putBlob 按内容寻址写入对象存储；相同内容已存在时只更新写入时间，
+// 避免垃圾回收在保存邮件元数据之前删除刚被复用的对象
+func (s *deliveryService) putBlob(ctx context.Context, key string, data []byte) error {
+	touched, err := s.blobStore.Touch(ctx, key)
+	if err != nil || touched {
+		return err
+	}
+	return s.blobStore.Put(ctx, key, data)
+}
+
+// copyAttachments 为每个收件人复制一份附件元数据
+func copyAttachments(attachments []message.Attachment) []message.Attachment {
+	if len(attachments) == 0 {
+		return nil
+	}
+	return append([]message.Attachment(nil), attachments...)
+}
+
+// lookupMailbox 查找可接收邮件的邮箱，不可用时返回对应的SMTP错误
+func (s *deliveryService) lookupMailbox(ctx context.Context, rcpt string) (*mailbox.Mailbox, error) {
+	address := strings.ToLower(strings.TrimSpace(rcpt))
+	at := strings.LastIndex(address, "@")
+	if at <= 0 || at == len(address)-1 {
+		return nil, smtp.ErrMailboxUnavailable
+	}
+	managed, err := s.isManagedDomain(ctx, address[at+1:])
+	if err != nil {
+		return nil, err
+	}
+	if !managed {
+		return nil, smtp.ErrRelayDenied
+	}
+
+	m, err := s.mailboxRepo.GetByAddress(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("查询邮箱失败: %w", err)
+	}
+	if m == nil || m.IsExpired() {
+		return nil, smtp.ErrMailboxUnavailable
+	}
+	return m, nil
+}
+
+// isManagedDomain 检查域名是否为本服务托管且已验证的域名
+func (s *deliveryService) isManagedDomain(ctx context.Context, domain string) (bool, error) {
+	d, err := s.domainRepo.GetByName(ctx, domain)
+	if err != nil {
+		return false, fmt.Errorf("查询域名失败: %w", err)
+	}
+	return d != nil && d.IsVerified(), nil
+}
@@ -0,0 +1,333 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"temp-mailbox-service/internal/domain/mailbox"
+	"temp-mailbox-service/internal/domain/maildomain"
+	"temp-mailbox-service/internal/domain/mailfilter"
+	"temp-mailbox-service/internal/domain/message"
+	"temp-mailbox-service/internal/infrastructure/config"
+	"temp-mailbox-service/internal/infrastructure/dns"
+	"temp-mailbox-service/internal/infrastructure/mailer"
+	"temp-mailbox-service/internal/infrastructure/persistence"
+	"temp-mailbox-service/internal/infrastructure/smtp"
+	"temp-mailbox-service/internal/infrastructure/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type deliveryFixture struct {
+	delivery    DeliveryService
+	messages    MessageService
+	filters     FilterService
+	mailboxRepo mailbox.Repository
+	domainRepo  maildomain.Repository
+	messageRepo message.Repository
+	blobStore   storage.BlobStore
+	mailboxes   []*mailbox.Mailbox
+}
+
+// setupDelivery 创建系统域名 test.local 和属于用户1的邮箱
+func setupDelivery(t *testing.T, localParts ...string) *deliveryFixture {
+	setupTestDB(t)
+	ctx := context.Background()
+	mailboxRepo := persistence.NewMailboxRepository()
+	domainRepo := persistence.NewDomainRepository()
+	messageRepo := persistence.NewMessageRepository()
+	blobStore := storage.NewMemoryStore()
+
+	domains := NewDomainService(domainRepo, mailboxRepo, persistence.NewUserRepository(), dns.NewStaticResolver(), nil, nil, "mx.test.local")
+	require.NoError(t, domains.EnsureSystemDomains(ctx, []string{"test.local"}))
+
+	f := &deliveryFixture{mailboxRepo: mailboxRepo, domainRepo: domainRepo, messageRepo: messageRepo, blobStore: blobStore}
+	for _, localPart := range localParts {
+		m := &mailbox.Mailbox{
+			LocalPart: localPart,
+			Domain:    "test.local",
+			Address:   mailbox.BuildAddress(localPart, "test.local"),
+			UserID:    1,
+			ExpiresAt: time.Now().Add(time.Hour),
+			Status:    mailbox.StatusActive,
+		}
+		require.NoError(t, mailboxRepo.Create(ctx, m))
+		f.mailboxes = append(f.mailboxes, m)
+	}
+
+	f.build(messageRepo, &config.SieveConfig{}, nil)
+	return f
+}
+
+// build 使用指定的邮件仓储、Sieve配置和转发器创建服务
+func (f *deliveryFixture) build(messageRepo message.Repository, cfg *config.SieveConfig, forwarder mailer.Sender) {
+	f.filters = NewFilterService(f.mailboxRepo, messageRepo, persistence.NewMailFilterRepository(), cfg)
+	f.delivery = NewDeliveryService(f.mailboxRepo, messageRepo, f.domainRepo, f.blobStore, nil, nil, f.filters, forwarder)
+	f.messages = NewMessageService(f.mailboxRepo, messageRepo, f.blobStore, f.filters)
+}
+
+func (f *deliveryFixture) deliver(t *testing.T, data string) {
+	t.Helper()
+	require.NoError(t, f.tryDeliver(data))
+}
+
+func (f *deliveryFixture) tryDeliver(data string) error {
+	env := &smtp.Envelope{
+		RemoteAddr: &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 25},
+		Helo:       "client.example",
+		From:       "sender@example.com",
+	}
+	for _, m := range f.mailboxes {
+		env.Recipients = append(env.Recipients, m.Address)
+	}
+	return f.delivery.Deliver(context.Background(), env, []byte(data))
+}
+
+// countMessages 统计邮箱中的邮件数量
+func (f *deliveryFixture) countMessages(t *testing.T, mailboxID uint) int {
+	t.Helper()
+	msgs, err := f.messageRepo.ListByMailbox(context.Background(), mailboxID, 0, 100)
+	require.NoError(t, err)
+	return len(msgs)
+}
+
+// recordingForwarder 记录转发请求，forward 不为 nil 时在转发前调用
+type recordingForwarder struct {
+	forward   func() error
+	forwarded [][]string
+}
+
+func (r *recordingForwarder) Send(ctx context.Context, msg *mailer.Message) error {
+	return nil
+}
+
+func (r *recordingForwarder) Forward(ctx context.Context, to []string, data []byte) error {
+	r.forwarded = append(r.forwarded, to)
+	if r.forward != nil {
+		return r.forward()
+	}
+	return nil
+}
+
+// failingBatchRepo 批量保存总是失败的邮件仓储
+type failingBatchRepo struct {
+	message.Repository
+}
+
+func (failingBatchRepo) CreateBatch(ctx context.Context, msgs []*message.Message) error {
+	return errors.New("database is locked")
+}
+
+// failingLookupRepo 按地址查询邮箱总是失败的邮箱仓储
+type failingLookupRepo struct {
+	mailbox.Repository
+}
+
+func (failingLookupRepo) GetByAddress(ctx context.Context, address string) (*mailbox.Mailbox, error) {
+	return nil, errors.New("database is locked")
+}
+
+// hookedBatchRepo 在批量保存邮件之前调用 before，用于在写入存储和保存元数据之间插入操作
+type hookedBatchRepo struct {
+	message.Repository
+	before func()
+}
+
+func (r hookedBatchRepo) CreateBatch(ctx context.Context, msgs []*message.Message) error {
+	r.before()
+	return r.Repository.CreateBatch(ctx, msgs)
+}
+
+func TestDeliver_StoresRawOnceInBlobStore(t *testing.T) {
+	f := setupDelivery(t, "alice", "bob")
+	ctx := context.Background()
+	data := "From: sender@example.com\r\nSubject: hello\r\n\r\nbody\r\n"
+	f.deliver(t, data)
+
+	var rawKeys []string
+	for _, m := range f.mailboxes {
+		msgs, err := f.messageRepo.ListByMailbox(ctx, m.ID, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, msgs, 1)
+		rawKeys = append(rawKeys, msgs[0].RawKey)
+
+		raw, err := f.messages.GetRawMessage(ctx, 1, m.ID, msgs[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, data, string(raw))
+	}
+	assert.Equal(t, rawKeys[0], rawKeys[1], "所有收件人应共享同一份原始邮件")
+	assert.True(t, strings.HasPrefix(rawKeys[0], "raw/"))
+
+	keys, err := f.blobStore.List(ctx, "raw/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{rawKeys[0]}, keys)
+
+	reader, err := f.blobStore.Get(ctx, rawKeys[0])
+	require.NoError(t, err)
+	defer reader.Close()
+	stored, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, data, string(stored))
+}
+
+func TestDeliver_DeduplicatesRecipients(t *testing.T) {
+	f := setupDelivery(t, "alice", "bob")
+	alice, bob := f.mailboxes[0], f.mailboxes[1]
+	env := &smtp.Envelope{
+		RemoteAddr: &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 25},
+		Helo:       "client.example",
+		From:       "sender@example.com",
+		Recipients: []string{alice.Address, strings.ToUpper(alice.Address), " " + alice.Address + " ", bob.Address, bob.Address},
+	}
+	require.NoError(t, f.delivery.Deliver(context.Background(), env, []byte("From: sender@example.com\r\nSubject: hello\r\n\r\nbody\r\n")))
+
+	assert.Equal(t, 1, f.countMessages(t, alice.ID), "同一邮箱的重复收件人只保存一份")
+	assert.Equal(t, 1, f.countMessages(t, bob.ID))
+}
+
+func TestDeliver_LookupFailureIsTemporary(t *testing.T) {
+	f := setupDelivery(t, "alice")
+	f.mailboxRepo = failingLookupRepo{Repository: f.mailboxRepo}
+	f.build(f.messageRepo, &config.SieveConfig{}, nil)
+
+	err := f.tryDeliver("From: sender@example.com\r\nSubject: hello\r\n\r\nbody\r\n")
+	require.Error(t, err, "查询邮箱失败时不能当作投递成功")
+	var smtpErr *smtp.Error
+	assert.False(t, errors.As(err, &smtpErr), "应按临时错误处理，让发件方重试")
+	assert.Equal(t, 0, f.countMessages(t, f.mailboxes[0].ID))
+}
+
+func TestDeliver_TruncatesOversizedHeaders(t *testing.T) {
+	f := setupDelivery(t, "alice")
+	ctx := context.Background()
+	long := strings.Repeat("长", 600) // 每个字符3字节
+	data := "From: " + strings.Repeat("a", 600) + "@example.com\r\n" +
+		"Reply-To: " + strings.Repeat("b", 600) + "@example.com\r\n" +
+		"Subject: " + long + "\r\n" +
+		"Message-ID: <" + strings.Repeat("c", 1200) + "@example.com>\r\n\r\nbody\r\n"
+	f.deliver(t, data)
+
+	msgs, err := f.messageRepo.ListByMailbox(ctx, f.mailboxes[0].ID, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	msg, err := f.messageRepo.GetByID(ctx, msgs[0].ID)
+	require.NoError(t, err)
+
+	assert.LessOrEqual(t, len(msg.From), 512)
+	assert.LessOrEqual(t, len(msg.ReplyTo), 512)
+	assert.LessOrEqual(t, len(msg.Subject), 998)
+	assert.LessOrEqual(t, len(msg.MessageID), 998)
+	assert.True(t, strings.HasPrefix(long, msg.Subject), "截断不应破坏UTF-8字符")
+	assert.NotEmpty(t, msg.Subject)
+}
+
+func TestFilterService_RedirectRequiresConfig(t *testing.T) {
+	f := setupDelivery(t, "alice")
+	ctx := context.Background()
+	req := &mailfilter.ScriptRequest{Content: "keep;\nredirect \"out@example.org\";"}
+
+	_, err := f.filters.SaveScript(ctx, 1, f.mailboxes[0].ID, req)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "第2行")
+	assert.Contains(t, err.Error(), "未启用 redirect")
+
+	f.build(f.messageRepo, &config.SieveConfig{AllowRedirect: true}, nil)
+	_, err = f.filters.SaveScript(ctx, 1, f.mailboxes[0].ID, req)
+	assert.NoError(t, err)
+}
+
+func TestDeliver_RedirectAfterSave(t *testing.T) {
+	f := setupDelivery(t, "alice")
+	ctx := context.Background()
+	data := "From: sender@example.com\r\nSubject: hello\r\n\r\nbody\r\n"
+	mailboxID := f.mailboxes[0].ID
+	cfg := &config.SieveConfig{AllowRedirect: true}
+
+	forwarder := &recordingForwarder{}
+	forwarder.forward = func() error {
+		assert.Equal(t, 1, f.countMessages(t, mailboxID), "转发时邮件应该已经保存")
+		return nil
+	}
+	f.build(f.messageRepo, cfg, forwarder)
+	_, err := f.filters.SaveScript(ctx, 1, mailboxID, &mailfilter.ScriptRequest{Content: `keep; redirect "out@example.org";`})
+	require.NoError(t, err)
+
+	t.Run("保存失败时不转发", func(t *testing.T) {
+		f.build(failingBatchRepo{f.messageRepo}, cfg, forwarder)
+		require.Error(t, f.tryDeliver(data))
+		assert.Empty(t, forwarder.forwarded)
+	})
+
+	t.Run("保存成功后转发", func(t *testing.T) {
+		f.build(f.messageRepo, cfg, forwarder)
+		f.deliver(t, data)
+		assert.Equal(t, [][]string{{"out@example.org"}}, forwarder.forwarded)
+	})
+}
+
+func TestDeliver_RedirectFailureKeepsInInbox(t *testing.T) {
+	f := setupDelivery(t, "alice")
+	ctx := context.Background()
+	mailboxID := f.mailboxes[0].ID
+	f.build(f.messageRepo, &config.SieveConfig{AllowRedirect: true}, nil)
+	_, err := f.filters.SaveScript(ctx, 1, mailboxID, &mailfilter.ScriptRequest{Content: `redirect "out@example.org";`})
+	require.NoError(t, err)
+
+	// 关闭转发后已保存的 redirect 脚本不会丢失邮件
+	f.build(f.messageRepo, &config.SieveConfig{}, nil)
+	f.deliver(t, "From: sender@example.com\r\nSubject: hello\r\n\r\nbody\r\n")
+
+	msgs, err := f.messageRepo.ListByMailbox(ctx, mailboxID, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Empty(t, msgs[0].Folder)
+}
+
+func TestDeliver_ReusedBlobSurvivesCollection(t *testing.T) {
+	f := setupDelivery(t, "alice")
+	ctx := context.Background()
+	root := t.TempDir()
+	store, err := storage.NewLocalStore(root)
+	require.NoError(t, err)
+	f.blobStore = store
+	f.build(f.messageRepo, &config.SieveConfig{}, nil)
+	maintenance := NewMaintenanceService(nil, f.messageRepo, nil, nil, nil, nil, nil, nil, store, &config.SchedulerConfig{})
+
+	data := "From: sender@example.com\r\nSubject: hello\r\n\r\nbody\r\n"
+	f.deliver(t, data)
+	msgs, err := f.messageRepo.ListByMailbox(ctx, f.mailboxes[0].ID, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	rawKey := msgs[0].RawKey
+
+	// 邮件被清理后原始邮件成为超过保护期的待删除对象
+	_, err = f.messageRepo.PurgeReceivedBefore(ctx, time.Now().Add(time.Minute), purgeBatchSize)
+	require.NoError(t, err)
+	old := time.Now().Add(-2 * blobGracePeriod)
+	require.NoError(t, os.Chtimes(filepath.Join(root, filepath.FromSlash(rawKey)), old, old))
+	_, err = maintenance.CollectOrphanBlobs(ctx)
+	require.NoError(t, err)
+
+	// 相同内容再次投递，垃圾回收恰好在复用对象之后、保存邮件之前执行
+	f.build(hookedBatchRepo{Repository: f.messageRepo, before: func() {
+		_, err := maintenance.CollectOrphanBlobs(ctx)
+		require.NoError(t, err)
+	}}, &config.SieveConfig{}, nil)
+	f.deliver(t, data)
+
+	msgs, err = f.messageRepo.ListByMailbox(ctx, f.mailboxes[0].ID, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, rawKey, msgs[0].RawKey)
+	raw, err := f.messages.GetRawMessage(ctx, 1, f.mailboxes[0].ID, msgs[0].ID)
+	require.NoError(t, err, "被复用的原始邮件不应被垃圾回收删除")
+	assert.Equal(t, data, string(raw))
+}
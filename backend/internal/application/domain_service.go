@@ -0,0 +1,441 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"temp-mailbox-service/internal/domain/mailbox"
+	"temp-mailbox-service/internal/domain/maildomain"
+	"temp-mailbox-service/internal/domain/user"
+	"temp-mailbox-service/internal/infrastructure/config"
+	"temp-mailbox-service/internal/infrastructure/dns"
+)
+
+const (
+	// verificationTokenLength 域名验证令牌长度
+	verificationTokenLength = 32
+)
+
+// DomainService 托管域名服务接口
+type DomainService interface {
+	AddDomain(ctx context.Context, userID uint, req *maildomain.CreateDomainRequest) (*maildomain.DomainResponse, error)
+	ListDomains(ctx context.Context, userID uint, page, pageSize int) (*maildomain.DomainListResponse, error)
+	GetDomain(ctx context.Context, userID, domainID uint) (*maildomain.DomainResponse, error)
+	VerifyDomain(ctx context.Context, userID, domainID uint) (*maildomain.DomainResponse, error)
+	DisableDomain(ctx context.Context, userID, domainID uint) (*maildomain.DomainResponse, error)
+	EnableDomain(ctx context.Context, userID, domainID uint) (*maildomain.DomainResponse, error)
+	ProvisionDNS(ctx context.Context, userID, domainID uint) (*maildomain.DomainResponse, error)
+	DeleteDomain(ctx context.Context, userID, domainID uint) error
+	ListAvailableDomains(ctx context.Context, userID uint) ([]string, error)
+	EnsureSystemDomains(ctx context.Context, names []string) error
+	PurgeUnverifiedBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+// domainService 托管域名服务实现
+type domainService struct {
+	domainRepo  maildomain.Repository
+	mailboxRepo mailbox.Repository
+	userRepo    user.Repository
+	resolver    dns.Resolver
+	provider    dns.DNSProvider
+	cfg         *config.DNSConfig
+	mxTarget    string
+}
+
+// NewDomainService 创建新的托管域名服务实例
+// provider为nil时不自动配置DNS记录，mxTarget为域名MX记录应指向的主机名
+func NewDomainService(domainRepo maildomain.Repository, mailboxRepo mailbox.Repository, userRepo user.Repository, resolver dns.Resolver, provider dns.DNSProvider, cfg *config.DNSConfig, mxTarget string) DomainService {
+	return &domainService{
+		domainRepo:  domainRepo,
+		mailboxRepo: mailboxRepo,
+		userRepo:    userRepo,
+		resolver:    resolver,
+		provider:    provider,
+		cfg:         cfg,
+		mxTarget:    mxTarget,
+	}
+}
+
+// AddDomain 添加待验证的域名
+func (s *domainService) AddDomain(ctx context.Context, userID uint, req *maildomain.CreateDomainRequest) (*maildomain.DomainResponse, error) {
+	name, err := maildomain.NormalizeName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(name, ".") {
+		return nil, fmt.Errorf("域名格式无效")
+	}
+
+	exists, err := s.domainRepo.ExistsByName(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("检查域名失败: %w", err)
+	}
+	if exists {
+		return nil, fmt.Errorf("域名已被添加")
+	}
+
+	token, err := randomString(verificationTokenLength, localPartAlphabet)
+	if err != nil {
+		return nil, fmt.Errorf("生成验证令牌失败: %w", err)
+	}
+
+	newDomain := &maildomain.Domain{
+		Name:              name,
+		UserID:            userID,
+		Status:            maildomain.StatusPending,
+		VerificationToken: token,
+	}
+
+	if err := s.domainRepo.Create(ctx, newDomain); err != nil {
+		return nil, fmt.Errorf("添加域名失败: %w", err)
+	}
+
+	// 配置了DNS服务商时为管理员和允许的区域自动创建所需记录，失败时保留域名，可稍后重试；
+	// 其他域名需要用户添加验证TXT记录后再调用 ProvisionDNS
+	if s.provider != nil {
+		trusted, err := s.isTrustedForProvision(ctx, userID, newDomain.Name)
+		if err != nil {
+			return nil, err
+		}
+		if trusted {
+			if err := s.provisionRecords(ctx, newDomain); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return newDomain.ToResponse(s.mxTarget), nil
+}
+
+// ListDomains 分页获取用户添加的域名
+func (s *domainService) ListDomains(ctx context.Context, userID uint, page, pageSize int) (*maildomain.DomainListResponse, error) {
+	total, err := s.domainRepo.CountByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("统计域名数量失败: %w", err)
+	}
+
+	domains, err := s.domainRepo.ListByUser(ctx, userID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("获取域名列表失败: %w", err)
+	}
+
+	items := make([]*maildomain.DomainResponse, 0, len(domains))
+	for _, d := range domains {
+		items = append(items, d.ToResponse(s.mxTarget))
+	}
+
+	return &maildomain.DomainListResponse{
+		Items:    items,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// GetDomain 获取域名详情
+func (s *domainService) GetDomain(ctx context.Context, userID, domainID uint) (*maildomain.DomainResponse, error) {
+	d, err := s.getOwnedDomain(ctx, userID, domainID)
+	if err != nil {
+		return nil, err
+	}
+	return d.ToResponse(s.mxTarget), nil
+}
+
+// VerifyDomain 检查验证TXT记录和MX记录，全部通过后将域名标记为已验证
+func (s *domainService) VerifyDomain(ctx context.Context, userID, domainID uint) (*maildomain.DomainResponse, error) {
+	d, err := s.getOwnedDomain(ctx, userID, domainID)
+	if err != nil {
+		return nil, err
+	}
+	if d.Status == maildomain.StatusDisabled {
+		return nil, fmt.Errorf("域名已停用，请先启用")
+	}
+
+	checkErr := dns.VerifyTXT(ctx, s.resolver, d.VerificationRecordName(), d.VerificationRecordValue())
+	if checkErr == nil {
+		checkErr = dns.VerifyMX(ctx, s.resolver, d.Name, s.mxTarget)
+	}
+
+	now := time.Now()
+	d.LastCheckedAt = &now
+	if checkErr != nil {
+		d.LastCheckError = checkErr.Error()
+	} else {
+		d.LastCheckError = ""
+		d.Status = maildomain.StatusVerified
+		if d.VerifiedAt == nil {
+			d.VerifiedAt = &now
+		}
+	}
+
+	if err := s.domainRepo.Update(ctx, d); err != nil {
+		return nil, fmt.Errorf("更新域名失败: %w", err)
+	}
+	if checkErr != nil {
+		return nil, fmt.Errorf("域名验证失败: %v", checkErr)
+	}
+
+	return d.ToResponse(s.mxTarget), nil
+}
+
+// DisableDomain 停用域名，停用后不再创建邮箱和接收邮件
+func (s *domainService) DisableDomain(ctx context.Context, userID, domainID uint) (*maildomain.DomainResponse, error) {
+	d, err := s.getOwnedDomain(ctx, userID, domainID)
+	if err != nil {
+		return nil, err
+	}
+
+	d.Status = maildomain.StatusDisabled
+	if err := s.domainRepo.Update(ctx, d); err != nil {
+		return nil, fmt.Errorf("停用域名失败: %w", err)
+	}
+	return d.ToResponse(s.mxTarget), nil
+}
+
+// EnableDomain 启用域名，曾经通过验证的域名直接恢复为已验证状态
+func (s *domainService) EnableDomain(ctx context.Context, userID, domainID uint) (*maildomain.DomainResponse, error) {
+	d, err := s.getOwnedDomain(ctx, userID, domainID)
+	if err != nil {
+		return nil, err
+	}
+	if d.Status != maildomain.StatusDisabled {
+		return d.ToResponse(s.mxTarget), nil
+	}
+
+	d.Status = maildomain.StatusPending
+	if d.VerifiedAt != nil {
+		d.Status = maildomain.StatusVerified
+	}
+	if err := s.domainRepo.Update(ctx, d); err != nil {
+		return nil, fmt.Errorf("启用域名失败: %w", err)
+	}
+	return d.ToResponse(s.mxTarget), nil
+}
+
+// ProvisionDNS 通过DNS服务商（重新）配置域名所需的记录
+func (s *domainService) ProvisionDNS(ctx context.Context, userID, domainID uint) (*maildomain.DomainResponse, error) {
+	if s.provider == nil {
+		return nil, fmt.Errorf("未配置DNS服务商，请手动配置DNS记录")
+	}
+
+	d, err := s.getOwnedDomain(ctx, userID, domainID)
+	if err != nil {
+		return nil, err
+	}
+
+	// DNS服务商的令牌可能有权管理运营方的其他区域，不受信任的用户必须先自行添加验证TXT记录
+	trusted, err := s.isTrustedForProvision(ctx, userID, d.Name)
+	if err != nil {
+		return nil, err
+	}
+	if !trusted {
+		if err := dns.VerifyTXT(ctx, s.resolver, d.VerificationRecordName(), d.VerificationRecordValue()); err != nil {
+			return nil, fmt.Errorf("请先添加TXT记录 %s 证明域名所有权后再自动配置: %v", d.VerificationRecordName(), err)
+		}
+	}
+
+	if err := s.provisionRecords(ctx, d); err != nil {
+		return nil, err
+	}
+	if !d.DNSManaged {
+		return nil, fmt.Errorf("%s", d.LastCheckError)
+	}
+	return d.ToResponse(s.mxTarget), nil
+}
+
+// DeleteDomain 删除域名，域名下仍有有效邮箱时拒绝删除
+func (s *domainService) DeleteDomain(ctx context.Context, userID, domainID uint) error {
+	d, err := s.getOwnedDomain(ctx, userID, domainID)
+	if err != nil {
+		return err
+	}
+
+	active, err := s.mailboxRepo.CountActiveByDomain(ctx, d.Name)
+	if err != nil {
+		return fmt.Errorf("统计域名下的邮箱失败: %w", err)
+	}
+	if active > 0 {
+		return fmt.Errorf("域名下仍有%d个有效邮箱，无法删除", active)
+	}
+
+	if err := s.domainRepo.Delete(ctx, d.ID); err != nil {
+		return fmt.Errorf("删除域名失败: %w", err)
+	}
+	s.removeCreatedRecords(ctx, d)
+	return nil
+}
+
+// ListAvailableDomains 获取用户可用于创建邮箱的域名（已验证的系统域名和用户本人的域名）
+func (s *domainService) ListAvailableDomains(ctx context.Context, userID uint) ([]string, error) {
+	domains, err := s.domainRepo.ListVerified(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取可用域名失败: %w", err)
+	}
+
+	names := make([]string, 0, len(domains))
+	for _, d := range domains {
+		if d.IsAvailableTo(userID) {
+			names = append(names, d.Name)
+		}
+	}
+	return names, nil
+}
+
+// EnsureSystemDomains 将配置中的系统域名登记为已验证，已登记的系统域名保持不变；
+// 用户抢先添加但从未通过验证的同名域名会被收回，用户已验证的同名域名需要运营方手动处理
+func (s *domainService) EnsureSystemDomains(ctx context.Context, names []string) error {
+	for _, raw := range names {
+		name, err := maildomain.NormalizeName(raw)
+		if err != nil {
+			return fmt.Errorf("无效的系统域名 %q: %w", raw, err)
+		}
+
+		existing, err := s.domainRepo.GetByName(ctx, name)
+		if err != nil {
+			return fmt.Errorf("检查域名失败: %w", err)
+		}
+		if existing != nil {
+			if existing.IsSystem() {
+				continue
+			}
+			if existing.VerifiedAt != nil {
+				return fmt.Errorf("系统域名 %s 已被用户%d添加并通过验证，请先删除该用户域名", name, existing.UserID)
+			}
+			// 只删除域名记录，DNS记录可能正被系统域名使用，保持不变
+			log.Printf("系统域名 %s 已被用户%d添加但尚未验证，收回为系统域名", name, existing.UserID)
+			if err := s.domainRepo.Delete(ctx, existing.ID); err != nil {
+				return fmt.Errorf("收回系统域名失败: %w", err)
+			}
+		}
+
+		token, err := randomString(verificationTokenLength, localPartAlphabet)
+		if err != nil {
+			return fmt.Errorf("生成验证令牌失败: %w", err)
+		}
+		now := time.Now()
+		if err := s.domainRepo.Create(ctx, &maildomain.Domain{
+			Name:              name,
+			Status:            maildomain.StatusVerified,
+			VerificationToken: token,
+			VerifiedAt:        &now,
+		}); err != nil {
+			return fmt.Errorf("登记系统域名失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// PurgeUnverifiedBefore 删除指定时间之前添加、从未通过验证的用户域名，释放被占用的域名
+func (s *domainService) PurgeUnverifiedBefore(ctx context.Context, before time.Time) (int, error) {
+	deleted := 0
+	for {
+		domains, err := s.domainRepo.ListUnverifiedBefore(ctx, before, purgeBatchSize)
+		if err != nil {
+			return deleted, fmt.Errorf("获取待验证域名失败: %w", err)
+		}
+		for _, d := range domains {
+			if err := s.domainRepo.Delete(ctx, d.ID); err != nil {
+				return deleted, fmt.Errorf("删除域名失败: %w", err)
+			}
+			s.removeCreatedRecords(ctx, d)
+			deleted++
+		}
+		if len(domains) < purgeBatchSize {
+			return deleted, nil
+		}
+	}
+}
+
+// isTrustedForProvision 检查是否可以不经所有权验证直接为域名配置DNS记录：
+// 管理员添加的域名，或配置允许的区域的子域名（区域本身只有管理员可以配置）
+func (s *domainService) isTrustedForProvision(ctx context.Context, userID uint, name string) (bool, error) {
+	if s.cfg != nil {
+		for _, zone := range s.cfg.ProvisionZones {
+			zone = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(zone)), ".")
+			if zone != "" && strings.HasSuffix(name, "."+zone) {
+				return true, nil
+			}
+		}
+	}
+
+	u, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("获取用户失败: %w", err)
+	}
+	return u != nil && u.IsAdmin(), nil
+}
+
+// provisionRecords 创建域名所需的DNS记录并保存配置结果，只有保存失败时返回错误；
+// 已存在的记录不重复创建，也不记为本服务创建的记录
+func (s *domainService) provisionRecords(ctx context.Context, d *maildomain.Domain) error {
+	var provisionErr error
+	ids := d.CreatedRecordIDs()
+	for _, record := range d.RequiredRecords(s.mxTarget) {
+		created, err := dns.EnsureRecord(ctx, s.provider, toProviderRecord(record))
+		if err != nil {
+			provisionErr = fmt.Errorf("自动配置%s记录失败: %v", record.Type, err)
+			break
+		}
+		if created != nil && created.ID != "" {
+			ids[record.Type] = created.ID
+		}
+	}
+	d.SetCreatedRecordIDs(ids)
+
+	if provisionErr != nil {
+		d.LastCheckError = provisionErr.Error()
+	} else {
+		d.DNSManaged = true
+		d.LastCheckError = ""
+	}
+
+	if err := s.domainRepo.Update(ctx, d); err != nil {
+		return fmt.Errorf("更新域名失败: %w", err)
+	}
+	return nil
+}
+
+// removeCreatedRecords 清理本服务为域名创建的DNS记录，运营方事先手动添加的记录保持不变；
+// 失败时只记录日志，不影响域名删除
+func (s *domainService) removeCreatedRecords(ctx context.Context, d *maildomain.Domain) {
+	if s.provider == nil {
+		return
+	}
+	ids := d.CreatedRecordIDs()
+	for _, record := range d.RequiredRecords(s.mxTarget) {
+		id, ok := ids[record.Type]
+		if !ok {
+			continue
+		}
+		providerRecord := toProviderRecord(record)
+		providerRecord.ID = id
+		if err := s.provider.DeleteRecord(ctx, providerRecord); err != nil {
+			log.Printf("清理域名 %s 的%s记录失败: %v", d.Name, record.Type, err)
+		}
+	}
+}
+
+// toProviderRecord 转换为DNS服务商记录
+func toProviderRecord(record maildomain.DNSRecord) dns.Record {
+	return dns.Record{
+		Type:     record.Type,
+		Name:     record.Name,
+		Content:  record.Value,
+		Priority: record.Priority,
+	}
+}
+
+// getOwnedDomain 获取属于指定用户的域名，不属于该用户时视为不存在
+func (s *domainService) getOwnedDomain(ctx context.Context, userID, domainID uint) (*maildomain.Domain, error) {
+	d, err := s.domainRepo.GetByID(ctx, domainID)
+	if err != nil {
+		return nil, fmt.Errorf("获取域名失败: %w", err)
+	}
+	if d == nil || !d.IsOwnedBy(userID) {
+		return nil, fmt.Errorf("域名不存在")
+	}
+	return d, nil
+}
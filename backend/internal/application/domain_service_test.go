@@ -0,0 +1,223 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"temp-mailbox-service/internal/domain/maildomain"
+	"temp-mailbox-service/internal/domain/user"
+	"temp-mailbox-service/internal/infrastructure/config"
+	"temp-mailbox-service/internal/infrastructure/dns"
+	"temp-mailbox-service/internal/infrastructure/persistence"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDNSProvider 保存记录并记录创建请求的DNS服务商
+type fakeDNSProvider struct {
+	records []dns.Record
+	created []dns.Record
+	nextID  int
+}
+
+func (p *fakeDNSProvider) ListRecords(ctx context.Context, name string) ([]dns.Record, error) {
+	var matched []dns.Record
+	for _, r := range p.records {
+		if r.Name == name {
+			matched = append(matched, r)
+		}
+	}
+	return matched, nil
+}
+
+func (p *fakeDNSProvider) CreateRecord(ctx context.Context, record dns.Record) (*dns.Record, error) {
+	p.nextID++
+	record.ID = fmt.Sprintf("rec-%d", p.nextID)
+	p.records = append(p.records, record)
+	p.created = append(p.created, record)
+	return &record, nil
+}
+
+func (p *fakeDNSProvider) DeleteRecord(ctx context.Context, record dns.Record) error {
+	for i, r := range p.records {
+		if r.ID == record.ID {
+			p.records = append(p.records[:i], p.records[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func TestDomainService_ProvisionRequiresTrust(t *testing.T) {
+	setupTestDB(t)
+	ctx := context.Background()
+	userRepo := persistence.NewUserRepository()
+	admin := &user.User{Username: "admin", Email: "admin@example.com", Password: "x", Role: user.RoleAdmin, IsActive: true}
+	member := &user.User{Username: "member", Email: "member@example.com", Password: "x", Role: user.RoleUser, IsActive: true}
+	require.NoError(t, userRepo.Create(ctx, admin))
+	require.NoError(t, userRepo.Create(ctx, member))
+
+	provider := &fakeDNSProvider{}
+	resolver := dns.NewStaticResolver()
+	svc := NewDomainService(persistence.NewDomainRepository(), persistence.NewMailboxRepository(), userRepo, resolver, provider,
+		&config.DNSConfig{ProvisionZones: []string{"users.ops.example."}}, "mx.ops.example")
+
+	t.Run("普通用户添加运营方区域内的域名不会自动配置", func(t *testing.T) {
+		provider.created = nil
+		d, err := svc.AddDomain(ctx, member.ID, &maildomain.CreateDomainRequest{Name: "mail.ops.example"})
+		require.NoError(t, err)
+		assert.False(t, d.DNSManaged)
+		assert.Empty(t, provider.created)
+
+		_, err = svc.ProvisionDNS(ctx, member.ID, d.ID)
+		assert.Error(t, err, "未添加验证TXT记录时不能调用DNS服务商")
+		assert.Empty(t, provider.created)
+	})
+
+	t.Run("普通用户自行添加验证TXT记录后可以自动配置", func(t *testing.T) {
+		provider.created = nil
+		d, err := svc.AddDomain(ctx, member.ID, &maildomain.CreateDomainRequest{Name: "owned.example"})
+		require.NoError(t, err)
+		assert.Empty(t, provider.created)
+
+		stored, err := persistence.NewDomainRepository().GetByID(ctx, d.ID)
+		require.NoError(t, err)
+		resolver.TXT[stored.VerificationRecordName()] = []string{stored.VerificationRecordValue()}
+
+		d, err = svc.ProvisionDNS(ctx, member.ID, d.ID)
+		require.NoError(t, err)
+		assert.True(t, d.DNSManaged)
+		assert.NotEmpty(t, provider.created)
+	})
+
+	t.Run("允许的区域内的域名直接自动配置", func(t *testing.T) {
+		provider.created = nil
+		d, err := svc.AddDomain(ctx, member.ID, &maildomain.CreateDomainRequest{Name: "alice.users.ops.example"})
+		require.NoError(t, err)
+		assert.True(t, d.DNSManaged)
+		assert.NotEmpty(t, provider.created)
+	})
+
+	t.Run("普通用户添加允许的区域本身不会自动配置", func(t *testing.T) {
+		provider.created = nil
+		d, err := svc.AddDomain(ctx, member.ID, &maildomain.CreateDomainRequest{Name: "users.ops.example"})
+		require.NoError(t, err)
+		assert.False(t, d.DNSManaged)
+		assert.Empty(t, provider.created)
+
+		_, err = svc.ProvisionDNS(ctx, member.ID, d.ID)
+		assert.Error(t, err, "区域本身需要证明所有权才能自动配置")
+		assert.Empty(t, provider.created)
+	})
+
+	t.Run("管理员添加的域名直接自动配置", func(t *testing.T) {
+		provider.created = nil
+		d, err := svc.AddDomain(ctx, admin.ID, &maildomain.CreateDomainRequest{Name: "shop.ops.example"})
+		require.NoError(t, err)
+		assert.True(t, d.DNSManaged)
+		assert.NotEmpty(t, provider.created)
+	})
+}
+
+func TestDomainService_DeleteKeepsExistingRecords(t *testing.T) {
+	setupTestDB(t)
+	ctx := context.Background()
+	userRepo := persistence.NewUserRepository()
+	admin := &user.User{Username: "admin", Email: "admin@example.com", Password: "x", Role: user.RoleAdmin, IsActive: true}
+	require.NoError(t, userRepo.Create(ctx, admin))
+
+	// 运营方事先手动添加的MX记录
+	provider := &fakeDNSProvider{}
+	manual, err := provider.CreateRecord(ctx, dns.Record{Type: "MX", Name: "shop.ops.example", Content: "mx.ops.example", Priority: 10})
+	require.NoError(t, err)
+	provider.created = nil
+
+	svc := NewDomainService(persistence.NewDomainRepository(), persistence.NewMailboxRepository(), userRepo, dns.NewStaticResolver(), provider,
+		&config.DNSConfig{}, "mx.ops.example")
+	d, err := svc.AddDomain(ctx, admin.ID, &maildomain.CreateDomainRequest{Name: "shop.ops.example"})
+	require.NoError(t, err)
+	assert.True(t, d.DNSManaged)
+	require.Len(t, provider.created, 1, "已存在的MX记录不应重复创建")
+	assert.Equal(t, "TXT", provider.created[0].Type)
+
+	// 重新配置不会把已存在的记录记为本服务创建
+	_, err = svc.ProvisionDNS(ctx, admin.ID, d.ID)
+	require.NoError(t, err)
+	assert.Len(t, provider.created, 1)
+
+	require.NoError(t, svc.DeleteDomain(ctx, admin.ID, d.ID))
+	require.Len(t, provider.records, 1, "只清理本服务创建的记录")
+	assert.Equal(t, manual.ID, provider.records[0].ID)
+}
+
+func TestDomainService_EnsureSystemDomainsReclaimsPendingClaims(t *testing.T) {
+	setupTestDB(t)
+	ctx := context.Background()
+	domainRepo := persistence.NewDomainRepository()
+	svc := NewDomainService(domainRepo, persistence.NewMailboxRepository(), persistence.NewUserRepository(), dns.NewStaticResolver(), nil, nil, "mx.test.local")
+
+	// 用户在系统域名登记之前抢先添加了同名域名
+	squatted, err := svc.AddDomain(ctx, 2, &maildomain.CreateDomainRequest{Name: "test.local"})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.EnsureSystemDomains(ctx, []string{"test.local"}))
+	d, err := domainRepo.GetByName(ctx, "test.local")
+	require.NoError(t, err)
+	assert.True(t, d.IsSystem(), "未验证的用户域名应被收回为系统域名")
+	assert.True(t, d.IsVerified())
+	assert.NotEqual(t, squatted.ID, d.ID)
+
+	_, err = svc.AddDomain(ctx, 2, &maildomain.CreateDomainRequest{Name: "test.local"})
+	assert.Error(t, err, "不能添加已登记的系统域名")
+	require.NoError(t, svc.EnsureSystemDomains(ctx, []string{"test.local"}), "重复登记系统域名不应报错")
+
+	// 用户已验证的同名域名不能静默收回
+	now := time.Now()
+	require.NoError(t, domainRepo.Create(ctx, &maildomain.Domain{Name: "owned.example", UserID: 3, Status: maildomain.StatusVerified, VerificationToken: "t", VerifiedAt: &now}))
+	err = svc.EnsureSystemDomains(ctx, []string{"owned.example"})
+	require.Error(t, err)
+	d, err = domainRepo.GetByName(ctx, "owned.example")
+	require.NoError(t, err)
+	assert.Equal(t, uint(3), d.UserID)
+}
+
+func TestDomainService_PurgeUnverifiedBefore(t *testing.T) {
+	setupTestDB(t)
+	ctx := context.Background()
+	domainRepo := persistence.NewDomainRepository()
+	svc := NewDomainService(domainRepo, persistence.NewMailboxRepository(), persistence.NewUserRepository(), dns.NewStaticResolver(), nil, nil, "mx.test.local")
+	require.NoError(t, svc.EnsureSystemDomains(ctx, []string{"test.local"}))
+
+	old := time.Now().Add(-48 * time.Hour)
+	verifiedAt := old.Add(time.Hour)
+	create := func(name string, userID uint, createdAt time.Time, verified *time.Time) {
+		status := maildomain.StatusPending
+		if verified != nil {
+			status = maildomain.StatusVerified
+		}
+		d := &maildomain.Domain{Name: name, UserID: userID, Status: status, VerificationToken: "t", VerifiedAt: verified}
+		require.NoError(t, domainRepo.Create(ctx, d))
+		d.CreatedAt = createdAt
+		require.NoError(t, domainRepo.Update(ctx, d))
+	}
+	create("stale.example", 2, old, nil)
+	create("fresh.example", 2, time.Now(), nil)
+	create("verified.example", 2, old, &verifiedAt)
+
+	count, err := svc.PurgeUnverifiedBefore(ctx, time.Now().Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	for name, want := range map[string]bool{"stale.example": false, "fresh.example": true, "verified.example": true, "test.local": true} {
+		exists, err := domainRepo.ExistsByName(ctx, name)
+		require.NoError(t, err)
+		assert.Equal(t, want, exists, name)
+	}
+
+	// 过期的占用被清理后其他用户可以重新添加
+	_, err = svc.AddDomain(ctx, 3, &maildomain.CreateDomainRequest{Name: "stale.example"})
+	assert.NoError(t, err)
+}
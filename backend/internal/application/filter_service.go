@@ -0,0 +1,346 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"temp-mailbox-service/internal/domain/mailbox"
+	"temp-mailbox-service/internal/domain/mailfilter"
+	"temp-mailbox-service/internal/domain/message"
+	"temp-mailbox-service/internal/infrastructure/config"
+	"temp-mailbox-service/internal/infrastructure/sieve"
+)
+
+// maxRulesPerMailbox 每个邮箱最多可配置的过滤规则数量
+const maxRulesPerMailbox = 50
+
+// SieveDryRunResult Sieve 脚本试运行结果
+type SieveDryRunResult struct {
+	MessageID uint           `json:"message_id"`
+	Actions   []sieve.Action `json:"actions"`
+	Error     string         `json:"error,omitempty"` // 执行出错时投递会按隐式保留处理
+}
+
+// FilterService 邮箱收信过滤规则服务接口
+type FilterService interface {
+	ListRules(ctx context.Context, userID, mailboxID uint) ([]*mailfilter.Rule, error)
+	CreateRule(ctx context.Context, userID, mailboxID uint, req *mailfilter.RuleRequest) (*mailfilter.Rule, error)
+	UpdateRule(ctx context.Context, userID, mailboxID, ruleID uint, req *mailfilter.RuleRequest) (*mailfilter.Rule, error)
+	DeleteRule(ctx context.Context, userID, mailboxID, ruleID uint) error
+	ListDecisions(ctx context.Context, userID, mailboxID uint, page, pageSize int) (*mailfilter.DecisionListResponse, error)
+
+	GetScript(ctx context.Context, userID, mailboxID uint) (*mailfilter.Script, error)
+	SaveScript(ctx context.Context, userID, mailboxID uint, req *mailfilter.ScriptRequest) (*mailfilter.Script, error)
+	DeleteScript(ctx context.Context, userID, mailboxID uint) error
+	DryRunScript(ctx context.Context, userID, mailboxID uint, req *mailfilter.DryRunRequest) (*SieveDryRunResult, error)
+
+	// Evaluate 在投递的指定阶段执行邮箱的过滤规则
+	Evaluate(ctx context.Context, mailboxID uint, stage string, in *mailfilter.Input) (*mailfilter.Verdict, error)
+	// RecordDecision 保存过滤决定，失败时只记录日志
+	RecordDecision(ctx context.Context, d *mailfilter.Decision)
+	// RunScript 对投递中的邮件执行邮箱的 Sieve 脚本，没有启用的脚本时返回 nil；
+	// 脚本执行出错时返回 *sieve.Error，调用方应保存到收件箱
+	RunScript(ctx context.Context, mailboxID uint, m *sieve.Message) (*sieve.Result, error)
+}
+
+// filterService 邮箱收信过滤规则服务实现
+type filterService struct {
+	mailboxRepo mailbox.Repository
+	messageRepo message.Repository
+	filterRepo  mailfilter.Repository
+	config      *config.SieveConfig
+}
+
+// NewFilterService 创建新的过滤规则服务实例
+func NewFilterService(mailboxRepo mailbox.Repository, messageRepo message.Repository, filterRepo mailfilter.Repository, cfg *config.SieveConfig) FilterService {
+	return &filterService{
+		mailboxRepo: mailboxRepo,
+		messageRepo: messageRepo,
+		filterRepo:  filterRepo,
+		config:      cfg,
+	}
+}
+
+// ListRules 按执行顺序获取邮箱的过滤规则
+func (s *filterService) ListRules(ctx context.Context, userID, mailboxID uint) ([]*mailfilter.Rule, error) {
+	if _, err := findOwnedMailbox(ctx, s.mailboxRepo, userID, mailboxID); err != nil {
+		return nil, err
+	}
+
+	rules, err := s.filterRepo.ListRules(ctx, mailboxID)
+	if err != nil {
+		return nil, fmt.Errorf("获取过滤规则失败: %w", err)
+	}
+	if rules == nil {
+		rules = []*mailfilter.Rule{}
+	}
+	return rules, nil
+}
+
+// CreateRule 为邮箱添加过滤规则
+func (s *filterService) CreateRule(ctx context.Context, userID, mailboxID uint, req *mailfilter.RuleRequest) (*mailfilter.Rule, error) {
+	if _, err := findOwnedMailbox(ctx, s.mailboxRepo, userID, mailboxID); err != nil {
+		return nil, err
+	}
+
+	count, err := s.filterRepo.CountRules(ctx, mailboxID)
+	if err != nil {
+		return nil, fmt.Errorf("统计过滤规则失败: %w", err)
+	}
+	if count >= maxRulesPerMailbox {
+		return nil, fmt.Errorf("每个邮箱最多只能配置%d条过滤规则", maxRulesPerMailbox)
+	}
+
+	rule := &mailfilter.Rule{MailboxID: mailboxID}
+	if err := applyRuleRequest(rule, req); err != nil {
+		return nil, err
+	}
+	if err := s.filterRepo.CreateRule(ctx, rule); err != nil {
+		return nil, fmt.Errorf("创建过滤规则失败: %w", err)
+	}
+	return rule, nil
+}
+
+// UpdateRule 替换过滤规则的全部内容
+func (s *filterService) UpdateRule(ctx context.Context, userID, mailboxID, ruleID uint, req *mailfilter.RuleRequest) (*mailfilter.Rule, error) {
+	rule, err := s.getOwnedRule(ctx, userID, mailboxID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyRuleRequest(rule, req); err != nil {
+		return nil, err
+	}
+	if err := s.filterRepo.UpdateRule(ctx, rule); err != nil {
+		return nil, fmt.Errorf("更新过滤规则失败: %w", err)
+	}
+	return rule, nil
+}
+
+// DeleteRule 删除过滤规则
+func (s *filterService) DeleteRule(ctx context.Context, userID, mailboxID, ruleID uint) error {
+	if _, err := s.getOwnedRule(ctx, userID, mailboxID, ruleID); err != nil {
+		return err
+	}
+	if err := s.filterRepo.DeleteRule(ctx, ruleID); err != nil {
+		return fmt.Errorf("删除过滤规则失败: %w", err)
+	}
+	return nil
+}
+
+// ListDecisions 分页获取邮箱的过滤决定记录
+func (s *filterService) ListDecisions(ctx context.Context, userID, mailboxID uint, page, pageSize int) (*mailfilter.DecisionListResponse, error) {
+	if _, err := findOwnedMailbox(ctx, s.mailboxRepo, userID, mailboxID); err != nil {
+		return nil, err
+	}
+
+	total, err := s.filterRepo.CountDecisions(ctx, mailboxID)
+	if err != nil {
+		return nil, fmt.Errorf("统计过滤记录失败: %w", err)
+	}
+	decisions, err := s.filterRepo.ListDecisions(ctx, mailboxID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("获取过滤记录失败: %w", err)
+	}
+	if decisions == nil {
+		decisions = []*mailfilter.Decision{}
+	}
+
+	return &mailfilter.DecisionListResponse{
+		Items:    decisions,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// Evaluate 加载邮箱的过滤规则并按阶段执行
+func (s *filterService) Evaluate(ctx context.Context, mailboxID uint, stage string, in *mailfilter.Input) (*mailfilter.Verdict, error) {
+	rules, err := s.filterRepo.ListRules(ctx, mailboxID)
+	if err != nil {
+		return nil, fmt.Errorf("获取过滤规则失败: %w", err)
+	}
+	return mailfilter.Evaluate(rules, stage, in), nil
+}
+
+// RecordDecision 保存过滤决定
+func (s *filterService) RecordDecision(ctx context.Context, d *mailfilter.Decision) {
+	if err := s.filterRepo.CreateDecision(ctx, d); err != nil {
+		log.Printf("保存过滤记录失败: mailbox=%d rule=%d: %v", d.MailboxID, d.RuleID, err)
+	}
+}
+
+// GetScript 获取邮箱的 Sieve 脚本，未设置时返回 nil
+func (s *filterService) GetScript(ctx context.Context, userID, mailboxID uint) (*mailfilter.Script, error) {
+	if _, err := findOwnedMailbox(ctx, s.mailboxRepo, userID, mailboxID); err != nil {
+		return nil, err
+	}
+
+	script, err := s.filterRepo.GetScript(ctx, mailboxID)
+	if err != nil {
+		return nil, fmt.Errorf("获取Sieve脚本失败: %w", err)
+	}
+	return script, nil
+}
+
+// SaveScript 校验并保存邮箱的 Sieve 脚本，替换已有脚本
+func (s *filterService) SaveScript(ctx context.Context, userID, mailboxID uint, req *mailfilter.ScriptRequest) (*mailfilter.Script, error) {
+	if _, err := findOwnedMailbox(ctx, s.mailboxRepo, userID, mailboxID); err != nil {
+		return nil, err
+	}
+	parsed, err := sieve.Parse(req.Content)
+	if err != nil {
+		return nil, fmt.Errorf("Sieve脚本无效，%v", err)
+	}
+	// redirect 会以本服务的身份向任意地址发信，只有管理员开启后才允许使用
+	if line := parsed.FirstUse(sieve.ActionRedirect); line > 0 && !s.config.AllowRedirect {
+		return nil, fmt.Errorf("Sieve脚本无效，%v", &sieve.Error{Line: line, Message: "服务器未启用 redirect 转发"})
+	}
+
+	script, err := s.filterRepo.GetScript(ctx, mailboxID)
+	if err != nil {
+		return nil, fmt.Errorf("获取Sieve脚本失败: %w", err)
+	}
+	if script == nil {
+		script = &mailfilter.Script{MailboxID: mailboxID}
+	}
+	script.Content = req.Content
+	script.Enabled = req.Enabled == nil || *req.Enabled
+	if err := s.filterRepo.SaveScript(ctx, script); err != nil {
+		return nil, fmt.Errorf("保存Sieve脚本失败: %w", err)
+	}
+	return script, nil
+}
+
+// DeleteScript 删除邮箱的 Sieve 脚本
+func (s *filterService) DeleteScript(ctx context.Context, userID, mailboxID uint) error {
+	if _, err := findOwnedMailbox(ctx, s.mailboxRepo, userID, mailboxID); err != nil {
+		return err
+	}
+	if err := s.filterRepo.DeleteScript(ctx, mailboxID); err != nil {
+		return fmt.Errorf("删除Sieve脚本失败: %w", err)
+	}
+	return nil
+}
+
+// DryRunScript 对邮箱中已收到的邮件执行脚本并返回产生的动作，不会修改邮件
+func (s *filterService) DryRunScript(ctx context.Context, userID, mailboxID uint, req *mailfilter.DryRunRequest) (*SieveDryRunResult, error) {
+	if _, err := findOwnedMailbox(ctx, s.mailboxRepo, userID, mailboxID); err != nil {
+		return nil, err
+	}
+
+	var content string
+	if req.Content != nil {
+		content = *req.Content
+	} else {
+		stored, err := s.filterRepo.GetScript(ctx, mailboxID)
+		if err != nil {
+			return nil, fmt.Errorf("获取Sieve脚本失败: %w", err)
+		}
+		if stored == nil {
+			return nil, fmt.Errorf("邮箱未设置Sieve脚本")
+		}
+		content = stored.Content
+	}
+	script, err := sieve.Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("Sieve脚本无效，%v", err)
+	}
+
+	msg, err := s.messageRepo.GetByID(ctx, req.MessageID)
+	if err != nil {
+		return nil, fmt.Errorf("获取邮件失败: %w", err)
+	}
+	if msg == nil || msg.MailboxID != mailboxID {
+		return nil, fmt.Errorf("邮件不存在")
+	}
+
+	dryRun := &SieveDryRunResult{MessageID: msg.ID}
+	result, err := script.Execute(&sieve.Message{
+		Header:       msg.HeaderMap(),
+		Size:         msg.Size,
+		EnvelopeFrom: msg.EnvelopeFrom,
+		EnvelopeTo:   msg.EnvelopeTo,
+	})
+	if err != nil {
+		dryRun.Actions = []sieve.Action{{Type: sieve.ActionKeep}}
+		dryRun.Error = err.Error()
+		return dryRun, nil
+	}
+	dryRun.Actions = result.Actions
+	return dryRun, nil
+}
+
+// RunScript 加载并执行邮箱已启用的 Sieve 脚本
+func (s *filterService) RunScript(ctx context.Context, mailboxID uint, m *sieve.Message) (*sieve.Result, error) {
+	stored, err := s.filterRepo.GetScript(ctx, mailboxID)
+	if err != nil {
+		return nil, fmt.Errorf("获取Sieve脚本失败: %w", err)
+	}
+	if stored == nil || !stored.Enabled {
+		return nil, nil
+	}
+
+	script, err := sieve.Parse(stored.Content)
+	if err != nil {
+		// 保存时已校验，解析失败说明脚本在升级后不再兼容
+		log.Printf("邮箱 %d 的Sieve脚本无效，跳过: %v", mailboxID, err)
+		return nil, nil
+	}
+	return script.Execute(m)
+}
+
+// getOwnedRule 获取属于指定用户邮箱的规则
+func (s *filterService) getOwnedRule(ctx context.Context, userID, mailboxID, ruleID uint) (*mailfilter.Rule, error) {
+	if _, err := findOwnedMailbox(ctx, s.mailboxRepo, userID, mailboxID); err != nil {
+		return nil, err
+	}
+
+	rule, err := s.filterRepo.GetRule(ctx, ruleID)
+	if err != nil {
+		return nil, fmt.Errorf("获取过滤规则失败: %w", err)
+	}
+	if rule == nil || rule.MailboxID != mailboxID {
+		return nil, fmt.Errorf("过滤规则不存在")
+	}
+	return rule, nil
+}
+
+// applyRuleRequest 校验请求并写入规则
+func applyRuleRequest(rule *mailfilter.Rule, req *mailfilter.RuleRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return fmt.Errorf("规则名称不能为空")
+	}
+
+	rule.Name = name
+	rule.Enabled = req.Enabled == nil || *req.Enabled
+	rule.Priority = req.Priority
+	rule.Action = req.Action
+
+	// 没有条件的规则匹配所有邮件
+	if req.Field == "" {
+		if req.Operator != "" || req.Value != "" {
+			return fmt.Errorf("未指定匹配字段时不能设置匹配方式和匹配值")
+		}
+		rule.Field, rule.Operator, rule.Value, rule.Negate = "", "", "", false
+		return nil
+	}
+
+	if req.Operator == "" {
+		return fmt.Errorf("请指定匹配方式")
+	}
+	if req.Value == "" && req.Operator != mailfilter.OperatorEquals {
+		return fmt.Errorf("匹配值不能为空")
+	}
+	if req.Operator == mailfilter.OperatorRegex {
+		if _, err := regexp.Compile(req.Value); err != nil {
+			return fmt.Errorf("正则表达式无效: %v", err)
+		}
+	}
+	rule.Field, rule.Operator, rule.Value, rule.Negate = req.Field, req.Operator, req.Value, req.Negate
+	return nil
+}
@@ -0,0 +1,185 @@
+package application
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"temp-mailbox-service/internal/domain/mailfilter"
+	"temp-mailbox-service/internal/infrastructure/config"
+	"temp-mailbox-service/internal/infrastructure/database"
+	"temp-mailbox-service/internal/infrastructure/persistence"
+	"temp-mailbox-service/internal/infrastructure/smtp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addRule 为邮箱添加过滤规则
+func (f *deliveryFixture) addRule(t *testing.T, mailboxID uint, req *mailfilter.RuleRequest) *mailfilter.Rule {
+	t.Helper()
+	rule, err := f.filters.CreateRule(context.Background(), 1, mailboxID, req)
+	require.NoError(t, err)
+	return rule
+}
+
+// decisions 获取邮箱的过滤决定记录，按时间倒序
+func (f *deliveryFixture) decisions(t *testing.T, mailboxID uint) []*mailfilter.Decision {
+	t.Helper()
+	resp, err := f.filters.ListDecisions(context.Background(), 1, mailboxID, 1, 100)
+	require.NoError(t, err)
+	return resp.Items
+}
+
+func TestFilterRules_Reject(t *testing.T) {
+	f := setupDelivery(t, "alice")
+	ctx := context.Background()
+	mailboxID := f.mailboxes[0].ID
+	f.addRule(t, mailboxID, &mailfilter.RuleRequest{
+		Name:     "只接收公司邮件",
+		Field:    mailfilter.FieldSender,
+		Operator: mailfilter.OperatorGlob,
+		Value:    "*@ourcompany.com",
+		Negate:   true,
+		Action:   mailfilter.ActionReject,
+	})
+
+	t.Run("RCPT阶段", func(t *testing.T) {
+		env := &smtp.Envelope{
+			RemoteAddr: &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 25},
+			From:       "sender@example.com",
+		}
+		assert.Equal(t, smtp.ErrRejectedByPolicy, f.delivery.CheckRecipient(ctx, env, f.mailboxes[0].Address))
+
+		env.From = "colleague@ourcompany.com"
+		assert.NoError(t, f.delivery.CheckRecipient(ctx, env, f.mailboxes[0].Address))
+	})
+
+	t.Run("DATA阶段", func(t *testing.T) {
+		err := f.tryDeliver("From: sender@example.com\r\nSubject: hello\r\n\r\nbody\r\n")
+		assert.Equal(t, smtp.ErrRejectedByPolicy, err)
+		assert.Equal(t, 0, f.countMessages(t, mailboxID))
+	})
+
+	decisions := f.decisions(t, mailboxID)
+	require.Len(t, decisions, 2)
+	assert.Equal(t, mailfilter.StageData, decisions[0].Stage)
+	assert.Equal(t, mailfilter.StageRCPT, decisions[1].Stage)
+	for _, d := range decisions {
+		assert.Equal(t, mailfilter.ActionReject, d.Action)
+		assert.Equal(t, "只接收公司邮件", d.RuleName)
+		assert.Equal(t, "sender@example.com", d.Sender)
+	}
+}
+
+func TestFilterRules_Discard(t *testing.T) {
+	f := setupDelivery(t, "alice", "bob")
+	alice, bob := f.mailboxes[0].ID, f.mailboxes[1].ID
+	f.addRule(t, alice, &mailfilter.RuleRequest{
+		Name:     "丢弃广告",
+		Field:    mailfilter.FieldSubject,
+		Operator: mailfilter.OperatorContains,
+		Value:    "promo",
+		Action:   mailfilter.ActionDiscard,
+	})
+
+	// 丢弃对发件方表现为投递成功，其他收件人照常收到
+	f.deliver(t, "From: sender@example.com\r\nSubject: Big PROMO today\r\n\r\nbody\r\n")
+	assert.Equal(t, 0, f.countMessages(t, alice))
+	assert.Equal(t, 1, f.countMessages(t, bob))
+
+	f.deliver(t, "From: sender@example.com\r\nSubject: hello\r\n\r\nbody\r\n")
+	assert.Equal(t, 1, f.countMessages(t, alice))
+
+	decisions := f.decisions(t, alice)
+	require.Len(t, decisions, 1)
+	assert.Equal(t, mailfilter.ActionDiscard, decisions[0].Action)
+	assert.Equal(t, "Big PROMO today", decisions[0].Subject)
+	assert.Empty(t, f.decisions(t, bob))
+}
+
+func TestGetMessage_MarksRead(t *testing.T) {
+	f := setupDelivery(t, "alice")
+	ctx := context.Background()
+	mailboxID := f.mailboxes[0].ID
+	f.deliver(t, "From: sender@example.com\r\nSubject: hello\r\n\r\nbody\r\n")
+
+	msgs, err := f.messageRepo.ListByMailbox(ctx, mailboxID, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	require.False(t, msgs[0].IsRead)
+
+	resp, err := f.messages.GetMessage(ctx, 1, mailboxID, msgs[0].ID, true)
+	require.NoError(t, err)
+	assert.True(t, resp.IsRead)
+
+	stored, err := f.messageRepo.GetByID(ctx, msgs[0].ID)
+	require.NoError(t, err)
+	assert.True(t, stored.IsRead)
+	assert.Empty(t, f.decisions(t, mailboxID), "没有规则时不记录过滤决定")
+}
+
+func TestFilterRules_DeleteAfterRead(t *testing.T) {
+	f := setupDelivery(t, "alice")
+	ctx := context.Background()
+	mailboxID := f.mailboxes[0].ID
+	rule := f.addRule(t, mailboxID, &mailfilter.RuleRequest{
+		Name:   "阅后即焚",
+		Action: mailfilter.ActionDeleteAfterRead,
+	})
+
+	f.deliver(t, "From: sender@example.com\r\nSubject: one-time code\r\n\r\n123456\r\n")
+	msgs, err := f.messageRepo.ListByMailbox(ctx, mailboxID, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	messageID := msgs[0].ID
+	assert.True(t, msgs[0].DeleteAfterRead)
+
+	resp, err := f.messages.GetMessage(ctx, 1, mailboxID, messageID, true)
+	require.NoError(t, err, "首次查看正常返回内容")
+	assert.Equal(t, "one-time code", resp.Subject)
+	assert.Contains(t, resp.TextBody, "123456")
+
+	_, err = f.messages.GetMessage(ctx, 1, mailboxID, messageID, true)
+	assert.Error(t, err, "查看之后邮件被删除")
+	assert.Equal(t, 0, f.countMessages(t, mailboxID))
+
+	decisions := f.decisions(t, mailboxID)
+	require.Len(t, decisions, 2)
+	assert.Equal(t, mailfilter.StageRead, decisions[0].Stage)
+	assert.Equal(t, mailfilter.StageData, decisions[1].Stage)
+	for _, d := range decisions {
+		assert.Equal(t, mailfilter.ActionDeleteAfterRead, d.Action)
+		assert.Equal(t, messageID, d.MessageID)
+	}
+	assert.Equal(t, rule.ID, decisions[1].RuleID)
+}
+
+func TestPurgeMessages_DeletesOldDecisions(t *testing.T) {
+	f := setupDelivery(t, "alice")
+	ctx := context.Background()
+	mailboxID := f.mailboxes[0].ID
+	filterRepo := persistence.NewMailFilterRepository()
+	maintenance := NewMaintenanceService(nil, f.messageRepo, filterRepo, nil, nil, nil, nil, nil, f.blobStore, &config.SchedulerConfig{MessageRetention: 60})
+
+	for _, subject := range []string{"old", "new"} {
+		require.NoError(t, filterRepo.CreateDecision(ctx, &mailfilter.Decision{
+			MailboxID: mailboxID,
+			Stage:     mailfilter.StageData,
+			Action:    mailfilter.ActionDiscard,
+			Subject:   subject,
+		}))
+	}
+	require.NoError(t, database.DB.Model(&mailfilter.Decision{}).
+		Where("subject = ?", "old").
+		Update("created_at", time.Now().Add(-2*time.Hour)).Error)
+
+	summary, err := maintenance.PurgeMessages(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, summary, "1条过滤记录")
+
+	decisions := f.decisions(t, mailboxID)
+	require.Len(t, decisions, 1, "保留期限内的记录不受影响")
+	assert.Equal(t, "new", decisions[0].Subject)
+}
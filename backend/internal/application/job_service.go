@@ -0,0 +1,139 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"temp-mailbox-service/internal/domain/job"
+	"temp-mailbox-service/internal/infrastructure/scheduler"
+)
+
+// JobService 后台任务管理服务接口
+type JobService interface {
+	ListJobs(ctx context.Context) ([]*job.JobResponse, error)
+	ListRuns(ctx context.Context, jobName string, page, pageSize int) (*job.RunListResponse, error)
+	TriggerJob(ctx context.Context, jobName string) error
+}
+
+// jobService 后台任务管理服务实现
+type jobService struct {
+	jobRepo   job.Repository
+	scheduler *scheduler.Scheduler
+}
+
+// NewJobService 创建新的后台任务管理服务实例
+func NewJobService(jobRepo job.Repository, s *scheduler.Scheduler) JobService {
+	return &jobService{
+		jobRepo:   jobRepo,
+		scheduler: s,
+	}
+}
+
+// ListJobs 获取所有任务及其最近一次执行记录
+func (s *jobService) ListJobs(ctx context.Context) ([]*job.JobResponse, error) {
+	infos := s.scheduler.Jobs()
+	jobs := make([]*job.JobResponse, 0, len(infos))
+	for _, info := range infos {
+		lastRun, err := s.jobRepo.LatestByJob(ctx, info.Name)
+		if err != nil {
+			return nil, fmt.Errorf("获取任务执行记录失败: %w", err)
+		}
+		jobs = append(jobs, &job.JobResponse{
+			Name:        info.Name,
+			Spec:        info.Spec,
+			Description: info.Description,
+			Running:     info.Running,
+			NextRunAt:   info.NextRun,
+			LastRun:     lastRun,
+		})
+	}
+	return jobs, nil
+}
+
+// ListRuns 分页获取任务的执行记录
+func (s *jobService) ListRuns(ctx context.Context, jobName string, page, pageSize int) (*job.RunListResponse, error) {
+	if _, ok := s.scheduler.Job(jobName); !ok {
+		return nil, fmt.Errorf("任务不存在")
+	}
+
+	total, err := s.jobRepo.CountByJob(ctx, jobName)
+	if err != nil {
+		return nil, fmt.Errorf("统计执行记录失败: %w", err)
+	}
+
+	runs, err := s.jobRepo.ListByJob(ctx, jobName, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("获取执行记录失败: %w", err)
+	}
+	if runs == nil {
+		runs = []*job.Run{}
+	}
+
+	return &job.RunListResponse{
+		Items:    runs,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// TriggerJob 立即执行一次任务（异步）
+func (s *jobService) TriggerJob(ctx context.Context, jobName string) error {
+	err := s.scheduler.Trigger(jobName)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, scheduler.ErrJobNotFound):
+		return fmt.Errorf("任务不存在")
+	case errors.Is(err, scheduler.ErrJobRunning):
+		return fmt.Errorf("任务正在执行，请稍后再试")
+	case errors.Is(err, scheduler.ErrStopped):
+		return fmt.Errorf("服务正在关闭，无法执行任务")
+	default:
+		return fmt.Errorf("触发任务失败: %w", err)
+	}
+}
+
+// jobRecorder 将任务执行结果保存到数据库
+type jobRecorder struct {
+	jobRepo job.Repository
+}
+
+// NewJobRecorder 创建任务执行记录器
+func NewJobRecorder(jobRepo job.Repository) scheduler.Recorder {
+	return &jobRecorder{jobRepo: jobRepo}
+}
+
+// RecordRun 保存一次任务执行记录
+func (r *jobRecorder) RecordRun(ctx context.Context, run *scheduler.Run) {
+	record := &job.Run{
+		JobName:    run.Job,
+		Trigger:    string(run.Trigger),
+		Status:     job.StatusSuccess,
+		StartedAt:  run.StartedAt,
+		FinishedAt: run.FinishedAt,
+		DurationMs: run.FinishedAt.Sub(run.StartedAt).Milliseconds(),
+		Result:     truncate(run.Result, 1024),
+	}
+	if run.Err != nil {
+		record.Status = job.StatusFailed
+		record.Error = truncate(run.Err.Error(), 1024)
+	}
+
+	if err := r.jobRepo.Create(ctx, record); err != nil {
+		log.Printf("保存任务 %s 的执行记录失败: %v", run.Job, err)
+	}
+}
+
+// truncate 按字节截断字符串，不截断多字节字符
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && max < len(s) && s[max]&0xC0 == 0x80 {
+		max--
+	}
+	return s[:max]
+}
@@ -0,0 +1,233 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"temp-mailbox-service/internal/domain/lockout"
+	"temp-mailbox-service/internal/infrastructure/config"
+)
+
+// LoginThrottledError 登录因失败次数过多被锁定或需要等待
+type LoginThrottledError struct {
+	Locked     bool
+	RetryAfter time.Duration
+}
+
+// Error 实现 error 接口
+func (e *LoginThrottledError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("登录失败次数过多，已临时锁定，请%s后再试", humanizeWait(e.RetryAfter))
+	}
+	return fmt.Sprintf("登录尝试过于频繁，请%s后再试", humanizeWait(e.RetryAfter))
+}
+
+// LockoutService 登录失败锁定服务接口
+type LockoutService interface {
+	// Check 检查登录邮箱和IP是否被锁定或仍需等待，返回 *LoginThrottledError
+	Check(ctx context.Context, email, ip string) error
+	RecordFailure(ctx context.Context, email, ip string) error
+	RecordSuccess(ctx context.Context, email string) error
+
+	// 管理
+	ListLockouts(ctx context.Context, filter *lockout.Filter, page, pageSize int) (*lockout.RecordListResponse, error)
+	ClearLockout(ctx context.Context, id uint) error
+	PruneStale(ctx context.Context) (int64, error)
+}
+
+// lockoutService 登录失败锁定服务实现
+type lockoutService struct {
+	repo lockout.Repository
+	cfg  *config.LockoutConfig
+}
+
+// NewLockoutService 创建新的登录失败锁定服务实例
+func NewLockoutService(repo lockout.Repository, cfg *config.LockoutConfig) LockoutService {
+	return &lockoutService{
+		repo: repo,
+		cfg:  cfg,
+	}
+}
+
+// lockoutTarget 一个统计维度及其失败上限
+type lockoutTarget struct {
+	kind        lockout.Kind
+	value       string
+	maxAttempts int
+}
+
+// Check 检查登录邮箱和IP是否被锁定或仍需等待，返回等待时间最长的结果
+func (s *lockoutService) Check(ctx context.Context, email, ip string) error {
+	if !s.cfg.Enabled {
+		return nil
+	}
+
+	now := time.Now()
+	var throttled *LoginThrottledError
+	for _, target := range s.targets(email, ip) {
+		rec, err := s.repo.Get(ctx, target.kind, target.value)
+		if err != nil {
+			return fmt.Errorf("获取登录失败记录失败: %w", err)
+		}
+		if rec == nil {
+			continue
+		}
+
+		var wait time.Duration
+		locked := rec.IsLocked(now)
+		if locked {
+			wait = rec.LockedUntil.Sub(now)
+		} else if rec.LockedUntil == nil && !rec.FirstFailureAt.Before(s.windowStart(now)) {
+			// 锁定到期后重新计数，不再叠加等待
+			wait = rec.LastFailureAt.Add(s.delay(rec.Failures)).Sub(now)
+		}
+
+		if wait > 0 && (throttled == nil || wait > throttled.RetryAfter) {
+			throttled = &LoginThrottledError{Locked: locked, RetryAfter: wait}
+		}
+	}
+
+	if throttled != nil {
+		return throttled
+	}
+	return nil
+}
+
+// RecordFailure 记录一次登录失败，达到上限时锁定
+func (s *lockoutService) RecordFailure(ctx context.Context, email, ip string) error {
+	if !s.cfg.Enabled {
+		return nil
+	}
+
+	now := time.Now()
+	for _, target := range s.targets(email, ip) {
+		rec, err := s.repo.RecordFailure(ctx, target.kind, target.value, now, s.windowStart(now))
+		if err != nil {
+			return fmt.Errorf("记录登录失败失败: %w", err)
+		}
+		if rec.Failures < target.maxAttempts || rec.IsLocked(now) {
+			continue
+		}
+
+		until := now.Add(time.Duration(s.cfg.Duration) * time.Minute)
+		if err := s.repo.Lock(ctx, rec.ID, until); err != nil {
+			return fmt.Errorf("锁定登录失败: %w", err)
+		}
+		log.Printf("登录失败%d次，锁定 %s %s 至 %s", rec.Failures, target.kind, target.value, until.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// RecordSuccess 登录成功后清除该账户的失败记录（IP维度的记录保留）
+func (s *lockoutService) RecordSuccess(ctx context.Context, email string) error {
+	if !s.cfg.Enabled {
+		return nil
+	}
+	if err := s.repo.Delete(ctx, lockout.KindAccount, normalizeEmail(email)); err != nil {
+		return fmt.Errorf("清除登录失败记录失败: %w", err)
+	}
+	return nil
+}
+
+// ListLockouts 分页获取登录失败记录
+func (s *lockoutService) ListLockouts(ctx context.Context, filter *lockout.Filter, page, pageSize int) (*lockout.RecordListResponse, error) {
+	if filter.Kind != "" && filter.Kind != lockout.KindAccount && filter.Kind != lockout.KindIP {
+		return nil, fmt.Errorf("无效的记录类型")
+	}
+
+	now := time.Now()
+	total, err := s.repo.Count(ctx, filter, now)
+	if err != nil {
+		return nil, fmt.Errorf("统计登录失败记录失败: %w", err)
+	}
+
+	records, err := s.repo.List(ctx, filter, now, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("获取登录失败记录失败: %w", err)
+	}
+
+	items := make([]*lockout.RecordResponse, 0, len(records))
+	for _, rec := range records {
+		items = append(items, rec.ToResponse(now))
+	}
+
+	return &lockout.RecordListResponse{
+		Items:    items,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// ClearLockout 清除登录失败记录，立即解除锁定
+func (s *lockoutService) ClearLockout(ctx context.Context, id uint) error {
+	rec, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("获取登录失败记录失败: %w", err)
+	}
+	if rec == nil {
+		return fmt.Errorf("记录不存在")
+	}
+
+	if err := s.repo.DeleteByID(ctx, id); err != nil {
+		return fmt.Errorf("清除登录失败记录失败: %w", err)
+	}
+	return nil
+}
+
+// PruneStale 删除统计窗口之外且未锁定的失败记录
+func (s *lockoutService) PruneStale(ctx context.Context) (int64, error) {
+	if s.cfg.Window <= 0 {
+		return 0, nil
+	}
+	return s.repo.DeleteStaleBefore(ctx, s.windowStart(time.Now()))
+}
+
+// targets 返回需要统计的维度，IP为空时只统计账户
+func (s *lockoutService) targets(email, ip string) []lockoutTarget {
+	targets := []lockoutTarget{
+		{kind: lockout.KindAccount, value: normalizeEmail(email), maxAttempts: s.cfg.MaxAttempts},
+	}
+	if ip != "" {
+		targets = append(targets, lockoutTarget{kind: lockout.KindIP, value: ip, maxAttempts: s.cfg.IPMaxAttempts})
+	}
+	return targets
+}
+
+// windowStart 失败计数窗口的起点
+func (s *lockoutService) windowStart(now time.Time) time.Time {
+	return now.Add(-time.Duration(s.cfg.Window) * time.Minute)
+}
+
+// delay 连续失败 failures 次后下一次尝试前需要等待的时间，从 DelayAfter 次开始每次翻倍
+func (s *lockoutService) delay(failures int) time.Duration {
+	if s.cfg.BaseDelay <= 0 || failures < s.cfg.DelayAfter {
+		return 0
+	}
+
+	delay := time.Duration(s.cfg.BaseDelay) * time.Second
+	maxDelay := time.Duration(s.cfg.MaxDelay) * time.Second
+	for i := s.cfg.DelayAfter; i < failures && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+// normalizeEmail 统一邮箱大小写，避免通过大小写变化绕过统计
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// humanizeWait 将等待时间格式化为提示文字
+func humanizeWait(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%d秒", int((d+time.Second-1)/time.Second))
+	}
+	return fmt.Sprintf("%d分钟", int((d+time.Minute-1)/time.Minute))
+}
@@ -0,0 +1,200 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"temp-mailbox-service/internal/domain/lockout"
+	"temp-mailbox-service/internal/domain/user"
+	"temp-mailbox-service/internal/infrastructure/config"
+	"temp-mailbox-service/internal/infrastructure/database"
+	"temp-mailbox-service/internal/infrastructure/persistence"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupLockouts 创建登录失败锁定服务：2次失败后从1秒开始延迟，5次失败锁定15分钟
+func setupLockouts(t *testing.T) (LockoutService, lockout.Repository) {
+	setupTestDB(t)
+	repo := persistence.NewLockoutRepository()
+	return NewLockoutService(repo, &config.LockoutConfig{
+		Enabled:       true,
+		MaxAttempts:   5,
+		IPMaxAttempts: 20,
+		Window:        15,
+		Duration:      15,
+		DelayAfter:    2,
+		BaseDelay:     1,
+		MaxDelay:      30,
+	}), repo
+}
+
+// throttled 返回 Check 的限制结果，未被限制时返回 nil
+func throttled(t *testing.T, lockouts LockoutService, email, ip string) *LoginThrottledError {
+	t.Helper()
+	err := lockouts.Check(context.Background(), email, ip)
+	if err == nil {
+		return nil
+	}
+	var te *LoginThrottledError
+	require.True(t, errors.As(err, &te), "unexpected error: %v", err)
+	return te
+}
+
+// recordFailures 记录 n 次登录失败
+func recordFailures(t *testing.T, lockouts LockoutService, email, ip string, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		require.NoError(t, lockouts.RecordFailure(context.Background(), email, ip))
+	}
+}
+
+func TestLockout_ProgressiveDelay(t *testing.T) {
+	lockouts, _ := setupLockouts(t)
+
+	recordFailures(t, lockouts, "alice@example.com", "192.0.2.1", 1)
+	assert.Nil(t, throttled(t, lockouts, "alice@example.com", "192.0.2.1"), "未达到 DelayAfter 时不需要等待")
+
+	var previous time.Duration
+	for _, max := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		recordFailures(t, lockouts, "alice@example.com", "192.0.2.1", 1)
+		te := throttled(t, lockouts, "alice@example.com", "192.0.2.1")
+		require.NotNil(t, te)
+		assert.False(t, te.Locked, "未达到上限时只延迟不锁定")
+		assert.LessOrEqual(t, te.RetryAfter, max)
+		assert.Greater(t, te.RetryAfter, previous, "等待时间应逐次翻倍")
+		previous = max
+	}
+
+	assert.Nil(t, throttled(t, lockouts, "bob@example.com", "198.51.100.1"), "其他账户和IP不受影响")
+}
+
+func TestLockout_LocksAfterMaxAttempts(t *testing.T) {
+	lockouts, repo := setupLockouts(t)
+	ctx := context.Background()
+
+	recordFailures(t, lockouts, "alice@example.com", "192.0.2.1", 4)
+	te := throttled(t, lockouts, "alice@example.com", "192.0.2.1")
+	require.NotNil(t, te)
+	assert.False(t, te.Locked)
+
+	recordFailures(t, lockouts, "Alice@Example.com", "192.0.2.1", 1)
+	te = throttled(t, lockouts, "alice@example.com", "198.51.100.1")
+	require.NotNil(t, te, "锁定按账户生效，更换IP也不能绕过")
+	assert.True(t, te.Locked)
+	assert.Greater(t, te.RetryAfter, 14*time.Minute)
+
+	rec, err := repo.Get(ctx, lockout.KindAccount, "alice@example.com")
+	require.NoError(t, err)
+	require.NotNil(t, rec)
+	assert.Equal(t, 5, rec.Failures)
+
+	require.NoError(t, lockouts.ClearLockout(ctx, rec.ID))
+	assert.Nil(t, throttled(t, lockouts, "alice@example.com", "198.51.100.1"), "管理员清除后立即解除锁定")
+}
+
+func TestLockout_WindowResetsAfterExpiry(t *testing.T) {
+	lockouts, repo := setupLockouts(t)
+	ctx := context.Background()
+	past := time.Now().Add(-20 * time.Minute)
+
+	t.Run("统计窗口过期", func(t *testing.T) {
+		recordFailures(t, lockouts, "alice@example.com", "", 3)
+		require.NotNil(t, throttled(t, lockouts, "alice@example.com", ""))
+
+		require.NoError(t, database.DB.Model(&lockout.Record{}).
+			Where("value = ?", "alice@example.com").
+			Updates(map[string]interface{}{"first_failure_at": past, "last_failure_at": past}).Error)
+		assert.Nil(t, throttled(t, lockouts, "alice@example.com", ""))
+
+		recordFailures(t, lockouts, "alice@example.com", "", 1)
+		rec, err := repo.Get(ctx, lockout.KindAccount, "alice@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, 1, rec.Failures, "窗口过期后重新计数")
+	})
+
+	t.Run("锁定到期", func(t *testing.T) {
+		recordFailures(t, lockouts, "bob@example.com", "", 5)
+		require.True(t, throttled(t, lockouts, "bob@example.com", "").Locked)
+
+		require.NoError(t, database.DB.Model(&lockout.Record{}).
+			Where("value = ?", "bob@example.com").
+			Update("locked_until", time.Now().Add(-time.Second)).Error)
+		assert.Nil(t, throttled(t, lockouts, "bob@example.com", ""), "锁定到期后不再叠加等待")
+
+		recordFailures(t, lockouts, "bob@example.com", "", 1)
+		rec, err := repo.Get(ctx, lockout.KindAccount, "bob@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, 1, rec.Failures, "锁定到期后重新计数")
+		assert.Nil(t, rec.LockedUntil)
+	})
+}
+
+func TestLoginUser_SuccessClearsAccountFailures(t *testing.T) {
+	f := setupUsers(t)
+	ctx := context.Background()
+	f.cfg.Lockout.DelayAfter = 10
+	repo := persistence.NewLockoutRepository()
+	client := &ClientInfo{IP: "192.0.2.1"}
+	u := f.createUserWithPassword(t, "alice", "password123")
+
+	for i := 0; i < 3; i++ {
+		_, err := f.users.LoginUser(ctx, &user.LoginRequest{Email: u.Email, Password: "wrong-password"}, client)
+		require.Error(t, err)
+	}
+	rec, err := repo.Get(ctx, lockout.KindAccount, u.Email)
+	require.NoError(t, err)
+	require.NotNil(t, rec)
+	assert.Equal(t, 3, rec.Failures)
+
+	_, err = f.users.LoginUser(ctx, &user.LoginRequest{Email: u.Email, Password: "password123"}, client)
+	require.NoError(t, err)
+
+	rec, err = repo.Get(ctx, lockout.KindAccount, u.Email)
+	require.NoError(t, err)
+	assert.Nil(t, rec, "登录成功后清除账户的失败记录")
+	rec, err = repo.Get(ctx, lockout.KindIP, client.IP)
+	require.NoError(t, err)
+	require.NotNil(t, rec, "IP维度的失败记录保留")
+	assert.Equal(t, 3, rec.Failures)
+}
+
+func TestLoginUser_UnknownUserFailuresRecordedAlike(t *testing.T) {
+	f := setupUsers(t)
+	ctx := context.Background()
+	f.cfg.Lockout.DelayAfter = 10
+	repo := persistence.NewLockoutRepository()
+	u := f.createUserWithPassword(t, "alice", "password123")
+
+	login := func(email string) error {
+		_, err := f.users.LoginUser(ctx, &user.LoginRequest{Email: email, Password: "wrong-password"}, &ClientInfo{IP: "192.0.2.1"})
+		return err
+	}
+
+	for i := 0; i < 4; i++ {
+		existingErr := login(u.Email)
+		unknownErr := login("ghost@example.com")
+		require.Error(t, existingErr)
+		require.Error(t, unknownErr)
+		assert.Equal(t, existingErr.Error(), unknownErr.Error(), "错误信息不能透露账户是否存在")
+	}
+
+	existing, err := repo.Get(ctx, lockout.KindAccount, u.Email)
+	require.NoError(t, err)
+	unknown, err := repo.Get(ctx, lockout.KindAccount, "ghost@example.com")
+	require.NoError(t, err)
+	require.NotNil(t, unknown, "不存在的账户同样记录失败")
+	assert.Equal(t, existing.Failures, unknown.Failures)
+
+	// 达到上限后两者以同样的方式锁定
+	require.Error(t, login(u.Email))
+	require.Error(t, login("ghost@example.com"))
+	var existingThrottled, unknownThrottled *LoginThrottledError
+	require.True(t, errors.As(login(u.Email), &existingThrottled))
+	require.True(t, errors.As(login("ghost@example.com"), &unknownThrottled))
+	assert.True(t, existingThrottled.Locked)
+	assert.True(t, unknownThrottled.Locked)
+}
@@ -0,0 +1,282 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"temp-mailbox-service/internal/domain/mailbox"
+	"temp-mailbox-service/internal/domain/maildomain"
+	"temp-mailbox-service/internal/domain/user"
+	"temp-mailbox-service/internal/infrastructure/config"
+)
+
+const (
+	// randomLocalPartLength 随机生成的邮箱名长度
+	randomLocalPartLength = 10
+	// maxRandomAttempts 随机生成邮箱名的最大重试次数
+	maxRandomAttempts = 5
+	// localPartAlphabet 随机邮箱名字符集
+	localPartAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+)
+
+// localPartPattern 允许的邮箱名格式
+var localPartPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9._-]*[a-z0-9])?$`)
+
+// MailboxService 临时邮箱服务接口
+type MailboxService interface {
+	CreateMailbox(ctx context.Context, userID uint, req *mailbox.CreateMailboxRequest) (*mailbox.MailboxResponse, error)
+	ListMailboxes(ctx context.Context, userID uint, page, pageSize int) (*mailbox.MailboxListResponse, error)
+	GetMailbox(ctx context.Context, userID, mailboxID uint) (*mailbox.MailboxResponse, error)
+	ExtendMailbox(ctx context.Context, userID, mailboxID uint, req *mailbox.ExtendMailboxRequest) (*mailbox.MailboxResponse, error)
+	DeleteMailbox(ctx context.Context, userID, mailboxID uint) error
+}
+
+// mailboxService 临时邮箱服务实现
+type mailboxService struct {
+	mailboxRepo mailbox.Repository
+	domainRepo  maildomain.Repository
+	userRepo    user.Repository
+	cfg         *config.MailboxConfig
+}
+
+// NewMailboxService 创建新的临时邮箱服务实例
+func NewMailboxService(mailboxRepo mailbox.Repository, domainRepo maildomain.Repository, userRepo user.Repository, cfg *config.MailboxConfig) MailboxService {
+	return &mailboxService{
+		mailboxRepo: mailboxRepo,
+		domainRepo:  domainRepo,
+		userRepo:    userRepo,
+		cfg:         cfg,
+	}
+}
+
+// CreateMailbox 创建临时邮箱
+func (s *mailboxService) CreateMailbox(ctx context.Context, userID uint, req *mailbox.CreateMailboxRequest) (*mailbox.MailboxResponse, error) {
+	if s.cfg.RequireVerifiedEmail {
+		owner, err := s.userRepo.GetByID(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("获取用户失败: %w", err)
+		}
+		if owner == nil || !owner.EmailVerified {
+			return nil, fmt.Errorf("请先验证邮箱后再创建临时邮箱")
+		}
+	}
+
+	domain, err := s.resolveDomain(ctx, userID, req.Domain)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl, err := s.resolveTTL(req.TTL)
+	if err != nil {
+		return nil, err
+	}
+
+	var localPart string
+	if req.LocalPart != "" {
+		localPart = strings.ToLower(req.LocalPart)
+		if !localPartPattern.MatchString(localPart) {
+			return nil, fmt.Errorf("邮箱名格式无效")
+		}
+		exists, err := s.mailboxRepo.ExistsByAddress(ctx, mailbox.BuildAddress(localPart, domain))
+		if err != nil {
+			return nil, fmt.Errorf("检查邮箱地址失败: %w", err)
+		}
+		if exists {
+			return nil, fmt.Errorf("邮箱地址已被占用")
+		}
+	} else {
+		localPart, err = s.generateLocalPart(ctx, domain)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	newMailbox := &mailbox.Mailbox{
+		LocalPart: localPart,
+		Domain:    domain,
+		Address:   mailbox.BuildAddress(localPart, domain),
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(ttl),
+		Status:    mailbox.StatusActive,
+	}
+
+	if err := s.mailboxRepo.Create(ctx, newMailbox); err != nil {
+		return nil, fmt.Errorf("创建邮箱失败: %w", err)
+	}
+
+	return newMailbox.ToResponse(), nil
+}
+
+// ListMailboxes 分页获取用户的邮箱列表
+func (s *mailboxService) ListMailboxes(ctx context.Context, userID uint, page, pageSize int) (*mailbox.MailboxListResponse, error) {
+	total, err := s.mailboxRepo.CountByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("统计邮箱数量失败: %w", err)
+	}
+
+	mailboxes, err := s.mailboxRepo.ListByUser(ctx, userID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("获取邮箱列表失败: %w", err)
+	}
+
+	items := make([]*mailbox.MailboxResponse, 0, len(mailboxes))
+	for _, m := range mailboxes {
+		items = append(items, m.ToResponse())
+	}
+
+	return &mailbox.MailboxListResponse{
+		Items:    items,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// GetMailbox 获取邮箱详情
+func (s *mailboxService) GetMailbox(ctx context.Context, userID, mailboxID uint) (*mailbox.MailboxResponse, error) {
+	m, err := s.getOwnedMailbox(ctx, userID, mailboxID)
+	if err != nil {
+		return nil, err
+	}
+	return m.ToResponse(), nil
+}
+
+// ExtendMailbox 延长邮箱有效期
+func (s *mailboxService) ExtendMailbox(ctx context.Context, userID, mailboxID uint, req *mailbox.ExtendMailboxRequest) (*mailbox.MailboxResponse, error) {
+	m, err := s.getOwnedMailbox(ctx, userID, mailboxID)
+	if err != nil {
+		return nil, err
+	}
+	if m.IsExpired() {
+		return nil, fmt.Errorf("邮箱已过期，无法延期")
+	}
+
+	ttl, err := s.resolveTTL(req.TTL)
+	if err != nil {
+		return nil, err
+	}
+
+	// 延期后的有效期不能超过从当前时间起算的最大有效期
+	expiresAt := m.ExpiresAt.Add(ttl)
+	if limit := time.Now().Add(time.Duration(s.cfg.MaxTTL) * time.Minute); expiresAt.After(limit) {
+		expiresAt = limit
+	}
+
+	if err := s.mailboxRepo.UpdateExpiry(ctx, m.ID, expiresAt); err != nil {
+		return nil, fmt.Errorf("更新邮箱有效期失败: %w", err)
+	}
+
+	m.ExpiresAt = expiresAt
+	m.Status = mailbox.StatusActive
+	return m.ToResponse(), nil
+}
+
+// DeleteMailbox 删除邮箱（软删除）
+func (s *mailboxService) DeleteMailbox(ctx context.Context, userID, mailboxID uint) error {
+	m, err := s.getOwnedMailbox(ctx, userID, mailboxID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.mailboxRepo.Delete(ctx, m.ID); err != nil {
+		return fmt.Errorf("删除邮箱失败: %w", err)
+	}
+	return nil
+}
+
+// getOwnedMailbox 获取属于指定用户的邮箱
+func (s *mailboxService) getOwnedMailbox(ctx context.Context, userID, mailboxID uint) (*mailbox.Mailbox, error) {
+	return findOwnedMailbox(ctx, s.mailboxRepo, userID, mailboxID)
+}
+
+// findOwnedMailbox 获取属于指定用户的邮箱，不属于该用户时视为不存在
+func findOwnedMailbox(ctx context.Context, mailboxRepo mailbox.Repository, userID, mailboxID uint) (*mailbox.Mailbox, error) {
+	m, err := mailboxRepo.GetByID(ctx, mailboxID)
+	if err != nil {
+		return nil, fmt.Errorf("获取邮箱失败: %w", err)
+	}
+	if m == nil || !m.IsOwnedBy(userID) {
+		return nil, fmt.Errorf("邮箱不存在")
+	}
+	return m, nil
+}
+
+// resolveDomain 校验并返回邮箱域名，只允许已验证的系统域名和用户本人的域名，未指定时使用第一个系统域名
+func (s *mailboxService) resolveDomain(ctx context.Context, userID uint, domain string) (string, error) {
+	if domain == "" {
+		verified, err := s.domainRepo.ListVerified(ctx)
+		if err != nil {
+			return "", fmt.Errorf("获取可用域名失败: %w", err)
+		}
+		for _, d := range verified {
+			if d.IsSystem() {
+				return d.Name, nil
+			}
+		}
+		return "", fmt.Errorf("没有可用的邮箱域名")
+	}
+
+	name, err := maildomain.NormalizeName(domain)
+	if err != nil {
+		return "", fmt.Errorf("不支持的邮箱域名: %s", domain)
+	}
+	d, err := s.domainRepo.GetByName(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("查询域名失败: %w", err)
+	}
+	if d == nil || !d.IsAvailableTo(userID) {
+		return "", fmt.Errorf("不支持的邮箱域名: %s", domain)
+	}
+	if !d.IsVerified() {
+		return "", fmt.Errorf("邮箱域名未通过验证或已停用: %s", d.Name)
+	}
+	return d.Name, nil
+}
+
+// resolveTTL 计算有效期，未指定时使用默认有效期
+func (s *mailboxService) resolveTTL(minutes int) (time.Duration, error) {
+	if minutes == 0 {
+		minutes = s.cfg.DefaultTTL
+	}
+	if minutes <= 0 {
+		return 0, fmt.Errorf("有效期必须大于0")
+	}
+	if minutes > s.cfg.MaxTTL {
+		return 0, fmt.Errorf("有效期不能超过%d分钟", s.cfg.MaxTTL)
+	}
+	return time.Duration(minutes) * time.Minute, nil
+}
+
+// generateLocalPart 生成未被占用的随机邮箱名
+func (s *mailboxService) generateLocalPart(ctx context.Context, domain string) (string, error) {
+	for i := 0; i < maxRandomAttempts; i++ {
+		localPart, err := randomString(randomLocalPartLength, localPartAlphabet)
+		if err != nil {
+			return "", fmt.Errorf("生成随机邮箱名失败: %w", err)
+		}
+		exists, err := s.mailboxRepo.ExistsByAddress(ctx, mailbox.BuildAddress(localPart, domain))
+		if err != nil {
+			return "", fmt.Errorf("检查邮箱地址失败: %w", err)
+		}
+		if !exists {
+			return localPart, nil
+		}
+	}
+	return "", fmt.Errorf("生成随机邮箱名失败，请重试")
+}
+
+// randomString 使用加密安全的随机数生成指定字符集的字符串
+func randomString(length int, alphabet string) (string, error) {
+	buf := make([]byte, length)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = alphabet[int(b)%len(alphabet)]
+	}
+	return string(buf), nil
+}
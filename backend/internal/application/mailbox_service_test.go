@@ -0,0 +1,189 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"temp-mailbox-service/internal/domain/mailbox"
+	"temp-mailbox-service/internal/domain/maildomain"
+	"temp-mailbox-service/internal/infrastructure/config"
+	"temp-mailbox-service/internal/infrastructure/dns"
+	"temp-mailbox-service/internal/infrastructure/persistence"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupMailboxes 创建只开放系统域名 test.local 的邮箱服务
+func setupMailboxes(t *testing.T) MailboxService {
+	setupTestDB(t)
+	domainRepo := persistence.NewDomainRepository()
+	addSystemDomain(t, domainRepo, "test.local")
+	return NewMailboxService(persistence.NewMailboxRepository(), domainRepo, persistence.NewUserRepository(), &config.MailboxConfig{
+		DefaultTTL: 60,
+		MaxTTL:     1440,
+	})
+}
+
+// addSystemDomain 登记无需DNS验证的系统域名
+func addSystemDomain(t *testing.T, domainRepo maildomain.Repository, name string) {
+	t.Helper()
+	now := time.Now()
+	require.NoError(t, domainRepo.Create(context.Background(), &maildomain.Domain{
+		Name:              name,
+		Status:            maildomain.StatusVerified,
+		VerificationToken: "system",
+		VerifiedAt:        &now,
+	}))
+}
+
+func TestCreateMailbox(t *testing.T) {
+	svc := setupMailboxes(t)
+	ctx := context.Background()
+
+	resp, err := svc.CreateMailbox(ctx, 1, &mailbox.CreateMailboxRequest{LocalPart: "Alice"})
+	require.NoError(t, err)
+	assert.Equal(t, "alice@test.local", resp.Address, "邮箱地址统一小写")
+	assert.Equal(t, "test.local", resp.Domain)
+	assert.Equal(t, mailbox.StatusActive, resp.Status)
+	assert.WithinDuration(t, time.Now().Add(60*time.Minute), resp.ExpiresAt, time.Minute, "未指定有效期时使用默认有效期")
+
+	_, err = svc.CreateMailbox(ctx, 2, &mailbox.CreateMailboxRequest{LocalPart: "alice"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "邮箱地址已被占用")
+
+	random, err := svc.CreateMailbox(ctx, 1, &mailbox.CreateMailboxRequest{TTL: 30})
+	require.NoError(t, err)
+	assert.Len(t, random.LocalPart, randomLocalPartLength, "未指定邮箱名时随机生成")
+	assert.Regexp(t, localPartPattern, random.LocalPart)
+	assert.WithinDuration(t, time.Now().Add(30*time.Minute), random.ExpiresAt, time.Minute)
+
+	tests := []struct {
+		name string
+		req  *mailbox.CreateMailboxRequest
+	}{
+		{"邮箱名格式无效", &mailbox.CreateMailboxRequest{LocalPart: "-bob"}},
+		{"邮箱名包含非法字符", &mailbox.CreateMailboxRequest{LocalPart: "bob+tag"}},
+		{"不支持的域名", &mailbox.CreateMailboxRequest{LocalPart: "bob", Domain: "other.example"}},
+		{"有效期超过上限", &mailbox.CreateMailboxRequest{LocalPart: "bob", TTL: 1441}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.CreateMailbox(ctx, 1, tt.req)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestExtendMailbox(t *testing.T) {
+	svc := setupMailboxes(t)
+	ctx := context.Background()
+
+	created, err := svc.CreateMailbox(ctx, 1, &mailbox.CreateMailboxRequest{LocalPart: "alice", TTL: 60})
+	require.NoError(t, err)
+
+	extended, err := svc.ExtendMailbox(ctx, 1, created.ID, &mailbox.ExtendMailboxRequest{TTL: 60})
+	require.NoError(t, err)
+	assert.WithinDuration(t, created.ExpiresAt.Add(60*time.Minute), extended.ExpiresAt, time.Second, "在原有效期基础上延长")
+
+	// 延期后的有效期不超过从当前时间起算的最大有效期
+	capped, err := svc.ExtendMailbox(ctx, 1, created.ID, &mailbox.ExtendMailboxRequest{TTL: 1440})
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(1440*time.Minute), capped.ExpiresAt, time.Minute)
+
+	stored, err := svc.GetMailbox(ctx, 1, created.ID)
+	require.NoError(t, err)
+	assert.WithinDuration(t, capped.ExpiresAt, stored.ExpiresAt, time.Second, "延期结果已保存")
+
+	_, err = svc.ExtendMailbox(ctx, 2, created.ID, &mailbox.ExtendMailboxRequest{TTL: 60})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "邮箱不存在", "不能延长其他用户的邮箱")
+}
+
+func TestListAndDeleteMailboxes(t *testing.T) {
+	svc := setupMailboxes(t)
+	ctx := context.Background()
+
+	var ids []uint
+	for _, localPart := range []string{"one", "two", "three"} {
+		resp, err := svc.CreateMailbox(ctx, 1, &mailbox.CreateMailboxRequest{LocalPart: localPart})
+		require.NoError(t, err)
+		ids = append(ids, resp.ID)
+	}
+	_, err := svc.CreateMailbox(ctx, 2, &mailbox.CreateMailboxRequest{LocalPart: "other"})
+	require.NoError(t, err)
+
+	first, err := svc.ListMailboxes(ctx, 1, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), first.Total, "只统计本人的邮箱")
+	assert.Len(t, first.Items, 2)
+
+	second, err := svc.ListMailboxes(ctx, 1, 2, 2)
+	require.NoError(t, err)
+	assert.Len(t, second.Items, 1)
+
+	err = svc.DeleteMailbox(ctx, 2, ids[0])
+	require.Error(t, err, "不能删除其他用户的邮箱")
+
+	require.NoError(t, svc.DeleteMailbox(ctx, 1, ids[0]))
+	_, err = svc.GetMailbox(ctx, 1, ids[0])
+	assert.Error(t, err, "删除后无法再获取")
+
+	list, err := svc.ListMailboxes(ctx, 1, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), list.Total)
+}
+
+func TestCreateMailbox_DomainScoping(t *testing.T) {
+	setupTestDB(t)
+	ctx := context.Background()
+	mailboxRepo := persistence.NewMailboxRepository()
+	domainRepo := persistence.NewDomainRepository()
+
+	// 用户域名先于系统域名登记，默认域名仍然只能是系统域名
+	now := time.Now()
+	for _, d := range []*maildomain.Domain{
+		{Name: "alice.example", UserID: 2, Status: maildomain.StatusVerified, VerificationToken: "a", VerifiedAt: &now},
+		{Name: "bob.example", UserID: 3, Status: maildomain.StatusVerified, VerificationToken: "b", VerifiedAt: &now},
+	} {
+		require.NoError(t, domainRepo.Create(ctx, d))
+	}
+	domains := NewDomainService(domainRepo, mailboxRepo, persistence.NewUserRepository(), dns.NewStaticResolver(), nil, nil, "mx.test.local")
+	require.NoError(t, domains.EnsureSystemDomains(ctx, []string{"test.local"}))
+
+	svc := NewMailboxService(mailboxRepo, domainRepo, persistence.NewUserRepository(), &config.MailboxConfig{
+		DefaultTTL: 60,
+		MaxTTL:     1440,
+	})
+
+	tests := []struct {
+		name       string
+		userID     uint
+		domain     string
+		wantDomain string
+		wantErr    bool
+	}{
+		{"未指定域名时使用系统域名", 2, "", "test.local", false},
+		{"其他用户未指定域名时同样使用系统域名", 4, "", "test.local", false},
+		{"系统域名对所有用户开放", 3, "test.local", "test.local", false},
+		{"可以使用本人的域名", 2, "alice.example", "alice.example", false},
+		{"不能使用其他用户的域名", 3, "alice.example", "", true},
+		{"不能使用未登记的域名", 2, "unknown.example", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := svc.CreateMailbox(ctx, tt.userID, &mailbox.CreateMailboxRequest{Domain: tt.domain})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantDomain, resp.Domain)
+		})
+	}
+
+	available, err := domains.ListAvailableDomains(ctx, 2)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"alice.example", "test.local"}, available)
+}
@@ -0,0 +1,226 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"temp-mailbox-service/internal/domain/job"
+	"temp-mailbox-service/internal/domain/mailbox"
+	"temp-mailbox-service/internal/domain/mailfilter"
+	"temp-mailbox-service/internal/domain/message"
+	"temp-mailbox-service/internal/domain/session"
+	"temp-mailbox-service/internal/infrastructure/config"
+	"temp-mailbox-service/internal/infrastructure/scheduler"
+	"temp-mailbox-service/internal/infrastructure/storage"
+)
+
+// 后台维护任务名称
+const (
+	JobExpireMailboxes = "expire-mailboxes"
+	JobPurgeMessages   = "purge-messages"
+	JobCollectBlobs    = "collect-blobs"
+	JobPruneJobRuns    = "prune-job-runs"
+	JobPruneSessions   = "prune-sessions"
+	JobPurgeDomains    = "purge-domains"
+)
+
+const (
+	// purgeBatchSize 每批删除的邮件数量
+	purgeBatchSize = 500
+	// attachmentKeyPrefix 附件在对象存储中的键前缀
+	attachmentKeyPrefix = "attachments/"
+	// rawKeyPrefix 原始邮件在对象存储中的键前缀
+	rawKeyPrefix = "raw/"
+	// blobGracePeriod 对象写入或被复用后的保护期，覆盖投递从写入存储到保存邮件元数据的时间
+	blobGracePeriod = time.Hour
+)
+
+// MaintenanceService 后台维护服务接口
+type MaintenanceService interface {
+	ExpireMailboxes(ctx context.Context) (string, error)
+	PurgeMessages(ctx context.Context) (string, error)
+	CollectOrphanBlobs(ctx context.Context) (string, error)
+	PruneJobRuns(ctx context.Context) (string, error)
+	PruneSessions(ctx context.Context) (string, error)
+	PurgeUnverifiedDomains(ctx context.Context) (string, error)
+}
+
+// maintenanceService 后台维护服务实现
+type maintenanceService struct {
+	mailboxRepo mailbox.Repository
+	messageRepo message.Repository
+	filterRepo  mailfilter.Repository
+	jobRepo     job.Repository
+	sessionRepo session.Repository
+	revocations RevocationService
+	lockouts    LockoutService
+	domains     DomainService
+	blobStore   storage.BlobStore
+	cfg         *config.SchedulerConfig
+
+	// 上一轮垃圾回收发现的无引用对象，连续两轮都无引用才删除，
+	// 避免删除投递过程中已写入存储但尚未保存元数据的附件和原始邮件
+	mu             sync.Mutex
+	orphanSuspects map[string]bool
+}
+
+// NewMaintenanceService 创建新的后台维护服务实例
+func NewMaintenanceService(mailboxRepo mailbox.Repository, messageRepo message.Repository, filterRepo mailfilter.Repository, jobRepo job.Repository, sessionRepo session.Repository, revocations RevocationService, lockouts LockoutService, domains DomainService, blobStore storage.BlobStore, cfg *config.SchedulerConfig) MaintenanceService {
+	return &maintenanceService{
+		mailboxRepo:    mailboxRepo,
+		messageRepo:    messageRepo,
+		filterRepo:     filterRepo,
+		jobRepo:        jobRepo,
+		sessionRepo:    sessionRepo,
+		revocations:    revocations,
+		lockouts:       lockouts,
+		domains:        domains,
+		blobStore:      blobStore,
+		cfg:            cfg,
+		orphanSuspects: make(map[string]bool),
+	}
+}
+
+// RegisterMaintenanceJobs 将后台维护任务注册到调度器
+func RegisterMaintenanceJobs(s *scheduler.Scheduler, svc MaintenanceService, cfg *config.SchedulerConfig) error {
+	jobs := []scheduler.Job{
+		{Name: JobExpireMailboxes, Spec: cfg.ExpireMailboxesSpec, Description: "将到期的邮箱标记为已过期", Run: svc.ExpireMailboxes},
+		{Name: JobPurgeMessages, Spec: cfg.PurgeMessagesSpec, Description: "删除超过保留期限的邮件", Run: svc.PurgeMessages},
+		{Name: JobCollectBlobs, Spec: cfg.CollectBlobsSpec, Description: "清理不再被引用的附件和原始邮件文件", Run: svc.CollectOrphanBlobs},
+		{Name: JobPruneJobRuns, Spec: cfg.PruneJobRunsSpec, Description: "清理过期的任务执行记录", Run: svc.PruneJobRuns},
+		{Name: JobPruneSessions, Spec: cfg.PruneSessionsSpec, Description: "清理过期的登录会话、令牌吊销记录和登录失败记录", Run: svc.PruneSessions},
+		{Name: JobPurgeDomains, Spec: cfg.PurgeDomainsSpec, Description: "删除长期未通过验证的用户域名", Run: svc.PurgeUnverifiedDomains},
+	}
+	for _, j := range jobs {
+		if err := s.Add(j); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExpireMailboxes 将到期的邮箱标记为已过期
+func (s *maintenanceService) ExpireMailboxes(ctx context.Context) (string, error) {
+	count, err := s.mailboxRepo.MarkExpired(ctx, time.Now())
+	if err != nil {
+		return "", fmt.Errorf("标记过期邮箱失败: %w", err)
+	}
+	return fmt.Sprintf("标记了%d个过期邮箱", count), nil
+}
+
+// PurgeMessages 物理删除超过保留期限的邮件
+func (s *maintenanceService) PurgeMessages(ctx context.Context) (string, error) {
+	if s.cfg.MessageRetention <= 0 {
+		return "未配置邮件保留期限，跳过", nil
+	}
+
+	before := time.Now().Add(-time.Duration(s.cfg.MessageRetention) * time.Minute)
+	count, err := s.messageRepo.PurgeReceivedBefore(ctx, before, purgeBatchSize)
+	if err != nil {
+		return "", fmt.Errorf("删除过期邮件失败（已删除%d封）: %w", count, err)
+	}
+
+	// 过滤记录与邮件使用相同的保留期限
+	decisions, err := s.filterRepo.DeleteDecisionsBefore(ctx, before)
+	if err != nil {
+		return "", fmt.Errorf("删除过期过滤记录失败: %w", err)
+	}
+	return fmt.Sprintf("删除了%d封过期邮件和%d条过滤记录", count, decisions), nil
+}
+
+// CollectOrphanBlobs 删除不再被任何附件或邮件引用的对象
+func (s *maintenanceService) CollectOrphanBlobs(ctx context.Context) (string, error) {
+	var keys []string
+	for _, prefix := range []string{attachmentKeyPrefix, rawKeyPrefix} {
+		found, err := s.blobStore.List(ctx, prefix)
+		if err != nil {
+			return "", fmt.Errorf("列出存储文件失败: %w", err)
+		}
+		keys = append(keys, found...)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	suspects := make(map[string]bool)
+	deleted := 0
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		inUse, err := s.messageRepo.StorageKeyInUse(ctx, key)
+		if err != nil {
+			return "", fmt.Errorf("检查文件引用失败: %w", err)
+		}
+		if inUse {
+			continue
+		}
+
+		if !s.orphanSuspects[key] {
+			suspects[key] = true
+			continue
+		}
+		// 保护期内写入或被投递复用的对象暂不删除
+		removed, err := s.blobStore.DeleteIfOlder(ctx, key, time.Now().Add(-blobGracePeriod))
+		if err != nil {
+			log.Printf("删除存储文件 %s 失败: %v", key, err)
+		}
+		if !removed {
+			suspects[key] = true
+			continue
+		}
+		deleted++
+	}
+	s.orphanSuspects = suspects
+
+	return fmt.Sprintf("检查了%d个存储文件，删除%d个，待下次确认%d个", len(keys), deleted, len(suspects)), nil
+}
+
+// PruneJobRuns 删除超过保留期限的任务执行记录
+func (s *maintenanceService) PruneJobRuns(ctx context.Context) (string, error) {
+	if s.cfg.JobRunRetention <= 0 {
+		return "未配置执行记录保留期限，跳过", nil
+	}
+
+	before := time.Now().Add(-time.Duration(s.cfg.JobRunRetention) * time.Minute)
+	count, err := s.jobRepo.DeleteBefore(ctx, before)
+	if err != nil {
+		return "", fmt.Errorf("删除任务执行记录失败: %w", err)
+	}
+	return fmt.Sprintf("删除了%d条任务执行记录", count), nil
+}
+
+// PruneSessions 删除已过期的登录会话、令牌吊销记录和登录失败记录
+func (s *maintenanceService) PruneSessions(ctx context.Context) (string, error) {
+	sessions, err := s.sessionRepo.DeleteExpiredBefore(ctx, time.Now())
+	if err != nil {
+		return "", fmt.Errorf("删除过期会话失败: %w", err)
+	}
+	revocations, err := s.revocations.PruneExpired(ctx)
+	if err != nil {
+		return "", fmt.Errorf("删除过期吊销记录失败: %w", err)
+	}
+	lockouts, err := s.lockouts.PruneStale(ctx)
+	if err != nil {
+		return "", fmt.Errorf("删除过期登录失败记录失败: %w", err)
+	}
+	return fmt.Sprintf("删除了%d个过期会话、%d条过期吊销记录和%d条登录失败记录", sessions, revocations, lockouts), nil
+}
+
+// PurgeUnverifiedDomains 删除超过验证期限仍未通过验证的用户域名
+func (s *maintenanceService) PurgeUnverifiedDomains(ctx context.Context) (string, error) {
+	if s.cfg.PendingDomainTTL <= 0 {
+		return "未配置域名验证期限，跳过", nil
+	}
+
+	before := time.Now().Add(-time.Duration(s.cfg.PendingDomainTTL) * time.Minute)
+	count, err := s.domains.PurgeUnverifiedBefore(ctx, before)
+	if err != nil {
+		return "", fmt.Errorf("删除未验证域名失败（已删除%d个）: %w", count, err)
+	}
+	return fmt.Sprintf("删除了%d个未验证的域名", count), nil
+}
@@ -0,0 +1,200 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"temp-mailbox-service/internal/domain/mailbox"
+	"temp-mailbox-service/internal/domain/mailfilter"
+	"temp-mailbox-service/internal/domain/message"
+	"temp-mailbox-service/internal/infrastructure/mailauth"
+	"temp-mailbox-service/internal/infrastructure/mailreport"
+	"temp-mailbox-service/internal/infrastructure/storage"
+)
+
+// MessageService 邮件查询服务接口
+type MessageService interface {
+	ListMessages(ctx context.Context, userID, mailboxID uint, page, pageSize int) (*message.MessageListResponse, error)
+	GetMessage(ctx context.Context, userID, mailboxID, messageID uint, markRead bool) (*message.MessageResponse, error)
+	GetRawMessage(ctx context.Context, userID, mailboxID, messageID uint) ([]byte, error)
+	OpenAttachment(ctx context.Context, userID, mailboxID, messageID, attachmentID uint) (*message.Attachment, io.ReadCloser, error)
+	GetReport(ctx context.Context, userID, mailboxID, messageID uint) (*mailreport.Report, error)
+}
+
+// messageService 邮件查询服务实现
+type messageService struct {
+	mailboxRepo mailbox.Repository
+	messageRepo message.Repository
+	blobStore   storage.BlobStore
+	filters     FilterService
+}
+
+// NewMessageService 创建新的邮件查询服务实例
+func NewMessageService(mailboxRepo mailbox.Repository, messageRepo message.Repository, blobStore storage.BlobStore, filters FilterService) MessageService {
+	return &messageService{
+		mailboxRepo: mailboxRepo,
+		messageRepo: messageRepo,
+		blobStore:   blobStore,
+		filters:     filters,
+	}
+}
+
+// ListMessages 分页获取邮箱中的邮件列表
+func (s *messageService) ListMessages(ctx context.Context, userID, mailboxID uint, page, pageSize int) (*message.MessageListResponse, error) {
+	if _, err := findOwnedMailbox(ctx, s.mailboxRepo, userID, mailboxID); err != nil {
+		return nil, err
+	}
+
+	total, err := s.messageRepo.CountByMailbox(ctx, mailboxID)
+	if err != nil {
+		return nil, fmt.Errorf("统计邮件数量失败: %w", err)
+	}
+
+	messages, err := s.messageRepo.ListByMailbox(ctx, mailboxID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("获取邮件列表失败: %w", err)
+	}
+
+	items := make([]*message.MessageSummary, 0, len(messages))
+	for _, msg := range messages {
+		items = append(items, msg.ToSummary())
+	}
+
+	return &message.MessageListResponse{
+		Items:    items,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// GetMessage 获取邮件详情并标记为已读，过滤规则要求查看后删除的邮件在返回内容后删除
+// markRead 为 false 时只读取内容，不标记已读也不执行查看后删除（用于只有读权限的API密钥）
+func (s *messageService) GetMessage(ctx context.Context, userID, mailboxID, messageID uint, markRead bool) (*message.MessageResponse, error) {
+	msg, err := s.getOwnedMessage(ctx, userID, mailboxID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if !markRead {
+		return msg.ToResponse(), nil
+	}
+
+	if msg.DeleteAfterRead {
+		if err := s.messageRepo.Delete(ctx, msg.ID); err != nil {
+			return nil, fmt.Errorf("删除邮件失败: %w", err)
+		}
+		s.filters.RecordDecision(ctx, &mailfilter.Decision{
+			MailboxID: mailboxID,
+			Stage:     mailfilter.StageRead,
+			Action:    mailfilter.ActionDeleteAfterRead,
+			Sender:    msg.EnvelopeFrom,
+			From:      msg.From,
+			Subject:   msg.Subject,
+			RemoteIP:  msg.RemoteIP,
+			MessageID: msg.ID,
+		})
+		msg.IsRead = true
+		return msg.ToResponse(), nil
+	}
+
+	if !msg.IsRead {
+		if err := s.messageRepo.MarkRead(ctx, msg.ID); err != nil {
+			return nil, fmt.Errorf("标记邮件已读失败: %w", err)
+		}
+		msg.IsRead = true
+	}
+
+	return msg.ToResponse(), nil
+}
+
+// GetRawMessage 获取邮件的原始内容
+func (s *messageService) GetRawMessage(ctx context.Context, userID, mailboxID, messageID uint) ([]byte, error) {
+	msg, err := s.getOwnedMessage(ctx, userID, mailboxID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	return s.readRaw(ctx, msg)
+}
+
+// OpenAttachment 获取附件元数据并打开附件内容，调用方负责关闭返回的Reader
+func (s *messageService) OpenAttachment(ctx context.Context, userID, mailboxID, messageID, attachmentID uint) (*message.Attachment, io.ReadCloser, error) {
+	if _, err := s.getOwnedMessage(ctx, userID, mailboxID, messageID); err != nil {
+		return nil, nil, err
+	}
+
+	attachment, err := s.messageRepo.GetAttachment(ctx, attachmentID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取附件失败: %w", err)
+	}
+	if attachment == nil || attachment.MessageID != messageID {
+		return nil, nil, fmt.Errorf("附件不存在")
+	}
+
+	reader, err := s.blobStore.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取附件内容失败: %w", err)
+	}
+	return attachment, reader, nil
+}
+
+// GetReport 根据原始邮件和接收时保存的来源验证结果生成投递质量报告
+func (s *messageService) GetReport(ctx context.Context, userID, mailboxID, messageID uint) (*mailreport.Report, error) {
+	msg, err := s.getOwnedMessage(ctx, userID, mailboxID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	var auth *mailauth.Results
+	if msg.AuthResults != "" {
+		auth = &mailauth.Results{}
+		if err := json.Unmarshal([]byte(msg.AuthResults), auth); err != nil {
+			return nil, fmt.Errorf("解析来源验证结果失败: %w", err)
+		}
+	}
+
+	raw, err := s.readRaw(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+	report, err := mailreport.Analyze(raw, auth)
+	if err != nil {
+		return nil, fmt.Errorf("生成报告失败: %w", err)
+	}
+	return report, nil
+}
+
+// readRaw 从对象存储读取邮件的原始内容
+func (s *messageService) readRaw(ctx context.Context, msg *message.Message) ([]byte, error) {
+	if msg.RawKey == "" {
+		return nil, fmt.Errorf("邮件原始内容不存在")
+	}
+	reader, err := s.blobStore.Get(ctx, msg.RawKey)
+	if err != nil {
+		return nil, fmt.Errorf("读取邮件原始内容失败: %w", err)
+	}
+	defer reader.Close()
+
+	raw, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("读取邮件原始内容失败: %w", err)
+	}
+	return raw, nil
+}
+
+// getOwnedMessage 获取属于指定用户邮箱的邮件
+func (s *messageService) getOwnedMessage(ctx context.Context, userID, mailboxID, messageID uint) (*message.Message, error) {
+	if _, err := findOwnedMailbox(ctx, s.mailboxRepo, userID, mailboxID); err != nil {
+		return nil, err
+	}
+
+	msg, err := s.messageRepo.GetByID(ctx, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("获取邮件失败: %w", err)
+	}
+	if msg == nil || msg.MailboxID != mailboxID {
+		return nil, fmt.Errorf("邮件不存在")
+	}
+	return msg, nil
+}
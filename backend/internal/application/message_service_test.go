@@ -0,0 +1,230 @@
+package application
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"temp-mailbox-service/internal/domain/mailbox"
+	"temp-mailbox-service/internal/domain/mailfilter"
+	"temp-mailbox-service/internal/infrastructure/config"
+	"temp-mailbox-service/internal/infrastructure/persistence"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reportMessage 带一个附件的测试邮件
+const reportMessage = "From: sender@example.com\r\n" +
+	"To: inbox@test.local\r\n" +
+	"Subject: Report\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=\"b1\"\r\n" +
+	"\r\n" +
+	"--b1\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"\r\n" +
+	"see attached\r\n" +
+	"--b1\r\n" +
+	"Content-Type: text/plain; name=\"report.txt\"\r\n" +
+	"Content-Disposition: attachment; filename=\"report.txt\"\r\n" +
+	"\r\n" +
+	"quarterly numbers\r\n" +
+	"--b1--\r\n"
+
+// latestMessageID 返回邮箱中最后投递的邮件ID
+func latestMessageID(t *testing.T, f *deliveryFixture, mailboxID uint) uint {
+	t.Helper()
+	msgs, err := f.messageRepo.ListByMailbox(context.Background(), mailboxID, 0, 100)
+	require.NoError(t, err)
+	require.NotEmpty(t, msgs)
+	var latest uint
+	for _, msg := range msgs {
+		if msg.ID > latest {
+			latest = msg.ID
+		}
+	}
+	return latest
+}
+
+func TestMessageService_ReadMessage(t *testing.T) {
+	f := setupDelivery(t, "inbox")
+	ctx := context.Background()
+	inbox := f.mailboxes[0]
+	f.deliver(t, reportMessage)
+	messageID := latestMessageID(t, f, inbox.ID)
+
+	list, err := f.messages.ListMessages(ctx, 1, inbox.ID, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), list.Total)
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, "Report", list.Items[0].Subject)
+	assert.False(t, list.Items[0].IsRead)
+
+	resp, err := f.messages.GetMessage(ctx, 1, inbox.ID, messageID, true)
+	require.NoError(t, err)
+	assert.Contains(t, resp.TextBody, "see attached")
+	assert.Equal(t, "inbox@test.local", resp.EnvelopeTo)
+	assert.True(t, resp.IsRead)
+	require.Len(t, resp.Attachments, 1)
+	assert.Equal(t, "report.txt", resp.Attachments[0].Filename)
+
+	list, err = f.messages.ListMessages(ctx, 1, inbox.ID, 1, 20)
+	require.NoError(t, err)
+	assert.True(t, list.Items[0].IsRead, "查看详情后标记为已读")
+
+	raw, err := f.messages.GetRawMessage(ctx, 1, inbox.ID, messageID)
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "Subject: Report\r\n")
+	assert.Contains(t, string(raw), "quarterly numbers")
+
+	attachment, reader, err := f.messages.OpenAttachment(ctx, 1, inbox.ID, messageID, resp.Attachments[0].ID)
+	require.NoError(t, err)
+	content, err := io.ReadAll(reader)
+	reader.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "report.txt", attachment.Filename)
+	assert.Equal(t, "quarterly numbers", string(content))
+}
+
+func TestMessageService_AttachmentBelongsToMessage(t *testing.T) {
+	f := setupDelivery(t, "inbox")
+	ctx := context.Background()
+	inbox := f.mailboxes[0]
+	f.deliver(t, reportMessage)
+	first := latestMessageID(t, f, inbox.ID)
+	f.deliver(t, "From: sender@example.com\r\nSubject: plain\r\n\r\nno attachments\r\n")
+	second := latestMessageID(t, f, inbox.ID)
+
+	resp, err := f.messages.GetMessage(ctx, 1, inbox.ID, first, true)
+	require.NoError(t, err)
+	require.Len(t, resp.Attachments, 1)
+
+	// 不能通过其他邮件的路径下载附件
+	_, _, err = f.messages.OpenAttachment(ctx, 1, inbox.ID, second, resp.Attachments[0].ID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "附件不存在")
+
+	_, _, err = f.messages.OpenAttachment(ctx, 1, inbox.ID, first, 9999)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "附件不存在")
+
+	// 其他用户不能读取邮件
+	_, err = f.messages.ListMessages(ctx, 2, inbox.ID, 1, 20)
+	assert.Error(t, err)
+	_, err = f.messages.GetMessage(ctx, 2, inbox.ID, first, true)
+	assert.Error(t, err)
+	_, err = f.messages.GetRawMessage(ctx, 1, inbox.ID+1, first)
+	assert.Error(t, err)
+}
+
+func TestMessageService_Ownership(t *testing.T) {
+	f := setupDelivery(t, "alice")
+	ctx := context.Background()
+	f.deliver(t, "From: sender@example.com\r\nSubject: secret\r\n\r\nbody\r\n")
+	owned := f.mailboxes[0]
+
+	// 用户2的邮箱，用来尝试通过自己的邮箱ID读取用户1的邮件
+	other := &mailbox.Mailbox{
+		LocalPart: "mallory",
+		Domain:    "test.local",
+		Address:   mailbox.BuildAddress("mallory", "test.local"),
+		UserID:    2,
+		ExpiresAt: time.Now().Add(time.Hour),
+		Status:    mailbox.StatusActive,
+	}
+	require.NoError(t, f.mailboxRepo.Create(ctx, other))
+
+	msgs, err := f.messageRepo.ListByMailbox(ctx, owned.ID, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	messageID := msgs[0].ID
+
+	_, err = f.messages.GetMessage(ctx, 1, owned.ID, messageID, true)
+	require.NoError(t, err, "邮箱所有者可以读取邮件")
+
+	tests := []struct {
+		name      string
+		userID    uint
+		mailboxID uint
+		message   string
+	}{
+		{"其他用户使用所有者的邮箱ID", 2, owned.ID, "邮箱不存在"},
+		{"其他用户使用自己的邮箱ID", 2, other.ID, "邮件不存在"},
+		{"所有者使用不属于自己的邮箱ID", 1, other.ID, "邮箱不存在"},
+		{"不存在的邮箱", 1, 9999, "邮箱不存在"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.messages.GetMessage(ctx, tt.userID, tt.mailboxID, messageID, true)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.message)
+
+			_, err = f.messages.GetRawMessage(ctx, tt.userID, tt.mailboxID, messageID)
+			assert.Error(t, err)
+			_, err = f.messages.GetReport(ctx, tt.userID, tt.mailboxID, messageID)
+			assert.Error(t, err)
+			_, _, err = f.messages.OpenAttachment(ctx, tt.userID, tt.mailboxID, messageID, 1)
+			assert.Error(t, err)
+			_, err = f.messages.ListMessages(ctx, tt.userID, tt.mailboxID, 1, 20)
+			if tt.mailboxID == other.ID && tt.userID == 2 {
+				assert.NoError(t, err, "可以列出自己邮箱的邮件")
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+
+	t.Run("邮箱操作", func(t *testing.T) {
+		mailboxes := NewMailboxService(f.mailboxRepo, f.domainRepo, persistence.NewUserRepository(), &config.MailboxConfig{DefaultTTL: 60, MaxTTL: 1440})
+		_, err := mailboxes.GetMailbox(ctx, 2, owned.ID)
+		assert.Error(t, err)
+		_, err = mailboxes.ExtendMailbox(ctx, 2, owned.ID, &mailbox.ExtendMailboxRequest{TTL: 60})
+		assert.Error(t, err)
+		assert.Error(t, mailboxes.DeleteMailbox(ctx, 2, owned.ID))
+
+		m, err := f.mailboxRepo.GetByID(ctx, owned.ID)
+		require.NoError(t, err)
+		require.NotNil(t, m, "其他用户不能删除邮箱")
+		assert.Equal(t, owned.ExpiresAt.Unix(), m.ExpiresAt.Unix(), "其他用户不能延长邮箱有效期")
+	})
+
+	t.Run("过滤规则", func(t *testing.T) {
+		_, err := f.filters.ListRules(ctx, 2, owned.ID)
+		assert.Error(t, err)
+		_, err = f.filters.GetScript(ctx, 2, owned.ID)
+		assert.Error(t, err)
+		assert.Error(t, f.filters.DeleteScript(ctx, 2, owned.ID))
+	})
+}
+
+func TestGetMessage_WithoutMarkRead(t *testing.T) {
+	f := setupDelivery(t, "alice")
+	ctx := context.Background()
+	mailboxID := f.mailboxes[0].ID
+	f.addRule(t, mailboxID, &mailfilter.RuleRequest{
+		Name:   "阅后即焚",
+		Action: mailfilter.ActionDeleteAfterRead,
+	})
+	f.deliver(t, "From: sender@example.com\r\nSubject: one-time code\r\n\r\n123456\r\n")
+
+	msgs, err := f.messageRepo.ListByMailbox(ctx, mailboxID, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	messageID := msgs[0].ID
+
+	// 只读访问可以重复查看，不改变邮件状态
+	for i := 0; i < 2; i++ {
+		resp, err := f.messages.GetMessage(ctx, 1, mailboxID, messageID, false)
+		require.NoError(t, err)
+		assert.False(t, resp.IsRead)
+		assert.Contains(t, resp.TextBody, "123456")
+	}
+
+	stored, err := f.messageRepo.GetByID(ctx, messageID)
+	require.NoError(t, err)
+	require.NotNil(t, stored, "只读访问不触发查看后删除")
+	assert.False(t, stored.IsRead, "只读访问不标记已读")
+	assert.Len(t, f.decisions(t, mailboxID), 1, "只记录投递时的过滤决定")
+}
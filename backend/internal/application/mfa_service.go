@@ -0,0 +1,304 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"temp-mailbox-service/internal/domain/user"
+	"temp-mailbox-service/internal/infrastructure/auth"
+	"temp-mailbox-service/internal/infrastructure/config"
+)
+
+const (
+	// recoveryCodeCount 每次生成的恢复码数量
+	recoveryCodeCount = 10
+	// defaultMFAPendingTTL 未配置时两步验证待完成令牌的有效期
+	defaultMFAPendingTTL = 5 * time.Minute
+)
+
+// totpCodePattern 六位数字验证码，其他格式按恢复码处理
+var totpCodePattern = regexp.MustCompile(`^[0-9]{6}$`)
+
+// MFAService 两步验证服务接口
+type MFAService interface {
+	// 用户自助管理
+	SetupTOTP(ctx context.Context, userID uint) (*user.TOTPSetupResponse, error)
+	ConfirmTOTP(ctx context.Context, userID uint, req *user.ConfirmTOTPRequest) (*user.RecoveryCodesResponse, error)
+	DisableTOTP(ctx context.Context, userID uint, req *user.DisableTOTPRequest) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uint, req *user.RegenerateRecoveryCodesRequest) (*user.RecoveryCodesResponse, error)
+
+	// 登录流程
+	VerifySecondFactor(ctx context.Context, u *user.User, code string) (bool, error)
+	IssuePendingToken(u *user.User) (string, time.Duration)
+	VerifyPendingToken(ctx context.Context, token string) (*user.User, error)
+}
+
+// mfaService 两步验证服务实现
+type mfaService struct {
+	userRepo user.Repository
+	box      *auth.SecretBox
+	signer   *auth.Signer
+	cfg      *config.MFAConfig
+}
+
+// NewMFAService 创建两步验证服务实例，box 用于加密保存TOTP密钥，signer 用于签发待完成令牌
+func NewMFAService(userRepo user.Repository, box *auth.SecretBox, signer *auth.Signer, cfg *config.MFAConfig) MFAService {
+	return &mfaService{
+		userRepo: userRepo,
+		box:      box,
+		signer:   signer,
+		cfg:      cfg,
+	}
+}
+
+// SetupTOTP 生成新的TOTP密钥，确认前不会生效，重复调用会替换未确认的密钥
+func (s *mfaService) SetupTOTP(ctx context.Context, userID uint) (*user.TOTPSetupResponse, error) {
+	u, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.TOTPEnabled {
+		return nil, fmt.Errorf("两步验证已启用")
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("生成密钥失败: %w", err)
+	}
+	sealed, err := s.box.Seal(secret)
+	if err != nil {
+		return nil, fmt.Errorf("加密密钥失败: %w", err)
+	}
+	if err := s.userRepo.SetTOTPSecret(ctx, u.ID, sealed); err != nil {
+		return nil, fmt.Errorf("保存密钥失败: %w", err)
+	}
+
+	return &user.TOTPSetupResponse{
+		Secret:     secret,
+		OTPAuthURI: auth.TOTPURI(s.cfg.Issuer, u.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP 使用身份验证器生成的验证码确认并启用两步验证，返回恢复码
+func (s *mfaService) ConfirmTOTP(ctx context.Context, userID uint, req *user.ConfirmTOTPRequest) (*user.RecoveryCodesResponse, error) {
+	u, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.TOTPEnabled {
+		return nil, fmt.Errorf("两步验证已启用")
+	}
+	if u.TOTPSecret == "" {
+		return nil, fmt.Errorf("请先获取两步验证密钥")
+	}
+
+	secret, err := s.box.Open(u.TOTPSecret)
+	if err != nil {
+		return nil, fmt.Errorf("两步验证密钥无效，请重新获取")
+	}
+	counter, ok := auth.ValidateTOTP(secret, req.Code, time.Now(), s.cfg.Skew)
+	if !ok {
+		return nil, fmt.Errorf("验证码错误")
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("生成恢复码失败: %w", err)
+	}
+	if err := s.userRepo.EnableTOTP(ctx, u.ID, counter, hashes); err != nil {
+		return nil, fmt.Errorf("启用两步验证失败: %w", err)
+	}
+
+	return &user.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableTOTP 验证密码和验证码（或恢复码）后关闭两步验证
+func (s *mfaService) DisableTOTP(ctx context.Context, userID uint, req *user.DisableTOTPRequest) error {
+	u, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !u.TOTPEnabled {
+		return fmt.Errorf("两步验证未启用")
+	}
+	if !auth.VerifyPassword(u.Password, req.Password) {
+		return fmt.Errorf("密码错误")
+	}
+
+	ok, err := s.VerifySecondFactor(ctx, u, req.Code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("验证码错误")
+	}
+
+	if err := s.userRepo.DisableTOTP(ctx, u.ID); err != nil {
+		return fmt.Errorf("关闭两步验证失败: %w", err)
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes 验证当前验证码后重新生成恢复码，旧恢复码全部失效
+func (s *mfaService) RegenerateRecoveryCodes(ctx context.Context, userID uint, req *user.RegenerateRecoveryCodesRequest) (*user.RecoveryCodesResponse, error) {
+	u, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !u.TOTPEnabled {
+		return nil, fmt.Errorf("两步验证未启用")
+	}
+
+	ok, err := s.verifyTOTP(ctx, u, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("验证码错误")
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("生成恢复码失败: %w", err)
+	}
+	if err := s.userRepo.ReplaceRecoveryCodes(ctx, u.ID, hashes); err != nil {
+		return nil, fmt.Errorf("保存恢复码失败: %w", err)
+	}
+
+	return &user.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// VerifySecondFactor 校验验证码或恢复码，验证码和恢复码都只能使用一次
+func (s *mfaService) VerifySecondFactor(ctx context.Context, u *user.User, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if totpCodePattern.MatchString(code) {
+		return s.verifyTOTP(ctx, u, code)
+	}
+
+	normalized := normalizeRecoveryCode(code)
+	if normalized == "" {
+		return false, nil
+	}
+	used, err := s.userRepo.UseRecoveryCode(ctx, u.ID, hashToken(normalized))
+	if err != nil {
+		return false, fmt.Errorf("校验恢复码失败: %w", err)
+	}
+	return used, nil
+}
+
+// verifyTOTP 校验TOTP验证码，并记录时间步防止同一验证码被重复使用
+func (s *mfaService) verifyTOTP(ctx context.Context, u *user.User, code string) (bool, error) {
+	secret, err := s.box.Open(u.TOTPSecret)
+	if err != nil {
+		return false, fmt.Errorf("两步验证密钥无效")
+	}
+	counter, ok := auth.ValidateTOTP(secret, code, time.Now(), s.cfg.Skew)
+	if !ok || counter <= u.TOTPLastCounter {
+		return false, nil
+	}
+
+	used, err := s.userRepo.UseTOTPCounter(ctx, u.ID, counter)
+	if err != nil {
+		return false, fmt.Errorf("记录验证码使用失败: %w", err)
+	}
+	if used {
+		u.TOTPLastCounter = counter
+	}
+	return used, nil
+}
+
+// IssuePendingToken 为已通过密码验证的用户签发两步验证待完成令牌
+// 载荷包含密码哈希的摘要，修改或重置密码后未完成的登录随之失效
+func (s *mfaService) IssuePendingToken(u *user.User) (string, time.Duration) {
+	ttl := s.pendingTTL()
+	return s.signer.Sign(fmt.Sprintf("%d:%s", u.ID, passwordFingerprint(u)), time.Now().Add(ttl)), ttl
+}
+
+// VerifyPendingToken 校验两步验证待完成令牌并返回对应用户
+func (s *mfaService) VerifyPendingToken(ctx context.Context, token string) (*user.User, error) {
+	payload, err := s.signer.Verify(token, time.Now())
+	if errors.Is(err, auth.ErrSignedTokenExpired) {
+		return nil, fmt.Errorf("两步验证已超时，请重新登录")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("两步验证令牌无效")
+	}
+
+	idPart, fingerprint, ok := strings.Cut(payload, ":")
+	userID, err := strconv.ParseUint(idPart, 10, 64)
+	if !ok || err != nil {
+		return nil, fmt.Errorf("两步验证令牌无效")
+	}
+	u, err := s.userRepo.GetByID(ctx, uint(userID))
+	if err != nil {
+		return nil, fmt.Errorf("获取用户失败: %w", err)
+	}
+	if u == nil || !u.TOTPEnabled || fingerprint != passwordFingerprint(u) {
+		return nil, fmt.Errorf("两步验证令牌无效")
+	}
+	if !u.IsActive {
+		return nil, fmt.Errorf("用户账户已被停用")
+	}
+	return u, nil
+}
+
+// pendingTTL 获取待完成令牌有效期
+func (s *mfaService) pendingTTL() time.Duration {
+	if s.cfg.PendingTTL <= 0 {
+		return defaultMFAPendingTTL
+	}
+	return time.Duration(s.cfg.PendingTTL) * time.Minute
+}
+
+// getUser 获取用户，不存在时返回错误
+func (s *mfaService) getUser(ctx context.Context, userID uint) (*user.User, error) {
+	u, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户失败: %w", err)
+	}
+	if u == nil {
+		return nil, fmt.Errorf("用户不存在")
+	}
+	return u, nil
+}
+
+// passwordFingerprint 密码哈希的摘要，用于让待完成令牌随密码变更失效
+func passwordFingerprint(u *user.User) string {
+	return hashToken(u.Password)[:16]
+}
+
+// newRecoveryCodes 生成一组恢复码，返回明文（形如 a1b2c-3d4e5）和对应的哈希
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := hex.EncodeToString(b)
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, hashToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode 去掉恢复码中的分隔符和空白并转为小写
+func normalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '-' || r == ' ':
+			return -1
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		}
+		return r
+	}, code)
+}
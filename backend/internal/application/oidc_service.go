@@ -0,0 +1,339 @@
+package application
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"temp-mailbox-service/internal/domain/user"
+	"temp-mailbox-service/internal/infrastructure/auth"
+	"temp-mailbox-service/internal/infrastructure/config"
+	"temp-mailbox-service/internal/infrastructure/oidc"
+)
+
+const (
+	// defaultOIDCStateTTL 未配置时发起登录到回调的时限
+	defaultOIDCStateTTL = 10 * time.Minute
+	// usernameAttempts 自动创建账户时生成不重复用户名的尝试次数
+	usernameAttempts = 5
+)
+
+// usernameInvalidChars 自动生成用户名时去掉的字符
+var usernameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// OIDCService 第三方登录服务接口
+type OIDCService interface {
+	Providers() []OIDCProviderInfo
+	BeginLogin(ctx context.Context, provider string) (*OIDCAuthorization, error)
+	CompleteLogin(ctx context.Context, provider, code, state, stateCookie string, client *ClientInfo) (*LoginResponse, error)
+}
+
+// OIDCProviderInfo 登录页展示的身份提供方
+type OIDCProviderInfo struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+// OIDCAuthorization 发起登录的结果，State 需要由调用方保存在 Cookie 中并在回调时传回
+type OIDCAuthorization struct {
+	URL       string
+	State     string
+	ExpiresIn time.Duration
+}
+
+// oidcState 保存在 Cookie 中的登录状态（加密）
+type oidcState struct {
+	Provider  string `json:"p"`
+	State     string `json:"s"`
+	Nonce     string `json:"n"`
+	Verifier  string `json:"v"`
+	ExpiresAt int64  `json:"e"`
+}
+
+// oidcService 第三方登录服务实现
+type oidcService struct {
+	userRepo  user.Repository
+	users     UserService
+	box       *auth.SecretBox
+	cfg       *config.OIDCConfig
+	providers map[string]*oidc.Provider
+	order     []string
+}
+
+// NewOIDCService 创建第三方登录服务实例，box 用于加密登录状态
+func NewOIDCService(userRepo user.Repository, users UserService, box *auth.SecretBox, cfg *config.OIDCConfig) OIDCService {
+	s := &oidcService{
+		userRepo:  userRepo,
+		users:     users,
+		box:       box,
+		cfg:       cfg,
+		providers: make(map[string]*oidc.Provider, len(cfg.Providers)),
+	}
+	for _, providerCfg := range cfg.Providers {
+		s.providers[providerCfg.Name] = oidc.NewProvider(providerCfg, nil)
+		s.order = append(s.order, providerCfg.Name)
+	}
+	return s
+}
+
+// Providers 获取已配置的身份提供方
+func (s *oidcService) Providers() []OIDCProviderInfo {
+	infos := make([]OIDCProviderInfo, 0, len(s.order))
+	for _, name := range s.order {
+		infos = append(infos, OIDCProviderInfo{
+			Name:        name,
+			DisplayName: s.providers[name].DisplayName(),
+		})
+	}
+	return infos
+}
+
+// BeginLogin 生成 state、nonce 和 PKCE 校验码，返回身份提供方授权地址
+func (s *oidcService) BeginLogin(ctx context.Context, providerName string) (*OIDCAuthorization, error) {
+	provider, err := s.getProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := oidc.RandomString(24)
+	if err != nil {
+		return nil, fmt.Errorf("生成登录状态失败: %w", err)
+	}
+	nonce, err := oidc.RandomString(24)
+	if err != nil {
+		return nil, fmt.Errorf("生成登录状态失败: %w", err)
+	}
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		return nil, fmt.Errorf("生成登录状态失败: %w", err)
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, oidc.CodeChallengeS256(verifier))
+	if err != nil {
+		log.Printf("身份提供方 %s 不可用: %v", providerName, err)
+		return nil, fmt.Errorf("身份提供方暂时不可用")
+	}
+
+	ttl := s.stateTTL()
+	payload, err := json.Marshal(&oidcState{
+		Provider:  providerName,
+		State:     state,
+		Nonce:     nonce,
+		Verifier:  verifier,
+		ExpiresAt: time.Now().Add(ttl).Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := s.box.Seal(string(payload))
+	if err != nil {
+		return nil, fmt.Errorf("保存登录状态失败: %w", err)
+	}
+
+	return &OIDCAuthorization{
+		URL:       authURL,
+		State:     sealed,
+		ExpiresIn: ttl,
+	}, nil
+}
+
+// CompleteLogin 校验回调的 state，换取并验证 ID 令牌后登录关联的用户
+func (s *oidcService) CompleteLogin(ctx context.Context, providerName, code, state, stateCookie string, client *ClientInfo) (*LoginResponse, error) {
+	provider, err := s.getProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	saved, err := s.openState(stateCookie)
+	if err != nil {
+		return nil, err
+	}
+	if saved.Provider != providerName || subtle.ConstantTimeCompare([]byte(saved.State), []byte(state)) != 1 {
+		return nil, fmt.Errorf("登录状态不匹配，请重新登录")
+	}
+	if code == "" {
+		return nil, fmt.Errorf("缺少授权码")
+	}
+
+	token, err := provider.Exchange(ctx, code, saved.Verifier)
+	if err != nil {
+		log.Printf("身份提供方 %s 换取令牌失败: %v", providerName, err)
+		return nil, fmt.Errorf("第三方登录失败，请重试")
+	}
+	claims, err := provider.VerifyIDToken(ctx, token.IDToken, saved.Nonce)
+	if err != nil {
+		log.Printf("身份提供方 %s ID令牌校验失败: %v", providerName, err)
+		return nil, fmt.Errorf("第三方登录失败，请重试")
+	}
+
+	u, err := s.resolveUser(ctx, providerName, claims)
+	if err != nil {
+		return nil, err
+	}
+	return s.users.LoginExternalUser(ctx, u, client)
+}
+
+// resolveUser 查找外部身份关联的用户；未关联时按已验证的邮箱关联已有用户，或在允许时创建新用户
+func (s *oidcService) resolveUser(ctx context.Context, providerName string, claims *oidc.Claims) (*user.User, error) {
+	identity, err := s.userRepo.GetIdentity(ctx, providerName, claims.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("获取外部身份失败: %w", err)
+	}
+	if identity != nil {
+		u, err := s.userRepo.GetByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("获取用户失败: %w", err)
+		}
+		if u == nil {
+			return nil, fmt.Errorf("关联的用户不存在")
+		}
+		return u, nil
+	}
+
+	email := strings.TrimSpace(claims.Email)
+	if email == "" || !bool(claims.EmailVerified) {
+		return nil, fmt.Errorf("身份提供方未提供已验证的邮箱")
+	}
+
+	u, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户失败: %w", err)
+	}
+	if u != nil {
+		// 未验证的邮箱可能是他人抢注的，关联后对方可以用密码登录，因此不自动关联
+		if !u.EmailVerified {
+			return nil, fmt.Errorf("该邮箱已注册但尚未验证，请先使用密码登录并完成邮箱验证")
+		}
+	} else {
+		if !s.providerConfig(providerName).AllowSignup {
+			return nil, fmt.Errorf("该邮箱尚未注册")
+		}
+		if u, err = s.createUser(ctx, email, claims); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.userRepo.CreateIdentity(ctx, &user.Identity{
+		UserID:   u.ID,
+		Provider: providerName,
+		Subject:  claims.Subject,
+		Email:    email,
+	}); err != nil {
+		return nil, fmt.Errorf("关联外部身份失败: %w", err)
+	}
+	return u, nil
+}
+
+// createUser 为第三方登录创建用户，邮箱视为已验证，密码随机生成（可通过找回密码设置）
+func (s *oidcService) createUser(ctx context.Context, email string, claims *oidc.Claims) (*user.User, error) {
+	username, err := s.uniqueUsername(ctx, claims.PreferredUsername, email)
+	if err != nil {
+		return nil, err
+	}
+
+	randomPassword, err := newResetToken()
+	if err != nil {
+		return nil, fmt.Errorf("生成密码失败: %w", err)
+	}
+	hashedPassword, err := auth.HashPassword(randomPassword)
+	if err != nil {
+		return nil, fmt.Errorf("密码哈希失败: %w", err)
+	}
+
+	now := time.Now()
+	newUser := &user.User{
+		Username:        username,
+		Email:           email,
+		Password:        hashedPassword,
+		Nickname:        truncate(claims.Name, 50),
+		Role:            user.RoleUser,
+		IsActive:        true,
+		EmailVerified:   true,
+		EmailVerifiedAt: &now,
+	}
+	if err := s.userRepo.Create(ctx, newUser); err != nil {
+		return nil, fmt.Errorf("创建用户失败: %w", err)
+	}
+	return newUser, nil
+}
+
+// uniqueUsername 根据 preferred_username 或邮箱前缀生成未被占用的用户名
+func (s *oidcService) uniqueUsername(ctx context.Context, preferred, email string) (string, error) {
+	base := usernameInvalidChars.ReplaceAllString(preferred, "")
+	if len(base) < 3 {
+		local, _, _ := strings.Cut(email, "@")
+		base = usernameInvalidChars.ReplaceAllString(local, "")
+	}
+	if len(base) < 3 {
+		base = "user"
+	}
+	base = truncate(base, 40)
+
+	candidate := base
+	for i := 0; i < usernameAttempts; i++ {
+		exists, err := s.userRepo.ExistsByUsername(ctx, candidate)
+		if err != nil {
+			return "", fmt.Errorf("检查用户名失败: %w", err)
+		}
+		if !exists {
+			return candidate, nil
+		}
+		suffix, err := newResetToken()
+		if err != nil {
+			return "", err
+		}
+		candidate = base + "_" + suffix[:6]
+	}
+	return "", fmt.Errorf("无法生成可用的用户名")
+}
+
+// openState 解密并校验 Cookie 中的登录状态
+func (s *oidcService) openState(stateCookie string) (*oidcState, error) {
+	if stateCookie == "" {
+		return nil, fmt.Errorf("登录状态已失效，请重新登录")
+	}
+	payload, err := s.box.Open(stateCookie)
+	if err != nil {
+		return nil, fmt.Errorf("登录状态已失效，请重新登录")
+	}
+	var saved oidcState
+	if err := json.Unmarshal([]byte(payload), &saved); err != nil {
+		return nil, fmt.Errorf("登录状态已失效，请重新登录")
+	}
+	if time.Now().Unix() > saved.ExpiresAt {
+		return nil, fmt.Errorf("登录已超时，请重新登录")
+	}
+	return &saved, nil
+}
+
+// getProvider 获取身份提供方
+func (s *oidcService) getProvider(name string) (*oidc.Provider, error) {
+	provider, ok := s.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("身份提供方不存在")
+	}
+	return provider, nil
+}
+
+// providerConfig 获取身份提供方配置
+func (s *oidcService) providerConfig(name string) config.OIDCProviderConfig {
+	for _, providerCfg := range s.cfg.Providers {
+		if providerCfg.Name == name {
+			return providerCfg
+		}
+	}
+	return config.OIDCProviderConfig{}
+}
+
+// stateTTL 获取登录状态有效期
+func (s *oidcService) stateTTL() time.Duration {
+	if s.cfg.StateTTL <= 0 {
+		return defaultOIDCStateTTL
+	}
+	return time.Duration(s.cfg.StateTTL) * time.Minute
+}
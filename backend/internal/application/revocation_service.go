@@ -0,0 +1,149 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"temp-mailbox-service/internal/domain/session"
+	"temp-mailbox-service/internal/infrastructure/auth"
+)
+
+// RevocationService 令牌吊销服务接口
+// 吊销记录写入数据库并缓存在内存中，认证中间件只查询内存缓存；
+// 缓存定期从数据库增量同步，以便多实例部署时感知其他实例的吊销
+type RevocationService interface {
+	auth.RevocationChecker
+	RevokeToken(ctx context.Context, claims *auth.JWTClaims, reason string) error
+	RevokeSession(ctx context.Context, userID uint, sid string, reason string) error
+	PruneExpired(ctx context.Context) (int64, error)
+}
+
+// revocationService 令牌吊销服务实现
+type revocationService struct {
+	repo         session.RevocationRepository
+	cache        *auth.RevocationCache
+	accessTTL    time.Duration
+	syncInterval time.Duration
+
+	mu       sync.Mutex
+	lastSync time.Time
+	loaded   atomic.Bool
+}
+
+// NewRevocationService 创建新的令牌吊销服务实例，syncInterval 为0时只在首次检查时加载一次
+func NewRevocationService(repo session.RevocationRepository, accessTTL, syncInterval time.Duration) RevocationService {
+	return &revocationService{
+		repo:         repo,
+		cache:        auth.NewRevocationCache(),
+		accessTTL:    accessTTL,
+		syncInterval: syncInterval,
+	}
+}
+
+// IsRevoked 检查令牌是否已被吊销
+func (s *revocationService) IsRevoked(ctx context.Context, claims *auth.JWTClaims) bool {
+	s.syncIfStale(ctx)
+	return s.cache.IsRevoked(claims)
+}
+
+// RevokeToken 吊销单个令牌直到其过期
+func (s *revocationService) RevokeToken(ctx context.Context, claims *auth.JWTClaims, reason string) error {
+	if claims.ID == "" {
+		return fmt.Errorf("令牌缺少ID，无法吊销")
+	}
+
+	expiresAt := time.Now().Add(s.accessTTL)
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	return s.revoke(ctx, &session.Revocation{
+		Kind:      session.RevocationKindToken,
+		Value:     claims.ID,
+		UserID:    claims.UserID,
+		Reason:    reason,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// RevokeSession 吊销会话下已签发的全部访问令牌
+func (s *revocationService) RevokeSession(ctx context.Context, userID uint, sid string, reason string) error {
+	if sid == "" {
+		return nil
+	}
+
+	// 会话注销后不会再签发新令牌，最后一个访问令牌至多在一个有效期后过期
+	return s.revoke(ctx, &session.Revocation{
+		Kind:      session.RevocationKindSession,
+		Value:     sid,
+		UserID:    userID,
+		Reason:    reason,
+		ExpiresAt: time.Now().Add(s.accessTTL),
+	})
+}
+
+// PruneExpired 删除已过期的吊销记录
+func (s *revocationService) PruneExpired(ctx context.Context) (int64, error) {
+	now := time.Now()
+	s.cache.Prune(now)
+	return s.repo.DeleteExpiredBefore(ctx, now)
+}
+
+// revoke 保存吊销记录并立即写入本地缓存
+func (s *revocationService) revoke(ctx context.Context, rev *session.Revocation) error {
+	if err := s.repo.Create(ctx, rev); err != nil {
+		return fmt.Errorf("保存吊销记录失败: %w", err)
+	}
+	s.apply(rev)
+	return nil
+}
+
+// apply 将吊销记录写入本地缓存
+func (s *revocationService) apply(rev *session.Revocation) {
+	switch rev.Kind {
+	case session.RevocationKindToken:
+		s.cache.RevokeToken(rev.Value, rev.ExpiresAt)
+	case session.RevocationKindSession:
+		s.cache.RevokeSession(rev.Value, rev.ExpiresAt)
+	}
+}
+
+// syncIfStale 缓存过期时从数据库增量同步吊销记录
+// 同步失败时继续使用现有缓存，不阻塞请求
+func (s *revocationService) syncIfStale(ctx context.Context) {
+	if s.loaded.Load() {
+		if !s.mu.TryLock() {
+			// 其他请求正在同步，先使用现有缓存
+			return
+		}
+	} else {
+		// 首次加载完成前缓存为空，需要等待
+		s.mu.Lock()
+	}
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if !s.lastSync.IsZero() && (s.syncInterval <= 0 || now.Sub(s.lastSync) < s.syncInterval) {
+		return
+	}
+
+	// 与上次同步的时间窗口重叠一个同步间隔，容忍各实例间的时钟偏差和延迟提交
+	var since time.Time
+	if !s.lastSync.IsZero() {
+		since = s.lastSync.Add(-s.syncInterval)
+	}
+	revocations, err := s.repo.ListActiveSince(ctx, since, now)
+	if err != nil {
+		log.Printf("同步令牌吊销记录失败: %v", err)
+		return
+	}
+	for _, rev := range revocations {
+		s.apply(rev)
+	}
+	s.cache.Prune(now)
+	s.lastSync = now
+	s.loaded.Store(true)
+}
@@ -0,0 +1,256 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+
+	"temp-mailbox-service/internal/domain/session"
+	"temp-mailbox-service/internal/domain/user"
+	"temp-mailbox-service/internal/infrastructure/auth"
+)
+
+// SessionService 登录会话服务接口，负责签发令牌并轮换刷新令牌
+type SessionService interface {
+	CreateSession(ctx context.Context, u *user.User, client *ClientInfo) (*auth.TokenPair, error)
+	RefreshSession(ctx context.Context, refreshToken string, client *ClientInfo) (*auth.TokenPair, error)
+	Logout(ctx context.Context, claims *auth.JWTClaims) error
+	LogoutAll(ctx context.Context, userID uint) (int, error)
+	
+	// 登录设备管理
+	ListSessions(ctx context.Context, userID uint, currentFamilyID string) ([]*session.SessionResponse, error)
+	RevokeSession(ctx context.Context, userID, sessionID uint) error
+	RevokeOtherSessions(ctx context.Context, userID uint, currentFamilyID string) (int, error)
+}
+
+// sessionService 登录会话服务实现
+type sessionService struct {
+	sessionRepo session.Repository
+	userRepo    user.Repository
+	jwtService  auth.JWTService
+	revocations RevocationService
+}
+
+// NewSessionService 创建新的登录会话服务实例
+func NewSessionService(sessionRepo session.Repository, userRepo user.Repository, jwtService auth.JWTService, revocations RevocationService) SessionService {
+	return &sessionService{
+		sessionRepo: sessionRepo,
+		userRepo:    userRepo,
+		jwtService:  jwtService,
+		revocations: revocations,
+	}
+}
+
+// CreateSession 为用户创建新会话并签发第一代令牌对，client 记录登录设备信息
+func (s *sessionService) CreateSession(ctx context.Context, u *user.User, client *ClientInfo) (*auth.TokenPair, error) {
+	familyID, err := newFamilyID()
+	if err != nil {
+		return nil, fmt.Errorf("生成会话ID失败: %w", err)
+	}
+
+	tokenPair, err := s.jwtService.GenerateSessionTokens(tokenSubject(u), familyID, 1)
+	if err != nil {
+		return nil, fmt.Errorf("生成令牌失败: %w", err)
+	}
+
+	now := time.Now()
+	sess := &session.Session{
+		UserID:           u.ID,
+		FamilyID:         familyID,
+		RefreshTokenHash: hashToken(tokenPair.RefreshToken),
+		Generation:       1,
+		ExpiresAt:        now.Add(s.jwtService.RefreshTokenTTL()),
+		UserAgent:        truncate(client.UserAgent, 512),
+		IP:               client.IP,
+		LastSeenAt:       now,
+		LastSeenIP:       client.IP,
+	}
+	if err := s.sessionRepo.Create(ctx, sess); err != nil {
+		return nil, fmt.Errorf("创建会话失败: %w", err)
+	}
+
+	return tokenPair, nil
+}
+
+// RefreshSession 使用刷新令牌换取新的令牌对
+// 每个刷新令牌只能使用一次；已轮换掉的刷新令牌再次出现时视为泄露，整个会话随之注销
+func (s *sessionService) RefreshSession(ctx context.Context, refreshToken string, client *ClientInfo) (*auth.TokenPair, error) {
+	claims, err := s.jwtService.ValidateRefreshToken(refreshToken)
+	if err != nil {
+		return nil, fmt.Errorf("刷新令牌无效")
+	}
+	if claims.SessionID == "" {
+		return nil, fmt.Errorf("刷新令牌无效")
+	}
+
+	sess, err := s.sessionRepo.GetByFamilyID(ctx, claims.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("获取会话失败: %w", err)
+	}
+	if sess == nil || sess.UserID != claims.UserID {
+		return nil, fmt.Errorf("刷新令牌无效")
+	}
+	if sess.IsRevoked() || sess.IsExpired() {
+		return nil, fmt.Errorf("会话已失效，请重新登录")
+	}
+
+	oldHash := hashToken(refreshToken)
+	if oldHash != sess.RefreshTokenHash {
+		return nil, s.revokeReused(ctx, sess)
+	}
+
+	// 用户被删除或停用后不再续期
+	existingUser, err := s.userRepo.GetByID(ctx, sess.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户失败: %w", err)
+	}
+	if existingUser == nil || !existingUser.IsActive {
+		if err := s.revokeSession(ctx, sess, session.RevokeReasonUserInactive); err != nil {
+			log.Printf("注销会话 %d 失败: %v", sess.ID, err)
+		}
+		return nil, fmt.Errorf("用户账户已被停用")
+	}
+
+	generation := sess.Generation + 1
+	tokenPair, err := s.jwtService.GenerateSessionTokens(tokenSubject(existingUser), sess.FamilyID, generation)
+	if err != nil {
+		return nil, fmt.Errorf("生成令牌失败: %w", err)
+	}
+
+	rotated, err := s.sessionRepo.Rotate(ctx, sess.ID, oldHash, hashToken(tokenPair.RefreshToken), generation, time.Now().Add(s.jwtService.RefreshTokenTTL()), client.IP)
+	if err != nil {
+		return nil, fmt.Errorf("轮换刷新令牌失败: %w", err)
+	}
+	if !rotated {
+		// 同一刷新令牌被并发使用，另一请求已完成轮换
+		return nil, s.revokeReused(ctx, sess)
+	}
+
+	return tokenPair, nil
+}
+
+// Logout 退出当前会话：吊销当前访问令牌，并注销其所属会话使刷新令牌失效
+func (s *sessionService) Logout(ctx context.Context, claims *auth.JWTClaims) error {
+	if err := s.revocations.RevokeToken(ctx, claims, session.RevokeReasonLogout); err != nil {
+		return err
+	}
+	if claims.SessionID == "" {
+		return nil
+	}
+
+	sess, err := s.sessionRepo.GetByFamilyID(ctx, claims.SessionID)
+	if err != nil {
+		return fmt.Errorf("获取会话失败: %w", err)
+	}
+	if sess == nil || sess.UserID != claims.UserID || sess.IsRevoked() {
+		return nil
+	}
+	return s.revokeSession(ctx, sess, session.RevokeReasonLogout)
+}
+
+// LogoutAll 退出所有设备：注销用户的全部会话，返回注销的会话数量
+func (s *sessionService) LogoutAll(ctx context.Context, userID uint) (int, error) {
+	sessions, err := s.sessionRepo.ListActiveByUser(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("获取会话失败: %w", err)
+	}
+
+	for i, sess := range sessions {
+		if err := s.revokeSession(ctx, sess, session.RevokeReasonLogoutAll); err != nil {
+			return i, err
+		}
+	}
+	return len(sessions), nil
+}
+
+// ListSessions 获取用户未注销且未过期的会话，currentFamilyID 对应的会话标记为当前会话
+func (s *sessionService) ListSessions(ctx context.Context, userID uint, currentFamilyID string) ([]*session.SessionResponse, error) {
+	sessions, err := s.sessionRepo.ListActiveByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取会话失败: %w", err)
+	}
+
+	items := make([]*session.SessionResponse, 0, len(sessions))
+	for _, sess := range sessions {
+		items = append(items, sess.ToResponse(currentFamilyID))
+	}
+	return items, nil
+}
+
+// RevokeSession 注销用户自己的某个会话，该会话的刷新令牌和访问令牌立即失效
+func (s *sessionService) RevokeSession(ctx context.Context, userID, sessionID uint) error {
+	sess, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("获取会话失败: %w", err)
+	}
+	if sess == nil || sess.UserID != userID || sess.IsRevoked() || sess.IsExpired() {
+		return fmt.Errorf("会话不存在")
+	}
+	return s.revokeSession(ctx, sess, session.RevokeReasonLogout)
+}
+
+// RevokeOtherSessions 注销除当前会话以外的全部会话，返回注销的会话数量
+func (s *sessionService) RevokeOtherSessions(ctx context.Context, userID uint, currentFamilyID string) (int, error) {
+	sessions, err := s.sessionRepo.ListActiveByUser(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("获取会话失败: %w", err)
+	}
+
+	revoked := 0
+	for _, sess := range sessions {
+		if sess.FamilyID == currentFamilyID {
+			continue
+		}
+		if err := s.revokeSession(ctx, sess, session.RevokeReasonLogoutOthers); err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+	return revoked, nil
+}
+
+// revokeReused 注销发生刷新令牌重放的会话
+func (s *sessionService) revokeReused(ctx context.Context, sess *session.Session) error {
+	log.Printf("检测到会话 %d（用户 %d）的刷新令牌被重复使用，注销整个会话", sess.ID, sess.UserID)
+	if err := s.revokeSession(ctx, sess, session.RevokeReasonReused); err != nil {
+		return err
+	}
+	return fmt.Errorf("刷新令牌已被使用，会话已注销，请重新登录")
+}
+
+// revokeSession 注销会话，并吊销该会话已签发且尚未过期的访问令牌
+func (s *sessionService) revokeSession(ctx context.Context, sess *session.Session, reason string) error {
+	if err := s.sessionRepo.Revoke(ctx, sess.ID, reason); err != nil {
+		return fmt.Errorf("注销会话失败: %w", err)
+	}
+	return s.revocations.RevokeSession(ctx, sess.UserID, sess.FamilyID, reason)
+}
+
+// tokenSubject 由用户生成令牌主体，角色在每次刷新时重新读取
+func tokenSubject(u *user.User) auth.TokenSubject {
+	return auth.TokenSubject{
+		UserID:   u.ID,
+		Username: u.Username,
+		Email:    u.Email,
+		Role:     u.Role,
+	}
+}
+
+// newFamilyID 生成随机的会话ID
+func newFamilyID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashToken 计算令牌的 SHA-256 哈希，数据库中只保存哈希
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
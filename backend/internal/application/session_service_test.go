@@ -0,0 +1,221 @@
+package application
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"temp-mailbox-service/internal/domain/session"
+	"temp-mailbox-service/internal/domain/user"
+	"temp-mailbox-service/internal/infrastructure/auth"
+	"temp-mailbox-service/internal/infrastructure/middleware"
+	"temp-mailbox-service/internal/infrastructure/persistence"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sessionFixture struct {
+	jwt         auth.JWTService
+	revocations RevocationService
+	sessions    SessionService
+	sessionRepo session.Repository
+	userRepo    user.Repository
+}
+
+// setupSessions 创建会话服务，吊销记录不做定期同步
+func setupSessions(t *testing.T) *sessionFixture {
+	setupTestDB(t)
+	f := &sessionFixture{
+		jwt:         auth.NewJWTService("test-secret", 15, 60, "test"),
+		sessionRepo: persistence.NewSessionRepository(),
+		userRepo:    persistence.NewUserRepository(),
+	}
+	f.revocations = NewRevocationService(persistence.NewRevocationRepository(), f.jwt.AccessTokenTTL(), 0)
+	f.sessions = NewSessionService(f.sessionRepo, f.userRepo, f.jwt, f.revocations)
+	return f
+}
+
+// createUser 创建指定角色的已激活用户
+func (f *sessionFixture) createUser(t *testing.T, username, role string) *user.User {
+	t.Helper()
+	u := &user.User{
+		Username: username,
+		Email:    username + "@example.com",
+		Password: "hashed",
+		Role:     role,
+		IsActive: true,
+	}
+	require.NoError(t, f.userRepo.Create(context.Background(), u))
+	return u
+}
+
+// login 为用户创建会话
+func (f *sessionFixture) login(t *testing.T, u *user.User) *auth.TokenPair {
+	t.Helper()
+	pair, err := f.sessions.CreateSession(context.Background(), u, &ClientInfo{IP: "192.0.2.1", UserAgent: "test"})
+	require.NoError(t, err)
+	return pair
+}
+
+func (f *sessionFixture) refresh(refreshToken string) (*auth.TokenPair, error) {
+	return f.sessions.RefreshSession(context.Background(), refreshToken, &ClientInfo{IP: "192.0.2.1"})
+}
+
+// authorize 使用 JWTAuth 中间件校验访问令牌，返回HTTP状态码
+func (f *sessionFixture) authorize(accessToken string) int {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", middleware.JWTAuth(f.jwt, f.revocations), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestRefreshSession_DetectsReplay(t *testing.T) {
+	f := setupSessions(t)
+	ctx := context.Background()
+	u := f.createUser(t, "alice", user.RoleUser)
+	first := f.login(t, u)
+
+	second, err := f.refresh(first.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken, "刷新后应签发新的刷新令牌")
+
+	// 重放已轮换掉的刷新令牌，整个会话随之注销
+	_, err = f.refresh(first.RefreshToken)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "已被使用")
+
+	_, err = f.refresh(second.RefreshToken)
+	assert.Error(t, err, "重放之后新一代刷新令牌也应失效")
+
+	sessions, err := f.sessionRepo.ListActiveByUser(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	claims, err := f.jwt.ValidateAccessToken(second.AccessToken)
+	require.NoError(t, err)
+	assert.True(t, f.revocations.IsRevoked(ctx, claims), "会话注销后已签发的访问令牌应被吊销")
+}
+
+func TestRefreshSession_RequiresRefreshToken(t *testing.T) {
+	f := setupSessions(t)
+	alice := f.login(t, f.createUser(t, "alice", user.RoleUser))
+
+	_, err := f.refresh(alice.AccessToken)
+	assert.Error(t, err, "访问令牌不能用于刷新")
+
+	pair, err := f.refresh(alice.RefreshToken)
+	require.NoError(t, err)
+	claims, err := f.jwt.ValidateAccessToken(pair.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", claims.Username)
+}
+
+func TestLogout_RevokesAccessToken(t *testing.T) {
+	f := setupSessions(t)
+	ctx := context.Background()
+	u := f.createUser(t, "alice", user.RoleUser)
+	current := f.login(t, u)
+	other := f.login(t, u)
+	require.Equal(t, http.StatusOK, f.authorize(current.AccessToken))
+
+	claims, err := f.jwt.ValidateAccessToken(current.AccessToken)
+	require.NoError(t, err)
+	require.NoError(t, f.sessions.Logout(ctx, claims))
+
+	assert.Equal(t, http.StatusUnauthorized, f.authorize(current.AccessToken), "退出后访问令牌应被拒绝")
+	_, err = f.refresh(current.RefreshToken)
+	assert.Error(t, err, "退出后刷新令牌应失效")
+	assert.Equal(t, http.StatusOK, f.authorize(other.AccessToken), "其他会话不受影响")
+}
+
+func TestLogoutAll_RevokesEverySession(t *testing.T) {
+	f := setupSessions(t)
+	ctx := context.Background()
+	alice := f.createUser(t, "alice", user.RoleUser)
+	first := f.login(t, alice)
+	second := f.login(t, alice)
+	bob := f.login(t, f.createUser(t, "bob", user.RoleUser))
+
+	count, err := f.sessions.LogoutAll(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	assert.Equal(t, http.StatusUnauthorized, f.authorize(first.AccessToken))
+	assert.Equal(t, http.StatusUnauthorized, f.authorize(second.AccessToken))
+	assert.Equal(t, http.StatusOK, f.authorize(bob.AccessToken))
+}
+
+func TestRevokeSession_OnlyOwnSessions(t *testing.T) {
+	f := setupSessions(t)
+	ctx := context.Background()
+	alice := f.createUser(t, "alice", user.RoleUser)
+	bob := f.createUser(t, "bob", user.RoleUser)
+	pair := f.login(t, alice)
+	f.login(t, bob)
+
+	sessions, err := f.sessionRepo.ListActiveByUser(ctx, alice.ID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+
+	assert.Error(t, f.sessions.RevokeSession(ctx, bob.ID, sessions[0].ID), "不能注销其他用户的会话")
+	assert.Equal(t, http.StatusOK, f.authorize(pair.AccessToken))
+
+	require.NoError(t, f.sessions.RevokeSession(ctx, alice.ID, sessions[0].ID))
+	assert.Equal(t, http.StatusUnauthorized, f.authorize(pair.AccessToken))
+}
+
+func TestListSessions_DeviceMetadata(t *testing.T) {
+	f := setupSessions(t)
+	ctx := context.Background()
+	u := f.createUser(t, "alice", user.RoleUser)
+
+	laptop, err := f.sessions.CreateSession(ctx, u, &ClientInfo{IP: "192.0.2.10", UserAgent: "Firefox on Linux"})
+	require.NoError(t, err)
+	phone, err := f.sessions.CreateSession(ctx, u, &ClientInfo{IP: "198.51.100.20", UserAgent: "Safari on iOS"})
+	require.NoError(t, err)
+	f.login(t, f.createUser(t, "bob", user.RoleUser))
+
+	// 手机换了网络后刷新令牌，最近活动IP随之更新，登录IP保持不变
+	_, err = f.sessions.RefreshSession(ctx, phone.RefreshToken, &ClientInfo{IP: "203.0.113.30", UserAgent: "Safari on iOS"})
+	require.NoError(t, err)
+
+	claims, err := f.jwt.ValidateAccessToken(laptop.AccessToken)
+	require.NoError(t, err)
+	sessions, err := f.sessions.ListSessions(ctx, u.ID, claims.SessionID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2, "只列出自己的会话")
+
+	byAgent := make(map[string]*session.SessionResponse)
+	for _, s := range sessions {
+		byAgent[s.UserAgent] = s
+	}
+	require.Contains(t, byAgent, "Firefox on Linux")
+	require.Contains(t, byAgent, "Safari on iOS")
+
+	current := byAgent["Firefox on Linux"]
+	assert.True(t, current.Current, "发起请求的会话标记为当前会话")
+	assert.Equal(t, "192.0.2.10", current.IP)
+	assert.Equal(t, "192.0.2.10", current.LastSeenIP)
+
+	other := byAgent["Safari on iOS"]
+	assert.False(t, other.Current)
+	assert.Equal(t, "198.51.100.20", other.IP)
+	assert.Equal(t, "203.0.113.30", other.LastSeenIP)
+	assert.Equal(t, current.Generation+1, other.Generation, "刷新后轮换代数加一")
+	assert.NotNil(t, other.RotatedAt)
+
+	sessions, err = f.sessions.ListSessions(ctx, u.ID, "")
+	require.NoError(t, err)
+	for _, s := range sessions {
+		assert.False(t, s.Current, "没有会话ID时不标记当前会话")
+	}
+}
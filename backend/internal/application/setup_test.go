@@ -0,0 +1,31 @@
+package application
+
+import (
+	"testing"
+
+	"temp-mailbox-service/internal/infrastructure/config"
+	"temp-mailbox-service/internal/infrastructure/database"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/logger"
+)
+
+// setupTestDB 初始化内存SQLite数据库并执行迁移，测试结束后关闭
+func setupTestDB(t *testing.T) {
+	t.Helper()
+	database.DB = nil
+	err := database.InitDatabase(&config.DatabaseConfig{
+		Driver:       "sqlite",
+		DSN:          ":memory:",
+		MaxOpenConns: 1, // 内存数据库每个连接相互独立
+		MaxIdleConns: 1,
+		MaxLifetime:  30,
+	})
+	require.NoError(t, err)
+	database.DB.Logger = logger.Discard
+	require.NoError(t, database.Migrate())
+	t.Cleanup(func() {
+		database.CloseDatabase()
+		database.DB = nil
+	})
+}
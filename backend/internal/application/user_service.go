@@ -2,46 +2,94 @@ package application
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
+	"temp-mailbox-service/internal/domain/session"
 	"temp-mailbox-service/internal/domain/user"
 	"temp-mailbox-service/internal/infrastructure/auth"
+	"temp-mailbox-service/internal/infrastructure/config"
+	"temp-mailbox-service/internal/infrastructure/mailer"
+)
+
+const (
+	// defaultPasswordResetTTL 未配置时密码重置令牌的有效期
+	defaultPasswordResetTTL = 30 * time.Minute
+	// defaultEmailVerificationTTL 未配置时邮箱验证链接的有效期
+	defaultEmailVerificationTTL = 24 * time.Hour
 )
 
 // UserService 用户服务接口
 type UserService interface {
 	// 认证相关
-	RegisterUser(ctx context.Context, req *user.CreateUserRequest) (*LoginResponse, error)
-	LoginUser(ctx context.Context, req *user.LoginRequest) (*LoginResponse, error)
+	RegisterUser(ctx context.Context, req *user.CreateUserRequest, client *ClientInfo) (*LoginResponse, error)
+	LoginUser(ctx context.Context, req *user.LoginRequest, client *ClientInfo) (*LoginResponse, error)
+	CompleteMFALogin(ctx context.Context, req *user.MFALoginRequest, client *ClientInfo) (*LoginResponse, error)
+	LoginExternalUser(ctx context.Context, u *user.User, client *ClientInfo) (*LoginResponse, error)
+	RefreshToken(ctx context.Context, req *session.RefreshRequest, client *ClientInfo) (*auth.TokenPair, error)
+	Logout(ctx context.Context, claims *auth.JWTClaims) error
+	LogoutAll(ctx context.Context, userID uint) (int, error)
+	ForgotPassword(ctx context.Context, req *user.ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, req *user.ResetPasswordRequest) error
+	ForcePasswordReset(ctx context.Context, userID uint) error
+	VerifyEmail(ctx context.Context, req *user.VerifyEmailRequest) (*user.UserResponse, error)
+	ResendVerification(ctx context.Context, req *user.ResendVerificationRequest) error
 	
 	// 用户管理
 	GetUserProfile(ctx context.Context, userID uint) (*user.UserResponse, error)
 	UpdateUserProfile(ctx context.Context, userID uint, req *user.UpdateUserRequest) (*user.UserResponse, error)
-	ChangePassword(ctx context.Context, userID uint, req *user.ChangePasswordRequest) error
+	ChangePassword(ctx context.Context, userID uint, req *user.ChangePasswordRequest, currentSessionID string) (int, error)
 }
 
-// LoginResponse 登录响应
+// LoginResponse 登录响应，需要两步验证时只返回 MFA 相关字段
 type LoginResponse struct {
-	User   *user.UserResponse `json:"user"`
-	Token  *auth.TokenPair    `json:"token"`
+	User   *user.UserResponse `json:"user,omitempty"`
+	Token  *auth.TokenPair    `json:"token,omitempty"`
+	
+	MFARequired  bool   `json:"mfa_required,omitempty"`
+	MFAToken     string `json:"mfa_token,omitempty"`
+	MFAExpiresIn int    `json:"mfa_expires_in,omitempty"` // seconds
+}
+
+// ClientInfo 发起请求的客户端信息
+type ClientInfo struct {
+	IP        string
+	UserAgent string
 }
 
 // userService 用户服务实现
 type userService struct {
-	userRepo   user.Repository
-	jwtService auth.JWTService
+	userRepo       user.Repository
+	sessionService SessionService
+	lockouts       LockoutService
+	mfa            MFAService
+	sender         mailer.Sender
+	verifier       *auth.Signer
+	cfg            *config.AuthConfig
 }
 
-// NewUserService 创建新的用户服务实例
-func NewUserService(userRepo user.Repository, jwtService auth.JWTService) UserService {
+// NewUserService 创建新的用户服务实例，verifier 用于签发和校验邮箱验证链接
+func NewUserService(userRepo user.Repository, sessionService SessionService, lockouts LockoutService, mfa MFAService, sender mailer.Sender, verifier *auth.Signer, cfg *config.AuthConfig) UserService {
 	return &userService{
-		userRepo:   userRepo,
-		jwtService: jwtService,
+		userRepo:       userRepo,
+		sessionService: sessionService,
+		lockouts:       lockouts,
+		mfa:            mfa,
+		sender:         sender,
+		verifier:       verifier,
+		cfg:            cfg,
 	}
 }
 
 // RegisterUser 用户注册
-func (s *userService) RegisterUser(ctx context.Context, req *user.CreateUserRequest) (*LoginResponse, error) {
+func (s *userService) RegisterUser(ctx context.Context, req *user.CreateUserRequest, client *ClientInfo) (*LoginResponse, error) {
 	// 检查邮箱是否已存在
 	exists, err := s.userRepo.ExistsByEmail(ctx, req.Email)
 	if err != nil {
@@ -77,6 +125,7 @@ func (s *userService) RegisterUser(ctx context.Context, req *user.CreateUserRequ
 		Email:     req.Email,
 		Password:  hashedPassword,
 		Nickname:  req.Nickname,
+		Role:      user.RoleUser,
 		IsActive:  true,
 	}
 	
@@ -85,10 +134,20 @@ func (s *userService) RegisterUser(ctx context.Context, req *user.CreateUserRequ
 		return nil, fmt.Errorf("创建用户失败: %w", err)
 	}
 	
+	// 发送邮箱验证邮件
+	go s.sendVerificationEmail(context.WithoutCancel(ctx), newUser)
+	
+	// 不允许未验证用户登录时，需要先完成邮箱验证
+	if !s.cfg.AllowUnverifiedLogin {
+		return &LoginResponse{
+			User: newUser.ToResponse(),
+		}, nil
+	}
+	
 	// 注册成功后自动生成token
-	tokenPair, err := s.jwtService.GenerateTokens(newUser.ID, newUser.Username, newUser.Email)
+	tokenPair, err := s.sessionService.CreateSession(ctx, newUser, client)
 	if err != nil {
-		return nil, fmt.Errorf("生成令牌失败: %w", err)
+		return nil, err
 	}
 	
 	return &LoginResponse{
@@ -97,14 +156,27 @@ func (s *userService) RegisterUser(ctx context.Context, req *user.CreateUserRequ
 	}, nil
 }
 
-// LoginUser 用户登录
-func (s *userService) LoginUser(ctx context.Context, req *user.LoginRequest) (*LoginResponse, error) {
+// LoginUser 用户登录，失败次数按登录邮箱和客户端IP统计，不区分账户是否存在
+func (s *userService) LoginUser(ctx context.Context, req *user.LoginRequest, client *ClientInfo) (*LoginResponse, error) {
+	// 检查是否被锁定或需要等待
+	if err := s.lockouts.Check(ctx, req.Email, client.IP); err != nil {
+		return nil, err
+	}
+	
 	// 根据邮箱获取用户
 	existingUser, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
 		return nil, fmt.Errorf("获取用户失败: %w", err)
 	}
+	
+	// 验证密码，账户不存在时同样执行一次哈希比较
 	if existingUser == nil {
+		auth.VerifyDummyPassword(req.Password)
+	}
+	if existingUser == nil || !auth.VerifyPassword(existingUser.Password, req.Password) {
+		if err := s.lockouts.RecordFailure(ctx, req.Email, client.IP); err != nil {
+			log.Printf("记录登录失败失败: %v", err)
+		}
 		return nil, fmt.Errorf("用户不存在或密码错误")
 	}
 	
@@ -113,15 +185,77 @@ func (s *userService) LoginUser(ctx context.Context, req *user.LoginRequest) (*L
 		return nil, fmt.Errorf("用户账户已被停用")
 	}
 	
-	// 验证密码
-	if !auth.VerifyPassword(existingUser.Password, req.Password) {
-		return nil, fmt.Errorf("用户不存在或密码错误")
+	// 检查邮箱是否已验证
+	if !existingUser.EmailVerified && !s.cfg.AllowUnverifiedLogin {
+		return nil, fmt.Errorf("邮箱尚未验证，请先查收验证邮件")
+	}
+	
+	// 启用两步验证时先返回待完成令牌，失败记录在验证码通过后才清除
+	if existingUser.TOTPEnabled {
+		token, ttl := s.mfa.IssuePendingToken(existingUser)
+		return &LoginResponse{
+			MFARequired:  true,
+			MFAToken:     token,
+			MFAExpiresIn: int(ttl.Seconds()),
+		}, nil
+	}
+	
+	return s.completeLogin(ctx, existingUser, client)
+}
+
+// CompleteMFALogin 使用待完成令牌和验证码（或恢复码）完成两步验证登录
+func (s *userService) CompleteMFALogin(ctx context.Context, req *user.MFALoginRequest, client *ClientInfo) (*LoginResponse, error) {
+	existingUser, err := s.mfa.VerifyPendingToken(ctx, req.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 验证码错误同样计入登录失败次数
+	if err := s.lockouts.Check(ctx, existingUser.Email, client.IP); err != nil {
+		return nil, err
+	}
+	ok, err := s.mfa.VerifySecondFactor(ctx, existingUser, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if err := s.lockouts.RecordFailure(ctx, existingUser.Email, client.IP); err != nil {
+			log.Printf("记录登录失败失败: %v", err)
+		}
+		return nil, fmt.Errorf("验证码错误")
+	}
+	
+	return s.completeLogin(ctx, existingUser, client)
+}
+
+// LoginExternalUser 为已通过第三方身份验证的用户登录，启用两步验证时同样需要完成验证码
+func (s *userService) LoginExternalUser(ctx context.Context, u *user.User, client *ClientInfo) (*LoginResponse, error) {
+	if !u.IsActive {
+		return nil, fmt.Errorf("用户账户已被停用")
+	}
+	
+	if u.TOTPEnabled {
+		token, ttl := s.mfa.IssuePendingToken(u)
+		return &LoginResponse{
+			MFARequired:  true,
+			MFAToken:     token,
+			MFAExpiresIn: int(ttl.Seconds()),
+		}, nil
+	}
+	
+	return s.completeLogin(ctx, u, client)
+}
+
+// completeLogin 清除失败记录并创建会话
+func (s *userService) completeLogin(ctx context.Context, existingUser *user.User, client *ClientInfo) (*LoginResponse, error) {
+	if err := s.lockouts.RecordSuccess(ctx, existingUser.Email); err != nil {
+		log.Printf("清除登录失败记录失败: %v", err)
 	}
 	
 	// 生成JWT令牌
-	tokenPair, err := s.jwtService.GenerateTokens(existingUser.ID, existingUser.Username, existingUser.Email)
+	tokenPair, err := s.sessionService.CreateSession(ctx, existingUser, client)
 	if err != nil {
-		return nil, fmt.Errorf("生成令牌失败: %w", err)
+		return nil, err
 	}
 	
 	// 更新最后登录时间
@@ -136,6 +270,256 @@ func (s *userService) LoginUser(ctx context.Context, req *user.LoginRequest) (*L
 	}, nil
 }
 
+// RefreshToken 使用刷新令牌换取新的令牌对
+func (s *userService) RefreshToken(ctx context.Context, req *session.RefreshRequest, client *ClientInfo) (*auth.TokenPair, error) {
+	return s.sessionService.RefreshSession(ctx, req.RefreshToken, client)
+}
+
+// Logout 退出登录
+func (s *userService) Logout(ctx context.Context, claims *auth.JWTClaims) error {
+	return s.sessionService.Logout(ctx, claims)
+}
+
+// LogoutAll 退出所有设备，返回注销的会话数量
+func (s *userService) LogoutAll(ctx context.Context, userID uint) (int, error) {
+	return s.sessionService.LogoutAll(ctx, userID)
+}
+
+// ForgotPassword 忘记密码，向用户邮箱发送重置链接
+// 无论邮箱是否存在都返回成功，避免泄露账户是否注册
+func (s *userService) ForgotPassword(ctx context.Context, req *user.ForgotPasswordRequest) error {
+	existingUser, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		return fmt.Errorf("获取用户失败: %w", err)
+	}
+	if existingUser == nil || !existingUser.IsActive {
+		return nil
+	}
+	
+	// 生成重置令牌，数据库中只保存哈希，新令牌会使之前的令牌失效
+	token, err := newResetToken()
+	if err != nil {
+		return fmt.Errorf("生成重置令牌失败: %w", err)
+	}
+	ttl := s.passwordResetTTL()
+	expiry := time.Now().Add(ttl)
+	if err := s.userRepo.SetPasswordResetToken(ctx, existingUser.ID, hashToken(token), &expiry); err != nil {
+		return fmt.Errorf("保存重置令牌失败: %w", err)
+	}
+	
+	// 异步发送邮件，响应时间不随邮件服务器变化
+	go s.sendPasswordResetEmail(context.WithoutCancel(ctx), existingUser, token, ttl)
+	return nil
+}
+
+// ResetPassword 使用重置令牌设置新密码，成功后令牌作废并注销用户的全部会话
+func (s *userService) ResetPassword(ctx context.Context, req *user.ResetPasswordRequest) error {
+	tokenHash := hashToken(req.Token)
+	existingUser, err := s.userRepo.GetByPasswordResetToken(ctx, tokenHash)
+	if err != nil {
+		return fmt.Errorf("获取用户失败: %w", err)
+	}
+	if existingUser == nil || !existingUser.IsPasswordResetValid() {
+		return fmt.Errorf("重置链接无效或已过期")
+	}
+	
+	// 验证新密码强度
+	if err := auth.IsValidPassword(req.NewPassword); err != nil {
+		return fmt.Errorf("新密码不符合要求: %w", err)
+	}
+	
+	// 哈希新密码
+	hashedPassword, err := auth.HashPassword(req.NewPassword)
+	if err != nil {
+		return fmt.Errorf("密码哈希失败: %w", err)
+	}
+	
+	// 更新密码的同时清除令牌，保证令牌只能使用一次
+	ok, err := s.userRepo.ResetPassword(ctx, existingUser.ID, tokenHash, hashedPassword)
+	if err != nil {
+		return fmt.Errorf("更新密码失败: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("重置链接无效或已过期")
+	}
+	
+	// 密码可能已泄露，注销所有设备上的登录
+	if _, err := s.sessionService.LogoutAll(ctx, existingUser.ID); err != nil {
+		log.Printf("重置密码后注销用户 %d 的会话失败: %v", existingUser.ID, err)
+	}
+	
+	return nil
+}
+
+// ForcePasswordReset 强制用户重置密码：原密码立即失效，注销全部会话并发送重置链接
+func (s *userService) ForcePasswordReset(ctx context.Context, userID uint) error {
+	existingUser, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("获取用户失败: %w", err)
+	}
+	if existingUser == nil {
+		return fmt.Errorf("用户不存在")
+	}
+	
+	// 用随机密码替换原密码，用户只能通过重置链接重新设置
+	randomPassword, err := newResetToken()
+	if err != nil {
+		return fmt.Errorf("生成随机密码失败: %w", err)
+	}
+	hashedPassword, err := auth.HashPassword(randomPassword)
+	if err != nil {
+		return fmt.Errorf("密码哈希失败: %w", err)
+	}
+	if err := s.userRepo.UpdatePassword(ctx, existingUser.ID, hashedPassword); err != nil {
+		return fmt.Errorf("更新密码失败: %w", err)
+	}
+	
+	if _, err := s.sessionService.LogoutAll(ctx, existingUser.ID); err != nil {
+		return err
+	}
+	
+	token, err := newResetToken()
+	if err != nil {
+		return fmt.Errorf("生成重置令牌失败: %w", err)
+	}
+	ttl := s.passwordResetTTL()
+	expiry := time.Now().Add(ttl)
+	if err := s.userRepo.SetPasswordResetToken(ctx, existingUser.ID, hashToken(token), &expiry); err != nil {
+		return fmt.Errorf("保存重置令牌失败: %w", err)
+	}
+	
+	// 同步发送，让管理员知道邮件是否发出
+	body := fmt.Sprintf("%s，您好：\n\n管理员要求您重置账户密码，原密码已失效，所有设备均已退出登录。请在%s内打开以下链接设置新密码：\n\n%s\n",
+		existingUser.GetFullName(), humanizeDuration(ttl), s.passwordResetLink(token))
+	err = s.sender.Send(ctx, &mailer.Message{
+		To:      []string{existingUser.Email},
+		Subject: "请重置您的临时邮箱账户密码",
+		Body:    body,
+	})
+	if err != nil {
+		return fmt.Errorf("发送重置邮件失败: %w", err)
+	}
+	return nil
+}
+
+// VerifyEmail 使用验证链接中的令牌确认邮箱，验证完成后链接即失效
+func (s *userService) VerifyEmail(ctx context.Context, req *user.VerifyEmailRequest) (*user.UserResponse, error) {
+	payload, err := s.verifier.Verify(req.Token, time.Now())
+	if errors.Is(err, auth.ErrSignedTokenExpired) {
+		return nil, fmt.Errorf("验证链接已过期，请重新发送验证邮件")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("验证链接无效")
+	}
+	
+	// 载荷为 用户ID:邮箱，邮箱变更后旧链接自动失效
+	idPart, email, ok := strings.Cut(payload, ":")
+	userID, err := strconv.ParseUint(idPart, 10, 64)
+	if !ok || err != nil {
+		return nil, fmt.Errorf("验证链接无效")
+	}
+	existingUser, err := s.userRepo.GetByID(ctx, uint(userID))
+	if err != nil {
+		return nil, fmt.Errorf("获取用户失败: %w", err)
+	}
+	if existingUser == nil || !strings.EqualFold(existingUser.Email, email) {
+		return nil, fmt.Errorf("验证链接无效")
+	}
+	if existingUser.EmailVerified {
+		return nil, fmt.Errorf("验证链接已使用，邮箱已完成验证")
+	}
+	
+	if err := s.userRepo.MarkEmailVerified(ctx, existingUser.ID); err != nil {
+		return nil, fmt.Errorf("更新验证状态失败: %w", err)
+	}
+	now := time.Now()
+	existingUser.EmailVerified = true
+	existingUser.EmailVerifiedAt = &now
+	
+	return existingUser.ToResponse(), nil
+}
+
+// ResendVerification 重新发送验证邮件
+// 无论邮箱是否存在或是否已验证都返回成功，避免泄露账户是否注册
+func (s *userService) ResendVerification(ctx context.Context, req *user.ResendVerificationRequest) error {
+	existingUser, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		return fmt.Errorf("获取用户失败: %w", err)
+	}
+	if existingUser == nil || !existingUser.IsActive || existingUser.EmailVerified {
+		return nil
+	}
+	
+	go s.sendVerificationEmail(context.WithoutCancel(ctx), existingUser)
+	return nil
+}
+
+// sendVerificationEmail 发送邮箱验证邮件
+func (s *userService) sendVerificationEmail(ctx context.Context, u *user.User) {
+	ttl := defaultEmailVerificationTTL
+	if s.cfg.EmailVerificationTTL > 0 {
+		ttl = time.Duration(s.cfg.EmailVerificationTTL) * time.Minute
+	}
+	token := s.verifier.Sign(fmt.Sprintf("%d:%s", u.ID, u.Email), time.Now().Add(ttl))
+	link := strings.TrimRight(s.cfg.FrontendURL, "/") + "/verify-email?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf("%s，您好：\n\n感谢注册临时邮箱服务。请在%s内打开以下链接验证您的邮箱地址：\n\n%s\n\n如果您没有注册过我们的服务，请忽略本邮件。\n",
+		u.GetFullName(), humanizeDuration(ttl), link)
+	
+	err := s.sender.Send(ctx, &mailer.Message{
+		To:      []string{u.Email},
+		Subject: "验证您的临时邮箱账户邮箱地址",
+		Body:    body,
+	})
+	if err != nil {
+		log.Printf("发送邮箱验证邮件给用户 %d 失败: %v", u.ID, err)
+	}
+}
+
+// sendPasswordResetEmail 发送密码重置邮件
+func (s *userService) sendPasswordResetEmail(ctx context.Context, u *user.User, token string, ttl time.Duration) {
+	body := fmt.Sprintf("%s，您好：\n\n我们收到了重置您账户密码的请求。请在%s内打开以下链接设置新密码：\n\n%s\n\n如果这不是您本人的操作，请忽略本邮件，您的密码不会被修改。\n",
+		u.GetFullName(), humanizeDuration(ttl), s.passwordResetLink(token))
+	
+	err := s.sender.Send(ctx, &mailer.Message{
+		To:      []string{u.Email},
+		Subject: "重置您的临时邮箱账户密码",
+		Body:    body,
+	})
+	if err != nil {
+		log.Printf("发送密码重置邮件给用户 %d 失败: %v", u.ID, err)
+	}
+}
+
+// passwordResetLink 生成前端的密码重置链接
+func (s *userService) passwordResetLink(token string) string {
+	return strings.TrimRight(s.cfg.FrontendURL, "/") + "/reset-password?token=" + url.QueryEscape(token)
+}
+
+// passwordResetTTL 获取密码重置令牌有效期
+func (s *userService) passwordResetTTL() time.Duration {
+	if s.cfg.PasswordResetTTL <= 0 {
+		return defaultPasswordResetTTL
+	}
+	return time.Duration(s.cfg.PasswordResetTTL) * time.Minute
+}
+
+// humanizeDuration 将时长格式化为邮件中展示的文字
+func humanizeDuration(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		return fmt.Sprintf("%d小时", int(d.Hours()))
+	}
+	return fmt.Sprintf("%d分钟", int(d.Minutes()))
+}
+
+// newResetToken 生成随机的密码重置令牌
+func newResetToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
 // GetUserProfile 获取用户资料
 func (s *userService) GetUserProfile(ctx context.Context, userID uint) (*user.UserResponse, error) {
 	existingUser, err := s.userRepo.GetByID(ctx, userID)
@@ -173,36 +557,39 @@ func (s *userService) UpdateUserProfile(ctx context.Context, userID uint, req *u
 	return existingUser.ToResponse(), nil
 }
 
-// ChangePassword 修改密码
-func (s *userService) ChangePassword(ctx context.Context, userID uint, req *user.ChangePasswordRequest) error {
+// ChangePassword 修改密码，按请求注销当前会话以外的其他会话，返回注销的会话数量
+func (s *userService) ChangePassword(ctx context.Context, userID uint, req *user.ChangePasswordRequest, currentSessionID string) (int, error) {
 	existingUser, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
-		return fmt.Errorf("获取用户失败: %w", err)
+		return 0, fmt.Errorf("获取用户失败: %w", err)
 	}
 	if existingUser == nil {
-		return fmt.Errorf("用户不存在")
+		return 0, fmt.Errorf("用户不存在")
 	}
 	
 	// 验证当前密码
 	if !auth.VerifyPassword(existingUser.Password, req.CurrentPassword) {
-		return fmt.Errorf("当前密码错误")
+		return 0, fmt.Errorf("当前密码错误")
 	}
 	
 	// 验证新密码强度
 	if err := auth.IsValidPassword(req.NewPassword); err != nil {
-		return fmt.Errorf("新密码不符合要求: %w", err)
+		return 0, fmt.Errorf("新密码不符合要求: %w", err)
 	}
 	
 	// 哈希新密码
 	hashedPassword, err := auth.HashPassword(req.NewPassword)
 	if err != nil {
-		return fmt.Errorf("密码哈希失败: %w", err)
+		return 0, fmt.Errorf("密码哈希失败: %w", err)
 	}
 	
 	// 更新密码
 	if err := s.userRepo.UpdatePassword(ctx, userID, hashedPassword); err != nil {
-		return fmt.Errorf("更新密码失败: %w", err)
+		return 0, fmt.Errorf("更新密码失败: %w", err)
 	}
 	
-	return nil
+	if !req.RevokeOtherSessions {
+		return 0, nil
+	}
+	return s.sessionService.RevokeOtherSessions(ctx, userID, currentSessionID)
 } 
\ No newline at end of file
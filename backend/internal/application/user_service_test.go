@@ -0,0 +1,342 @@
+package application
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"temp-mailbox-service/internal/domain/user"
+	"temp-mailbox-service/internal/infrastructure/auth"
+	"temp-mailbox-service/internal/infrastructure/config"
+	"temp-mailbox-service/internal/infrastructure/mailer"
+	"temp-mailbox-service/internal/infrastructure/persistence"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// linkTokenPattern 邮件正文中链接携带的令牌
+var linkTokenPattern = regexp.MustCompile(`[?&]token=([^\s&]+)`)
+
+// capturingSender 记录发出的邮件，邮件可能在后台发送，通过通道读取
+type capturingSender struct {
+	sent chan *mailer.Message
+}
+
+func (s *capturingSender) Send(ctx context.Context, msg *mailer.Message) error {
+	s.sent <- msg
+	return nil
+}
+
+func (s *capturingSender) Forward(ctx context.Context, to []string, data []byte) error {
+	return nil
+}
+
+type userFixture struct {
+	*sessionFixture
+	users    UserService
+	lockouts LockoutService
+	mfa      MFAService
+	sender   *capturingSender
+	cfg      *config.AuthConfig
+}
+
+// setupUsers 创建用户服务，cfg 中的配置在测试中可以直接修改
+func setupUsers(t *testing.T) *userFixture {
+	f := &userFixture{
+		sessionFixture: setupSessions(t),
+		sender:         &capturingSender{sent: make(chan *mailer.Message, 10)},
+		cfg: &config.AuthConfig{
+			FrontendURL: "https://mail.example.com",
+			Lockout: config.LockoutConfig{
+				Enabled:       true,
+				MaxAttempts:   5,
+				IPMaxAttempts: 20,
+				Window:        15,
+				Duration:      15,
+				DelayAfter:    2,
+				BaseDelay:     1,
+				MaxDelay:      30,
+			},
+			MFA: config.MFAConfig{Issuer: "test", Skew: 1},
+		},
+	}
+	f.lockouts = NewLockoutService(persistence.NewLockoutRepository(), &f.cfg.Lockout)
+	f.mfa = NewMFAService(f.userRepo, auth.NewSecretBox("test-secret", "totp"), auth.NewSigner("test-secret", "mfa-pending"), &f.cfg.MFA)
+	f.users = NewUserService(f.userRepo, f.sessions, f.lockouts, f.mfa, f.sender, auth.NewSigner("test-secret", "email-verification"), f.cfg)
+	return f
+}
+
+// createUserWithPassword 创建邮箱已验证、使用指定密码的用户
+func (f *userFixture) createUserWithPassword(t *testing.T, username, password string) *user.User {
+	t.Helper()
+	hashed, err := auth.HashPassword(password)
+	require.NoError(t, err)
+	u := &user.User{
+		Username:      username,
+		Email:         username + "@example.com",
+		Password:      hashed,
+		Role:          user.RoleUser,
+		IsActive:      true,
+		EmailVerified: true,
+	}
+	require.NoError(t, f.userRepo.Create(context.Background(), u))
+	return u
+}
+
+// nextMailToken 等待下一封邮件并取出其中链接的令牌
+func (f *userFixture) nextMailToken(t *testing.T) string {
+	t.Helper()
+	select {
+	case msg := <-f.sender.sent:
+		m := linkTokenPattern.FindStringSubmatch(msg.Body)
+		require.NotNil(t, m, "邮件中应包含带令牌的链接")
+		token, err := url.QueryUnescape(m[1])
+		require.NoError(t, err)
+		return token
+	case <-time.After(2 * time.Second):
+		t.Fatal("没有收到邮件")
+		return ""
+	}
+}
+
+func TestResetPassword_SingleUse(t *testing.T) {
+	f := setupUsers(t)
+	ctx := context.Background()
+	u := f.createUserWithPassword(t, "alice", "old-password")
+	kept := f.login(t, u)
+
+	require.NoError(t, f.users.ForgotPassword(ctx, &user.ForgotPasswordRequest{Email: u.Email}))
+	token := f.nextMailToken(t)
+
+	stored, err := f.userRepo.GetByID(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, hashToken(token), stored.PasswordResetToken, "数据库中只保存令牌的哈希")
+
+	require.NoError(t, f.users.ResetPassword(ctx, &user.ResetPasswordRequest{Token: token, NewPassword: "new-password"}))
+	stored, err = f.userRepo.GetByID(ctx, u.ID)
+	require.NoError(t, err)
+	assert.True(t, auth.VerifyPassword(stored.Password, "new-password"))
+	assert.Empty(t, stored.PasswordResetToken)
+	_, err = f.refresh(kept.RefreshToken)
+	assert.Error(t, err, "重置密码后原有会话应被注销")
+
+	err = f.users.ResetPassword(ctx, &user.ResetPasswordRequest{Token: token, NewPassword: "another-password"})
+	assert.Error(t, err, "令牌只能使用一次")
+	stored, err = f.userRepo.GetByID(ctx, u.ID)
+	require.NoError(t, err)
+	assert.True(t, auth.VerifyPassword(stored.Password, "new-password"))
+}
+
+func TestResetPassword_RejectsExpiredAndReplacedTokens(t *testing.T) {
+	f := setupUsers(t)
+	ctx := context.Background()
+	u := f.createUserWithPassword(t, "alice", "old-password")
+
+	t.Run("过期的令牌", func(t *testing.T) {
+		require.NoError(t, f.users.ForgotPassword(ctx, &user.ForgotPasswordRequest{Email: u.Email}))
+		token := f.nextMailToken(t)
+		expired := time.Now().Add(-time.Minute)
+		require.NoError(t, f.userRepo.SetPasswordResetToken(ctx, u.ID, hashToken(token), &expired))
+
+		err := f.users.ResetPassword(ctx, &user.ResetPasswordRequest{Token: token, NewPassword: "new-password"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "已过期")
+	})
+
+	t.Run("重新申请后旧令牌失效", func(t *testing.T) {
+		require.NoError(t, f.users.ForgotPassword(ctx, &user.ForgotPasswordRequest{Email: u.Email}))
+		first := f.nextMailToken(t)
+		require.NoError(t, f.users.ForgotPassword(ctx, &user.ForgotPasswordRequest{Email: u.Email}))
+		second := f.nextMailToken(t)
+
+		assert.Error(t, f.users.ResetPassword(ctx, &user.ResetPasswordRequest{Token: first, NewPassword: "new-password"}))
+		assert.NoError(t, f.users.ResetPassword(ctx, &user.ResetPasswordRequest{Token: second, NewPassword: "new-password"}))
+	})
+
+	t.Run("未注册的邮箱", func(t *testing.T) {
+		require.NoError(t, f.users.ForgotPassword(ctx, &user.ForgotPasswordRequest{Email: "nobody@example.com"}))
+		assert.Empty(t, f.sender.sent, "未注册的邮箱不发送邮件")
+	})
+}
+
+func TestVerifyEmail_ConsumesToken(t *testing.T) {
+	f := setupUsers(t)
+	ctx := context.Background()
+	client := &ClientInfo{IP: "192.0.2.1"}
+
+	resp, err := f.users.RegisterUser(ctx, &user.CreateUserRequest{
+		Username: "alice",
+		Email:    "alice@example.com",
+		Password: "password123",
+	}, client)
+	require.NoError(t, err)
+	assert.Nil(t, resp.Token, "未验证邮箱时注册后不直接登录")
+	token := f.nextMailToken(t)
+
+	login := &user.LoginRequest{Email: "alice@example.com", Password: "password123"}
+	_, err = f.users.LoginUser(ctx, login, client)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "尚未验证")
+
+	verified, err := f.users.VerifyEmail(ctx, &user.VerifyEmailRequest{Token: token})
+	require.NoError(t, err)
+	assert.True(t, verified.EmailVerified)
+
+	_, err = f.users.VerifyEmail(ctx, &user.VerifyEmailRequest{Token: token})
+	assert.Error(t, err, "验证链接只能使用一次")
+
+	loggedIn, err := f.users.LoginUser(ctx, login, client)
+	require.NoError(t, err)
+	assert.NotNil(t, loggedIn.Token)
+}
+
+func TestVerifyEmail_RejectsInvalidTokens(t *testing.T) {
+	f := setupUsers(t)
+	ctx := context.Background()
+	u := f.createUserWithPassword(t, "alice", "password123")
+	u.EmailVerified = false
+	require.NoError(t, f.userRepo.Update(ctx, u))
+	verifier := auth.NewSigner("test-secret", "email-verification")
+
+	t.Run("过期的链接", func(t *testing.T) {
+		token := verifier.Sign(fmt.Sprintf("%d:%s", u.ID, u.Email), time.Now().Add(-time.Minute))
+		_, err := f.users.VerifyEmail(ctx, &user.VerifyEmailRequest{Token: token})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "已过期")
+	})
+
+	t.Run("其他用途的签名", func(t *testing.T) {
+		token := auth.NewSigner("test-secret", "mfa-pending").Sign(fmt.Sprintf("%d:%s", u.ID, u.Email), time.Now().Add(time.Hour))
+		_, err := f.users.VerifyEmail(ctx, &user.VerifyEmailRequest{Token: token})
+		assert.Error(t, err)
+	})
+
+	t.Run("邮箱变更后的旧链接", func(t *testing.T) {
+		token := verifier.Sign(fmt.Sprintf("%d:%s", u.ID, "old@example.com"), time.Now().Add(time.Hour))
+		_, err := f.users.VerifyEmail(ctx, &user.VerifyEmailRequest{Token: token})
+		assert.Error(t, err)
+	})
+
+	stored, err := f.userRepo.GetByID(ctx, u.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.EmailVerified)
+}
+
+// enableTOTP 为用户启用两步验证，返回密钥和确认时使用的时间步
+func (f *userFixture) enableTOTP(t *testing.T, u *user.User) (string, int64) {
+	t.Helper()
+	ctx := context.Background()
+	setup, err := f.mfa.SetupTOTP(ctx, u.ID)
+	require.NoError(t, err)
+	counter := auth.TOTPCounter(time.Now())
+	code, err := auth.TOTPCode(setup.Secret, counter)
+	require.NoError(t, err)
+	_, err = f.mfa.ConfirmTOTP(ctx, u.ID, &user.ConfirmTOTPRequest{Code: code})
+	require.NoError(t, err)
+	return setup.Secret, counter
+}
+
+func TestLoginUser_MFA(t *testing.T) {
+	f := setupUsers(t)
+	ctx := context.Background()
+	client := &ClientInfo{IP: "192.0.2.1"}
+	u := f.createUserWithPassword(t, "alice", "password123")
+	secret, counter := f.enableTOTP(t, u)
+
+	resp, err := f.users.LoginUser(ctx, &user.LoginRequest{Email: u.Email, Password: "password123"}, client)
+	require.NoError(t, err)
+	require.True(t, resp.MFARequired)
+	assert.Nil(t, resp.Token, "完成两步验证前不签发令牌")
+	require.NotEmpty(t, resp.MFAToken)
+
+	// 确认时已使用当前时间步，登录使用下一个时间步的验证码
+	code, err := auth.TOTPCode(secret, counter+1)
+	require.NoError(t, err)
+
+	t.Run("篡改的令牌", func(t *testing.T) {
+		encoded, sig, ok := strings.Cut(resp.MFAToken, ".")
+		require.True(t, ok)
+		payload, err := base64.RawURLEncoding.DecodeString(encoded)
+		require.NoError(t, err)
+		forged := strings.Replace(string(payload), fmt.Sprintf("%d:", u.ID), fmt.Sprintf("%d:", u.ID+1), 1)
+		tampered := base64.RawURLEncoding.EncodeToString([]byte(forged)) + "." + sig
+
+		_, err = f.users.CompleteMFALogin(ctx, &user.MFALoginRequest{MFAToken: tampered, Code: code}, client)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "令牌无效")
+	})
+
+	t.Run("过期的令牌", func(t *testing.T) {
+		stored, err := f.userRepo.GetByID(ctx, u.ID)
+		require.NoError(t, err)
+		expired := auth.NewSigner("test-secret", "mfa-pending").Sign(fmt.Sprintf("%d:%s", u.ID, passwordFingerprint(stored)), time.Now().Add(-time.Minute))
+
+		_, err = f.users.CompleteMFALogin(ctx, &user.MFALoginRequest{MFAToken: expired, Code: code}, client)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "超时")
+	})
+
+	t.Run("用验证码换取令牌", func(t *testing.T) {
+		done, err := f.users.CompleteMFALogin(ctx, &user.MFALoginRequest{MFAToken: resp.MFAToken, Code: code}, client)
+		require.NoError(t, err)
+		require.NotNil(t, done.Token)
+		assert.Equal(t, http.StatusOK, f.authorize(done.Token.AccessToken))
+
+		_, err = f.users.CompleteMFALogin(ctx, &user.MFALoginRequest{MFAToken: resp.MFAToken, Code: code}, client)
+		assert.Error(t, err, "同一个验证码不能重复使用")
+	})
+}
+
+func TestChangePassword_RevokesOtherSessions(t *testing.T) {
+	f := setupUsers(t)
+	ctx := context.Background()
+	u := f.createUserWithPassword(t, "alice", "old-password")
+	current := f.login(t, u)
+	others := []*auth.TokenPair{f.login(t, u), f.login(t, u)}
+	claims, err := f.jwt.ValidateAccessToken(current.AccessToken)
+	require.NoError(t, err)
+
+	_, err = f.users.ChangePassword(ctx, u.ID, &user.ChangePasswordRequest{
+		CurrentPassword: "wrong-password",
+		NewPassword:     "new-password",
+	}, claims.SessionID)
+	require.Error(t, err)
+
+	// 不要求注销时其他会话保留
+	count, err := f.users.ChangePassword(ctx, u.ID, &user.ChangePasswordRequest{
+		CurrentPassword: "old-password",
+		NewPassword:     "new-password",
+	}, claims.SessionID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, http.StatusOK, f.authorize(others[0].AccessToken))
+
+	count, err = f.users.ChangePassword(ctx, u.ID, &user.ChangePasswordRequest{
+		CurrentPassword:     "new-password",
+		NewPassword:         "newer-password",
+		RevokeOtherSessions: true,
+	}, claims.SessionID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	assert.Equal(t, http.StatusOK, f.authorize(current.AccessToken), "当前会话保持登录")
+	_, err = f.refresh(current.RefreshToken)
+	assert.NoError(t, err)
+	for _, pair := range others {
+		assert.Equal(t, http.StatusUnauthorized, f.authorize(pair.AccessToken), "其他会话的访问令牌立即失效")
+		_, err = f.refresh(pair.RefreshToken)
+		assert.Error(t, err)
+	}
+
+	sessions, err := f.sessions.ListSessions(ctx, u.ID, claims.SessionID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.True(t, sessions[0].Current)
+}
@@ -0,0 +1,99 @@
+package apikey
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	// ScopeMailboxRead 读取邮箱和邮件
+	ScopeMailboxRead = "mailbox:read"
+	// ScopeMailboxWrite 创建、延期和删除邮箱
+	ScopeMailboxWrite = "mailbox:write"
+)
+
+// IsValidScope 检查授权范围是否有效
+func IsValidScope(scope string) bool {
+	return scope == ScopeMailboxRead || scope == ScopeMailboxWrite
+}
+
+// APIKey 用户API密钥，用于脚本和CI等非交互场景访问邮箱接口
+type APIKey struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID uint   `json:"user_id" gorm:"index;not null"`
+	Name   string `json:"name" gorm:"size:100;not null"`
+
+	// 密钥格式为 tmk_<Prefix>_<秘密>，只保存秘密部分的 SHA-256 哈希
+	Prefix     string `json:"prefix" gorm:"uniqueIndex;size:16;not null"`
+	SecretHash string `json:"-" gorm:"size:64;not null"`
+	Scopes     string `json:"scopes" gorm:"size:255;not null"` // 空格分隔的授权范围
+
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip" gorm:"size:45"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// TableName 指定表名
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// ScopeList 获取授权范围列表
+func (k *APIKey) ScopeList() []string {
+	return strings.Fields(k.Scopes)
+}
+
+// IsRevoked 检查密钥是否已吊销
+func (k *APIKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
+
+// IsExpired 检查密钥是否已过期，未设置过期时间的密钥永不过期
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// CreateAPIKeyRequest 创建API密钥请求
+type CreateAPIKeyRequest struct {
+	Name      string   `json:"name" validate:"required,max=100"`
+	Scopes    []string `json:"scopes" validate:"required,min=1,dive,oneof=mailbox:read mailbox:write"`
+	ExpiresIn int      `json:"expires_in" validate:"omitempty,min=1,max=365"` // days，0表示永不过期
+}
+
+// APIKeyResponse API密钥响应（不包含密钥本身）
+type APIKeyResponse struct {
+	ID         uint       `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip"`
+	Expired    bool       `json:"expired"`
+}
+
+// ToResponse 转换为响应格式
+func (k *APIKey) ToResponse(now time.Time) *APIKeyResponse {
+	return &APIKeyResponse{
+		ID:         k.ID,
+		CreatedAt:  k.CreatedAt,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     k.ScopeList(),
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+		LastUsedIP: k.LastUsedIP,
+		Expired:    k.IsExpired(now),
+	}
+}
+
+// CreatedAPIKeyResponse 新建API密钥响应，完整密钥只在创建时返回一次
+type CreatedAPIKeyResponse struct {
+	*APIKeyResponse
+	Key string `json:"key"`
+}
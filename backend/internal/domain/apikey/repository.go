@@ -0,0 +1,19 @@
+package apikey
+
+import (
+	"context"
+	"time"
+)
+
+// Repository API密钥仓储接口
+type Repository interface {
+	Create(ctx context.Context, k *APIKey) error
+	// GetByPrefix 按前缀获取未吊销的密钥，不存在时返回nil
+	GetByPrefix(ctx context.Context, prefix string) (*APIKey, error)
+	// ListActiveByUser 获取用户未吊销的密钥（包括已过期的）
+	ListActiveByUser(ctx context.Context, userID uint) ([]*APIKey, error)
+	CountActiveByUser(ctx context.Context, userID uint) (int64, error)
+	// Revoke 吊销属于指定用户的密钥，返回是否成功
+	Revoke(ctx context.Context, id, userID uint) (bool, error)
+	TouchLastUsed(ctx context.Context, id uint, at time.Time, ip string) error
+}
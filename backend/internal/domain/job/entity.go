@@ -0,0 +1,51 @@
+package job
+
+import "time"
+
+// Status 任务执行状态
+type Status string
+
+const (
+	// StatusSuccess 执行成功
+	StatusSuccess Status = "success"
+	// StatusFailed 执行失败
+	StatusFailed Status = "failed"
+)
+
+// Run 后台任务执行记录
+type Run struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	JobName    string    `json:"job_name" gorm:"size:100;index;not null"`
+	Trigger    string    `json:"trigger" gorm:"size:20"` // schedule, manual
+	Status     Status    `json:"status" gorm:"size:20;index"`
+	StartedAt  time.Time `json:"started_at" gorm:"index"`
+	FinishedAt time.Time `json:"finished_at"`
+	DurationMs int64     `json:"duration_ms"`
+	Result     string    `json:"result" gorm:"size:1024"`
+	Error      string    `json:"error" gorm:"size:1024"`
+}
+
+// TableName 指定表名
+func (Run) TableName() string {
+	return "job_runs"
+}
+
+// JobResponse 任务状态响应
+type JobResponse struct {
+	Name        string    `json:"name"`
+	Spec        string    `json:"spec"`
+	Description string    `json:"description"`
+	Running     bool      `json:"running"`
+	NextRunAt   time.Time `json:"next_run_at"`
+	LastRun     *Run      `json:"last_run"`
+}
+
+// RunListResponse 执行记录列表响应
+type RunListResponse struct {
+	Items    []*Run `json:"items"`
+	Total    int64  `json:"total"`
+	Page     int    `json:"page"`
+	PageSize int    `json:"page_size"`
+}
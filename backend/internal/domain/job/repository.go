@@ -0,0 +1,15 @@
+package job
+
+import (
+	"context"
+	"time"
+)
+
+// Repository 任务执行记录仓储接口
+type Repository interface {
+	Create(ctx context.Context, run *Run) error
+	LatestByJob(ctx context.Context, jobName string) (*Run, error)
+	ListByJob(ctx context.Context, jobName string, offset, limit int) ([]*Run, error)
+	CountByJob(ctx context.Context, jobName string) (int64, error)
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,79 @@
+package lockout
+
+import (
+	"time"
+)
+
+// Kind 登录失败统计维度
+type Kind string
+
+const (
+	// KindAccount 按登录邮箱统计（不论账户是否存在）
+	KindAccount Kind = "account"
+	// KindIP 按客户端IP统计
+	KindIP Kind = "ip"
+)
+
+// Record 登录失败记录，窗口内失败次数达到上限后临时锁定
+type Record struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Kind           Kind       `json:"kind" gorm:"size:10;uniqueIndex:idx_lockout_kind_value;not null"`
+	Value          string     `json:"value" gorm:"size:255;uniqueIndex:idx_lockout_kind_value;not null"` // 小写邮箱或IP
+	Failures       int        `json:"failures"`
+	FirstFailureAt time.Time  `json:"first_failure_at"`
+	LastFailureAt  time.Time  `json:"last_failure_at" gorm:"index"`
+	LockedUntil    *time.Time `json:"locked_until" gorm:"index"`
+}
+
+// TableName 指定表名
+func (Record) TableName() string {
+	return "login_lockouts"
+}
+
+// IsLocked 检查在指定时间是否处于锁定状态
+func (r *Record) IsLocked(now time.Time) bool {
+	return r.LockedUntil != nil && now.Before(*r.LockedUntil)
+}
+
+// Filter 失败记录查询条件
+type Filter struct {
+	Kind   Kind
+	Locked bool // 只返回当前处于锁定状态的记录
+}
+
+// RecordResponse 失败记录响应
+type RecordResponse struct {
+	ID             uint       `json:"id"`
+	Kind           Kind       `json:"kind"`
+	Value          string     `json:"value"`
+	Failures       int        `json:"failures"`
+	FirstFailureAt time.Time  `json:"first_failure_at"`
+	LastFailureAt  time.Time  `json:"last_failure_at"`
+	LockedUntil    *time.Time `json:"locked_until"`
+	Locked         bool       `json:"locked"`
+}
+
+// ToResponse 转换为响应格式
+func (r *Record) ToResponse(now time.Time) *RecordResponse {
+	return &RecordResponse{
+		ID:             r.ID,
+		Kind:           r.Kind,
+		Value:          r.Value,
+		Failures:       r.Failures,
+		FirstFailureAt: r.FirstFailureAt,
+		LastFailureAt:  r.LastFailureAt,
+		LockedUntil:    r.LockedUntil,
+		Locked:         r.IsLocked(now),
+	}
+}
+
+// RecordListResponse 失败记录列表响应
+type RecordListResponse struct {
+	Items    []*RecordResponse `json:"items"`
+	Total    int64             `json:"total"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"page_size"`
+}
@@ -0,0 +1,24 @@
+package lockout
+
+import (
+	"context"
+	"time"
+)
+
+// Repository 登录失败记录仓储接口
+type Repository interface {
+	Get(ctx context.Context, kind Kind, value string) (*Record, error)
+	GetByID(ctx context.Context, id uint) (*Record, error)
+	// RecordFailure 原子地累加一次失败；首次失败早于 windowStart 或锁定已到期时重新计数
+	RecordFailure(ctx context.Context, kind Kind, value string, now, windowStart time.Time) (*Record, error)
+	Lock(ctx context.Context, id uint, until time.Time) error
+	Delete(ctx context.Context, kind Kind, value string) error
+	DeleteByID(ctx context.Context, id uint) error
+
+	// 查询操作
+	List(ctx context.Context, filter *Filter, now time.Time, offset, limit int) ([]*Record, error)
+	Count(ctx context.Context, filter *Filter, now time.Time) (int64, error)
+
+	// DeleteStaleBefore 删除最后一次失败早于 before 且未处于锁定状态的记录
+	DeleteStaleBefore(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,106 @@
+package mailbox
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Status 邮箱状态
+type Status string
+
+const (
+	// StatusActive 有效
+	StatusActive Status = "active"
+	// StatusExpired 已过期
+	StatusExpired Status = "expired"
+)
+
+// Mailbox 临时邮箱实体
+type Mailbox struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// 地址信息
+	LocalPart string `json:"local_part" gorm:"size:64;not null"`
+	Domain    string `json:"domain" gorm:"size:255;not null;index"`
+	Address   string `json:"address" gorm:"uniqueIndex;size:320;not null"`
+
+	// 所属用户
+	UserID uint `json:"user_id" gorm:"index;not null"`
+
+	// 生命周期
+	ExpiresAt time.Time `json:"expires_at" gorm:"index;not null"`
+	Status    Status    `json:"status" gorm:"size:20;index;default:'active'"`
+}
+
+// TableName 指定表名
+func (Mailbox) TableName() string {
+	return "mailboxes"
+}
+
+// BuildAddress 组合完整邮箱地址（统一小写）
+func BuildAddress(localPart, domain string) string {
+	return strings.ToLower(localPart) + "@" + strings.ToLower(domain)
+}
+
+// IsExpired 检查邮箱是否已过期
+func (m *Mailbox) IsExpired() bool {
+	return m.Status == StatusExpired || !time.Now().Before(m.ExpiresAt)
+}
+
+// IsOwnedBy 检查邮箱是否属于指定用户
+func (m *Mailbox) IsOwnedBy(userID uint) bool {
+	return m.UserID == userID
+}
+
+// CreateMailboxRequest 创建邮箱请求
+type CreateMailboxRequest struct {
+	LocalPart string `json:"local_part" validate:"omitempty,min=3,max=64"`
+	Domain    string `json:"domain" validate:"omitempty,fqdn,max=255"`
+	TTL       int    `json:"ttl" validate:"omitempty,min=1"` // minutes
+}
+
+// ExtendMailboxRequest 延长有效期请求
+type ExtendMailboxRequest struct {
+	TTL int `json:"ttl" validate:"required,min=1"` // minutes
+}
+
+// MailboxResponse 邮箱响应
+type MailboxResponse struct {
+	ID        uint      `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Address   string    `json:"address"`
+	LocalPart string    `json:"local_part"`
+	Domain    string    `json:"domain"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Status    Status    `json:"status"`
+}
+
+// ToResponse 转换为响应格式
+func (m *Mailbox) ToResponse() *MailboxResponse {
+	status := m.Status
+	if m.IsExpired() {
+		status = StatusExpired
+	}
+	return &MailboxResponse{
+		ID:        m.ID,
+		CreatedAt: m.CreatedAt,
+		Address:   m.Address,
+		LocalPart: m.LocalPart,
+		Domain:    m.Domain,
+		ExpiresAt: m.ExpiresAt,
+		Status:    status,
+	}
+}
+
+// MailboxListResponse 邮箱列表响应
+type MailboxListResponse struct {
+	Items    []*MailboxResponse `json:"items"`
+	Total    int64              `json:"total"`
+	Page     int                `json:"page"`
+	PageSize int                `json:"page_size"`
+}
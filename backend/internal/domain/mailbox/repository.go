@@ -0,0 +1,26 @@
+package mailbox
+
+import (
+	"context"
+	"time"
+)
+
+// Repository 邮箱仓储接口
+type Repository interface {
+	// 基础CRUD操作
+	Create(ctx context.Context, mailbox *Mailbox) error
+	GetByID(ctx context.Context, id uint) (*Mailbox, error)
+	GetByAddress(ctx context.Context, address string) (*Mailbox, error)
+	Update(ctx context.Context, mailbox *Mailbox) error
+	Delete(ctx context.Context, id uint) error
+
+	// 查询操作
+	ListByUser(ctx context.Context, userID uint, offset, limit int) ([]*Mailbox, error)
+	CountByUser(ctx context.Context, userID uint) (int64, error)
+	ExistsByAddress(ctx context.Context, address string) (bool, error)
+	CountActiveByDomain(ctx context.Context, domain string) (int64, error)
+
+	// 生命周期
+	UpdateExpiry(ctx context.Context, id uint, expiresAt time.Time) error
+	MarkExpired(ctx context.Context, before time.Time) (int64, error)
+}
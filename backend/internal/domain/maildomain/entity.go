@@ -0,0 +1,183 @@
+package maildomain
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Status 域名状态
+type Status string
+
+const (
+	// StatusPending 待验证
+	StatusPending Status = "pending"
+	// StatusVerified 已验证，可创建邮箱并接收邮件
+	StatusVerified Status = "verified"
+	// StatusDisabled 已停用
+	StatusDisabled Status = "disabled"
+)
+
+const (
+	// VerificationRecordPrefix 所有权验证TXT记录的名称前缀
+	VerificationRecordPrefix = "_tempmail-verify"
+	// verificationValuePrefix 所有权验证TXT记录的值前缀
+	verificationValuePrefix = "tempmail-verify="
+)
+
+// labelPattern 域名标签格式
+var labelPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// Domain 托管域名实体
+type Domain struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// 域名（统一小写，不带末尾的点）
+	Name string `json:"name" gorm:"uniqueIndex;size:255;not null"`
+
+	// 添加域名的用户，0表示配置文件中的系统域名
+	UserID uint `json:"user_id" gorm:"index"`
+
+	// 状态与验证信息
+	Status            Status     `json:"status" gorm:"size:20;index;default:'pending'"`
+	VerificationToken string     `json:"-" gorm:"size:64;not null"`
+	VerifiedAt        *time.Time `json:"verified_at"`
+	LastCheckedAt     *time.Time `json:"last_checked_at"`
+	LastCheckError    string     `json:"last_check_error" gorm:"size:512"`
+
+	// DNS记录是否已由DNS服务商自动配置
+	DNSManaged bool `json:"dns_managed" gorm:"default:false"`
+	// 由本服务在DNS服务商处创建的记录，空格分隔的 类型=记录ID，删除域名时只清理这些记录
+	DNSRecordIDs string `json:"-" gorm:"size:512"`
+}
+
+// TableName 指定表名
+func (Domain) TableName() string {
+	return "mail_domains"
+}
+
+// NormalizeName 规范化并校验域名
+func NormalizeName(name string) (string, error) {
+	name = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+	if name == "" || len(name) > 253 {
+		return "", fmt.Errorf("域名格式无效")
+	}
+	for _, label := range strings.Split(name, ".") {
+		if !labelPattern.MatchString(label) {
+			return "", fmt.Errorf("域名格式无效")
+		}
+	}
+	return name, nil
+}
+
+// IsVerified 检查域名是否已验证
+func (d *Domain) IsVerified() bool {
+	return d.Status == StatusVerified
+}
+
+// IsOwnedBy 检查域名是否属于指定用户
+func (d *Domain) IsOwnedBy(userID uint) bool {
+	return d.UserID == userID
+}
+
+// IsSystem 检查域名是否为配置文件中的系统域名
+func (d *Domain) IsSystem() bool {
+	return d.UserID == 0
+}
+
+// IsAvailableTo 检查用户能否在该域名下创建邮箱：系统域名对所有用户开放，用户添加的域名只供其本人使用
+func (d *Domain) IsAvailableTo(userID uint) bool {
+	return d.IsSystem() || d.IsOwnedBy(userID)
+}
+
+// VerificationRecordName 所有权验证TXT记录的完整名称
+func (d *Domain) VerificationRecordName() string {
+	return VerificationRecordPrefix + "." + d.Name
+}
+
+// VerificationRecordValue 所有权验证TXT记录的值
+func (d *Domain) VerificationRecordValue() string {
+	return verificationValuePrefix + d.VerificationToken
+}
+
+// CreatedRecordIDs 由本服务创建的DNS服务商记录ID，按记录类型索引
+func (d *Domain) CreatedRecordIDs() map[string]string {
+	ids := make(map[string]string)
+	for _, field := range strings.Fields(d.DNSRecordIDs) {
+		if recordType, id, ok := strings.Cut(field, "="); ok && id != "" {
+			ids[recordType] = id
+		}
+	}
+	return ids
+}
+
+// SetCreatedRecordIDs 保存由本服务创建的DNS服务商记录ID
+func (d *Domain) SetCreatedRecordIDs(ids map[string]string) {
+	fields := make([]string, 0, len(ids))
+	for recordType, id := range ids {
+		fields = append(fields, recordType+"="+id)
+	}
+	sort.Strings(fields)
+	d.DNSRecordIDs = strings.Join(fields, " ")
+}
+
+// DNSRecord 需要配置的DNS记录
+type DNSRecord struct {
+	Type     string `json:"type"`
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Priority int    `json:"priority,omitempty"`
+}
+
+// RequiredRecords 域名通过验证所需配置的DNS记录
+func (d *Domain) RequiredRecords(mxTarget string) []DNSRecord {
+	return []DNSRecord{
+		{Type: "MX", Name: d.Name, Value: mxTarget, Priority: 10},
+		{Type: "TXT", Name: d.VerificationRecordName(), Value: d.VerificationRecordValue()},
+	}
+}
+
+// CreateDomainRequest 添加域名请求
+type CreateDomainRequest struct {
+	Name string `json:"name" validate:"required,fqdn,max=253"`
+}
+
+// DomainResponse 域名响应
+type DomainResponse struct {
+	ID             uint        `json:"id"`
+	CreatedAt      time.Time   `json:"created_at"`
+	Name           string      `json:"name"`
+	Status         Status      `json:"status"`
+	VerifiedAt     *time.Time  `json:"verified_at"`
+	LastCheckedAt  *time.Time  `json:"last_checked_at"`
+	LastCheckError string      `json:"last_check_error"`
+	DNSManaged     bool        `json:"dns_managed"`
+	DNSRecords     []DNSRecord `json:"dns_records"`
+}
+
+// ToResponse 转换为响应格式
+func (d *Domain) ToResponse(mxTarget string) *DomainResponse {
+	return &DomainResponse{
+		ID:             d.ID,
+		CreatedAt:      d.CreatedAt,
+		Name:           d.Name,
+		Status:         d.Status,
+		VerifiedAt:     d.VerifiedAt,
+		LastCheckedAt:  d.LastCheckedAt,
+		LastCheckError: d.LastCheckError,
+		DNSManaged:     d.DNSManaged,
+		DNSRecords:     d.RequiredRecords(mxTarget),
+	}
+}
+
+// DomainListResponse 域名列表响应
+type DomainListResponse struct {
+	Items    []*DomainResponse `json:"items"`
+	Total    int64             `json:"total"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"page_size"`
+}
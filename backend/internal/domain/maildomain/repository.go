@@ -0,0 +1,25 @@
+package maildomain
+
+import (
+	"context"
+	"time"
+)
+
+// Repository 托管域名仓储接口
+type Repository interface {
+	// 基础CRUD操作
+	Create(ctx context.Context, domain *Domain) error
+	GetByID(ctx context.Context, id uint) (*Domain, error)
+	GetByName(ctx context.Context, name string) (*Domain, error)
+	Update(ctx context.Context, domain *Domain) error
+	Delete(ctx context.Context, id uint) error
+
+	// 查询操作
+	ListByUser(ctx context.Context, userID uint, offset, limit int) ([]*Domain, error)
+	CountByUser(ctx context.Context, userID uint) (int64, error)
+	ListVerified(ctx context.Context) ([]*Domain, error)
+	ExistsByName(ctx context.Context, name string) (bool, error)
+
+	// 清理操作
+	ListUnverifiedBefore(ctx context.Context, before time.Time, limit int) ([]*Domain, error)
+}
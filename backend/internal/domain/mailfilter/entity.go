@@ -0,0 +1,223 @@
+package mailfilter
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 过滤规则匹配的字段
+const (
+	FieldSender  = "sender"  // 信封发件人（MAIL FROM）
+	FieldFrom    = "from"    // 邮件头 From 的地址
+	FieldSubject = "subject" // 邮件主题
+)
+
+// 匹配方式（均不区分大小写）
+const (
+	OperatorEquals   = "equals"
+	OperatorContains = "contains"
+	OperatorGlob     = "glob" // * 匹配任意字符，? 匹配单个字符
+	OperatorRegex    = "regex"
+)
+
+// 规则动作
+const (
+	ActionReject          = "reject"            // 以550拒收
+	ActionDiscard         = "discard"           // 接收但不保存
+	ActionDeleteAfterRead = "delete_after_read" // 保存，首次查看详情后删除
+)
+
+// 规则执行阶段
+const (
+	StageRCPT = "rcpt" // SMTP RCPT 阶段，只能匹配信封发件人
+	StageData = "data" // SMTP DATA 阶段
+	StageRead = "read" // 用户查看邮件后
+)
+
+// Rule 邮箱收信过滤规则
+type Rule struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	MailboxID uint   `json:"mailbox_id" gorm:"index;not null"`
+	Name      string `json:"name" gorm:"size:100;not null"`
+	Enabled   bool   `json:"enabled" gorm:"not null"`
+	Priority  int    `json:"priority" gorm:"not null"` // 数值小的先执行
+
+	// 匹配条件，Field 为空时匹配所有邮件
+	Field    string `json:"field" gorm:"size:20"`
+	Operator string `json:"operator" gorm:"size:20"`
+	Value    string `json:"value" gorm:"size:500"`
+	Negate   bool   `json:"negate"` // 条件取反，例如“发件人不是 *@example.com”
+
+	Action string `json:"action" gorm:"size:30;not null"`
+}
+
+// TableName 指定表名
+func (Rule) TableName() string {
+	return "mailbox_filter_rules"
+}
+
+// Input 参与匹配的邮件信息，RCPT 阶段只有 Sender
+type Input struct {
+	Sender  string
+	From    string
+	Subject string
+}
+
+// Matches 检查邮件是否满足规则条件
+func (r *Rule) Matches(in *Input) bool {
+	if r.Field == "" {
+		return true
+	}
+
+	var value string
+	switch r.Field {
+	case FieldSender:
+		value = in.Sender
+	case FieldFrom:
+		value = in.From
+	case FieldSubject:
+		value = in.Subject
+	}
+
+	matched := false
+	switch r.Operator {
+	case OperatorEquals:
+		matched = strings.EqualFold(value, r.Value)
+	case OperatorContains:
+		matched = strings.Contains(strings.ToLower(value), strings.ToLower(r.Value))
+	case OperatorGlob:
+		matched = globPattern(r.Value).MatchString(value)
+	case OperatorRegex:
+		if re, err := regexp.Compile("(?i)" + r.Value); err == nil {
+			matched = re.MatchString(value)
+		}
+	}
+	return matched != r.Negate
+}
+
+// AppliesAt 检查规则能否在指定阶段执行：RCPT 阶段只能执行匹配信封发件人的拒收规则
+func (r *Rule) AppliesAt(stage string) bool {
+	if stage != StageRCPT {
+		return true
+	}
+	return r.Action == ActionReject && (r.Field == "" || r.Field == FieldSender)
+}
+
+// Verdict 过滤结果
+type Verdict struct {
+	Rule            *Rule // 拒收或丢弃邮件的规则，为 nil 时正常投递
+	DeleteAfterRead *Rule // 要求查看后删除的规则
+}
+
+// Evaluate 按优先级执行已启用的规则：拒收和丢弃规则命中后停止，查看后删除规则命中后继续
+func Evaluate(rules []*Rule, stage string, in *Input) *Verdict {
+	verdict := &Verdict{}
+	for _, r := range rules {
+		if !r.Enabled || !r.AppliesAt(stage) || !r.Matches(in) {
+			continue
+		}
+		if r.Action == ActionDeleteAfterRead {
+			if verdict.DeleteAfterRead == nil {
+				verdict.DeleteAfterRead = r
+			}
+			continue
+		}
+		verdict.Rule = r
+		return verdict
+	}
+	return verdict
+}
+
+// globPattern 将通配符转换为不区分大小写的完整匹配正则表达式
+func globPattern(glob string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("(?is)^")
+	for _, c := range glob {
+		switch c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// RuleRequest 创建或更新过滤规则请求
+type RuleRequest struct {
+	Name     string `json:"name" validate:"required,max=100"`
+	Enabled  *bool  `json:"enabled"` // 默认启用
+	Priority int    `json:"priority" validate:"min=0,max=10000"`
+	Field    string `json:"field" validate:"omitempty,oneof=sender from subject"`
+	Operator string `json:"operator" validate:"omitempty,oneof=equals contains glob regex"`
+	Value    string `json:"value" validate:"max=500"`
+	Negate   bool   `json:"negate"`
+	Action   string `json:"action" validate:"required,oneof=reject discard delete_after_read"`
+}
+
+// Decision 过滤决定记录，用于向用户说明邮件为何未投递或被删除
+type Decision struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+
+	MailboxID uint   `json:"mailbox_id" gorm:"index;not null"`
+	RuleID    uint   `json:"rule_id"`
+	RuleName  string `json:"rule_name" gorm:"size:100"`
+	Stage     string `json:"stage" gorm:"size:10"`
+	Action    string `json:"action" gorm:"size:30"`
+
+	Sender    string `json:"sender" gorm:"size:320"`
+	From      string `json:"from" gorm:"size:512"`
+	Subject   string `json:"subject" gorm:"size:998"`
+	RemoteIP  string `json:"remote_ip" gorm:"size:45"`
+	MessageID uint   `json:"message_id,omitempty"`             // 查看后删除时为被删除的邮件ID
+	Detail    string `json:"detail,omitempty" gorm:"size:500"` // Sieve 脚本的拒收原因或转发地址
+}
+
+// TableName 指定表名
+func (Decision) TableName() string {
+	return "mailbox_filter_decisions"
+}
+
+// Script 邮箱的 Sieve 过滤脚本，每个邮箱最多一个，在过滤规则之后执行
+type Script struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	MailboxID uint   `json:"mailbox_id" gorm:"uniqueIndex;not null"`
+	Content   string `json:"content" gorm:"type:text;not null"`
+	Enabled   bool   `json:"enabled" gorm:"not null"`
+}
+
+// TableName 指定表名
+func (Script) TableName() string {
+	return "mailbox_sieve_scripts"
+}
+
+// ScriptRequest 上传 Sieve 脚本请求
+type ScriptRequest struct {
+	Content string `json:"content" validate:"required,max=65536"`
+	Enabled *bool  `json:"enabled"` // 默认启用
+}
+
+// DryRunRequest 试运行 Sieve 脚本请求
+type DryRunRequest struct {
+	MessageID uint    `json:"message_id" validate:"required"`
+	Content   *string `json:"content" validate:"omitempty,max=65536"` // 为空时使用已保存的脚本
+}
+
+// DecisionListResponse 过滤决定列表响应
+type DecisionListResponse struct {
+	Items    []*Decision `json:"items"`
+	Total    int64       `json:"total"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"page_size"`
+}
@@ -0,0 +1,31 @@
+package mailfilter
+
+import (
+	"context"
+	"time"
+)
+
+// Repository 过滤规则仓储接口
+type Repository interface {
+	// 规则
+	CreateRule(ctx context.Context, r *Rule) error
+	UpdateRule(ctx context.Context, r *Rule) error
+	DeleteRule(ctx context.Context, id uint) error
+	// GetRule 获取规则，不存在时返回nil
+	GetRule(ctx context.Context, id uint) (*Rule, error)
+	// ListRules 按执行顺序获取邮箱的全部规则
+	ListRules(ctx context.Context, mailboxID uint) ([]*Rule, error)
+	CountRules(ctx context.Context, mailboxID uint) (int64, error)
+
+	// Sieve 脚本
+	// GetScript 获取邮箱的脚本，不存在时返回nil
+	GetScript(ctx context.Context, mailboxID uint) (*Script, error)
+	SaveScript(ctx context.Context, s *Script) error
+	DeleteScript(ctx context.Context, mailboxID uint) error
+
+	// 决定记录
+	CreateDecision(ctx context.Context, d *Decision) error
+	ListDecisions(ctx context.Context, mailboxID uint, offset, limit int) ([]*Decision, error)
+	CountDecisions(ctx context.Context, mailboxID uint) (int64, error)
+	DeleteDecisionsBefore(ctx context.Context, before time.Time) (int64, error)
+}
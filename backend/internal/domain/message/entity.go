@@ -0,0 +1,246 @@
+package message
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Message 邮件实体
+type Message struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// 所属邮箱，Folder 为 Sieve 脚本 fileinto 指定的文件夹，空字符串表示收件箱
+	MailboxID uint   `json:"mailbox_id" gorm:"index;not null"`
+	Folder    string `json:"folder" gorm:"size:100"`
+
+	// 信封信息
+	EnvelopeFrom string `json:"envelope_from" gorm:"size:320"`
+	EnvelopeTo   string `json:"envelope_to" gorm:"size:320;not null"`
+	RemoteIP     string `json:"remote_ip" gorm:"size:45"`
+	Helo         string `json:"helo" gorm:"size:255"`
+
+	// 邮件头信息
+	From      string     `json:"from" gorm:"size:512"`
+	To        string     `json:"to" gorm:"type:text"`
+	Cc        string     `json:"cc" gorm:"type:text"`
+	ReplyTo   string     `json:"reply_to" gorm:"size:512"`
+	Subject   string     `json:"subject" gorm:"size:998"`
+	MessageID string     `json:"message_id" gorm:"size:998"`
+	SentAt    *time.Time `json:"sent_at"`
+	Headers   string     `json:"-" gorm:"type:text"` // JSON编码的完整邮件头
+
+	// 来源验证结果（JSON编码的 SPF/DKIM/DMARC 结果，未验证时为空）
+	AuthResults string `json:"-" gorm:"type:text"`
+
+	// 垃圾邮件评分（JSON编码的命中规则，未评分时为空）
+	IsSpam     bool    `json:"is_spam" gorm:"default:false"`
+	SpamScore  float64 `json:"spam_score"`
+	SpamResult string  `json:"-" gorm:"type:text"`
+
+	// 正文
+	TextBody string `json:"text_body" gorm:"type:text"`
+	HTMLBody string `json:"html_body" gorm:"type:text"`
+
+	// 原始邮件内容（保存在对象存储中）
+	Size   int    `json:"size"`
+	RawKey string `json:"-" gorm:"size:255;index"`
+
+	// 附件（内容保存在对象存储中）
+	Attachments []Attachment `json:"attachments" gorm:"constraint:OnDelete:CASCADE"`
+
+	// 状态
+	IsRead          bool      `json:"is_read" gorm:"default:false"`
+	DeleteAfterRead bool      `json:"delete_after_read" gorm:"default:false"` // 由过滤规则设置，首次查看详情后删除
+	ReceivedAt      time.Time `json:"received_at" gorm:"index"`
+}
+
+// TableName 指定表名
+func (Message) TableName() string {
+	return "messages"
+}
+
+// SetHeaders 以JSON格式保存邮件头
+func (m *Message) SetHeaders(headers map[string][]string) error {
+	data, err := json.Marshal(headers)
+	if err != nil {
+		return err
+	}
+	m.Headers = string(data)
+	return nil
+}
+
+// HeaderMap 获取解码后的邮件头
+func (m *Message) HeaderMap() map[string][]string {
+	headers := make(map[string][]string)
+	if m.Headers != "" {
+		_ = json.Unmarshal([]byte(m.Headers), &headers)
+	}
+	return headers
+}
+
+// SetAuthResults 以JSON格式保存来源验证结果
+func (m *Message) SetAuthResults(results interface{}) error {
+	data, err := json.Marshal(results)
+	if err != nil {
+		return err
+	}
+	m.AuthResults = string(data)
+	return nil
+}
+
+// AuthResultsJSON 获取来源验证结果，未验证时返回 nil
+func (m *Message) AuthResultsJSON() json.RawMessage {
+	if m.AuthResults == "" {
+		return nil
+	}
+	return json.RawMessage(m.AuthResults)
+}
+
+// SetSpamResult 以JSON格式保存垃圾邮件评分结果
+func (m *Message) SetSpamResult(result interface{}) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	m.SpamResult = string(data)
+	return nil
+}
+
+// SpamResultJSON 获取垃圾邮件评分结果，未评分时返回 nil
+func (m *Message) SpamResultJSON() json.RawMessage {
+	if m.SpamResult == "" {
+		return nil
+	}
+	return json.RawMessage(m.SpamResult)
+}
+
+// Attachment 附件元数据
+type Attachment struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	MessageID   uint   `json:"message_id" gorm:"index;not null"`
+	Filename    string `json:"filename" gorm:"size:255"`
+	ContentType string `json:"content_type" gorm:"size:255"`
+	ContentID   string `json:"content_id" gorm:"size:255"`
+	Inline      bool   `json:"inline"`
+	Size        int    `json:"size"`
+	Checksum    string `json:"checksum" gorm:"size:64"`          // SHA-256
+	StorageKey  string `json:"-" gorm:"size:255;index;not null"` // 对象存储中的键
+}
+
+// TableName 指定表名
+func (Attachment) TableName() string {
+	return "message_attachments"
+}
+
+// AttachmentStorageKey 根据内容校验和生成对象存储键（相同内容只存储一份）
+func AttachmentStorageKey(checksum string) string {
+	return "attachments/" + checksum[:2] + "/" + checksum
+}
+
+// RawStorageKey 根据原始邮件的校验和生成对象存储键，多个收件人共享同一份内容
+func RawStorageKey(checksum string) string {
+	return "raw/" + checksum[:2] + "/" + checksum
+}
+
+// MessageSummary 邮件列表项
+type MessageSummary struct {
+	ID         uint      `json:"id"`
+	Folder     string    `json:"folder"`
+	From       string    `json:"from"`
+	Subject    string    `json:"subject"`
+	Size       int       `json:"size"`
+	IsRead     bool      `json:"is_read"`
+	IsSpam     bool      `json:"is_spam"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// ToSummary 转换为列表项
+func (m *Message) ToSummary() *MessageSummary {
+	return &MessageSummary{
+		ID:         m.ID,
+		Folder:     m.Folder,
+		From:       m.From,
+		Subject:    m.Subject,
+		Size:       m.Size,
+		IsRead:     m.IsRead,
+		IsSpam:     m.IsSpam,
+		ReceivedAt: m.ReceivedAt,
+	}
+}
+
+// MessageListResponse 邮件列表响应
+type MessageListResponse struct {
+	Items    []*MessageSummary `json:"items"`
+	Total    int64             `json:"total"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"page_size"`
+}
+
+// MessageResponse 邮件详情响应
+type MessageResponse struct {
+	ID              uint                `json:"id"`
+	MailboxID       uint                `json:"mailbox_id"`
+	Folder          string              `json:"folder"`
+	EnvelopeFrom    string              `json:"envelope_from"`
+	EnvelopeTo      string              `json:"envelope_to"`
+	From            string              `json:"from"`
+	To              string              `json:"to"`
+	Cc              string              `json:"cc"`
+	ReplyTo         string              `json:"reply_to"`
+	Subject         string              `json:"subject"`
+	MessageID       string              `json:"message_id"`
+	SentAt          *time.Time          `json:"sent_at"`
+	ReceivedAt      time.Time           `json:"received_at"`
+	Headers         map[string][]string `json:"headers"`
+	AuthResults     json.RawMessage     `json:"authentication_results"`
+	TextBody        string              `json:"text_body"`
+	HTMLBody        string              `json:"html_body"`
+	Size            int                 `json:"size"`
+	IsRead          bool                `json:"is_read"`
+	DeleteAfterRead bool                `json:"delete_after_read"`
+	IsSpam          bool                `json:"is_spam"`
+	SpamScore       float64             `json:"spam_score"`
+	SpamResult      json.RawMessage     `json:"spam_result"`
+	Attachments     []Attachment        `json:"attachments"`
+}
+
+// ToResponse 转换为详情响应
+func (m *Message) ToResponse() *MessageResponse {
+	attachments := m.Attachments
+	if attachments == nil {
+		attachments = []Attachment{}
+	}
+	return &MessageResponse{
+		ID:              m.ID,
+		MailboxID:       m.MailboxID,
+		Folder:          m.Folder,
+		EnvelopeFrom:    m.EnvelopeFrom,
+		EnvelopeTo:      m.EnvelopeTo,
+		From:            m.From,
+		To:              m.To,
+		Cc:              m.Cc,
+		ReplyTo:         m.ReplyTo,
+		Subject:         m.Subject,
+		MessageID:       m.MessageID,
+		SentAt:          m.SentAt,
+		ReceivedAt:      m.ReceivedAt,
+		Headers:         m.HeaderMap(),
+		AuthResults:     m.AuthResultsJSON(),
+		TextBody:        m.TextBody,
+		HTMLBody:        m.HTMLBody,
+		Size:            m.Size,
+		IsRead:          m.IsRead,
+		DeleteAfterRead: m.DeleteAfterRead,
+		IsSpam:          m.IsSpam,
+		SpamScore:       m.SpamScore,
+		SpamResult:      m.SpamResultJSON(),
+		Attachments:     attachments,
+	}
+}
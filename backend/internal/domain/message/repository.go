@@ -0,0 +1,29 @@
+package message
+
+import (
+	"context"
+	"time"
+)
+
+// Repository 邮件仓储接口
+type Repository interface {
+	// 基础CRUD操作
+	Create(ctx context.Context, msg *Message) error
+	CreateBatch(ctx context.Context, msgs []*Message) error
+	GetByID(ctx context.Context, id uint) (*Message, error)
+	Delete(ctx context.Context, id uint) error
+
+	// 查询操作
+	ListByMailbox(ctx context.Context, mailboxID uint, offset, limit int) ([]*Message, error)
+	CountByMailbox(ctx context.Context, mailboxID uint) (int64, error)
+
+	// 数据清理
+	PurgeReceivedBefore(ctx context.Context, before time.Time, batchSize int) (int64, error)
+	StorageKeyInUse(ctx context.Context, key string) (bool, error)
+
+	// 附件
+	GetAttachment(ctx context.Context, id uint) (*Attachment, error)
+
+	// 状态管理
+	MarkRead(ctx context.Context, id uint) error
+}
@@ -0,0 +1,119 @@
+package session
+
+import "time"
+
+// RevokeReason 会话注销原因
+const (
+	// RevokeReasonReused 检测到已使用的刷新令牌被重放
+	RevokeReasonReused = "refresh_token_reused"
+	// RevokeReasonUserInactive 用户已被停用
+	RevokeReasonUserInactive = "user_inactive"
+	// RevokeReasonLogout 用户退出登录
+	RevokeReasonLogout = "logout"
+	// RevokeReasonLogoutAll 用户退出所有设备
+	RevokeReasonLogoutAll = "logout_all"
+	// RevokeReasonLogoutOthers 用户注销当前设备以外的其他会话
+	RevokeReasonLogoutOthers = "logout_others"
+)
+
+// RevocationKind 令牌吊销记录类型
+type RevocationKind string
+
+const (
+	// RevocationKindToken 按令牌ID（jti）吊销单个令牌
+	RevocationKindToken RevocationKind = "jti"
+	// RevocationKindSession 按会话ID（sid）吊销会话下的全部令牌
+	RevocationKindSession RevocationKind = "sid"
+)
+
+// Session 登录会话，对应一条刷新令牌链路（令牌族）
+type Session struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID   uint   `json:"user_id" gorm:"index;not null"`
+	FamilyID string `json:"-" gorm:"uniqueIndex;size:64;not null"` // 写入令牌的 sid 声明
+
+	// 当前有效刷新令牌的 SHA-256 哈希，每次刷新都会轮换
+	RefreshTokenHash string     `json:"-" gorm:"size:64;index;not null"`
+	Generation       int        `json:"generation"`
+	RotatedAt        *time.Time `json:"rotated_at"`
+	ExpiresAt        time.Time  `json:"expires_at" gorm:"index"`
+
+	// 设备信息，最近活动时间和IP在登录和刷新令牌时更新
+	UserAgent  string    `json:"user_agent" gorm:"size:512"`
+	IP         string    `json:"ip" gorm:"size:45"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	LastSeenIP string    `json:"last_seen_ip" gorm:"size:45"`
+
+	RevokedAt    *time.Time `json:"revoked_at"`
+	RevokeReason string     `json:"revoke_reason" gorm:"size:50"`
+}
+
+// TableName 指定表名
+func (Session) TableName() string {
+	return "sessions"
+}
+
+// IsRevoked 检查会话是否已注销
+func (s *Session) IsRevoked() bool {
+	return s.RevokedAt != nil
+}
+
+// IsExpired 检查会话是否已过期
+func (s *Session) IsExpired() bool {
+	return time.Now().After(s.ExpiresAt)
+}
+
+// SessionResponse 会话（登录设备）响应
+type SessionResponse struct {
+	ID         uint       `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	LastSeenIP string     `json:"last_seen_ip"`
+	Generation int        `json:"generation"` // 刷新令牌已轮换的代数
+	RotatedAt  *time.Time `json:"rotated_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	Current    bool       `json:"current"` // 是否为发起请求的会话
+}
+
+// ToResponse 转换为响应格式，currentFamilyID 为当前请求令牌的 sid
+func (s *Session) ToResponse(currentFamilyID string) *SessionResponse {
+	return &SessionResponse{
+		ID:         s.ID,
+		CreatedAt:  s.CreatedAt,
+		UserAgent:  s.UserAgent,
+		IP:         s.IP,
+		LastSeenAt: s.LastSeenAt,
+		LastSeenIP: s.LastSeenIP,
+		Generation: s.Generation,
+		RotatedAt:  s.RotatedAt,
+		ExpiresAt:  s.ExpiresAt,
+		Current:    currentFamilyID != "" && s.FamilyID == currentFamilyID,
+	}
+}
+
+// Revocation 令牌吊销记录，认证中间件据此拒绝尚未过期的访问令牌
+type Revocation struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Kind      RevocationKind `json:"kind" gorm:"size:10;uniqueIndex:idx_revocation_kind_value;not null"`
+	Value     string         `json:"value" gorm:"size:100;uniqueIndex:idx_revocation_kind_value;not null"`
+	UserID    uint           `json:"user_id" gorm:"index"`
+	Reason    string         `json:"reason" gorm:"size:50"`
+	ExpiresAt time.Time      `json:"expires_at" gorm:"index"` // 受影响的令牌全部过期后即可删除
+}
+
+// TableName 指定表名
+func (Revocation) TableName() string {
+	return "token_revocations"
+}
+
+// RefreshRequest 刷新令牌请求
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
@@ -0,0 +1,27 @@
+package session
+
+import (
+	"context"
+	"time"
+)
+
+// Repository 登录会话仓储接口
+type Repository interface {
+	Create(ctx context.Context, s *Session) error
+	GetByID(ctx context.Context, id uint) (*Session, error)
+	GetByFamilyID(ctx context.Context, familyID string) (*Session, error)
+	// Rotate 仅当会话未注销且当前令牌哈希仍为 oldHash 时轮换并更新最近活动信息，返回是否成功
+	Rotate(ctx context.Context, id uint, oldHash, newHash string, generation int, expiresAt time.Time, seenIP string) (bool, error)
+	Revoke(ctx context.Context, id uint, reason string) error
+	ListActiveByUser(ctx context.Context, userID uint) ([]*Session, error)
+	DeleteExpiredBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// RevocationRepository 令牌吊销记录仓储接口
+type RevocationRepository interface {
+	// Create 保存吊销记录，同一令牌或会话重复吊销时忽略
+	Create(ctx context.Context, r *Revocation) error
+	// ListActiveSince 获取 since 之后创建且尚未过期的记录
+	ListActiveSince(ctx context.Context, since, now time.Time) ([]*Revocation, error)
+	DeleteExpiredBefore(ctx context.Context, before time.Time) (int64, error)
+}
@@ -6,6 +6,34 @@ import (
 	"gorm.io/gorm"
 )
 
+// 用户角色
+const (
+	// RoleUser 普通用户
+	RoleUser = "user"
+	// RoleAdmin 管理员
+	RoleAdmin = "admin"
+)
+
+// IsValidRole 检查角色是否有效
+func IsValidRole(role string) bool {
+	return role == RoleUser || role == RoleAdmin
+}
+
+// 用户列表筛选状态
+const (
+	// StatusActive 已激活
+	StatusActive = "active"
+	// StatusInactive 已停用
+	StatusInactive = "inactive"
+	// StatusDeleted 已删除（软删除）
+	StatusDeleted = "deleted"
+)
+
+// IsValidStatus 检查筛选状态是否有效，空字符串表示全部未删除用户
+func IsValidStatus(status string) bool {
+	return status == "" || status == StatusActive || status == StatusInactive || status == StatusDeleted
+}
+
 // User 用户实体
 type User struct {
 	ID        uint           `json:"id" gorm:"primarykey"`
@@ -19,7 +47,10 @@ type User struct {
 	Password string `json:"-" gorm:"size:255;not null" validate:"required,min=6"`
 	
 	// 用户状态
-	IsActive bool `json:"is_active" gorm:"default:true"`
+	Role            string     `json:"role" gorm:"size:20;index;default:'user'"`
+	IsActive        bool       `json:"is_active" gorm:"default:true"`
+	EmailVerified   bool       `json:"email_verified" gorm:"default:false"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
 	
 	// 用户资料
 	Nickname string `json:"nickname" gorm:"size:50" validate:"max=50"`
@@ -30,6 +61,12 @@ type User struct {
 	PasswordResetToken string `json:"-" gorm:"size:255"`
 	PasswordResetExpiry *time.Time `json:"-"`
 	
+	// 两步验证
+	TOTPSecret      string     `json:"-" gorm:"size:255"` // 加密后的TOTP密钥，未启用时为待确认的密钥
+	TOTPEnabled     bool       `json:"totp_enabled" gorm:"default:false"`
+	TOTPEnabledAt   *time.Time `json:"totp_enabled_at"`
+	TOTPLastCounter int64      `json:"-"` // 最近一次通过验证的时间步，防止验证码重放
+	
 	// 用户设置
 	TimeZone string `json:"timezone" gorm:"size:50;default:'UTC'"`
 	Language string `json:"language" gorm:"size:10;default:'zh-CN'"`
@@ -48,6 +85,11 @@ func (u *User) GetFullName() string {
 	return u.Nickname
 }
 
+// IsAdmin 检查用户是否为管理员
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
 // IsPasswordResetValid 检查密码重置令牌是否有效
 func (u *User) IsPasswordResetValid() bool {
 	if u.PasswordResetToken == "" || u.PasswordResetExpiry == nil {
@@ -56,6 +98,35 @@ func (u *User) IsPasswordResetValid() bool {
 	return time.Now().Before(*u.PasswordResetExpiry)
 }
 
+// RecoveryCode 两步验证恢复码，只保存哈希，每个只能使用一次
+type RecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time  `json:"created_at"`
+	UserID    uint       `json:"user_id" gorm:"index;not null"`
+	CodeHash  string     `json:"-" gorm:"size:64;index;not null"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+// TableName 指定表名
+func (RecoveryCode) TableName() string {
+	return "user_recovery_codes"
+}
+
+// Identity 外部身份（OpenID Connect 登录），同一身份提供方的 subject 只能关联一个用户
+type Identity struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UserID    uint      `json:"user_id" gorm:"index;not null"`
+	Provider  string    `json:"provider" gorm:"uniqueIndex:idx_identity_provider_subject;size:50;not null"`
+	Subject   string    `json:"subject" gorm:"uniqueIndex:idx_identity_provider_subject;size:255;not null"`
+	Email     string    `json:"email" gorm:"size:100"` // 关联时身份提供方返回的邮箱
+}
+
+// TableName 指定表名
+func (Identity) TableName() string {
+	return "user_identities"
+}
+
 // CreateUserRequest 创建用户请求
 type CreateUserRequest struct {
 	Username string `json:"username" validate:"required,min=3,max=50"`
@@ -76,6 +147,9 @@ type UpdateUserRequest struct {
 type ChangePasswordRequest struct {
 	CurrentPassword string `json:"current_password" validate:"required"`
 	NewPassword     string `json:"new_password" validate:"required,min=6"`
+	
+	// RevokeOtherSessions 修改成功后注销当前会话以外的其他登录设备
+	RevokeOtherSessions bool `json:"revoke_other_sessions"`
 }
 
 // LoginRequest 登录请求
@@ -84,34 +158,119 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+// ForgotPasswordRequest 忘记密码请求
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordRequest 重置密码请求
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=6"`
+}
+
+// VerifyEmailRequest 邮箱验证请求
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ResendVerificationRequest 重新发送验证邮件请求
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// MFALoginRequest 两步验证登录请求，code 可以是验证码或恢复码
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required,max=32"`
+}
+
+// ConfirmTOTPRequest 确认启用两步验证请求
+type ConfirmTOTPRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// DisableTOTPRequest 关闭两步验证请求，code 可以是验证码或恢复码
+type DisableTOTPRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required,max=32"`
+}
+
+// RegenerateRecoveryCodesRequest 重新生成恢复码请求
+type RegenerateRecoveryCodesRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// TOTPSetupResponse 两步验证注册信息
+type TOTPSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// RecoveryCodesResponse 恢复码（只在生成时返回一次）
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// UpdateRoleRequest 修改用户角色请求
+type UpdateRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=user admin"`
+}
+
+// SearchFilter 用户搜索条件
+type SearchFilter struct {
+	Keyword string // 按邮箱或用户名模糊匹配
+	Role    string
+	Status  string
+}
+
 // UserResponse 用户响应（不包含敏感信息）
 type UserResponse struct {
-	ID          uint       `json:"id"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
-	Username    string     `json:"username"`
-	Email       string     `json:"email"`
-	IsActive    bool       `json:"is_active"`
-	Nickname    string     `json:"nickname"`
-	Avatar      string     `json:"avatar"`
-	LastLoginAt *time.Time `json:"last_login_at"`
-	TimeZone    string     `json:"timezone"`
-	Language    string     `json:"language"`
+	ID            uint       `json:"id"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	Username      string     `json:"username"`
+	Email         string     `json:"email"`
+	Role          string     `json:"role"`
+	IsActive      bool       `json:"is_active"`
+	EmailVerified bool       `json:"email_verified"`
+	TOTPEnabled   bool       `json:"totp_enabled"`
+	Nickname      string     `json:"nickname"`
+	Avatar        string     `json:"avatar"`
+	LastLoginAt   *time.Time `json:"last_login_at"`
+	TimeZone      string     `json:"timezone"`
+	Language      string     `json:"language"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
+}
+
+// UserListResponse 用户列表响应
+type UserListResponse struct {
+	Items    []*UserResponse `json:"items"`
+	Total    int64           `json:"total"`
+	Page     int             `json:"page"`
+	PageSize int             `json:"page_size"`
 }
 
 // ToResponse 转换为响应格式
 func (u *User) ToResponse() *UserResponse {
-	return &UserResponse{
-		ID:          u.ID,
-		CreatedAt:   u.CreatedAt,
-		UpdatedAt:   u.UpdatedAt,
-		Username:    u.Username,
-		Email:       u.Email,
-		IsActive:    u.IsActive,
-		Nickname:    u.Nickname,
-		Avatar:      u.Avatar,
-		LastLoginAt: u.LastLoginAt,
-		TimeZone:    u.TimeZone,
-		Language:    u.Language,
+	resp := &UserResponse{
+		ID:            u.ID,
+		CreatedAt:     u.CreatedAt,
+		UpdatedAt:     u.UpdatedAt,
+		Username:      u.Username,
+		Email:         u.Email,
+		Role:          u.Role,
+		IsActive:      u.IsActive,
+		EmailVerified: u.EmailVerified,
+		TOTPEnabled:   u.TOTPEnabled,
+		Nickname:      u.Nickname,
+		Avatar:        u.Avatar,
+		LastLoginAt:   u.LastLoginAt,
+		TimeZone:      u.TimeZone,
+		Language:      u.Language,
+	}
+	if u.DeletedAt.Valid {
+		resp.DeletedAt = &u.DeletedAt.Time
 	}
+	return resp
 } 
\ No newline at end of file
@@ -12,12 +12,16 @@ type Repository interface {
 	GetByID(ctx context.Context, id uint) (*User, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
 	GetByUsername(ctx context.Context, username string) (*User, error)
+	GetByIDWithDeleted(ctx context.Context, id uint) (*User, error)
 	Update(ctx context.Context, user *User) error
 	Delete(ctx context.Context, id uint) error
 	
 	// 查询操作
 	List(ctx context.Context, offset, limit int) ([]*User, error)
 	Count(ctx context.Context) (int64, error)
+	CountByRole(ctx context.Context, role string) (int64, error)
+	Search(ctx context.Context, filter *SearchFilter, offset, limit int) ([]*User, error)
+	CountSearch(ctx context.Context, filter *SearchFilter) (int64, error)
 	Exists(ctx context.Context, id uint) (bool, error)
 	ExistsByEmail(ctx context.Context, email string) (bool, error)
 	ExistsByUsername(ctx context.Context, username string) (bool, error)
@@ -27,8 +31,27 @@ type Repository interface {
 	UpdateLastLogin(ctx context.Context, id uint) error
 	SetPasswordResetToken(ctx context.Context, id uint, token string, expiry *time.Time) error
 	ClearPasswordResetToken(ctx context.Context, id uint) error
+	GetByPasswordResetToken(ctx context.Context, token string) (*User, error)
+	ResetPassword(ctx context.Context, id uint, token, hashedPassword string) (bool, error)
+	
+	// 两步验证
+	SetTOTPSecret(ctx context.Context, id uint, encryptedSecret string) error
+	EnableTOTP(ctx context.Context, id uint, counter int64, recoveryCodeHashes []string) error
+	DisableTOTP(ctx context.Context, id uint) error
+	// UseTOTPCounter 仅当 counter 大于上次使用的时间步时更新，返回是否成功
+	UseTOTPCounter(ctx context.Context, id uint, counter int64) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, id uint, codeHashes []string) error
+	// UseRecoveryCode 将未使用的恢复码标记为已使用，返回是否成功
+	UseRecoveryCode(ctx context.Context, id uint, codeHash string) (bool, error)
+	
+	// 外部身份
+	GetIdentity(ctx context.Context, provider, subject string) (*Identity, error)
+	CreateIdentity(ctx context.Context, identity *Identity) error
 	
 	// 状态管理
+	MarkEmailVerified(ctx context.Context, id uint) error
+	UpdateRole(ctx context.Context, id uint, role string) error
 	Activate(ctx context.Context, id uint) error
 	Deactivate(ctx context.Context, id uint) error
+	Restore(ctx context.Context, id uint) error
 } 
\ No newline at end of file
@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+const (
+	// APIKeyPrefix API密钥固定前缀，便于密钥扫描工具识别泄露的密钥
+	APIKeyPrefix = "tmk_"
+	// apiKeyIDLength 密钥标识长度（十六进制字符），明文保存用于查找
+	apiKeyIDLength = 8
+	// apiKeySecretLength 密钥秘密部分长度（十六进制字符），只保存哈希
+	apiKeySecretLength = 40
+)
+
+var (
+	// ErrAPIKeyInvalid API密钥格式错误、不存在或已吊销
+	ErrAPIKeyInvalid = errors.New("API密钥无效")
+	// ErrAPIKeyExpired API密钥已过期
+	ErrAPIKeyExpired = errors.New("API密钥已过期")
+)
+
+// APIKeyPrincipal API密钥对应的用户身份和授权范围
+type APIKeyPrincipal struct {
+	KeyID    uint
+	UserID   uint
+	Username string
+	Email    string
+	Role     string
+	Scopes   []string
+}
+
+// HasScope 检查是否拥有指定授权范围
+func (p *APIKeyPrincipal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyAuthenticator API密钥校验接口，认证中间件收到API密钥时调用
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key, clientIP string) (*APIKeyPrincipal, error)
+}
+
+// GenerateAPIKey 生成新的API密钥，格式为 tmk_<标识>_<秘密>，返回完整密钥、标识和秘密
+func GenerateAPIKey() (key, id, secret string, err error) {
+	b := make([]byte, (apiKeyIDLength+apiKeySecretLength)/2)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	raw := hex.EncodeToString(b)
+	id, secret = raw[:apiKeyIDLength], raw[apiKeyIDLength:]
+	return APIKeyPrefix + id + "_" + secret, id, secret, nil
+}
+
+// ParseAPIKey 拆分API密钥的标识和秘密部分
+func ParseAPIKey(key string) (id, secret string, ok bool) {
+	rest, found := strings.CutPrefix(key, APIKeyPrefix)
+	if !found {
+		return "", "", false
+	}
+	id, secret, found = strings.Cut(rest, "_")
+	if !found || len(id) != apiKeyIDLength || len(secret) != apiKeySecretLength || !isHex(id) || !isHex(secret) {
+		return "", "", false
+	}
+	return id, secret, true
+}
+
+// ExtractAPIKey 从 Authorization: ApiKey <key> 或 X-API-Key 请求头中提取API密钥
+func ExtractAPIKey(authHeader, apiKeyHeader string) (string, bool) {
+	const apiKeyScheme = "ApiKey "
+	if len(authHeader) > len(apiKeyScheme) && strings.EqualFold(authHeader[:len(apiKeyScheme)], apiKeyScheme) {
+		return strings.TrimSpace(authHeader[len(apiKeyScheme):]), true
+	}
+	if apiKeyHeader != "" {
+		return strings.TrimSpace(apiKeyHeader), true
+	}
+	return "", false
+}
+
+// isHex 检查字符串是否只包含小写十六进制字符
+func isHex(s string) bool {
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
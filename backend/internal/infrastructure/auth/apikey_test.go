@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateAndParseAPIKey(t *testing.T) {
+	key, id, secret, err := GenerateAPIKey()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key, "tmk_"+id+"_"))
+	assert.Len(t, id, 8)
+	assert.Len(t, secret, 40)
+
+	parsedID, parsedSecret, ok := ParseAPIKey(key)
+	require.True(t, ok)
+	assert.Equal(t, id, parsedID)
+	assert.Equal(t, secret, parsedSecret)
+
+	other, _, _, err := GenerateAPIKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, key, other)
+}
+
+func TestParseAPIKey_Invalid(t *testing.T) {
+	invalid := []string{
+		"",
+		"tmk_",
+		"abc_0123abcd_0123456789abcdef0123456789abcdef01234567",
+		"tmk_0123abcd",
+		"tmk_0123abc_0123456789abcdef0123456789abcdef01234567",
+		"tmk_0123ABCD_0123456789abcdef0123456789abcdef01234567",
+		"tmk_0123abcd_0123456789abcdef0123456789abcdef0123456",
+		"tmk_0123abcd_0123456789abcdef0123456789abcdef0123456g",
+	}
+	for _, key := range invalid {
+		_, _, ok := ParseAPIKey(key)
+		assert.False(t, ok, key)
+	}
+}
+
+func TestExtractAPIKey(t *testing.T) {
+	key, ok := ExtractAPIKey("ApiKey tmk_abc", "")
+	assert.True(t, ok)
+	assert.Equal(t, "tmk_abc", key)
+
+	key, ok = ExtractAPIKey("apikey tmk_abc", "")
+	assert.True(t, ok)
+	assert.Equal(t, "tmk_abc", key)
+
+	key, ok = ExtractAPIKey("", "tmk_def")
+	assert.True(t, ok)
+	assert.Equal(t, "tmk_def", key)
+
+	_, ok = ExtractAPIKey("Bearer eyJ...", "")
+	assert.False(t, ok)
+}
+
+func TestAPIKeyPrincipal_HasScope(t *testing.T) {
+	p := &APIKeyPrincipal{Scopes: []string{"mailbox:read"}}
+	assert.True(t, p.HasScope("mailbox:read"))
+	assert.False(t, p.HasScope("mailbox:write"))
+}
@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"time"
 
@@ -12,15 +14,28 @@ type JWTClaims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
 	Email    string `json:"email"`
+	Role     string `json:"role,omitempty"`
+	// 会话（刷新令牌族）ID 及刷新令牌的轮换代数，所有令牌都绑定到会话
+	SessionID  string `json:"sid,omitempty"`
+	Generation int    `json:"gen,omitempty"`
 	jwt.RegisteredClaims
 }
 
 // JWTService JWT服务接口
 type JWTService interface {
-	GenerateTokens(userID uint, username, email string) (*TokenPair, error)
+	GenerateSessionTokens(subject TokenSubject, sessionID string, generation int) (*TokenPair, error)
 	ValidateAccessToken(tokenString string) (*JWTClaims, error)
 	ValidateRefreshToken(tokenString string) (*JWTClaims, error)
-	RefreshTokens(refreshToken string) (*TokenPair, error)
+	AccessTokenTTL() time.Duration
+	RefreshTokenTTL() time.Duration
+}
+
+// TokenSubject 令牌主体，即写入令牌声明的用户信息
+type TokenSubject struct {
+	UserID   uint
+	Username string
+	Email    string
+	Role     string
 }
 
 // TokenPair 令牌对
@@ -49,18 +64,22 @@ func NewJWTService(secretKey string, accessTokenTTL, refreshTokenTTL int, issuer
 	}
 }
 
-// GenerateTokens 生成访问令牌和刷新令牌
-func (s *jwtService) GenerateTokens(userID uint, username, email string) (*TokenPair, error) {
+// GenerateSessionTokens 生成绑定到会话的令牌对，刷新令牌携带轮换代数以保证每次轮换都不相同。
+// 不签发无会话的令牌，否则退出登录和注销会话无法吊销它们
+func (s *jwtService) GenerateSessionTokens(subject TokenSubject, sessionID string, generation int) (*TokenPair, error) {
+	if sessionID == "" {
+		return nil, fmt.Errorf("缺少会话ID")
+	}
 	now := time.Now()
 	
 	// 生成访问令牌
-	accessToken, err := s.generateToken(userID, username, email, now.Add(s.accessTokenTTL), "access")
+	accessToken, err := s.generateToken(subject, sessionID, 0, now.Add(s.accessTokenTTL), "access")
 	if err != nil {
 		return nil, fmt.Errorf("生成访问令牌失败: %w", err)
 	}
 	
 	// 生成刷新令牌
-	refreshToken, err := s.generateToken(userID, username, email, now.Add(s.refreshTokenTTL), "refresh")
+	refreshToken, err := s.generateToken(subject, sessionID, generation, now.Add(s.refreshTokenTTL), "refresh")
 	if err != nil {
 		return nil, fmt.Errorf("生成刷新令牌失败: %w", err)
 	}
@@ -73,6 +92,16 @@ func (s *jwtService) GenerateTokens(userID uint, username, email string) (*Token
 	}, nil
 }
 
+// AccessTokenTTL 获取访问令牌有效期
+func (s *jwtService) AccessTokenTTL() time.Duration {
+	return s.accessTokenTTL
+}
+
+// RefreshTokenTTL 获取刷新令牌有效期
+func (s *jwtService) RefreshTokenTTL() time.Duration {
+	return s.refreshTokenTTL
+}
+
 // ValidateAccessToken 验证访问令牌
 func (s *jwtService) ValidateAccessToken(tokenString string) (*JWTClaims, error) {
 	return s.validateToken(tokenString, "access")
@@ -83,31 +112,27 @@ func (s *jwtService) ValidateRefreshToken(tokenString string) (*JWTClaims, error
 	return s.validateToken(tokenString, "refresh")
 }
 
-// RefreshTokens 使用刷新令牌获取新的令牌对
-func (s *jwtService) RefreshTokens(refreshToken string) (*TokenPair, error) {
-	// 验证刷新令牌
-	claims, err := s.ValidateRefreshToken(refreshToken)
+// generateToken 生成JWT令牌
+func (s *jwtService) generateToken(subject TokenSubject, sessionID string, generation int, expiresAt time.Time, tokenType string) (string, error) {
+	jti, err := newTokenID(tokenType, subject.UserID)
 	if err != nil {
-		return nil, fmt.Errorf("刷新令牌无效: %w", err)
+		return "", err
 	}
 	
-	// 生成新的令牌对
-	return s.GenerateTokens(claims.UserID, claims.Username, claims.Email)
-}
-
-// generateToken 生成JWT令牌
-func (s *jwtService) generateToken(userID uint, username, email string, expiresAt time.Time, tokenType string) (string, error) {
 	claims := &JWTClaims{
-		UserID:   userID,
-		Username: username,
-		Email:    email,
+		UserID:     subject.UserID,
+		Username:   subject.Username,
+		Email:      subject.Email,
+		Role:       subject.Role,
+		SessionID:  sessionID,
+		Generation: generation,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    s.issuer,
-			Subject:   fmt.Sprintf("user_%d", userID),
-			ID:        fmt.Sprintf("%s_%d_%d", tokenType, userID, time.Now().Unix()),
+			Subject:   fmt.Sprintf("user_%d", subject.UserID),
+			ID:        jti,
 		},
 	}
 	
@@ -115,6 +140,16 @@ func (s *jwtService) generateToken(userID uint, username, email string, expiresA
 	return token.SignedString(s.secretKey)
 }
 
+// newTokenID 生成令牌ID，格式为 type_userID_unixSeconds_random
+// 前缀用于区分令牌类型，随机后缀保证同一秒内签发的令牌ID也不会重复
+func newTokenID(tokenType string, userID uint) (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成令牌ID失败: %w", err)
+	}
+	return fmt.Sprintf("%s_%d_%d_%s", tokenType, userID, time.Now().Unix(), hex.EncodeToString(b)), nil
+}
+
 // validateToken 验证JWT令牌
 func (s *jwtService) validateToken(tokenString, expectedType string) (*JWTClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
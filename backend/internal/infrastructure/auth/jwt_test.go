@@ -3,7 +3,6 @@ package auth
 import (
 	"strings"
 	"testing"
-	"time"
 )
 
 func TestJWTService_TokenGeneration(t *testing.T) {
@@ -19,7 +18,7 @@ func TestJWTService_TokenGeneration(t *testing.T) {
 	username := "testuser"
 
 	// 测试令牌对生成
-	tokens, err := jwtService.GenerateTokens(userID, username, email)
+	tokens, err := jwtService.GenerateSessionTokens(TokenSubject{UserID: userID, Username: username, Email: email}, "sid-1", 1)
 	if err != nil {
 		t.Fatalf("生成令牌对失败: %v", err)
 	}
@@ -50,7 +49,7 @@ func TestJWTService_TokenValidation(t *testing.T) {
 	username := "testuser"
 
 	// 生成令牌对
-	tokens, err := jwtService.GenerateTokens(userID, username, email)
+	tokens, err := jwtService.GenerateSessionTokens(TokenSubject{UserID: userID, Username: username, Email: email}, "sid-1", 1)
 	if err != nil {
 		t.Fatalf("生成令牌对失败: %v", err)
 	}
@@ -95,7 +94,7 @@ func TestJWTService_RefreshTokenValidation(t *testing.T) {
 	username := "testuser"
 
 	// 生成令牌对
-	tokens, err := jwtService.GenerateTokens(userID, username, email)
+	tokens, err := jwtService.GenerateSessionTokens(TokenSubject{UserID: userID, Username: username, Email: email}, "sid-1", 1)
 	if err != nil {
 		t.Fatalf("生成令牌对失败: %v", err)
 	}
@@ -148,7 +147,7 @@ func TestJWTService_ExpiredToken(t *testing.T) {
 	username := "testuser"
 
 	// 生成过期令牌
-	tokens, err := jwtService.GenerateTokens(userID, username, email)
+	tokens, err := jwtService.GenerateSessionTokens(TokenSubject{UserID: userID, Username: username, Email: email}, "sid-1", 1)
 	if err != nil {
 		t.Fatalf("生成令牌对失败: %v", err)
 	}
@@ -173,7 +172,7 @@ func TestJWTService_WrongTokenType(t *testing.T) {
 	username := "testuser"
 
 	// 生成令牌对
-	tokens, err := jwtService.GenerateTokens(userID, username, email)
+	tokens, err := jwtService.GenerateSessionTokens(TokenSubject{UserID: userID, Username: username, Email: email}, "sid-1", 1)
 	if err != nil {
 		t.Fatalf("生成令牌对失败: %v", err)
 	}
@@ -185,7 +184,7 @@ func TestJWTService_WrongTokenType(t *testing.T) {
 	}
 }
 
-func TestJWTService_RefreshTokens(t *testing.T) {
+func TestJWTService_SessionTokens(t *testing.T) {
 	jwtService := NewJWTService(
 		"test-secret-key-for-testing-only",
 		60,    // 1小时
@@ -193,59 +192,68 @@ func TestJWTService_RefreshTokens(t *testing.T) {
 		"test-issuer",
 	)
 
-	userID := uint(123)
-	email := "test@example.com"
-	username := "testuser"
-
-	// 生成原始令牌对
-	originalTokens, err := jwtService.GenerateTokens(userID, username, email)
+	// 同一会话相邻两代的刷新令牌即使在同一秒内签发也应该不同
+	subject := TokenSubject{UserID: 123, Username: "testuser", Email: "test@example.com", Role: "admin"}
+	first, err := jwtService.GenerateSessionTokens(subject, "sid-1", 1)
 	if err != nil {
-		t.Fatalf("生成原始令牌对失败: %v", err)
+		t.Fatalf("生成会话令牌对失败: %v", err)
 	}
-
-	// 等待一秒以确保时间戳不同
-	time.Sleep(1 * time.Second)
-
-	// 使用刷新令牌获取新令牌对
-	newTokens, err := jwtService.RefreshTokens(originalTokens.RefreshToken)
+	second, err := jwtService.GenerateSessionTokens(subject, "sid-1", 2)
 	if err != nil {
-		t.Fatalf("刷新令牌失败: %v", err)
+		t.Fatalf("生成会话令牌对失败: %v", err)
+	}
+	if first.RefreshToken == second.RefreshToken {
+		t.Error("不同代的刷新令牌不应该相同")
 	}
 
-	// 验证新令牌对
-	if newTokens.AccessToken == "" {
-		t.Error("新访问令牌不应该为空")
+	claims, err := jwtService.ValidateRefreshToken(second.RefreshToken)
+	if err != nil {
+		t.Fatalf("验证刷新令牌失败: %v", err)
 	}
-	if newTokens.RefreshToken == "" {
-		t.Error("新刷新令牌不应该为空")
+	if claims.SessionID != "sid-1" || claims.Generation != 2 {
+		t.Errorf("期望会话为 'sid-1' 代数为 2，得到 '%s' 和 %d", claims.SessionID, claims.Generation)
 	}
-	
-	// 验证新访问令牌可以被验证
-	newClaims, err := jwtService.ValidateAccessToken(newTokens.AccessToken)
+
+	accessClaims, err := jwtService.ValidateAccessToken(second.AccessToken)
 	if err != nil {
-		t.Errorf("新访问令牌验证失败: %v", err)
-	}
-	
-	// 验证用户信息保持一致
-	if newClaims.UserID != userID {
-		t.Errorf("期望用户ID为 %d，得到 %d", userID, newClaims.UserID)
+		t.Fatalf("验证访问令牌失败: %v", err)
 	}
-	if newClaims.Email != email {
-		t.Errorf("期望邮箱为 '%s'，得到 '%s'", email, newClaims.Email)
+	if accessClaims.SessionID != "sid-1" {
+		t.Errorf("期望访问令牌会话为 'sid-1'，得到 '%s'", accessClaims.SessionID)
 	}
-	if newClaims.Username != username {
-		t.Errorf("期望用户名为 '%s'，得到 '%s'", username, newClaims.Username)
+	if accessClaims.Role != "admin" {
+		t.Errorf("期望访问令牌角色为 'admin'，得到 '%s'", accessClaims.Role)
 	}
-	
-	// 验证令牌确实不同（或者至少功能正常）
-	originalClaims, err := jwtService.ValidateAccessToken(originalTokens.AccessToken)
-	if err != nil {
-		t.Errorf("原始访问令牌验证失败: %v", err)
+
+	// 不签发无会话的令牌
+	if _, err := jwtService.GenerateSessionTokens(subject, "", 1); err == nil {
+		t.Error("缺少会话ID时应该生成失败")
 	}
-	
-	// 比较令牌ID（时间戳应该不同）
-	if originalClaims.ID == newClaims.ID && newTokens.AccessToken == originalTokens.AccessToken {
-		t.Error("刷新后的令牌与原始令牌完全相同，这可能不是期望的行为")
+}
+
+func TestJWTService_UniqueTokenID(t *testing.T) {
+	jwtService := NewJWTService(
+		"test-secret-key-for-testing-only",
+		60,    // 1小时
+		10080, // 7天
+		"test-issuer",
+	)
+
+	// 同一秒内为同一用户签发的令牌ID也不应该重复
+	seen := make(map[string]bool)
+	for i := 0; i < 20; i++ {
+		tokens, err := jwtService.GenerateSessionTokens(TokenSubject{UserID: 123, Username: "testuser", Email: "test@example.com"}, "sid-1", 1)
+		if err != nil {
+			t.Fatalf("生成令牌对失败: %v", err)
+		}
+		claims, err := jwtService.ValidateAccessToken(tokens.AccessToken)
+		if err != nil {
+			t.Fatalf("验证访问令牌失败: %v", err)
+		}
+		if seen[claims.ID] {
+			t.Fatalf("令牌ID重复: %s", claims.ID)
+		}
+		seen[claims.ID] = true
 	}
 }
 
@@ -269,7 +277,7 @@ func TestJWTService_DifferentSecrets(t *testing.T) {
 	username := "testuser"
 
 	// 用第一个服务生成令牌
-	tokens, err := jwtService1.GenerateTokens(userID, username, email)
+	tokens, err := jwtService1.GenerateSessionTokens(TokenSubject{UserID: userID, Username: username, Email: email}, "sid-1", 1)
 	if err != nil {
 		t.Fatalf("生成令牌对失败: %v", err)
 	}
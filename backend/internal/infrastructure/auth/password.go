@@ -2,6 +2,7 @@ package auth
 
 import (
 	"fmt"
+	"sync"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -33,6 +34,17 @@ func VerifyPassword(hashedPassword, password string) bool {
 	return err == nil
 }
 
+// dummyPasswordHash 账户不存在时用于比较的哈希，首次使用时生成
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("dummy-password-for-timing"), BcryptCost)
+	return hashed
+})
+
+// VerifyDummyPassword 账户不存在时调用，耗时与 VerifyPassword 相当，避免通过响应时间判断账户是否存在
+func VerifyDummyPassword(password string) {
+	_ = bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(password))
+}
+
 // IsValidPassword 检查密码强度
 func IsValidPassword(password string) error {
 	if len(password) < MinPasswordLength {
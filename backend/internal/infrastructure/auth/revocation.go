@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RevocationChecker 令牌吊销检查接口，认证中间件在令牌签名校验通过后调用
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, claims *JWTClaims) bool
+}
+
+// RevocationCache 已吊销令牌的内存缓存
+// 按令牌ID（jti）或会话ID（sid）记录，条目在对应令牌全部过期后即可清理
+type RevocationCache struct {
+	mu       sync.RWMutex
+	tokens   map[string]time.Time
+	sessions map[string]time.Time
+}
+
+// NewRevocationCache 创建吊销缓存
+func NewRevocationCache() *RevocationCache {
+	return &RevocationCache{
+		tokens:   make(map[string]time.Time),
+		sessions: make(map[string]time.Time),
+	}
+}
+
+// RevokeToken 吊销单个令牌，expiresAt 为该令牌的过期时间
+func (c *RevocationCache) RevokeToken(jti string, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tokens[jti] = expiresAt
+}
+
+// RevokeSession 吊销会话下签发的全部令牌，expiresAt 为该会话最后一个访问令牌的过期时间
+func (c *RevocationCache) RevokeSession(sid string, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if expiresAt.After(c.sessions[sid]) {
+		c.sessions[sid] = expiresAt
+	}
+}
+
+// IsRevoked 检查令牌是否已被吊销
+func (c *RevocationCache) IsRevoked(claims *JWTClaims) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if _, ok := c.tokens[claims.ID]; ok && claims.ID != "" {
+		return true
+	}
+	if _, ok := c.sessions[claims.SessionID]; ok && claims.SessionID != "" {
+		return true
+	}
+	return false
+}
+
+// Prune 清理在 now 之前过期的条目，返回清理的数量
+func (c *RevocationCache) Prune(now time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	pruned := 0
+	for _, m := range []map[string]time.Time{c.tokens, c.sessions} {
+		for key, expiresAt := range m {
+			if expiresAt.Before(now) {
+				delete(m, key)
+				pruned++
+			}
+		}
+	}
+	return pruned
+}
+
+// Len 获取缓存中的条目数量
+func (c *RevocationCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.tokens) + len(c.sessions)
+}
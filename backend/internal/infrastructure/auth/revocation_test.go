@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevocationCache_Token(t *testing.T) {
+	cache := NewRevocationCache()
+	claims := &JWTClaims{RegisteredClaims: jwt.RegisteredClaims{ID: "access_1_1700000000_aa"}}
+	other := &JWTClaims{RegisteredClaims: jwt.RegisteredClaims{ID: "access_1_1700000000_bb"}}
+
+	assert.False(t, cache.IsRevoked(claims))
+
+	cache.RevokeToken(claims.ID, time.Now().Add(time.Hour))
+	assert.True(t, cache.IsRevoked(claims))
+	assert.False(t, cache.IsRevoked(other))
+}
+
+func TestRevocationCache_Session(t *testing.T) {
+	cache := NewRevocationCache()
+	inSession := &JWTClaims{SessionID: "sid-1", RegisteredClaims: jwt.RegisteredClaims{ID: "access_1_1700000000_aa"}}
+	otherSession := &JWTClaims{SessionID: "sid-2", RegisteredClaims: jwt.RegisteredClaims{ID: "access_1_1700000000_bb"}}
+	noSession := &JWTClaims{RegisteredClaims: jwt.RegisteredClaims{ID: "access_1_1700000000_cc"}}
+
+	cache.RevokeSession("sid-1", time.Now().Add(time.Hour))
+	assert.True(t, cache.IsRevoked(inSession))
+	assert.False(t, cache.IsRevoked(otherSession))
+	assert.False(t, cache.IsRevoked(noSession))
+}
+
+func TestRevocationCache_Prune(t *testing.T) {
+	cache := NewRevocationCache()
+	now := time.Now()
+	cache.RevokeToken("expired", now.Add(-time.Minute))
+	cache.RevokeToken("live", now.Add(time.Minute))
+	cache.RevokeSession("sid-expired", now.Add(-time.Minute))
+
+	// 较早的过期时间不应该缩短已有会话条目的保留时间
+	cache.RevokeSession("sid-live", now.Add(time.Minute))
+	cache.RevokeSession("sid-live", now.Add(-time.Hour))
+
+	assert.Equal(t, 2, cache.Prune(now))
+	assert.Equal(t, 2, cache.Len())
+	assert.True(t, cache.IsRevoked(&JWTClaims{RegisteredClaims: jwt.RegisteredClaims{ID: "live"}}))
+	assert.True(t, cache.IsRevoked(&JWTClaims{SessionID: "sid-live"}))
+}
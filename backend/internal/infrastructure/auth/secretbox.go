@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+// ErrSecretBoxInvalid 密文格式错误或无法解密
+var ErrSecretBoxInvalid = errors.New("密文无效")
+
+// SecretBox 使用 AES-256-GCM 加密需要落库但可还原的敏感数据（如TOTP密钥）
+type SecretBox struct {
+	aead cipher.AEAD
+}
+
+// NewSecretBox 创建加密器，与 NewSigner 一样按 purpose 派生子密钥
+func NewSecretBox(secret, purpose string) *SecretBox {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("secretbox:" + purpose))
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		// 32字节密钥不会出错
+		panic(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+	return &SecretBox{aead: aead}
+}
+
+// Seal 加密明文，返回 base64url(随机数|密文)
+func (b *SecretBox) Seal(plaintext string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Open 解密 Seal 生成的密文
+func (b *SecretBox) Open(ciphertext string) (string, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(ciphertext)
+	if err != nil || len(sealed) < b.aead.NonceSize() {
+		return "", ErrSecretBoxInvalid
+	}
+	nonce, data := sealed[:b.aead.NonceSize()], sealed[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, data, nil)
+	if err != nil {
+		return "", ErrSecretBoxInvalid
+	}
+	return string(plaintext), nil
+}
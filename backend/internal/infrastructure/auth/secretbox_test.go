@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretBox(t *testing.T) {
+	box := NewSecretBox("test-secret", "totp")
+
+	sealed, err := box.Seal("JBSWY3DPEHPK3PXP")
+	require.NoError(t, err)
+	assert.NotContains(t, sealed, "JBSWY3DPEHPK3PXP")
+
+	opened, err := box.Open(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", opened)
+
+	// 相同明文每次加密结果不同
+	again, err := box.Seal("JBSWY3DPEHPK3PXP")
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again)
+
+	// 不同用途的密钥无法解密
+	_, err = NewSecretBox("test-secret", "other").Open(sealed)
+	assert.ErrorIs(t, err, ErrSecretBoxInvalid)
+
+	_, err = box.Open("invalid")
+	assert.ErrorIs(t, err, ErrSecretBoxInvalid)
+}
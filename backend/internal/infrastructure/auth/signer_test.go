@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigner_SignAndVerify(t *testing.T) {
+	signer := NewSigner("test-secret", "email-verification")
+	now := time.Now()
+
+	token := signer.Sign("42:user@example.com", now.Add(time.Hour))
+	payload, err := signer.Verify(token, now)
+	require.NoError(t, err)
+	assert.Equal(t, "42:user@example.com", payload)
+
+	// 载荷中包含分隔符也能正确解析
+	token = signer.Sign("a|b", now.Add(time.Hour))
+	payload, err = signer.Verify(token, now)
+	require.NoError(t, err)
+	assert.Equal(t, "a|b", payload)
+}
+
+func TestSigner_Expired(t *testing.T) {
+	signer := NewSigner("test-secret", "email-verification")
+	now := time.Now()
+
+	token := signer.Sign("42:user@example.com", now.Add(time.Hour))
+	_, err := signer.Verify(token, now.Add(2*time.Hour))
+	assert.ErrorIs(t, err, ErrSignedTokenExpired)
+}
+
+func TestSigner_Tampered(t *testing.T) {
+	signer := NewSigner("test-secret", "email-verification")
+	now := time.Now()
+	token := signer.Sign("42:user@example.com", now.Add(time.Hour))
+
+	// 篡改载荷
+	forged := NewSigner("test-secret", "email-verification").Sign("43:user@example.com", now.Add(time.Hour))
+	_, sig, _ := strings.Cut(token, ".")
+	body, _, _ := strings.Cut(forged, ".")
+	_, err := signer.Verify(body+"."+sig, now)
+	assert.ErrorIs(t, err, ErrSignedTokenInvalid)
+
+	// 不同用途或不同密钥签发的令牌不能通过校验
+	_, err = NewSigner("test-secret", "password-reset").Verify(token, now)
+	assert.ErrorIs(t, err, ErrSignedTokenInvalid)
+	_, err = NewSigner("other-secret", "email-verification").Verify(token, now)
+	assert.ErrorIs(t, err, ErrSignedTokenInvalid)
+
+	for _, bad := range []string{"", "abc", "abc.def", "."} {
+		_, err = signer.Verify(bad, now)
+		assert.ErrorIs(t, err, ErrSignedTokenInvalid, bad)
+	}
+}
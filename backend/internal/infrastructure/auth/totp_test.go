@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHOTP_RFC4226Vectors(t *testing.T) {
+	key := []byte("12345678901234567890")
+	expected := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+	for counter, want := range expected {
+		assert.Equal(t, want, hotp(key, uint64(counter), 6), "counter %d", counter)
+	}
+}
+
+func TestTOTP_RFC6238Vectors(t *testing.T) {
+	key := []byte("12345678901234567890")
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+	}
+	for _, tt := range tests {
+		counter := TOTPCounter(time.Unix(tt.unix, 0))
+		assert.Equal(t, tt.want, hotp(key, uint64(counter), 8), "time %d", tt.unix)
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+
+	code, err := TOTPCode(secret, TOTPCounter(now))
+	require.NoError(t, err)
+	counter, ok := ValidateTOTP(secret, code, now, 1)
+	assert.True(t, ok)
+	assert.Equal(t, TOTPCounter(now), counter)
+
+	// 允许一个时间步的偏差
+	previous, err := TOTPCode(secret, TOTPCounter(now)-1)
+	require.NoError(t, err)
+	counter, ok = ValidateTOTP(secret, previous, now, 1)
+	assert.True(t, ok)
+	assert.Equal(t, TOTPCounter(now)-1, counter)
+
+	// 超出偏差范围
+	_, ok = ValidateTOTP(secret, previous, now.Add(2*TOTPPeriod), 1)
+	assert.False(t, ok)
+
+	_, ok = ValidateTOTP(secret, "12345", now, 1)
+	assert.False(t, ok)
+	_, ok = ValidateTOTP("not base32!", code, now, 1)
+	assert.False(t, ok)
+}
+
+func TestTOTPURI(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	uri, err := url.Parse(TOTPURI("临时邮箱", "user@example.com", secret))
+	require.NoError(t, err)
+
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/临时邮箱:user@example.com", uri.Path)
+	assert.Equal(t, secret, uri.Query().Get("secret"))
+	assert.Equal(t, "临时邮箱", uri.Query().Get("issuer"))
+	assert.Equal(t, "6", uri.Query().Get("digits"))
+	assert.Equal(t, "30", uri.Query().Get("period"))
+}
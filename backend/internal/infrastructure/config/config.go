@@ -2,26 +2,44 @@ package config
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/spf13/viper"
 )
 
+// oidcProviderNamePattern 身份提供方名称只能包含小写字母、数字和连字符
+var oidcProviderNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,49}$`)
+
 // Config 应用配置结构
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	JWT      JWTConfig      `mapstructure:"jwt"`
-	Log      LogConfig      `mapstructure:"log"`
+	Server    ServerConfig    `mapstructure:"server"`
+	Database  DatabaseConfig  `mapstructure:"database"`
+	JWT       JWTConfig       `mapstructure:"jwt"`
+	Auth      AuthConfig      `mapstructure:"auth"`
+	Log       LogConfig       `mapstructure:"log"`
+	Mailbox   MailboxConfig   `mapstructure:"mailbox"`
+	SMTP      SMTPConfig      `mapstructure:"smtp"`
+	Spam      SpamConfig      `mapstructure:"spam"`
+	Sieve     SieveConfig     `mapstructure:"sieve"`
+	Mailer    MailerConfig    `mapstructure:"mailer"`
+	Storage   StorageConfig   `mapstructure:"storage"`
+	DNS       DNSConfig       `mapstructure:"dns"`
+	Scheduler SchedulerConfig `mapstructure:"scheduler"`
+	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Host         string `mapstructure:"host"`
-	Port         int    `mapstructure:"port"`
-	Mode         string `mapstructure:"mode"`         // debug, release
-	ReadTimeout  int    `mapstructure:"read_timeout"` // seconds
-	WriteTimeout int    `mapstructure:"write_timeout"`
+	Host            string `mapstructure:"host"`
+	Port            int    `mapstructure:"port"`
+	Mode            string `mapstructure:"mode"`         // debug, release
+	ReadTimeout     int    `mapstructure:"read_timeout"` // seconds
+	WriteTimeout    int    `mapstructure:"write_timeout"`
+	ShutdownTimeout int    `mapstructure:"shutdown_timeout"` // seconds
+	// TrustedProxies 可信反向代理的IP或CIDR，只有来自这些地址的请求才使用 X-Forwarded-For 确定客户端IP，
+	// 为空时忽略该请求头（限流和登录失败统计都按客户端IP计算）
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
 }
 
 // DatabaseConfig 数据库配置
@@ -39,6 +57,164 @@ type JWTConfig struct {
 	AccessTokenTTL   int    `mapstructure:"access_token_ttl"`   // minutes
 	RefreshTokenTTL  int    `mapstructure:"refresh_token_ttl"`  // minutes
 	Issuer           string `mapstructure:"issuer"`
+	RevocationSync   int    `mapstructure:"revocation_sync"`    // seconds，从数据库同步吊销记录的间隔，0表示只在启动后加载一次
+}
+
+// AuthConfig 账户认证配置
+type AuthConfig struct {
+	FrontendURL          string               `mapstructure:"frontend_url"`           // 前端地址，用于生成邮件中的链接
+	PasswordResetTTL     int                  `mapstructure:"password_reset_ttl"`     // minutes
+	EmailVerificationTTL int                  `mapstructure:"email_verification_ttl"` // minutes
+	AllowUnverifiedLogin bool                 `mapstructure:"allow_unverified_login"` // 是否允许未验证邮箱的用户登录
+	Admin                AdminBootstrapConfig `mapstructure:"admin"`                  // 初始管理员，启动时创建或提升
+	Lockout              LockoutConfig        `mapstructure:"lockout"`                // 登录失败锁定
+	MFA                  MFAConfig            `mapstructure:"mfa"`                    // 两步验证
+	OIDC                 OIDCConfig           `mapstructure:"oidc"`                   // 第三方登录
+}
+
+// LockoutConfig 登录失败锁定配置，按登录邮箱和客户端IP分别统计
+type LockoutConfig struct {
+	Enabled       bool `mapstructure:"enabled"`
+	MaxAttempts   int  `mapstructure:"max_attempts"`    // 单个账户在窗口内允许的失败次数
+	IPMaxAttempts int  `mapstructure:"ip_max_attempts"` // 单个IP在窗口内允许的失败次数
+	Window        int  `mapstructure:"window"`          // minutes，失败计数窗口
+	Duration      int  `mapstructure:"duration"`        // minutes，锁定时长
+	DelayAfter    int  `mapstructure:"delay_after"`     // 连续失败达到该次数后开始递增等待
+	BaseDelay     int  `mapstructure:"base_delay"`      // seconds，首次等待时间，之后每次失败翻倍
+	MaxDelay      int  `mapstructure:"max_delay"`       // seconds，等待时间上限
+}
+
+// MFAConfig 两步验证配置
+type MFAConfig struct {
+	Issuer     string `mapstructure:"issuer"`      // 身份验证器中显示的服务名称
+	PendingTTL int    `mapstructure:"pending_ttl"` // minutes，密码验证通过后完成两步验证的时限
+	Skew       int    `mapstructure:"skew"`        // 允许前后偏移的时间步数
+}
+
+// OIDCConfig OpenID Connect 第三方登录配置
+type OIDCConfig struct {
+	StateTTL         int                  `mapstructure:"state_ttl"`         // minutes，发起登录到回调的时限
+	FrontendCallback string               `mapstructure:"frontend_callback"` // 登录完成后跳转的前端路径，令牌放在URL片段中
+	Providers        []OIDCProviderConfig `mapstructure:"providers"`
+}
+
+// OIDCProviderConfig 身份提供方配置，RedirectURL 指向本服务的 /api/auth/oidc/<name>/callback
+type OIDCProviderConfig struct {
+	Name         string   `mapstructure:"name"`         // 路由中使用的标识，例如 google
+	DisplayName  string   `mapstructure:"display_name"` // 登录按钮上显示的名称
+	Issuer       string   `mapstructure:"issuer"`       // 用于自动发现，例如 https://accounts.google.com
+	ClientID     string   `mapstructure:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	RedirectURL  string   `mapstructure:"redirect_url"`
+	Scopes       []string `mapstructure:"scopes"`       // 为空时使用 openid email profile
+	AllowSignup  bool     `mapstructure:"allow_signup"` // 邮箱未注册时是否自动创建账户
+}
+
+// AdminBootstrapConfig 初始管理员配置，Email为空时跳过；邮箱已注册时只提升角色，不使用Password
+type AdminBootstrapConfig struct {
+	Email    string `mapstructure:"email"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+// MailboxConfig 临时邮箱配置
+type MailboxConfig struct {
+	Domains              []string `mapstructure:"domains"`                // 系统域名，启动时登记为已验证（无需DNS验证）
+	DefaultTTL           int      `mapstructure:"default_ttl"`            // minutes
+	MaxTTL               int      `mapstructure:"max_ttl"`                // minutes
+	RequireVerifiedEmail bool     `mapstructure:"require_verified_email"` // 是否要求用户验证邮箱后才能创建邮箱（需要配置可用的发信服务）
+}
+
+// SMTPConfig SMTP接收服务配置
+type SMTPConfig struct {
+	Enabled        bool   `mapstructure:"enabled"`
+	Host           string `mapstructure:"host"`
+	Port           int    `mapstructure:"port"`
+	Hostname       string `mapstructure:"hostname"`         // 对外宣称的主机名（EHLO/Received头）
+	MaxMessageSize int    `mapstructure:"max_message_size"` // bytes
+	MaxRecipients  int    `mapstructure:"max_recipients"`
+	ReadTimeout    int    `mapstructure:"read_timeout"` // seconds
+	AuthCheck      bool   `mapstructure:"auth_check"`   // 是否对收到的邮件进行 SPF/DKIM/DMARC 验证
+	AuthTimeout    int    `mapstructure:"auth_timeout"` // seconds
+}
+
+// SpamConfig 收信垃圾邮件评分配置（规则文件格式见 spam.RuleSet）
+type SpamConfig struct {
+	Enabled   bool   `mapstructure:"enabled"`
+	RulesFile string `mapstructure:"rules_file"`
+	Timeout   int    `mapstructure:"timeout"` // seconds
+}
+
+// SieveConfig 邮箱 Sieve 脚本配置
+type SieveConfig struct {
+	// AllowRedirect 是否允许脚本使用 redirect 将收到的邮件通过外发邮件服务转发到外部地址，
+	// 开启后服务会以自身身份转发任意来源的邮件，转发的邮件也可能无法通过收件方的 DMARC 检查
+	AllowRedirect bool `mapstructure:"allow_redirect"`
+}
+
+// MailerConfig 外发邮件配置（密码重置等通知邮件）
+type MailerConfig struct {
+	Driver   string `mapstructure:"driver"`   // log, smtp
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"` // 为空时不进行认证
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+	Timeout  int    `mapstructure:"timeout"` // seconds
+}
+
+// StorageConfig 对象存储配置（邮件附件等）
+type StorageConfig struct {
+	Driver string `mapstructure:"driver"` // local, memory
+	Path   string `mapstructure:"path"`   // local驱动的存储目录
+}
+
+// DNSConfig DNS服务商配置（用于自动配置托管域名的DNS记录）
+type DNSConfig struct {
+	Provider   string           `mapstructure:"provider"` // none, cloudflare
+	Cloudflare CloudflareConfig `mapstructure:"cloudflare"`
+	// ProvisionZones 普通用户添加这些区域的子域名时直接自动配置DNS记录，区域本身只有管理员可以配置；
+	// 其他域名需要用户先自行添加验证TXT记录证明所有权，管理员添加的域名不受限制
+	ProvisionZones []string `mapstructure:"provision_zones"`
+}
+
+// CloudflareConfig Cloudflare API配置
+type CloudflareConfig struct {
+	APIToken string `mapstructure:"api_token"` // 需要Zone.Zone读取和Zone.DNS编辑权限
+	BaseURL  string `mapstructure:"base_url"`  // 为空时使用官方API地址
+	TTL      int    `mapstructure:"ttl"`       // seconds，1表示自动
+}
+
+// SchedulerConfig 后台任务配置（调度表达式见 scheduler.Parse）
+type SchedulerConfig struct {
+	Enabled             bool   `mapstructure:"enabled"`
+	ExpireMailboxesSpec string `mapstructure:"expire_mailboxes_spec"`
+	PurgeMessagesSpec   string `mapstructure:"purge_messages_spec"`
+	CollectBlobsSpec    string `mapstructure:"collect_blobs_spec"`
+	PruneJobRunsSpec    string `mapstructure:"prune_job_runs_spec"`
+	PruneSessionsSpec   string `mapstructure:"prune_sessions_spec"`
+	PurgeDomainsSpec    string `mapstructure:"purge_domains_spec"`
+	MessageRetention    int    `mapstructure:"message_retention"`  // minutes，0表示不清理
+	JobRunRetention     int    `mapstructure:"job_run_retention"`  // minutes，0表示不清理
+	PendingDomainTTL    int    `mapstructure:"pending_domain_ttl"` // minutes，用户域名需在此期限内通过验证，0表示不清理
+}
+
+// RateLimitConfig 接口限流配置（令牌桶）
+type RateLimitConfig struct {
+	Enabled  bool            `mapstructure:"enabled"`
+	Driver   string          `mapstructure:"driver"`   // memory
+	Login    RateLimitPolicy `mapstructure:"login"`    // POST /api/auth/login
+	Register RateLimitPolicy `mapstructure:"register"` // POST /api/auth/register
+	Auth     RateLimitPolicy `mapstructure:"auth"`     // 其他公开认证接口（找回密码、重发验证邮件等）
+	Read     RateLimitPolicy `mapstructure:"read"`     // GET/HEAD 请求
+	Write    RateLimitPolicy `mapstructure:"write"`    // 其他请求
+}
+
+// RateLimitPolicy 限流策略：每 Window 秒补充 Requests 个令牌，桶容量为 Burst
+type RateLimitPolicy struct {
+	Requests int `mapstructure:"requests"`
+	Window   int `mapstructure:"window"` // seconds
+	Burst    int `mapstructure:"burst"`  // 0表示与Requests相同
 }
 
 // LogConfig 日志配置
@@ -93,6 +269,8 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("server.mode", "debug")
 	v.SetDefault("server.read_timeout", 30)
 	v.SetDefault("server.write_timeout", 30)
+	v.SetDefault("server.shutdown_timeout", 15)
+	v.SetDefault("server.trusted_proxies", []string{})
 	
 	// 数据库默认配置
 	v.SetDefault("database.driver", "sqlite")
@@ -106,11 +284,111 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("jwt.access_token_ttl", 60)  // 1小时
 	v.SetDefault("jwt.refresh_token_ttl", 10080) // 7天
 	v.SetDefault("jwt.issuer", "temp-mailbox-service")
+	v.SetDefault("jwt.revocation_sync", 10)
+	
+	// 账户认证默认配置
+	v.SetDefault("auth.frontend_url", "http://localhost:3000")
+	v.SetDefault("auth.password_reset_ttl", 30)
+	v.SetDefault("auth.email_verification_ttl", 1440) // 1天
+	v.SetDefault("auth.allow_unverified_login", true)
+	v.SetDefault("auth.admin.email", "")
+	v.SetDefault("auth.admin.username", "admin")
+	v.SetDefault("auth.admin.password", "")
+	v.SetDefault("auth.lockout.enabled", true)
+	v.SetDefault("auth.lockout.max_attempts", 5)
+	v.SetDefault("auth.lockout.ip_max_attempts", 20)
+	v.SetDefault("auth.lockout.window", 15)
+	v.SetDefault("auth.lockout.duration", 15)
+	v.SetDefault("auth.lockout.delay_after", 2)
+	v.SetDefault("auth.lockout.base_delay", 1)
+	v.SetDefault("auth.lockout.max_delay", 30)
+	v.SetDefault("auth.mfa.issuer", "TempMailbox")
+	v.SetDefault("auth.mfa.pending_ttl", 5)
+	v.SetDefault("auth.mfa.skew", 1)
+	v.SetDefault("auth.oidc.state_ttl", 10)
+	v.SetDefault("auth.oidc.frontend_callback", "/oauth/callback")
+	v.SetDefault("auth.oidc.providers", []map[string]interface{}{})
 	
 	// 日志默认配置
 	v.SetDefault("log.level", "info")
 	v.SetDefault("log.format", "text")
 	v.SetDefault("log.output", "stdout")
+	
+	// 临时邮箱默认配置
+	v.SetDefault("mailbox.domains", []string{"localhost"})
+	v.SetDefault("mailbox.default_ttl", 60)  // 1小时
+	v.SetDefault("mailbox.max_ttl", 10080)   // 7天
+	v.SetDefault("mailbox.require_verified_email", false) // 默认的 log 发信驱动不会真正发出验证邮件
+	
+	// SMTP默认配置
+	v.SetDefault("smtp.enabled", true)
+	v.SetDefault("smtp.host", "localhost")
+	v.SetDefault("smtp.port", 2525)
+	v.SetDefault("smtp.hostname", "localhost")
+	v.SetDefault("smtp.max_message_size", 10485760) // 10MB
+	v.SetDefault("smtp.max_recipients", 50)
+	v.SetDefault("smtp.read_timeout", 60)
+	v.SetDefault("smtp.auth_check", true)
+	v.SetDefault("smtp.auth_timeout", 10)
+	
+	// 垃圾邮件评分默认配置
+	v.SetDefault("spam.enabled", false)
+	v.SetDefault("spam.rules_file", "")
+	v.SetDefault("spam.timeout", 10)
+	
+	// Sieve脚本默认配置
+	v.SetDefault("sieve.allow_redirect", false)
+	
+	// 外发邮件默认配置
+	v.SetDefault("mailer.driver", "log")
+	v.SetDefault("mailer.host", "localhost")
+	v.SetDefault("mailer.port", 25)
+	v.SetDefault("mailer.username", "")
+	v.SetDefault("mailer.password", "")
+	v.SetDefault("mailer.from", "临时邮箱 <no-reply@localhost>")
+	v.SetDefault("mailer.timeout", 10)
+	
+	// 对象存储默认配置
+	v.SetDefault("storage.driver", "local")
+	v.SetDefault("storage.path", "./data/blobs")
+	
+	// DNS服务商默认配置
+	v.SetDefault("dns.provider", "none")
+	v.SetDefault("dns.cloudflare.api_token", "")
+	v.SetDefault("dns.cloudflare.base_url", "")
+	v.SetDefault("dns.cloudflare.ttl", 1)
+	v.SetDefault("dns.provision_zones", []string{})
+	
+	// 后台任务默认配置
+	v.SetDefault("scheduler.enabled", true)
+	v.SetDefault("scheduler.expire_mailboxes_spec", "* * * * *") // 每分钟
+	v.SetDefault("scheduler.purge_messages_spec", "0 * * * *")   // 每小时
+	v.SetDefault("scheduler.collect_blobs_spec", "30 3 * * *")   // 每天03:30
+	v.SetDefault("scheduler.prune_job_runs_spec", "0 4 * * *")   // 每天04:00
+	v.SetDefault("scheduler.prune_sessions_spec", "0 5 * * *")   // 每天05:00
+	v.SetDefault("scheduler.purge_domains_spec", "15 * * * *")   // 每小时
+	v.SetDefault("scheduler.message_retention", 43200)           // 30天
+	v.SetDefault("scheduler.job_run_retention", 10080)           // 7天
+	v.SetDefault("scheduler.pending_domain_ttl", 4320)           // 3天
+	
+	// 接口限流默认配置
+	v.SetDefault("rate_limit.enabled", true)
+	v.SetDefault("rate_limit.driver", "memory")
+	v.SetDefault("rate_limit.login.requests", 5)
+	v.SetDefault("rate_limit.login.window", 60)
+	v.SetDefault("rate_limit.login.burst", 5)
+	v.SetDefault("rate_limit.register.requests", 5)
+	v.SetDefault("rate_limit.register.window", 3600)
+	v.SetDefault("rate_limit.register.burst", 3)
+	v.SetDefault("rate_limit.auth.requests", 10)
+	v.SetDefault("rate_limit.auth.window", 600)
+	v.SetDefault("rate_limit.auth.burst", 5)
+	v.SetDefault("rate_limit.read.requests", 300)
+	v.SetDefault("rate_limit.read.window", 60)
+	v.SetDefault("rate_limit.read.burst", 100)
+	v.SetDefault("rate_limit.write.requests", 60)
+	v.SetDefault("rate_limit.write.window", 60)
+	v.SetDefault("rate_limit.write.burst", 30)
 }
 
 // validateConfig 验证配置
@@ -146,6 +424,106 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("访问令牌TTL必须大于0")
 	}
 	
+	if config.JWT.RevocationSync < 0 {
+		return fmt.Errorf("吊销记录同步间隔不能为负数")
+	}
+	
+	// 验证账户认证配置
+	if config.Auth.PasswordResetTTL < 0 || config.Auth.EmailVerificationTTL < 0 {
+		return fmt.Errorf("邮件链接有效期不能为负数")
+	}
+	if lockout := config.Auth.Lockout; lockout.Enabled {
+		if lockout.MaxAttempts <= 0 || lockout.IPMaxAttempts <= 0 || lockout.Window <= 0 || lockout.Duration <= 0 {
+			return fmt.Errorf("登录锁定的失败次数、统计窗口和锁定时长必须大于0")
+		}
+		if lockout.DelayAfter < 0 || lockout.BaseDelay < 0 || lockout.MaxDelay < lockout.BaseDelay {
+			return fmt.Errorf("无效的登录等待配置: 首次%d秒, 上限%d秒", lockout.BaseDelay, lockout.MaxDelay)
+		}
+	}
+	if config.Auth.MFA.PendingTTL < 0 || config.Auth.MFA.Skew < 0 {
+		return fmt.Errorf("两步验证的有效期和时间偏移不能为负数")
+	}
+	providerNames := make(map[string]bool)
+	for _, provider := range config.Auth.OIDC.Providers {
+		if !oidcProviderNamePattern.MatchString(provider.Name) {
+			return fmt.Errorf("无效的身份提供方名称: %q", provider.Name)
+		}
+		if providerNames[provider.Name] {
+			return fmt.Errorf("身份提供方名称重复: %s", provider.Name)
+		}
+		providerNames[provider.Name] = true
+		if provider.Issuer == "" || provider.ClientID == "" || provider.RedirectURL == "" {
+			return fmt.Errorf("身份提供方 %s 缺少 issuer、client_id 或 redirect_url", provider.Name)
+		}
+	}
+	
+	// 验证临时邮箱配置
+	if config.Mailbox.DefaultTTL < 0 || config.Mailbox.MaxTTL < config.Mailbox.DefaultTTL {
+		return fmt.Errorf("无效的邮箱有效期配置: 默认%d分钟, 最大%d分钟", config.Mailbox.DefaultTTL, config.Mailbox.MaxTTL)
+	}
+	
+	// 验证SMTP配置
+	if config.SMTP.Enabled {
+		if config.SMTP.Port <= 0 || config.SMTP.Port > 65535 {
+			return fmt.Errorf("无效的SMTP端口: %d", config.SMTP.Port)
+		}
+		if config.SMTP.MaxMessageSize <= 0 {
+			return fmt.Errorf("SMTP最大邮件大小必须大于0")
+		}
+	}
+	
+	// 验证垃圾邮件评分配置
+	if config.Spam.Enabled && config.Spam.RulesFile == "" {
+		return fmt.Errorf("启用垃圾邮件评分时必须配置规则文件")
+	}
+	
+	// 验证外发邮件配置
+	switch config.Mailer.Driver {
+	case "", "log":
+		// 日志驱动会把重置密码、验证邮箱的链接写入日志
+		if config.Server.Mode == "release" {
+			return fmt.Errorf("生产环境中不能使用log邮件发送驱动，请配置smtp")
+		}
+	case "smtp":
+		if config.Mailer.Host == "" || config.Mailer.From == "" {
+			return fmt.Errorf("使用SMTP发送邮件时必须配置服务器地址和发件地址")
+		}
+	default:
+		return fmt.Errorf("不支持的邮件发送驱动: %s", config.Mailer.Driver)
+	}
+	
+	// 验证后台任务配置
+	if config.Scheduler.MessageRetention < 0 || config.Scheduler.JobRunRetention < 0 || config.Scheduler.PendingDomainTTL < 0 {
+		return fmt.Errorf("数据保留时间不能为负数")
+	}
+	
+	// 验证限流配置
+	if config.RateLimit.Enabled {
+		policies := map[string]RateLimitPolicy{
+			"login":    config.RateLimit.Login,
+			"register": config.RateLimit.Register,
+			"auth":     config.RateLimit.Auth,
+			"read":     config.RateLimit.Read,
+			"write":    config.RateLimit.Write,
+		}
+		for name, policy := range policies {
+			if policy.Requests <= 0 || policy.Window <= 0 || policy.Burst < 0 {
+				return fmt.Errorf("无效的限流策略 %s: 每%d秒%d次, 突发%d", name, policy.Window, policy.Requests, policy.Burst)
+			}
+		}
+	}
+	
+	// 验证DNS服务商配置
+	switch config.DNS.Provider {
+	case "", "none":
+	case "cloudflare":
+		if config.DNS.Cloudflare.APIToken == "" {
+			return fmt.Errorf("使用Cloudflare时必须配置API令牌")
+		}
+	default:
+		return fmt.Errorf("不支持的DNS服务商: %s", config.DNS.Provider)
+	}
+	
 	// 验证日志配置
 	validLogLevels := []string{"debug", "info", "warn", "error"}
 	validLevel := false
@@ -175,4 +553,18 @@ func (c *Config) IsProduction() bool {
 // GetServerAddress 获取服务器地址
 func (c *Config) GetServerAddress() string {
 	return fmt.Sprintf("%s:%d", c.Server.Host, c.Server.Port)
+}
+
+// GetSMTPAddress 获取SMTP服务监听地址
+func (c *Config) GetSMTPAddress() string {
+	return fmt.Sprintf("%s:%d", c.SMTP.Host, c.SMTP.Port)
+}
+
+// GetOIDCCallbackURL 获取第三方登录完成后跳转的前端地址，配置为相对路径时拼接前端地址
+func (c *Config) GetOIDCCallbackURL() string {
+	callback := c.Auth.OIDC.FrontendCallback
+	if strings.HasPrefix(callback, "http://") || strings.HasPrefix(callback, "https://") {
+		return callback
+	}
+	return strings.TrimRight(c.Auth.FrontendURL, "/") + callback
 } 
\ No newline at end of file
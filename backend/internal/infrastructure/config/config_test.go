@@ -25,6 +25,9 @@ func TestLoad_WithDefaults(t *testing.T) {
 	if cfg.JWT.AccessTokenTTL != 60 {
 		t.Errorf("期望访问令牌TTL为 60，得到 %d", cfg.JWT.AccessTokenTTL)
 	}
+	if cfg.Mailbox.RequireVerifiedEmail {
+		t.Error("期望默认不要求验证邮箱即可创建邮箱")
+	}
 }
 
 func TestLoad_WithEnvironmentVariables(t *testing.T) {
@@ -133,6 +136,26 @@ func TestValidateConfig_ProductionWithDefaultSecret(t *testing.T) {
 	}
 }
 
+func TestValidateConfig_ProductionWithLogMailer(t *testing.T) {
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("加载默认配置失败: %v", err)
+	}
+
+	cfg.Server.Mode = "release"
+	cfg.JWT.Secret = "secure-secret-key"
+	if err := validateConfig(cfg); err == nil {
+		t.Error("生产环境使用log邮件发送驱动应该导致验证失败")
+	}
+
+	cfg.Mailer.Driver = "smtp"
+	cfg.Mailer.Host = "smtp.example.com"
+	cfg.Mailer.From = "noreply@example.com"
+	if err := validateConfig(cfg); err != nil {
+		t.Errorf("生产环境配置smtp驱动后验证不应该失败: %v", err)
+	}
+}
+
 func TestValidateConfig_Valid(t *testing.T) {
 	cfg := &Config{
 		Server: ServerConfig{
@@ -186,4 +209,143 @@ func TestGetServerAddress(t *testing.T) {
 	if addr != expected {
 		t.Errorf("期望服务器地址为 '%s'，得到 '%s'", expected, addr)
 	}
-} 
\ No newline at end of file
+} 
+func TestValidateConfig_InvalidMailboxTTL(t *testing.T) {
+	cfg := &Config{
+		Server: ServerConfig{
+			Port: 8080,
+			Mode: "debug",
+		},
+		Database: DatabaseConfig{
+			Driver: "sqlite",
+			DSN:    "./test.db",
+		},
+		JWT: JWTConfig{
+			Secret:          "secure-secret-key",
+			AccessTokenTTL:  60,
+			RefreshTokenTTL: 10080,
+		},
+		Log: LogConfig{
+			Level: "info",
+		},
+		Mailbox: MailboxConfig{
+			DefaultTTL: 120,
+			MaxTTL:     60, // 最大有效期小于默认有效期
+		},
+	}
+
+	err := validateConfig(cfg)
+	if err == nil {
+		t.Error("最大有效期小于默认有效期应该导致验证失败")
+	}
+}
+
+func TestValidateConfig_CloudflareWithoutToken(t *testing.T) {
+	cfg := &Config{
+		Server: ServerConfig{
+			Port: 8080,
+			Mode: "debug",
+		},
+		Database: DatabaseConfig{
+			Driver: "sqlite",
+			DSN:    "./test.db",
+		},
+		JWT: JWTConfig{
+			Secret:          "secure-secret-key",
+			AccessTokenTTL:  60,
+			RefreshTokenTTL: 10080,
+		},
+		Log: LogConfig{
+			Level: "info",
+		},
+		DNS: DNSConfig{
+			Provider: "cloudflare",
+		},
+	}
+
+	err := validateConfig(cfg)
+	if err == nil {
+		t.Error("使用Cloudflare但未配置API令牌应该导致验证失败")
+	}
+
+	cfg.DNS.Cloudflare.APIToken = "token"
+	if err := validateConfig(cfg); err != nil {
+		t.Errorf("配置了API令牌后验证不应该失败: %v", err)
+	}
+}
+
+func TestValidateConfig_InvalidRateLimitPolicy(t *testing.T) {
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("加载默认配置失败: %v", err)
+	}
+
+	cfg.RateLimit.Login.Window = 0
+	if err := validateConfig(cfg); err == nil {
+		t.Error("限流窗口为0应该导致验证失败")
+	}
+
+	cfg.RateLimit.Enabled = false
+	if err := validateConfig(cfg); err != nil {
+		t.Errorf("关闭限流后不应该校验策略: %v", err)
+	}
+}
+
+func TestValidateConfig_OIDCProviders(t *testing.T) {
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("加载默认配置失败: %v", err)
+	}
+	if len(cfg.Auth.OIDC.Providers) != 0 {
+		t.Errorf("默认不应该配置身份提供方，得到 %d 个", len(cfg.Auth.OIDC.Providers))
+	}
+
+	provider := OIDCProviderConfig{
+		Name:        "google",
+		Issuer:      "https://accounts.google.com",
+		ClientID:    "client",
+		RedirectURL: "http://localhost:8080/api/auth/oidc/google/callback",
+	}
+	cfg.Auth.OIDC.Providers = []OIDCProviderConfig{provider}
+	if err := validateConfig(cfg); err != nil {
+		t.Errorf("有效的身份提供方配置验证失败: %v", err)
+	}
+
+	cfg.Auth.OIDC.Providers = []OIDCProviderConfig{provider, provider}
+	if err := validateConfig(cfg); err == nil {
+		t.Error("身份提供方名称重复应该导致验证失败")
+	}
+
+	provider.Name = "Google/SSO"
+	cfg.Auth.OIDC.Providers = []OIDCProviderConfig{provider}
+	if err := validateConfig(cfg); err == nil {
+		t.Error("身份提供方名称包含非法字符应该导致验证失败")
+	}
+
+	provider.Name = "google"
+	provider.ClientID = ""
+	cfg.Auth.OIDC.Providers = []OIDCProviderConfig{provider}
+	if err := validateConfig(cfg); err == nil {
+		t.Error("缺少client_id应该导致验证失败")
+	}
+}
+
+func TestValidateConfig_Spam(t *testing.T) {
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("加载默认配置失败: %v", err)
+	}
+	if cfg.Spam.Enabled {
+		t.Error("默认不应该启用垃圾邮件评分")
+	}
+
+	cfg.Spam.Enabled = true
+	if err := validateConfig(cfg); err == nil {
+		t.Error("启用垃圾邮件评分但未配置规则文件应该导致验证失败")
+	}
+
+	cfg.Spam.RulesFile = "/etc/temp-mailbox/spam.yaml"
+	if err := validateConfig(cfg); err != nil {
+		t.Errorf("配置了规则文件后验证不应该失败: %v", err)
+	}
+}
@@ -3,6 +3,14 @@ package database
 import (
 	"fmt"
 
+	"temp-mailbox-service/internal/domain/apikey"
+	"temp-mailbox-service/internal/domain/job"
+	"temp-mailbox-service/internal/domain/lockout"
+	"temp-mailbox-service/internal/domain/maildomain"
+	"temp-mailbox-service/internal/domain/mailfilter"
+	"temp-mailbox-service/internal/domain/mailbox"
+	"temp-mailbox-service/internal/domain/message"
+	"temp-mailbox-service/internal/domain/session"
 	"temp-mailbox-service/internal/domain/user"
 )
 
@@ -15,6 +23,20 @@ func Migrate() error {
 	// 自动迁移所有模型
 	err := DB.AutoMigrate(
 		&user.User{},
+		&user.RecoveryCode{},
+		&user.Identity{},
+		&session.Session{},
+		&session.Revocation{},
+		&apikey.APIKey{},
+		&lockout.Record{},
+		&maildomain.Domain{},
+		&mailbox.Mailbox{},
+		&message.Message{},
+		&message.Attachment{},
+		&mailfilter.Rule{},
+		&mailfilter.Decision{},
+		&mailfilter.Script{},
+		&job.Run{},
 		// 在这里添加其他需要迁移的模型
 	)
 	
@@ -34,6 +56,20 @@ func DropTables() error {
 	
 	// 删除所有表
 	err := DB.Migrator().DropTable(
+		&job.Run{},
+		&mailfilter.Script{},
+		&mailfilter.Decision{},
+		&mailfilter.Rule{},
+		&message.Attachment{},
+		&message.Message{},
+		&mailbox.Mailbox{},
+		&maildomain.Domain{},
+		&lockout.Record{},
+		&apikey.APIKey{},
+		&session.Revocation{},
+		&session.Session{},
+		&user.Identity{},
+		&user.RecoveryCode{},
 		&user.User{},
 		// 在这里添加其他需要删除的表
 	)
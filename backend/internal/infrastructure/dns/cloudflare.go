@@ -0,0 +1,224 @@
+package dns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"temp-mailbox-service/internal/infrastructure/config"
+)
+
+const (
+	// defaultCloudflareBaseURL Cloudflare API地址
+	defaultCloudflareBaseURL = "https://api.cloudflare.com/client/v4"
+	// cloudflarePageSize 列表接口每页数量
+	cloudflarePageSize = 100
+)
+
+// CloudflareProvider 基于Cloudflare API的DNS服务商实现
+type CloudflareProvider struct {
+	apiToken string
+	baseURL  string
+	ttl      int
+	client   *http.Client
+
+	mu    sync.Mutex
+	zones map[string]string // 记录名或区域名 -> 区域ID
+}
+
+// NewCloudflareProvider 创建Cloudflare DNS服务商实例
+func NewCloudflareProvider(cfg *config.CloudflareConfig) *CloudflareProvider {
+	baseURL := strings.TrimSuffix(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = defaultCloudflareBaseURL
+	}
+	return &CloudflareProvider{
+		apiToken: cfg.APIToken,
+		baseURL:  baseURL,
+		ttl:      cfg.TTL,
+		client:   &http.Client{Timeout: 15 * time.Second},
+		zones:    make(map[string]string),
+	}
+}
+
+// cloudflareResponse Cloudflare API通用响应
+type cloudflareResponse struct {
+	Success bool `json:"success"`
+	Errors  []struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+	Result     json.RawMessage `json:"result"`
+	ResultInfo struct {
+		Page       int `json:"page"`
+		TotalPages int `json:"total_pages"`
+	} `json:"result_info"`
+}
+
+// cloudflareZone Cloudflare区域
+type cloudflareZone struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// ListRecords 列出名称为name的所有记录
+func (p *CloudflareProvider) ListRecords(ctx context.Context, name string) ([]Record, error) {
+	name = canonicalName(name)
+	zoneID, err := p.zoneFor(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("name", name)
+		query.Set("page", fmt.Sprint(page))
+		query.Set("per_page", fmt.Sprint(cloudflarePageSize))
+
+		var batch []Record
+		resp, err := p.do(ctx, http.MethodGet, "/zones/"+zoneID+"/dns_records?"+query.Encode(), nil, &batch)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, batch...)
+		if resp.ResultInfo.TotalPages <= page {
+			break
+		}
+	}
+	return records, nil
+}
+
+// CreateRecord 创建记录
+func (p *CloudflareProvider) CreateRecord(ctx context.Context, record Record) (*Record, error) {
+	record.Name = canonicalName(record.Name)
+	zoneID, err := p.zoneFor(ctx, record.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]interface{}{
+		"type":    record.Type,
+		"name":    record.Name,
+		"content": record.Content,
+		"ttl":     p.recordTTL(record),
+	}
+	if strings.EqualFold(record.Type, "MX") {
+		body["priority"] = record.Priority
+	}
+
+	var created Record
+	if _, err := p.do(ctx, http.MethodPost, "/zones/"+zoneID+"/dns_records", body, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// DeleteRecord 删除记录
+func (p *CloudflareProvider) DeleteRecord(ctx context.Context, record Record) error {
+	if record.ID == "" {
+		return fmt.Errorf("cloudflare: 删除记录需要记录ID")
+	}
+	zoneID, err := p.zoneFor(ctx, record.Name)
+	if err != nil {
+		return err
+	}
+	_, err = p.do(ctx, http.MethodDelete, "/zones/"+zoneID+"/dns_records/"+url.PathEscape(record.ID), nil, nil)
+	return err
+}
+
+// zoneFor 查找包含指定名称的区域ID（从完整名称逐级向上查找）
+func (p *CloudflareProvider) zoneFor(ctx context.Context, name string) (string, error) {
+	name = canonicalName(name)
+	labels := strings.Split(name, ".")
+	for i := 0; i < len(labels)-1; i++ {
+		candidate := strings.Join(labels[i:], ".")
+
+		p.mu.Lock()
+		zoneID, ok := p.zones[candidate]
+		if ok {
+			p.zones[name] = zoneID
+		}
+		p.mu.Unlock()
+		if ok {
+			return zoneID, nil
+		}
+
+		var zones []cloudflareZone
+		if _, err := p.do(ctx, http.MethodGet, "/zones?name="+url.QueryEscape(candidate), nil, &zones); err != nil {
+			return "", err
+		}
+		if len(zones) > 0 {
+			p.mu.Lock()
+			p.zones[candidate] = zones[0].ID
+			p.zones[name] = zones[0].ID
+			p.mu.Unlock()
+			return zones[0].ID, nil
+		}
+	}
+	return "", fmt.Errorf("cloudflare: 账号下没有包含 %s 的区域", name)
+}
+
+// recordTTL 记录TTL，未指定时使用配置值，配置也为空时使用自动TTL
+func (p *CloudflareProvider) recordTTL(record Record) int {
+	if record.TTL > 0 {
+		return record.TTL
+	}
+	if p.ttl > 0 {
+		return p.ttl
+	}
+	return 1 // Cloudflare中1表示自动
+}
+
+// do 发送API请求并解析result字段
+func (p *CloudflareProvider) do(ctx context.Context, method, path string, body interface{}, result interface{}) (*cloudflareResponse, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("cloudflare: 编码请求失败: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.baseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("cloudflare: 创建请求失败: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.apiToken)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cloudflare: 请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var cfResp cloudflareResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 10<<20)).Decode(&cfResp); err != nil {
+		return nil, fmt.Errorf("cloudflare: 解析响应失败（HTTP %d）: %w", resp.StatusCode, err)
+	}
+	if !cfResp.Success {
+		messages := make([]string, 0, len(cfResp.Errors))
+		for _, e := range cfResp.Errors {
+			messages = append(messages, fmt.Sprintf("%d %s", e.Code, e.Message))
+		}
+		return nil, fmt.Errorf("cloudflare: 请求失败（HTTP %d）: %s", resp.StatusCode, strings.Join(messages, "; "))
+	}
+
+	if result != nil && len(cfResp.Result) > 0 {
+		if err := json.Unmarshal(cfResp.Result, result); err != nil {
+			return nil, fmt.Errorf("cloudflare: 解析结果失败: %w", err)
+		}
+	}
+	return &cfResp, nil
+}
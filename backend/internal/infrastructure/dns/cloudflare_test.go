@@ -0,0 +1,215 @@
+package dns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"temp-mailbox-service/internal/infrastructure/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCloudflareToken = "test-token"
+
+// fakeCloudflare 模拟Cloudflare API的测试服务
+type fakeCloudflare struct {
+	mu          sync.Mutex
+	zones       map[string]string   // 区域名 -> 区域ID
+	records     map[string][]Record // 区域ID -> 记录
+	nextID      int
+	zoneQueries int
+}
+
+func newFakeCloudflare() *fakeCloudflare {
+	return &fakeCloudflare{
+		zones:   map[string]string{"example.com": "zone-1"},
+		records: make(map[string][]Record),
+	}
+}
+
+func (f *fakeCloudflare) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer "+testCloudflareToken {
+		writeCloudflare(w, http.StatusForbidden, nil, 0, "Invalid API Token")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "zones" && r.Method == http.MethodGet:
+		f.zoneQueries++
+		var result []cloudflareZone
+		if id, ok := f.zones[r.URL.Query().Get("name")]; ok {
+			result = append(result, cloudflareZone{ID: id, Name: r.URL.Query().Get("name")})
+		}
+		writeCloudflare(w, http.StatusOK, result, 1, "")
+
+	case len(parts) == 3 && parts[2] == "dns_records" && r.Method == http.MethodGet:
+		var matched []Record
+		for _, rec := range f.records[parts[1]] {
+			if rec.Name == r.URL.Query().Get("name") {
+				matched = append(matched, rec)
+			}
+		}
+		// 每页只返回一条记录，以覆盖分页逻辑
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		var result []Record
+		if page >= 1 && page <= len(matched) {
+			result = matched[page-1 : page]
+		}
+		writeCloudflare(w, http.StatusOK, result, len(matched), "")
+
+	case len(parts) == 3 && parts[2] == "dns_records" && r.Method == http.MethodPost:
+		var rec Record
+		if err := json.NewDecoder(r.Body).Decode(&rec); err != nil {
+			writeCloudflare(w, http.StatusBadRequest, nil, 0, "bad json")
+			return
+		}
+		f.nextID++
+		rec.ID = fmt.Sprintf("rec-%d", f.nextID)
+		if rec.Type == "TXT" {
+			rec.Content = `"` + rec.Content + `"` // Cloudflare返回的TXT内容带引号
+		}
+		f.records[parts[1]] = append(f.records[parts[1]], rec)
+		writeCloudflare(w, http.StatusOK, rec, 1, "")
+
+	case len(parts) == 4 && parts[2] == "dns_records" && r.Method == http.MethodDelete:
+		records := f.records[parts[1]]
+		for i, rec := range records {
+			if rec.ID == parts[3] {
+				f.records[parts[1]] = append(records[:i], records[i+1:]...)
+				writeCloudflare(w, http.StatusOK, map[string]string{"id": rec.ID}, 1, "")
+				return
+			}
+		}
+		writeCloudflare(w, http.StatusNotFound, nil, 0, "Record not found")
+
+	default:
+		writeCloudflare(w, http.StatusNotFound, nil, 0, "not found")
+	}
+}
+
+// writeCloudflare 写入Cloudflare格式的响应，totalPages为0时表示失败
+func writeCloudflare(w http.ResponseWriter, status int, result interface{}, totalPages int, errMsg string) {
+	resp := map[string]interface{}{
+		"success":     errMsg == "",
+		"errors":      []map[string]interface{}{},
+		"result":      result,
+		"result_info": map[string]int{"page": 1, "total_pages": totalPages},
+	}
+	if errMsg != "" {
+		resp["errors"] = []map[string]interface{}{{"code": 1000, "message": errMsg}}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func newTestCloudflareProvider(t *testing.T, token string) (*CloudflareProvider, *fakeCloudflare) {
+	fake := newFakeCloudflare()
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	provider := NewCloudflareProvider(&config.CloudflareConfig{
+		APIToken: token,
+		BaseURL:  server.URL + "/",
+		TTL:      300,
+	})
+	return provider, fake
+}
+
+func TestCloudflareProvider_CreateListDelete(t *testing.T) {
+	ctx := context.Background()
+	provider, fake := newTestCloudflareProvider(t, testCloudflareToken)
+
+	created, err := provider.CreateRecord(ctx, Record{Type: "MX", Name: "Mail.Example.com.", Content: "mx.service.test", Priority: 10})
+	require.NoError(t, err)
+	assert.NotEmpty(t, created.ID)
+	assert.Equal(t, "mail.example.com", created.Name)
+	assert.Equal(t, 300, created.TTL)
+	assert.Equal(t, 10, created.Priority)
+
+	_, err = provider.CreateRecord(ctx, Record{Type: "TXT", Name: "mail.example.com", Content: "hello"})
+	require.NoError(t, err)
+
+	records, err := provider.ListRecords(ctx, "mail.example.com")
+	require.NoError(t, err)
+	assert.Len(t, records, 2, "应该读取所有分页")
+
+	require.NoError(t, provider.DeleteRecord(ctx, *created))
+	records, err = provider.ListRecords(ctx, "mail.example.com")
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "TXT", records[0].Type)
+
+	// 首次查找依次查询 mail.example.com 和 example.com，之后使用缓存
+	assert.Equal(t, 2, fake.zoneQueries, "区域ID应该被缓存")
+}
+
+func TestCloudflareProvider_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	provider, _ := newTestCloudflareProvider(t, "wrong-token")
+	_, err := provider.ListRecords(ctx, "example.com")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid API Token")
+
+	provider, _ = newTestCloudflareProvider(t, testCloudflareToken)
+	_, err = provider.CreateRecord(ctx, Record{Type: "MX", Name: "other.org", Content: "mx.service.test"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "other.org")
+
+	err = provider.DeleteRecord(ctx, Record{Name: "example.com"})
+	assert.Error(t, err, "没有记录ID时应该拒绝删除")
+}
+
+func TestEnsureAndRemoveRecord(t *testing.T) {
+	ctx := context.Background()
+	provider, fake := newTestCloudflareProvider(t, testCloudflareToken)
+
+	txt := Record{Type: "TXT", Name: "_verify.example.com", Content: "token=abc"}
+	mx := Record{Type: "MX", Name: "example.com", Content: "mx.service.test", Priority: 10}
+
+	for i := 0; i < 2; i++ {
+		created, err := EnsureRecord(ctx, provider, txt)
+		require.NoError(t, err)
+		assert.Equal(t, i == 0, created != nil, "只有第一次调用创建记录")
+		_, err = EnsureRecord(ctx, provider, mx)
+		require.NoError(t, err)
+	}
+	assert.Len(t, fake.records["zone-1"], 2, "重复调用不应该创建重复记录")
+
+	// 已有的其他MX记录不受影响
+	_, err := provider.CreateRecord(ctx, Record{Type: "MX", Name: "example.com", Content: "backup.mx.test", Priority: 20})
+	require.NoError(t, err)
+
+	require.NoError(t, RemoveRecord(ctx, provider, Record{Type: "MX", Name: "example.com", Content: "MX.Service.Test."}))
+	require.NoError(t, RemoveRecord(ctx, provider, txt))
+	require.NoError(t, RemoveRecord(ctx, provider, txt), "重复删除不应该报错")
+
+	require.Len(t, fake.records["zone-1"], 1)
+	assert.Equal(t, "backup.mx.test", fake.records["zone-1"][0].Content)
+}
+
+func TestNewDNSProvider(t *testing.T) {
+	provider, err := NewDNSProvider(&config.DNSConfig{Provider: "none"})
+	require.NoError(t, err)
+	assert.Nil(t, provider)
+
+	provider, err = NewDNSProvider(&config.DNSConfig{Provider: "cloudflare", Cloudflare: config.CloudflareConfig{APIToken: "x"}})
+	require.NoError(t, err)
+	assert.IsType(t, &CloudflareProvider{}, provider)
+
+	_, err = NewDNSProvider(&config.DNSConfig{Provider: "route53"})
+	assert.Error(t, err)
+}
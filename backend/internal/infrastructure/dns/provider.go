@@ -0,0 +1,83 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"temp-mailbox-service/internal/infrastructure/config"
+)
+
+// Record DNS记录
+type Record struct {
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type"`
+	Name     string `json:"name"`
+	Content  string `json:"content"`
+	Priority int    `json:"priority,omitempty"`
+	TTL      int    `json:"ttl,omitempty"`
+}
+
+// DNSProvider DNS服务商接口，用于自动配置托管域名所需的记录
+type DNSProvider interface {
+	// ListRecords 列出名称为name的所有记录
+	ListRecords(ctx context.Context, name string) ([]Record, error)
+	// CreateRecord 创建记录，返回带有服务商记录ID的记录
+	CreateRecord(ctx context.Context, record Record) (*Record, error)
+	// DeleteRecord 删除记录（根据Name定位所在区域，根据ID删除）
+	DeleteRecord(ctx context.Context, record Record) error
+}
+
+// NewDNSProvider 根据配置创建DNS服务商实例，未配置服务商时返回nil
+func NewDNSProvider(cfg *config.DNSConfig) (DNSProvider, error) {
+	switch cfg.Provider {
+	case "", "none":
+		return nil, nil
+	case "cloudflare":
+		return NewCloudflareProvider(&cfg.Cloudflare), nil
+	default:
+		return nil, fmt.Errorf("不支持的DNS服务商: %s", cfg.Provider)
+	}
+}
+
+// EnsureRecord 确保记录存在，已存在相同类型和内容的记录时不重复创建；
+// 返回本次新创建的记录，记录已存在时返回nil
+func EnsureRecord(ctx context.Context, p DNSProvider, record Record) (*Record, error) {
+	existing, err := p.ListRecords(ctx, record.Name)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range existing {
+		if sameRecord(r, record) {
+			return nil, nil
+		}
+	}
+	return p.CreateRecord(ctx, record)
+}
+
+// RemoveRecord 删除与给定类型和内容相同的记录，记录不存在时不报错
+func RemoveRecord(ctx context.Context, p DNSProvider, record Record) error {
+	existing, err := p.ListRecords(ctx, record.Name)
+	if err != nil {
+		return err
+	}
+	for _, r := range existing {
+		if sameRecord(r, record) {
+			if err := p.DeleteRecord(ctx, r); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sameRecord 比较记录的类型和内容（忽略大小写、末尾的点和TXT引号）
+func sameRecord(a, b Record) bool {
+	if !strings.EqualFold(a.Type, b.Type) {
+		return false
+	}
+	if strings.EqualFold(a.Type, "TXT") {
+		return strings.Trim(a.Content, `"`) == strings.Trim(b.Content, `"`)
+	}
+	return canonicalName(a.Content) == canonicalName(b.Content)
+}
@@ -0,0 +1,80 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"strings"
+)
+
+// Resolver DNS查询接口，便于在测试中替换为不访问网络的实现
+type Resolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+	// LookupIP 查询A/AAAA记录，network 为 ip、ip4 或 ip6
+	LookupIP(ctx context.Context, network, host string) ([]net.IP, error)
+}
+
+// NewResolver 创建使用系统DNS配置的解析器
+func NewResolver() Resolver {
+	return net.DefaultResolver
+}
+
+// StaticResolver 基于内存记录表的解析器，用于测试和本地开发（记录表的键为小写且不带末尾的点）
+type StaticResolver struct {
+	MX  map[string][]*net.MX
+	TXT map[string][]string
+	IP  map[string][]net.IP
+}
+
+// NewStaticResolver 创建空的内存解析器
+func NewStaticResolver() *StaticResolver {
+	return &StaticResolver{
+		MX:  make(map[string][]*net.MX),
+		TXT: make(map[string][]string),
+		IP:  make(map[string][]net.IP),
+	}
+}
+
+// LookupMX 查询MX记录
+func (r *StaticResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	records, ok := r.MX[canonicalName(name)]
+	if !ok {
+		return nil, notFound(name)
+	}
+	return records, nil
+}
+
+// LookupTXT 查询TXT记录
+func (r *StaticResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	records, ok := r.TXT[canonicalName(name)]
+	if !ok {
+		return nil, notFound(name)
+	}
+	return records, nil
+}
+
+// LookupIP 查询A/AAAA记录，按 network 过滤地址族
+func (r *StaticResolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	var ips []net.IP
+	for _, ip := range r.IP[canonicalName(host)] {
+		isV4 := ip.To4() != nil
+		if (network == "ip4" && !isV4) || (network == "ip6" && isV4) {
+			continue
+		}
+		ips = append(ips, ip)
+	}
+	if len(ips) == 0 {
+		return nil, notFound(host)
+	}
+	return ips, nil
+}
+
+// canonicalName 规范化域名（小写、去掉末尾的点）
+func canonicalName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+}
+
+// notFound 构造与标准库一致的“记录不存在”错误
+func notFound(name string) error {
+	return &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
@@ -0,0 +1,55 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// VerifyMX 检查域名的MX记录是否指向指定主机
+func VerifyMX(ctx context.Context, r Resolver, domain, target string) error {
+	records, err := r.LookupMX(ctx, domain)
+	if err != nil {
+		if isNotFound(err) {
+			return fmt.Errorf("未找到 %s 的MX记录", domain)
+		}
+		return fmt.Errorf("查询 %s 的MX记录失败: %w", domain, err)
+	}
+
+	want := canonicalName(target)
+	hosts := make([]string, 0, len(records))
+	for _, mx := range records {
+		host := canonicalName(mx.Host)
+		if host == want {
+			return nil
+		}
+		hosts = append(hosts, host)
+	}
+	return fmt.Errorf("%s 的MX记录未指向 %s（当前: %s）", domain, want, strings.Join(hosts, ", "))
+}
+
+// VerifyTXT 检查指定名称下是否存在给定值的TXT记录
+func VerifyTXT(ctx context.Context, r Resolver, name, value string) error {
+	records, err := r.LookupTXT(ctx, name)
+	if err != nil {
+		if isNotFound(err) {
+			return fmt.Errorf("未找到 %s 的TXT记录", name)
+		}
+		return fmt.Errorf("查询 %s 的TXT记录失败: %w", name, err)
+	}
+
+	for _, txt := range records {
+		if strings.TrimSpace(txt) == value {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s 的TXT记录中未找到验证值", name)
+}
+
+// isNotFound 判断是否为记录不存在错误
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
@@ -0,0 +1,99 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+)
+
+// failingResolver 总是返回临时错误的解析器
+type failingResolver struct{}
+
+func (failingResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	return nil, errors.New("i/o timeout")
+}
+
+func (failingResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	return nil, errors.New("i/o timeout")
+}
+
+func (failingResolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	return nil, errors.New("i/o timeout")
+}
+
+func TestVerifyMX(t *testing.T) {
+	r := NewStaticResolver()
+	r.MX["example.com"] = []*net.MX{
+		{Host: "backup.example.net.", Pref: 20},
+		{Host: "MX.Mail.Example.", Pref: 10},
+	}
+
+	if err := VerifyMX(context.Background(), r, "example.com", "mx.mail.example"); err != nil {
+		t.Errorf("MX记录匹配时不应该失败: %v", err)
+	}
+	if err := VerifyMX(context.Background(), r, "Example.COM.", "mx.mail.example."); err != nil {
+		t.Errorf("域名大小写和末尾的点不应该影响匹配: %v", err)
+	}
+
+	err := VerifyMX(context.Background(), r, "example.com", "other.example")
+	if err == nil {
+		t.Fatal("MX记录不匹配时应该失败")
+	}
+	if !strings.Contains(err.Error(), "backup.example.net") {
+		t.Errorf("错误信息应该包含当前的MX记录，得到: %v", err)
+	}
+
+	if err := VerifyMX(context.Background(), r, "missing.com", "mx.mail.example"); err == nil || !strings.Contains(err.Error(), "未找到") {
+		t.Errorf("缺少MX记录时应该返回未找到错误，得到: %v", err)
+	}
+
+	if err := VerifyMX(context.Background(), failingResolver{}, "example.com", "mx.mail.example"); err == nil || !strings.Contains(err.Error(), "查询") {
+		t.Errorf("查询失败时应该返回查询错误，得到: %v", err)
+	}
+}
+
+func TestVerifyTXT(t *testing.T) {
+	r := NewStaticResolver()
+	r.TXT["_verify.example.com"] = []string{"v=spf1 -all", " token=abc "}
+
+	if err := VerifyTXT(context.Background(), r, "_verify.example.com", "token=abc"); err != nil {
+		t.Errorf("TXT记录匹配时不应该失败: %v", err)
+	}
+	if err := VerifyTXT(context.Background(), r, "_verify.example.com", "token=xyz"); err == nil {
+		t.Error("TXT记录不匹配时应该失败")
+	}
+	if err := VerifyTXT(context.Background(), r, "_verify.other.com", "token=abc"); err == nil || !strings.Contains(err.Error(), "未找到") {
+		t.Errorf("缺少TXT记录时应该返回未找到错误，得到: %v", err)
+	}
+	if err := VerifyTXT(context.Background(), failingResolver{}, "_verify.example.com", "token=abc"); err == nil || !strings.Contains(err.Error(), "查询") {
+		t.Errorf("查询失败时应该返回查询错误，得到: %v", err)
+	}
+}
+
+func TestStaticResolver_NotFound(t *testing.T) {
+	r := NewStaticResolver()
+	_, err := r.LookupMX(context.Background(), "nothing.example")
+	var dnsErr *net.DNSError
+	if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+		t.Errorf("不存在的记录应该返回IsNotFound的DNSError，得到: %v", err)
+	}
+}
+
+func TestStaticResolver_LookupIP(t *testing.T) {
+	r := NewStaticResolver()
+	r.IP["mail.example.com"] = []net.IP{net.ParseIP("192.0.2.1"), net.ParseIP("2001:db8::1")}
+
+	ips, err := r.LookupIP(context.Background(), "ip4", "Mail.Example.com.")
+	if err != nil || len(ips) != 1 || !ips[0].Equal(net.ParseIP("192.0.2.1")) {
+		t.Errorf("应该只返回IPv4地址，得到: %v %v", ips, err)
+	}
+	ips, err = r.LookupIP(context.Background(), "ip", "mail.example.com")
+	if err != nil || len(ips) != 2 {
+		t.Errorf("应该返回全部地址，得到: %v %v", ips, err)
+	}
+	if _, err := r.LookupIP(context.Background(), "ip6", "other.example.com"); err == nil {
+		t.Error("不存在的记录应该返回错误")
+	}
+}
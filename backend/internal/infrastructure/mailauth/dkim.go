@@ -0,0 +1,400 @@
+package mailauth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+)
+
+const (
+	// dkimMaxSignatures 每封邮件最多验证的签名数量
+	dkimMaxSignatures = 5
+	// dkimMinRSABits 接受的最短RSA密钥长度
+	dkimMinRSABits = 1024
+)
+
+// dkimSignature 解析后的 DKIM-Signature 头
+type dkimSignature struct {
+	field       headerField
+	tags        map[string]string
+	hash        crypto.Hash
+	keyType     string
+	domain      string
+	selector    string
+	headers     []string
+	headerCanon string
+	bodyCanon   string
+	bodyLength  int64 // l= 限制的正文长度，-1 表示不限制
+	bodyHash    []byte
+	signature   []byte
+	expires     int64
+}
+
+// VerifyDKIM 验证邮件中的所有 DKIM 签名（最多 dkimMaxSignatures 个），没有签名时返回空列表
+func (v *Verifier) VerifyDKIM(ctx context.Context, header []headerField, body []byte) []DKIMResult {
+	fields := findHeaders(header, "DKIM-Signature")
+	if len(fields) > dkimMaxSignatures {
+		fields = fields[:dkimMaxSignatures]
+	}
+
+	results := make([]DKIMResult, 0, len(fields))
+	for _, field := range fields {
+		results = append(results, v.verifySignature(ctx, header, body, field))
+	}
+	return results
+}
+
+// verifySignature 验证单个签名
+func (v *Verifier) verifySignature(ctx context.Context, header []headerField, body []byte, field headerField) DKIMResult {
+	sig, err := parseDKIMSignature(field)
+	if sig == nil {
+		return DKIMResult{Result: ResultPermError, Detail: err.Error()}
+	}
+	result := DKIMResult{
+		Domain:    sig.domain,
+		Selector:  sig.selector,
+		Algorithm: sig.tags["a"],
+	}
+	if err != nil {
+		result.Result, result.Detail = ResultPermError, err.Error()
+		return result
+	}
+
+	if sig.expires > 0 && v.now().Unix() > sig.expires {
+		result.Result, result.Detail = ResultFail, "签名已过期"
+		return result
+	}
+
+	key, res, err := v.lookupDKIMKey(ctx, sig)
+	if err != nil {
+		result.Result, result.Detail = res, err.Error()
+		return result
+	}
+
+	bodyHash := computeBodyHash(sig, body)
+	if !bytes.Equal(bodyHash, sig.bodyHash) {
+		result.Result, result.Detail = ResultFail, "正文哈希不匹配"
+		return result
+	}
+
+	digest := computeHeaderHash(sig, header)
+	if err := verifyDKIMSignature(key, sig, digest); err != nil {
+		result.Result, result.Detail = ResultFail, "签名验证失败"
+		return result
+	}
+	result.Result = ResultPass
+	return result
+}
+
+// parseDKIMSignature 解析签名头；能解析出 d= 和 s= 但其他标签无效时同时返回签名和错误
+func parseDKIMSignature(field headerField) (*dkimSignature, error) {
+	tags, ok := parseTags(field.value())
+	if !ok {
+		return nil, fmt.Errorf("签名标签格式错误")
+	}
+	sig := &dkimSignature{
+		field:      field,
+		tags:       tags,
+		domain:     strings.ToLower(tags["d"]),
+		selector:   tags["s"],
+		bodyLength: -1,
+	}
+
+	for _, required := range []string{"v", "a", "b", "bh", "d", "h", "s"} {
+		if _, ok := tags[required]; !ok {
+			return sig, fmt.Errorf("缺少 %s= 标签", required)
+		}
+	}
+	if tags["v"] != "1" {
+		return sig, fmt.Errorf("不支持的版本 %s", tags["v"])
+	}
+
+	switch strings.ToLower(tags["a"]) {
+	case "rsa-sha256":
+		sig.keyType, sig.hash = "rsa", crypto.SHA256
+	case "rsa-sha1":
+		sig.keyType, sig.hash = "rsa", crypto.SHA1
+	case "ed25519-sha256":
+		sig.keyType, sig.hash = "ed25519", crypto.SHA256
+	default:
+		return sig, fmt.Errorf("不支持的签名算法 %s", tags["a"])
+	}
+
+	var err error
+	if sig.signature, err = decodeBase64Tag(tags["b"]); err != nil || len(sig.signature) == 0 {
+		return sig, fmt.Errorf("b= 标签格式错误")
+	}
+	if sig.bodyHash, err = decodeBase64Tag(tags["bh"]); err != nil || len(sig.bodyHash) == 0 {
+		return sig, fmt.Errorf("bh= 标签格式错误")
+	}
+
+	for _, name := range strings.Split(tags["h"], ":") {
+		if name = strings.TrimSpace(name); name != "" {
+			sig.headers = append(sig.headers, name)
+		}
+	}
+	signsFrom := false
+	for _, name := range sig.headers {
+		if strings.EqualFold(name, "From") {
+			signsFrom = true
+		}
+	}
+	if !signsFrom {
+		return sig, fmt.Errorf("签名未覆盖 From 头")
+	}
+
+	sig.headerCanon, sig.bodyCanon = "simple", "simple"
+	if c, ok := tags["c"]; ok {
+		headerCanon, bodyCanon, hasBody := strings.Cut(strings.ToLower(c), "/")
+		sig.headerCanon = headerCanon
+		if hasBody {
+			sig.bodyCanon = bodyCanon
+		}
+	}
+	if !isCanonicalization(sig.headerCanon) || !isCanonicalization(sig.bodyCanon) {
+		return sig, fmt.Errorf("不支持的规范化算法 %s", tags["c"])
+	}
+
+	if l, ok := tags["l"]; ok {
+		if sig.bodyLength, err = strconv.ParseInt(l, 10, 64); err != nil || sig.bodyLength < 0 {
+			return sig, fmt.Errorf("l= 标签格式错误")
+		}
+	}
+	if x, ok := tags["x"]; ok {
+		if sig.expires, err = strconv.ParseInt(x, 10, 64); err != nil {
+			return sig, fmt.Errorf("x= 标签格式错误")
+		}
+	}
+	if i, ok := tags["i"]; ok {
+		identityDomain := domainOf(i)
+		if identityDomain != sig.domain && !strings.HasSuffix(identityDomain, "."+sig.domain) {
+			return sig, fmt.Errorf("i= 与 d= 的域名不一致")
+		}
+	}
+	return sig, nil
+}
+
+// lookupDKIMKey 查询签名公钥（<selector>._domainkey.<domain>），返回公钥或失败结果
+func (v *Verifier) lookupDKIMKey(ctx context.Context, sig *dkimSignature) (interface{}, Result, error) {
+	name := sig.selector + "._domainkey." + sig.domain
+	records, found, err := lookupTXT(ctx, v.resolver, name)
+	if err != nil {
+		return nil, ResultTempError, fmt.Errorf("查询公钥 %s 失败: %v", name, err)
+	}
+	if !found || len(records) == 0 {
+		return nil, ResultPermError, fmt.Errorf("公钥 %s 不存在", name)
+	}
+
+	tags, ok := parseTags(strings.Join(records, ""))
+	if !ok {
+		return nil, ResultPermError, fmt.Errorf("公钥记录格式错误")
+	}
+	if version, ok := tags["v"]; ok && version != "DKIM1" {
+		return nil, ResultPermError, fmt.Errorf("公钥版本无效")
+	}
+	keyType := strings.ToLower(tags["k"])
+	if keyType == "" {
+		keyType = "rsa"
+	}
+	if keyType != sig.keyType {
+		return nil, ResultPermError, fmt.Errorf("公钥类型与签名算法不匹配")
+	}
+	if allowed, ok := tags["h"]; ok && !containsFold(strings.Split(allowed, ":"), hashName(sig.hash)) {
+		return nil, ResultPermError, fmt.Errorf("公钥不允许该哈希算法")
+	}
+	if flags, ok := tags["t"]; ok && containsFold(strings.Split(flags, ":"), "s") {
+		if i, ok := sig.tags["i"]; ok && domainOf(i) != sig.domain {
+			return nil, ResultPermError, fmt.Errorf("公钥要求 i= 与 d= 的域名完全一致")
+		}
+	}
+
+	data, err := decodeBase64Tag(tags["p"])
+	if err != nil {
+		return nil, ResultPermError, fmt.Errorf("公钥格式错误")
+	}
+	if len(data) == 0 {
+		return nil, ResultPermError, fmt.Errorf("公钥已撤销")
+	}
+
+	if keyType == "ed25519" {
+		if len(data) != ed25519.PublicKeySize {
+			return nil, ResultPermError, fmt.Errorf("公钥格式错误")
+		}
+		return ed25519.PublicKey(data), ResultNone, nil
+	}
+
+	var rsaKey *rsa.PublicKey
+	if parsed, err := x509.ParsePKIXPublicKey(data); err == nil {
+		rsaKey, _ = parsed.(*rsa.PublicKey)
+	} else if parsed, err := x509.ParsePKCS1PublicKey(data); err == nil {
+		rsaKey = parsed
+	}
+	if rsaKey == nil {
+		return nil, ResultPermError, fmt.Errorf("公钥格式错误")
+	}
+	if rsaKey.N.BitLen() < dkimMinRSABits {
+		return nil, ResultPermError, fmt.Errorf("RSA公钥长度不足%d位", dkimMinRSABits)
+	}
+	return rsaKey, ResultNone, nil
+}
+
+// computeBodyHash 计算规范化后正文的哈希
+func computeBodyHash(sig *dkimSignature, body []byte) []byte {
+	canonical := canonicalizeBody(body, sig.bodyCanon)
+	if sig.bodyLength >= 0 && sig.bodyLength < int64(len(canonical)) {
+		canonical = canonical[:sig.bodyLength]
+	}
+	h := newHash(sig.hash)
+	h.Write(canonical)
+	return h.Sum(nil)
+}
+
+// computeHeaderHash 按 h= 的顺序计算签名覆盖的邮件头哈希（RFC 6376 3.7）
+func computeHeaderHash(sig *dkimSignature, header []headerField) []byte {
+	h := newHash(sig.hash)
+	used := make(map[int]bool)
+	for _, name := range sig.headers {
+		// 同名字段从下往上依次选取，不存在的字段不参与计算
+		for i := len(header) - 1; i >= 0; i-- {
+			if !used[i] && strings.EqualFold(header[i].name, name) {
+				used[i] = true
+				h.Write([]byte(canonicalizeHeader(header[i].raw, sig.headerCanon)))
+				break
+			}
+		}
+	}
+
+	self := canonicalizeHeader(stripSignatureValue(sig.field.raw), sig.headerCanon)
+	h.Write([]byte(strings.TrimRight(self, "\r\n")))
+	return h.Sum(nil)
+}
+
+// verifyDKIMSignature 使用公钥验证签名
+func verifyDKIMSignature(key interface{}, sig *dkimSignature, digest []byte) error {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, sig.hash, digest, sig.signature)
+	case ed25519.PublicKey:
+		if !ed25519.Verify(k, digest, sig.signature) {
+			return fmt.Errorf("ed25519 签名无效")
+		}
+		return nil
+	}
+	return fmt.Errorf("不支持的公钥类型")
+}
+
+// canonicalizeHeader 规范化单个邮件头字段（包含行尾CRLF）
+func canonicalizeHeader(raw, canon string) string {
+	if canon == "simple" {
+		return raw
+	}
+	name, value, _ := strings.Cut(raw, ":")
+	value = strings.ReplaceAll(value, "\r\n", "")
+	value = strings.ReplaceAll(value, "\n", "")
+	value = strings.Join(strings.FieldsFunc(value, isWSP), " ")
+	return strings.ToLower(strings.TrimRight(name, " \t")) + ":" + value + "\r\n"
+}
+
+// canonicalizeBody 规范化正文
+func canonicalizeBody(body []byte, canon string) []byte {
+	lines := strings.Split(strings.ReplaceAll(string(body), "\r\n", "\n"), "\n")
+	if canon == "relaxed" {
+		for i, line := range lines {
+			lines[i] = strings.Join(strings.FieldsFunc(line, isWSP), " ")
+			if line != "" && isWSP(rune(line[0])) && lines[i] != "" {
+				lines[i] = " " + lines[i]
+			}
+		}
+	}
+
+	// 去掉末尾的空行
+	end := len(lines)
+	for end > 0 && lines[end-1] == "" {
+		end--
+	}
+	if end == 0 {
+		if canon == "relaxed" {
+			return nil
+		}
+		return []byte("\r\n")
+	}
+	return []byte(strings.Join(lines[:end], "\r\n") + "\r\n")
+}
+
+// stripSignatureValue 将签名头中 b= 标签的值置空，其余内容保持原样
+func stripSignatureValue(raw string) string {
+	colon := strings.IndexByte(raw, ':')
+	if colon < 0 {
+		return raw
+	}
+	var b strings.Builder
+	b.WriteString(raw[:colon+1])
+	for i, part := range strings.Split(raw[colon+1:], ";") {
+		if i > 0 {
+			b.WriteByte(';')
+		}
+		name, _, ok := strings.Cut(part, "=")
+		if ok && strings.TrimSpace(name) == "b" {
+			b.WriteString(part[:strings.IndexByte(part, '=')+1])
+			continue
+		}
+		b.WriteString(part)
+	}
+	return b.String()
+}
+
+// decodeBase64Tag 解码允许包含空白的 base64 标签值
+func decodeBase64Tag(value string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\t' || r == '\r' || r == '\n' {
+			return -1
+		}
+		return r
+	}, value))
+}
+
+// isCanonicalization 是否为支持的规范化算法
+func isCanonicalization(canon string) bool {
+	return canon == "simple" || canon == "relaxed"
+}
+
+// isWSP 是否为空格或制表符
+func isWSP(r rune) bool {
+	return r == ' ' || r == '\t'
+}
+
+// newHash 创建哈希函数
+func newHash(h crypto.Hash) hash.Hash {
+	if h == crypto.SHA1 {
+		return sha1.New()
+	}
+	return sha256.New()
+}
+
+// hashName 公钥记录 h= 中使用的哈希名称
+func hashName(h crypto.Hash) string {
+	if h == crypto.SHA1 {
+		return "sha1"
+	}
+	return "sha256"
+}
+
+// containsFold 不区分大小写地检查列表中是否包含指定值
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), value) {
+			return true
+		}
+	}
+	return false
+}
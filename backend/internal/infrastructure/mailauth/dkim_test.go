@@ -0,0 +1,229 @@
+package mailauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"temp-mailbox-service/internal/infrastructure/dns"
+)
+
+const testMessageHeader = "Received: from client.example ([192.0.2.1])\r\n" +
+	"\tby mx.local with ESMTP\r\n" +
+	"From: Alice <alice@example.com>\r\n" +
+	"To: bob@example.net\r\n" +
+	"Subject:  Hello \r\n" +
+	"\t World \r\n"
+
+const testMessageBody = "Hi Bob,  \r\n" +
+	"\r\n" +
+	"  See\tyou.\r\n" +
+	"\r\n" +
+	"\r\n"
+
+// testSignedPrefix 签名头中 b= 之前的部分
+const testSignedPrefix = "DKIM-Signature: v=1; a=%s; c=relaxed/relaxed; d=example.com; s=sel;\r\n" +
+	"\th=from:to:subject; bh=%s; b="
+
+// signTestMessage 按 relaxed/relaxed 规范化结果手工构造签名（不依赖被测的规范化实现）
+func signTestMessage(t *testing.T, algorithm string, sign func(digest []byte) []byte) string {
+	t.Helper()
+	canonicalBody := "Hi Bob,\r\n\r\n See you.\r\n"
+	bodySum := sha256.Sum256([]byte(canonicalBody))
+	bh := base64.StdEncoding.EncodeToString(bodySum[:])
+
+	prefix := strings.Replace(strings.Replace(testSignedPrefix, "%s", algorithm, 1), "%s", bh, 1)
+	canonicalHeaders := "from:Alice <alice@example.com>\r\n" +
+		"to:bob@example.net\r\n" +
+		"subject:Hello World\r\n" +
+		"dkim-signature:v=1; a=" + algorithm + "; c=relaxed/relaxed; d=example.com; s=sel; h=from:to:subject; bh=" + bh + "; b="
+	digest := sha256.Sum256([]byte(canonicalHeaders))
+
+	signature := base64.StdEncoding.EncodeToString(sign(digest[:]))
+	// 签名值折行，验证 b= 中的空白会被忽略
+	folded := signature[:20] + "\r\n\t" + signature[20:]
+	return prefix + folded + "\r\n" + testMessageHeader + "\r\n" + testMessageBody
+}
+
+func newRSATestMessage(t *testing.T) (string, *dns.StaticResolver) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := dns.NewStaticResolver()
+	encoded := base64.StdEncoding.EncodeToString(der)
+	// 长公钥拆成多个字符串
+	r.TXT["sel._domainkey.example.com"] = []string{"v=DKIM1; k=rsa; p=" + encoded[:100], encoded[100:]}
+
+	raw := signTestMessage(t, "rsa-sha256", func(digest []byte) []byte {
+		sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sig
+	})
+	return raw, r
+}
+
+func verifyDKIMRaw(v *Verifier, raw string) []DKIMResult {
+	header, body := splitMessage([]byte(raw))
+	return v.VerifyDKIM(context.Background(), header, body)
+}
+
+func TestVerifyDKIM_RSA(t *testing.T) {
+	raw, r := newRSATestMessage(t)
+	v := NewVerifier(r, time.Second)
+
+	results := verifyDKIMRaw(v, raw)
+	if len(results) != 1 || results[0].Result != ResultPass {
+		t.Fatalf("签名应该验证通过，得到: %+v", results)
+	}
+	if results[0].Domain != "example.com" || results[0].Selector != "sel" || results[0].Algorithm != "rsa-sha256" {
+		t.Errorf("结果字段错误: %+v", results[0])
+	}
+
+	// 修改正文
+	tampered := strings.Replace(raw, "See\tyou.", "See you later.", 1)
+	if results := verifyDKIMRaw(v, tampered); results[0].Result != ResultFail || !strings.Contains(results[0].Detail, "正文") {
+		t.Errorf("正文被修改时应该失败，得到: %+v", results[0])
+	}
+
+	// 修改已签名的邮件头
+	tampered = strings.Replace(raw, "Subject:  Hello", "Subject: Goodbye", 1)
+	if results := verifyDKIMRaw(v, tampered); results[0].Result != ResultFail {
+		t.Errorf("邮件头被修改时应该失败，得到: %+v", results[0])
+	}
+
+	// 添加未签名的邮件头不影响结果
+	extra := strings.Replace(raw, "To: bob@example.net\r\n", "To: bob@example.net\r\nX-Extra: 1\r\n", 1)
+	if results := verifyDKIMRaw(v, extra); results[0].Result != ResultPass {
+		t.Errorf("未签名的邮件头不应该影响结果，得到: %+v", results[0])
+	}
+
+	// 正文末尾增加空行不影响结果
+	if results := verifyDKIMRaw(v, raw+"\r\n\r\n"); results[0].Result != ResultPass {
+		t.Errorf("正文末尾的空行不应该影响结果，得到: %+v", results[0])
+	}
+}
+
+func TestVerifyDKIM_Ed25519(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := dns.NewStaticResolver()
+	r.TXT["sel._domainkey.example.com"] = []string{"v=DKIM1; k=ed25519; p=" + base64.StdEncoding.EncodeToString(pub)}
+
+	raw := signTestMessage(t, "ed25519-sha256", func(digest []byte) []byte {
+		return ed25519.Sign(priv, digest)
+	})
+	results := verifyDKIMRaw(NewVerifier(r, time.Second), raw)
+	if len(results) != 1 || results[0].Result != ResultPass {
+		t.Fatalf("ed25519 签名应该验证通过，得到: %+v", results)
+	}
+}
+
+func TestVerifyDKIM_KeyErrors(t *testing.T) {
+	raw, r := newRSATestMessage(t)
+
+	tests := []struct {
+		name   string
+		record []string
+		want   Result
+	}{
+		{"公钥已撤销", []string{"v=DKIM1; k=rsa; p="}, ResultPermError},
+		{"公钥类型不匹配", []string{"v=DKIM1; k=ed25519; p=AAAA"}, ResultPermError},
+		{"公钥不允许sha256", []string{"v=DKIM1; h=sha1; p=" + strings.Join(r.TXT["sel._domainkey.example.com"], "")[18:]}, ResultPermError},
+		{"公钥不存在", nil, ResultPermError},
+	}
+	for _, tt := range tests {
+		resolver := dns.NewStaticResolver()
+		if tt.record != nil {
+			resolver.TXT["sel._domainkey.example.com"] = tt.record
+		}
+		results := verifyDKIMRaw(NewVerifier(resolver, time.Second), raw)
+		if results[0].Result != tt.want {
+			t.Errorf("%s: 期望 %s，得到 %+v", tt.name, tt.want, results[0])
+		}
+	}
+
+	results := verifyDKIMRaw(NewVerifier(failingResolver{}, time.Second), raw)
+	if results[0].Result != ResultTempError {
+		t.Errorf("查询公钥失败时应该返回 temperror，得到 %+v", results[0])
+	}
+}
+
+func TestVerifyDKIM_InvalidSignature(t *testing.T) {
+	v := NewVerifier(dns.NewStaticResolver(), time.Second)
+
+	tests := map[string]string{
+		"缺少标签":    "DKIM-Signature: v=1; a=rsa-sha256; d=example.com; s=sel; h=from; b=AAAA\r\n",
+		"未签名From": "DKIM-Signature: v=1; a=rsa-sha256; d=example.com; s=sel; h=to:subject; bh=AAAA; b=AAAA\r\n",
+		"不支持的算法":  "DKIM-Signature: v=1; a=rsa-md5; d=example.com; s=sel; h=from; bh=AAAA; b=AAAA\r\n",
+		"i与d不一致":  "DKIM-Signature: v=1; a=rsa-sha256; d=example.com; i=@other.com; s=sel; h=from; bh=AAAA; b=AAAA\r\n",
+	}
+	for name, signature := range tests {
+		results := verifyDKIMRaw(v, signature+"From: a@example.com\r\n\r\nbody\r\n")
+		if len(results) != 1 || results[0].Result != ResultPermError {
+			t.Errorf("%s: 应该返回 permerror，得到 %+v", name, results)
+		}
+	}
+
+	if results := verifyDKIMRaw(v, "From: a@example.com\r\n\r\nbody\r\n"); len(results) != 0 {
+		t.Errorf("没有签名时应该返回空列表，得到 %+v", results)
+	}
+}
+
+func TestVerifyDKIM_Expired(t *testing.T) {
+	raw, r := newRSATestMessage(t)
+	raw = strings.Replace(raw, "s=sel;", "s=sel; x=1000;", 1)
+	results := verifyDKIMRaw(NewVerifier(r, time.Second), raw)
+	if results[0].Result != ResultFail || !strings.Contains(results[0].Detail, "过期") {
+		t.Errorf("过期的签名应该失败，得到 %+v", results[0])
+	}
+}
+
+func TestCanonicalization(t *testing.T) {
+	// RFC 6376 3.4.5 示例
+	if got := canonicalizeHeader("A: X\r\n", "relaxed"); got != "a:X\r\n" {
+		t.Errorf("relaxed 邮件头规范化错误: %q", got)
+	}
+	if got := canonicalizeHeader("B : Y\t\r\n\tZ  \r\n", "relaxed"); got != "b:Y Z\r\n" {
+		t.Errorf("relaxed 邮件头规范化错误: %q", got)
+	}
+
+	body := []byte(" C \r\nD \t E\r\n\r\n\r\n")
+	if got := string(canonicalizeBody(body, "relaxed")); got != " C\r\nD E\r\n" {
+		t.Errorf("relaxed 正文规范化错误: %q", got)
+	}
+	if got := string(canonicalizeBody(body, "simple")); got != " C \r\nD \t E\r\n" {
+		t.Errorf("simple 正文规范化错误: %q", got)
+	}
+	if got := string(canonicalizeBody(nil, "simple")); got != "\r\n" {
+		t.Errorf("simple 空正文应该规范化为 CRLF: %q", got)
+	}
+	if got := canonicalizeBody(nil, "relaxed"); len(got) != 0 {
+		t.Errorf("relaxed 空正文应该规范化为空: %q", got)
+	}
+}
+
+func TestStripSignatureValue(t *testing.T) {
+	raw := "DKIM-Signature: v=1; bh=abc=; b=\r\n\tdef=;  \r\n"
+	if got := stripSignatureValue(raw); got != "DKIM-Signature: v=1; bh=abc=; b=;  \r\n" {
+		t.Errorf("应该只清空 b= 的值: %q", got)
+	}
+}
@@ -0,0 +1,184 @@
+package mailauth
+
+import (
+	"context"
+	"net/mail"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// DMARC 策略
+const (
+	PolicyNone       = "none"
+	PolicyQuarantine = "quarantine"
+	PolicyReject     = "reject"
+)
+
+// dmarcRecord 解析后的 DMARC 记录
+type dmarcRecord struct {
+	policy          string
+	subdomainPolicy string
+	strictSPF       bool
+	strictDKIM      bool
+	percent         int
+}
+
+// CheckDMARC 根据邮件头 From 的域名查询 DMARC 策略，并检查 SPF 和 DKIM 结果是否与之对齐
+func (v *Verifier) CheckDMARC(ctx context.Context, header []headerField, spf *SPFResult, dkim []DKIMResult) DMARCResult {
+	fromDomain, detail := headerFromDomain(header)
+	if fromDomain == "" {
+		return DMARCResult{Result: ResultNone, Detail: detail}
+	}
+	result := DMARCResult{Domain: fromDomain}
+
+	record, policyDomain, err := v.lookupDMARC(ctx, fromDomain)
+	if err != nil {
+		result.Result, result.Detail = resultOf(err), err.Error()
+		return result
+	}
+	if record == nil {
+		result.Result, result.Detail = ResultNone, fromDomain+" 没有DMARC记录"
+		return result
+	}
+
+	result.Policy = record.policy
+	if policyDomain != fromDomain && record.subdomainPolicy != "" {
+		result.Policy = record.subdomainPolicy
+	}
+	result.Percent = record.percent
+
+	result.SPFAligned = spf != nil && spf.Result == ResultPass && aligned(spf.Domain, fromDomain, record.strictSPF)
+	for _, r := range dkim {
+		if r.Result == ResultPass && aligned(r.Domain, fromDomain, record.strictDKIM) {
+			result.DKIMAligned = true
+			break
+		}
+	}
+
+	if result.SPFAligned || result.DKIMAligned {
+		result.Result = ResultPass
+		result.Disposition = PolicyNone
+		return result
+	}
+	result.Result = ResultFail
+	result.Disposition = result.Policy
+	result.Detail = "SPF和DKIM均未通过或未与发件人域名对齐"
+	return result
+}
+
+// lookupDMARC 查询 _dmarc.<域名>，不存在时回退到组织域名，返回记录和记录所在的域名
+func (v *Verifier) lookupDMARC(ctx context.Context, fromDomain string) (*dmarcRecord, string, error) {
+	record, err := v.queryDMARC(ctx, fromDomain)
+	if err != nil || record != nil {
+		return record, fromDomain, err
+	}
+
+	org := organizationalDomain(fromDomain)
+	if org == fromDomain {
+		return nil, fromDomain, nil
+	}
+	record, err = v.queryDMARC(ctx, org)
+	return record, org, err
+}
+
+// queryDMARC 查询并解析单个域名的 DMARC 记录，存在多条有效记录时视为没有记录
+func (v *Verifier) queryDMARC(ctx context.Context, domain string) (*dmarcRecord, error) {
+	records, found, err := lookupTXT(ctx, v.resolver, "_dmarc."+domain)
+	if err != nil {
+		return nil, tempError("查询 %s 的DMARC记录失败: %v", domain, err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	var parsed []*dmarcRecord
+	for _, record := range records {
+		if r := parseDMARC(record); r != nil {
+			parsed = append(parsed, r)
+		}
+	}
+	if len(parsed) != 1 {
+		return nil, nil
+	}
+	return parsed[0], nil
+}
+
+// parseDMARC 解析 DMARC 记录，不是有效记录时返回 nil
+func parseDMARC(record string) *dmarcRecord {
+	if !strings.HasPrefix(strings.TrimSpace(record), "v=DMARC1") {
+		return nil
+	}
+	tags, ok := parseTags(record)
+	if !ok || tags["v"] != "DMARC1" {
+		return nil
+	}
+
+	r := &dmarcRecord{percent: 100}
+	r.policy = normalizePolicy(tags["p"])
+	if r.policy == "" {
+		// 策略无效时按 none 处理（RFC 7489 6.6.3）
+		r.policy = PolicyNone
+	}
+	r.subdomainPolicy = normalizePolicy(tags["sp"])
+	r.strictSPF = strings.EqualFold(tags["aspf"], "s")
+	r.strictDKIM = strings.EqualFold(tags["adkim"], "s")
+	if pct, err := strconv.Atoi(tags["pct"]); err == nil && pct >= 0 && pct <= 100 {
+		r.percent = pct
+	}
+	return r
+}
+
+// normalizePolicy 规范化策略取值，无效时返回空字符串
+func normalizePolicy(policy string) string {
+	switch strings.ToLower(policy) {
+	case PolicyNone, PolicyQuarantine, PolicyReject:
+		return strings.ToLower(policy)
+	}
+	return ""
+}
+
+// headerFromDomain 获取邮件头 From 的域名，存在多个或不同域名的发件人时返回空字符串和原因
+func headerFromDomain(header []headerField) (string, string) {
+	fields := findHeaders(header, "From")
+	if len(fields) != 1 {
+		return "", "邮件头 From 不存在或重复"
+	}
+	addresses, err := mail.ParseAddressList(strings.TrimSpace(fields[0].value()))
+	if err != nil || len(addresses) == 0 {
+		return "", "邮件头 From 格式错误"
+	}
+
+	domain := domainOf(addresses[0].Address)
+	for _, addr := range addresses[1:] {
+		if domainOf(addr.Address) != domain {
+			return "", "邮件头 From 包含多个域名"
+		}
+	}
+	if !isValidDomain(domain) {
+		return "", "邮件头 From 域名无效"
+	}
+	return domain, ""
+}
+
+// aligned 检查域名是否对齐：严格模式要求完全一致，宽松模式要求组织域名一致
+func aligned(domain, fromDomain string, strict bool) bool {
+	domain = strings.ToLower(domain)
+	if domain == "" {
+		return false
+	}
+	if strict {
+		return domain == fromDomain
+	}
+	return organizationalDomain(domain) == organizationalDomain(fromDomain)
+}
+
+// organizationalDomain 根据公共后缀列表获取组织域名，例如 mail.example.co.uk -> example.co.uk
+func organizationalDomain(domain string) string {
+	org, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	if err != nil {
+		return domain
+	}
+	return org
+}
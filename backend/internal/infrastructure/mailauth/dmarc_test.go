@@ -0,0 +1,118 @@
+package mailauth
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"temp-mailbox-service/internal/infrastructure/dns"
+)
+
+func newDMARCResolver() *dns.StaticResolver {
+	r := dns.NewStaticResolver()
+	r.TXT["_dmarc.example.com"] = []string{"v=DMARC1; p=reject; sp=quarantine; pct=50"}
+	r.TXT["_dmarc.strict.example"] = []string{"v=DMARC1; p=quarantine; aspf=s; adkim=s"}
+	r.TXT["_dmarc.invalid.example"] = []string{"v=DMARC1; p=bogus"}
+	r.TXT["_dmarc.double.example"] = []string{"v=DMARC1; p=reject", "v=DMARC1; p=none"}
+	return r
+}
+
+func checkDMARC(v *Verifier, from string, spf *SPFResult, dkim []DKIMResult) DMARCResult {
+	header, _ := splitMessage([]byte(from + "Subject: test\r\n\r\nbody\r\n"))
+	return v.CheckDMARC(context.Background(), header, spf, dkim)
+}
+
+func TestCheckDMARC(t *testing.T) {
+	v := NewVerifier(newDMARCResolver(), time.Second)
+	spfPass := &SPFResult{Result: ResultPass, Domain: "bounce.example.com"}
+	spfFail := &SPFResult{Result: ResultFail, Domain: "example.com"}
+	dkimPass := []DKIMResult{{Result: ResultPass, Domain: "example.com"}}
+	dkimOther := []DKIMResult{{Result: ResultPass, Domain: "esp.example"}}
+
+	tests := []struct {
+		name        string
+		from        string
+		spf         *SPFResult
+		dkim        []DKIMResult
+		want        Result
+		policy      string
+		disposition string
+	}{
+		{"DKIM对齐", "From: a@example.com\r\n", spfFail, dkimPass, ResultPass, PolicyReject, PolicyNone},
+		{"SPF宽松对齐", "From: a@example.com\r\n", spfPass, nil, ResultPass, PolicyReject, PolicyNone},
+		{"未对齐", "From: a@example.com\r\n", spfFail, dkimOther, ResultFail, PolicyReject, PolicyReject},
+		{"子域名使用sp", "From: a@news.example.com\r\n", spfFail, nil, ResultFail, PolicyQuarantine, PolicyQuarantine},
+		{"严格模式SPF", "From: a@strict.example\r\n", &SPFResult{Result: ResultPass, Domain: "mail.strict.example"}, nil, ResultFail, PolicyQuarantine, PolicyQuarantine},
+		{"严格模式DKIM", "From: a@strict.example\r\n", spfFail, []DKIMResult{{Result: ResultPass, Domain: "strict.example"}}, ResultPass, PolicyQuarantine, PolicyNone},
+		{"无效策略按none", "From: a@invalid.example\r\n", spfFail, nil, ResultFail, PolicyNone, PolicyNone},
+		{"多条记录", "From: a@double.example\r\n", spfFail, nil, ResultNone, "", ""},
+		{"没有记录", "From: a@none.example\r\n", spfPass, nil, ResultNone, "", ""},
+		{"From重复", "From: a@example.com\r\nFrom: b@example.com\r\n", spfPass, dkimPass, ResultNone, "", ""},
+		{"From缺失", "", spfPass, dkimPass, ResultNone, "", ""},
+	}
+	for _, tt := range tests {
+		got := checkDMARC(v, tt.from, tt.spf, tt.dkim)
+		if got.Result != tt.want || got.Policy != tt.policy || got.Disposition != tt.disposition {
+			t.Errorf("%s: 期望 %s/%s/%s，得到 %+v", tt.name, tt.want, tt.policy, tt.disposition, got)
+		}
+	}
+
+	got := checkDMARC(v, "From: Alice <a@example.com>\r\n", spfFail, dkimPass)
+	if got.Percent != 50 || !got.DKIMAligned || got.SPFAligned {
+		t.Errorf("结果字段错误: %+v", got)
+	}
+}
+
+func TestCheckDMARC_TempError(t *testing.T) {
+	v := NewVerifier(failingResolver{}, time.Second)
+	got := checkDMARC(v, "From: a@example.com\r\n", nil, nil)
+	if got.Result != ResultTempError {
+		t.Errorf("DNS查询失败时应该返回 temperror，得到 %+v", got)
+	}
+}
+
+func TestOrganizationalDomain(t *testing.T) {
+	tests := map[string]string{
+		"mail.example.com":  "example.com",
+		"example.com":       "example.com",
+		"a.b.example.co.uk": "example.co.uk",
+		"example.github.io": "example.github.io",
+	}
+	for domain, want := range tests {
+		if got := organizationalDomain(domain); got != want {
+			t.Errorf("%s: 期望 %s，得到 %s", domain, want, got)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	raw, r := newRSATestMessage(t)
+	r.TXT["example.com"] = []string{"v=spf1 ip4:192.0.2.0/24 -all"}
+	r.TXT["_dmarc.example.com"] = []string{"v=DMARC1; p=reject"}
+	v := NewVerifier(r, time.Second)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	v.now = func() time.Time { return now }
+
+	results := v.Verify(context.Background(), net.ParseIP("192.0.2.1"), "client.example", "bounce@example.com", []byte(raw))
+	if results.SPF.Result != ResultPass || len(results.DKIM) != 1 || results.DKIM[0].Result != ResultPass {
+		t.Fatalf("SPF和DKIM应该通过，得到: %+v", results)
+	}
+	if results.DMARC.Result != ResultPass || !results.DMARC.SPFAligned || !results.DMARC.DKIMAligned {
+		t.Errorf("DMARC应该通过且两者均对齐，得到: %+v", results.DMARC)
+	}
+	if !results.CheckedAt.Equal(now) {
+		t.Errorf("检查时间错误: %v", results.CheckedAt)
+	}
+
+	// 伪造的来源：SPF失败、签名被破坏
+	forged := strings.Replace(raw, "Hi Bob", "Hi Eve", 1)
+	results = v.Verify(context.Background(), net.ParseIP("203.0.113.1"), "client.example", "bounce@example.com", []byte(forged))
+	if results.SPF.Result != ResultFail || results.DKIM[0].Result != ResultFail {
+		t.Fatalf("SPF和DKIM应该失败，得到: %+v", results)
+	}
+	if results.DMARC.Result != ResultFail || results.DMARC.Disposition != PolicyReject {
+		t.Errorf("DMARC应该失败并建议拒收，得到: %+v", results.DMARC)
+	}
+}
@@ -0,0 +1,200 @@
+// Package mailauth 对收到的邮件进行 SPF、DKIM 和 DMARC 验证
+package mailauth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"temp-mailbox-service/internal/infrastructure/dns"
+)
+
+// Result 验证结果，取值与 Authentication-Results 头（RFC 8601）一致
+type Result string
+
+const (
+	ResultNone      Result = "none"
+	ResultPass      Result = "pass"
+	ResultFail      Result = "fail"
+	ResultSoftFail  Result = "softfail"
+	ResultNeutral   Result = "neutral"
+	ResultTempError Result = "temperror"
+	ResultPermError Result = "permerror"
+)
+
+// defaultTimeout 未配置时单封邮件验证的总时限
+const defaultTimeout = 10 * time.Second
+
+// SPFResult SPF 验证结果
+type SPFResult struct {
+	Result Result `json:"result"`
+	Domain string `json:"domain"` // 验证的域名（MAIL FROM 域名，空发件人时为 HELO）
+	Detail string `json:"detail,omitempty"`
+}
+
+// DKIMResult 单个 DKIM 签名的验证结果
+type DKIMResult struct {
+	Result    Result `json:"result"`
+	Domain    string `json:"domain"`    // d= 签名域名
+	Selector  string `json:"selector"`  // s= 选择器
+	Algorithm string `json:"algorithm"` // a= 签名算法
+	Detail    string `json:"detail,omitempty"`
+}
+
+// DMARCResult DMARC 验证结果
+type DMARCResult struct {
+	Result      Result `json:"result"`
+	Domain      string `json:"domain"`                // 邮件头 From 的域名
+	Policy      string `json:"policy,omitempty"`      // 适用的策略：none、quarantine、reject
+	Percent     int    `json:"percent,omitempty"`     // pct= 策略适用比例
+	Disposition string `json:"disposition,omitempty"` // 验证失败时接收方应采取的处理
+	SPFAligned  bool   `json:"spf_aligned"`
+	DKIMAligned bool   `json:"dkim_aligned"`
+	Detail      string `json:"detail,omitempty"`
+}
+
+// Results 单封邮件的验证结果
+type Results struct {
+	SPF       SPFResult    `json:"spf"`
+	DKIM      []DKIMResult `json:"dkim"`
+	DMARC     DMARCResult  `json:"dmarc"`
+	CheckedAt time.Time    `json:"checked_at"`
+}
+
+// Verifier 邮件来源验证器，所有DNS查询都通过注入的解析器完成
+type Verifier struct {
+	resolver dns.Resolver
+	timeout  time.Duration
+	now      func() time.Time
+}
+
+// NewVerifier 创建验证器，timeout 为单封邮件验证的总时限
+func NewVerifier(resolver dns.Resolver, timeout time.Duration) *Verifier {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &Verifier{
+		resolver: resolver,
+		timeout:  timeout,
+		now:      time.Now,
+	}
+}
+
+// Verify 对邮件依次进行 SPF、DKIM 和 DMARC 验证
+func (v *Verifier) Verify(ctx context.Context, ip net.IP, helo, mailFrom string, raw []byte) *Results {
+	ctx, cancel := context.WithTimeout(ctx, v.timeout)
+	defer cancel()
+
+	header, body := splitMessage(raw)
+	results := &Results{
+		SPF:       v.CheckSPF(ctx, ip, helo, mailFrom),
+		DKIM:      v.VerifyDKIM(ctx, header, body),
+		CheckedAt: v.now(),
+	}
+	results.DMARC = v.CheckDMARC(ctx, header, &results.SPF, results.DKIM)
+	return results
+}
+
+// headerField 保留原始格式的邮件头字段（含折行和行尾CRLF）
+type headerField struct {
+	name string
+	raw  string
+}
+
+// value 字段值（冒号之后的部分，未展开折行）
+func (f *headerField) value() string {
+	_, value, _ := strings.Cut(f.raw, ":")
+	return value
+}
+
+// splitMessage 拆分邮件头字段和正文，正文不包含分隔空行
+func splitMessage(raw []byte) ([]headerField, []byte) {
+	var fields []headerField
+	rest := raw
+	for len(rest) > 0 {
+		end := bytes.IndexByte(rest, '\n')
+		var line []byte
+		if end < 0 {
+			line, rest = rest, nil
+		} else {
+			line, rest = rest[:end+1], rest[end+1:]
+		}
+
+		if len(bytes.TrimRight(line, "\r\n")) == 0 {
+			return fields, rest
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(fields) > 0 {
+			fields[len(fields)-1].raw += string(line)
+			continue
+		}
+		name, _, ok := strings.Cut(string(line), ":")
+		if !ok {
+			// 格式错误的行视为正文开始
+			return fields, append(line, rest...)
+		}
+		fields = append(fields, headerField{name: strings.TrimSpace(name), raw: string(line)})
+	}
+	return fields, nil
+}
+
+// findHeaders 按出现顺序返回指定名称的所有字段
+func findHeaders(fields []headerField, name string) []headerField {
+	var found []headerField
+	for _, f := range fields {
+		if strings.EqualFold(f.name, name) {
+			found = append(found, f)
+		}
+	}
+	return found
+}
+
+// parseTags 解析 DKIM/DMARC 形式的 tag=value 列表，重复的标签返回 false
+func parseTags(s string) (map[string]string, bool) {
+	tags := make(map[string]string)
+	for _, part := range strings.Split(s, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, false
+		}
+		name = strings.TrimSpace(name)
+		if _, dup := tags[name]; dup {
+			return nil, false
+		}
+		tags[name] = strings.TrimSpace(value)
+	}
+	return tags, true
+}
+
+// lookupTXT 查询TXT记录，区分“不存在”和临时错误
+func lookupTXT(ctx context.Context, resolver dns.Resolver, name string) ([]string, bool, error) {
+	records, err := resolver.LookupTXT(ctx, name)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return records, true, nil
+}
+
+// isNotFound 是否为“记录不存在”错误
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+// domainOf 获取邮箱地址的域名部分（小写）
+func domainOf(address string) string {
+	at := strings.LastIndex(address, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSuffix(address[at+1:], "."))
+}
@@ -0,0 +1,568 @@
+package mailauth
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const (
+	// spfMaxLookups 单次验证中需要DNS查询的机制和修饰符上限（RFC 7208 4.6.4）
+	spfMaxLookups = 10
+	// spfMaxVoidLookups 返回空结果的DNS查询上限
+	spfMaxVoidLookups = 2
+	// spfMaxMXHosts mx 机制最多检查的MX主机数
+	spfMaxMXHosts = 10
+)
+
+// spfError 终止验证的错误，携带对应的结果
+type spfError struct {
+	result Result
+	detail string
+}
+
+func (e *spfError) Error() string {
+	return e.detail
+}
+
+func permError(format string, args ...interface{}) error {
+	return &spfError{result: ResultPermError, detail: fmt.Sprintf(format, args...)}
+}
+
+func tempError(format string, args ...interface{}) error {
+	return &spfError{result: ResultTempError, detail: fmt.Sprintf(format, args...)}
+}
+
+// spfCheck 单次 SPF 验证的状态
+type spfCheck struct {
+	ctx         context.Context
+	verifier    *Verifier
+	ip          net.IP
+	sender      string // local-part@domain
+	helo        string
+	lookups     int
+	voidLookups int
+}
+
+// spfTerm 解析后的 SPF 机制
+type spfTerm struct {
+	qualifier Result
+	name      string
+	domain    string // 未展开宏的域名参数
+	network   *net.IPNet
+	cidr4     int
+	cidr6     int
+}
+
+// CheckSPF 验证连接IP是否被 MAIL FROM 域名授权发送邮件，空发件人时验证 HELO 域名
+func (v *Verifier) CheckSPF(ctx context.Context, ip net.IP, helo, mailFrom string) SPFResult {
+	sender := mailFrom
+	if sender == "" {
+		sender = "postmaster@" + helo
+	} else if !strings.Contains(sender, "@") {
+		sender = "postmaster@" + sender
+	}
+	domain := domainOf(sender)
+	result := SPFResult{Domain: domain}
+
+	if ip == nil {
+		result.Result = ResultNone
+		result.Detail = "无法确定连接IP"
+		return result
+	}
+	if !isValidDomain(domain) {
+		result.Result = ResultNone
+		result.Detail = "发件人域名无效"
+		return result
+	}
+
+	check := &spfCheck{
+		ctx:      ctx,
+		verifier: v,
+		ip:       ip,
+		sender:   sender,
+		helo:     helo,
+	}
+	result.Result, result.Detail = check.checkHost(domain)
+	return result
+}
+
+// checkHost 实现 RFC 7208 的 check_host() 函数，返回结果和说明
+func (c *spfCheck) checkHost(domain string) (Result, string) {
+	record, err := c.lookupRecord(domain)
+	if err != nil {
+		return resultOf(err), err.Error()
+	}
+	if record == "" {
+		return ResultNone, fmt.Sprintf("%s 没有SPF记录", domain)
+	}
+
+	terms, redirect, err := parseSPF(record)
+	if err != nil {
+		return resultOf(err), err.Error()
+	}
+
+	for _, term := range terms {
+		matched, err := c.match(domain, term)
+		if err != nil {
+			return resultOf(err), err.Error()
+		}
+		if matched {
+			return term.qualifier, fmt.Sprintf("%s 的记录中 %s 机制匹配 %s", domain, term.name, c.ip)
+		}
+	}
+
+	if redirect != "" {
+		target, err := c.expand(redirect, domain)
+		if err == nil {
+			err = c.countLookup()
+		}
+		if err != nil {
+			return resultOf(err), err.Error()
+		}
+		res, detail := c.checkHost(target)
+		if res == ResultNone {
+			return ResultPermError, fmt.Sprintf("redirect 目标 %s 没有SPF记录", target)
+		}
+		return res, detail
+	}
+	return ResultNeutral, fmt.Sprintf("%s 的记录中没有匹配 %s 的机制", domain, c.ip)
+}
+
+// lookupRecord 查询域名的 SPF 记录，不存在时返回空字符串
+func (c *spfCheck) lookupRecord(domain string) (string, error) {
+	records, found, err := lookupTXT(c.ctx, c.verifier.resolver, domain)
+	if err != nil {
+		return "", tempError("查询 %s 的SPF记录失败: %v", domain, err)
+	}
+	if !found {
+		return "", nil
+	}
+
+	var spf []string
+	for _, record := range records {
+		lower := strings.ToLower(record)
+		if lower == "v=spf1" || strings.HasPrefix(lower, "v=spf1 ") {
+			spf = append(spf, record)
+		}
+	}
+	if len(spf) > 1 {
+		return "", permError("%s 存在多条SPF记录", domain)
+	}
+	if len(spf) == 0 {
+		return "", nil
+	}
+	return spf[0], nil
+}
+
+// parseSPF 解析 SPF 记录，返回机制列表和 redirect 修饰符
+func parseSPF(record string) ([]spfTerm, string, error) {
+	fields := strings.Fields(record)[1:]
+	terms := make([]spfTerm, 0, len(fields))
+	redirect := ""
+	seenRedirect, seenExp := false, false
+
+	for _, field := range fields {
+		// 修饰符 name=value
+		if eq := strings.IndexByte(field, '='); eq > 0 && !strings.ContainsAny(field[:eq], ":/") {
+			name := strings.ToLower(field[:eq])
+			switch name {
+			case "redirect":
+				if seenRedirect {
+					return nil, "", permError("redirect 修饰符重复")
+				}
+				seenRedirect = true
+				redirect = field[eq+1:]
+			case "exp":
+				if seenExp {
+					return nil, "", permError("exp 修饰符重复")
+				}
+				seenExp = true
+			}
+			continue
+		}
+
+		term := spfTerm{qualifier: ResultPass}
+		switch field[0] {
+		case '+':
+			field = field[1:]
+		case '-':
+			term.qualifier, field = ResultFail, field[1:]
+		case '~':
+			term.qualifier, field = ResultSoftFail, field[1:]
+		case '?':
+			term.qualifier, field = ResultNeutral, field[1:]
+		}
+
+		name, arg := field, ""
+		if i := strings.IndexAny(field, ":/"); i >= 0 {
+			name, arg = field[:i], field[i:]
+		}
+		term.name = strings.ToLower(name)
+
+		var err error
+		switch term.name {
+		case "all":
+			if arg != "" {
+				err = permError("无效的机制 %s", field)
+			}
+		case "include", "exists":
+			if !strings.HasPrefix(arg, ":") || len(arg) < 2 {
+				err = permError("%s 机制缺少域名", term.name)
+			}
+			term.domain = strings.TrimPrefix(arg, ":")
+		case "a", "mx":
+			term.domain, term.cidr4, term.cidr6, err = parseDualCIDR(arg)
+		case "ptr":
+			term.domain = strings.TrimPrefix(arg, ":")
+		case "ip4", "ip6":
+			term.network, err = parseIPNetwork(term.name, strings.TrimPrefix(arg, ":"))
+		default:
+			err = permError("未知的机制 %s", field)
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		terms = append(terms, term)
+	}
+
+	if seenRedirect {
+		// 存在 all 机制时忽略 redirect
+		for _, term := range terms {
+			if term.name == "all" {
+				redirect = ""
+			}
+		}
+	}
+	return terms, redirect, nil
+}
+
+// parseDualCIDR 解析 a/mx 机制的参数 [:domain][/cidr4][//cidr6]
+func parseDualCIDR(arg string) (string, int, int, error) {
+	cidr4, cidr6 := 32, 128
+	domain := ""
+	if strings.HasPrefix(arg, ":") {
+		domain = arg[1:]
+		if i := strings.IndexByte(domain, '/'); i >= 0 {
+			domain, arg = domain[:i], domain[i:]
+		} else {
+			arg = ""
+		}
+		if domain == "" {
+			return "", 0, 0, permError("机制缺少域名")
+		}
+	}
+
+	if i := strings.Index(arg, "//"); i >= 0 {
+		n, err := strconv.Atoi(arg[i+2:])
+		if err != nil || n < 0 || n > 128 {
+			return "", 0, 0, permError("无效的IPv6前缀长度 %s", arg[i+2:])
+		}
+		cidr6, arg = n, arg[:i]
+	}
+	if strings.HasPrefix(arg, "/") {
+		n, err := strconv.Atoi(arg[1:])
+		if err != nil || n < 0 || n > 32 {
+			return "", 0, 0, permError("无效的IPv4前缀长度 %s", arg[1:])
+		}
+		cidr4 = n
+	} else if arg != "" {
+		return "", 0, 0, permError("无效的机制参数 %s", arg)
+	}
+	return domain, cidr4, cidr6, nil
+}
+
+// parseIPNetwork 解析 ip4/ip6 机制的地址段
+func parseIPNetwork(family, value string) (*net.IPNet, error) {
+	if !strings.Contains(value, "/") {
+		if family == "ip4" {
+			value += "/32"
+		} else {
+			value += "/128"
+		}
+	}
+	ip, network, err := net.ParseCIDR(value)
+	if err != nil || (family == "ip4") != (ip.To4() != nil) {
+		return nil, permError("无效的 %s 地址 %s", family, value)
+	}
+	return network, nil
+}
+
+// match 检查机制是否匹配连接IP
+func (c *spfCheck) match(domain string, term spfTerm) (bool, error) {
+	switch term.name {
+	case "all":
+		return true, nil
+
+	case "ip4", "ip6":
+		return term.network.Contains(c.ip), nil
+
+	case "include":
+		if err := c.countLookup(); err != nil {
+			return false, err
+		}
+		target, err := c.expand(term.domain, domain)
+		if err != nil {
+			return false, err
+		}
+		res, detail := c.checkHost(target)
+		switch res {
+		case ResultPass:
+			return true, nil
+		case ResultFail, ResultSoftFail, ResultNeutral:
+			return false, nil
+		case ResultTempError:
+			return false, tempError("%s", detail)
+		case ResultNone:
+			return false, permError("include 目标 %s 没有SPF记录", target)
+		default:
+			return false, permError("%s", detail)
+		}
+
+	case "a":
+		if err := c.countLookup(); err != nil {
+			return false, err
+		}
+		target, err := c.targetDomain(term.domain, domain)
+		if err != nil {
+			return false, err
+		}
+		return c.matchHost(target, term.cidr4, term.cidr6)
+
+	case "mx":
+		if err := c.countLookup(); err != nil {
+			return false, err
+		}
+		target, err := c.targetDomain(term.domain, domain)
+		if err != nil {
+			return false, err
+		}
+		records, err := c.verifier.resolver.LookupMX(c.ctx, target)
+		if err != nil {
+			if isNotFound(err) {
+				return false, c.countVoidLookup()
+			}
+			return false, tempError("查询 %s 的MX记录失败: %v", target, err)
+		}
+		if len(records) > spfMaxMXHosts {
+			return false, permError("%s 的MX记录超过%d条", target, spfMaxMXHosts)
+		}
+		for _, mx := range records {
+			matched, err := c.matchHost(strings.TrimSuffix(mx.Host, "."), term.cidr4, term.cidr6)
+			if err != nil || matched {
+				return matched, err
+			}
+		}
+		return false, nil
+
+	case "ptr":
+		// RFC 7208 不建议使用 ptr，这里只计入查询次数而不做反向解析
+		return false, c.countLookup()
+
+	case "exists":
+		if err := c.countLookup(); err != nil {
+			return false, err
+		}
+		target, err := c.expand(term.domain, domain)
+		if err != nil {
+			return false, err
+		}
+		ips, err := c.verifier.resolver.LookupIP(c.ctx, "ip4", target)
+		if err != nil {
+			if isNotFound(err) {
+				return false, c.countVoidLookup()
+			}
+			return false, tempError("查询 %s 失败: %v", target, err)
+		}
+		return len(ips) > 0, nil
+	}
+	return false, permError("未知的机制 %s", term.name)
+}
+
+// matchHost 检查连接IP是否在主机的A/AAAA记录网段内
+func (c *spfCheck) matchHost(host string, cidr4, cidr6 int) (bool, error) {
+	network, bits, prefix := "ip6", 128, cidr6
+	if c.ip.To4() != nil {
+		network, bits, prefix = "ip4", 32, cidr4
+	}
+	ips, err := c.verifier.resolver.LookupIP(c.ctx, network, host)
+	if err != nil {
+		if isNotFound(err) {
+			return false, c.countVoidLookup()
+		}
+		return false, tempError("查询 %s 的地址失败: %v", host, err)
+	}
+	mask := net.CIDRMask(prefix, bits)
+	for _, ip := range ips {
+		if ip.Mask(mask).Equal(c.ip.Mask(mask)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// targetDomain 机制未指定域名时使用当前域名
+func (c *spfCheck) targetDomain(spec, domain string) (string, error) {
+	if spec == "" {
+		return domain, nil
+	}
+	return c.expand(spec, domain)
+}
+
+// countLookup 计入一次DNS查询，超过上限时返回 permerror
+func (c *spfCheck) countLookup() error {
+	c.lookups++
+	if c.lookups > spfMaxLookups {
+		return permError("DNS查询次数超过%d次", spfMaxLookups)
+	}
+	return nil
+}
+
+// countVoidLookup 计入一次空结果查询
+func (c *spfCheck) countVoidLookup() error {
+	c.voidLookups++
+	if c.voidLookups > spfMaxVoidLookups {
+		return permError("空结果的DNS查询超过%d次", spfMaxVoidLookups)
+	}
+	return nil
+}
+
+// expand 展开域名参数中的宏（RFC 7208 第7节）
+func (c *spfCheck) expand(spec, domain string) (string, error) {
+	if !strings.Contains(spec, "%") {
+		return strings.TrimSuffix(spec, "."), nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(spec); i++ {
+		if spec[i] != '%' {
+			b.WriteByte(spec[i])
+			continue
+		}
+		if i+1 >= len(spec) {
+			return "", permError("宏格式错误: %s", spec)
+		}
+		i++
+		switch spec[i] {
+		case '%':
+			b.WriteByte('%')
+		case '_':
+			b.WriteByte(' ')
+		case '-':
+			b.WriteString("%20")
+		case '{':
+			end := strings.IndexByte(spec[i:], '}')
+			if end < 0 {
+				return "", permError("宏格式错误: %s", spec)
+			}
+			value, err := c.expandMacro(spec[i+1:i+end], domain)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(value)
+			i += end
+		default:
+			return "", permError("宏格式错误: %s", spec)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "."), nil
+}
+
+// expandMacro 展开单个宏，例如 {ir}、{d2}、{l-}
+func (c *spfCheck) expandMacro(macro, domain string) (string, error) {
+	if macro == "" {
+		return "", permError("空的宏")
+	}
+
+	var value string
+	local, senderDomain, _ := strings.Cut(c.sender, "@")
+	switch strings.ToLower(macro[:1]) {
+	case "s":
+		value = c.sender
+	case "l":
+		value = local
+	case "o":
+		value = senderDomain
+	case "d":
+		value = domain
+	case "i":
+		value = dottedIP(c.ip)
+	case "p":
+		value = "unknown"
+	case "v":
+		value = "in-addr"
+		if c.ip.To4() == nil {
+			value = "ip6"
+		}
+	case "h":
+		value = c.helo
+	default:
+		return "", permError("未知的宏 %s", macro)
+	}
+
+	rest := macro[1:]
+	digits := 0
+	for len(rest) > 0 && rest[0] >= '0' && rest[0] <= '9' {
+		digits = digits*10 + int(rest[0]-'0')
+		rest = rest[1:]
+	}
+	reverse := false
+	if len(rest) > 0 && (rest[0] == 'r' || rest[0] == 'R') {
+		reverse = true
+		rest = rest[1:]
+	}
+	delimiters := "."
+	if rest != "" {
+		if strings.Trim(rest, ".-+,/_=") != "" {
+			return "", permError("无效的宏分隔符 %s", macro)
+		}
+		delimiters = rest
+	}
+
+	parts := strings.FieldsFunc(value, func(r rune) bool { return strings.ContainsRune(delimiters, r) })
+	if reverse {
+		for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+			parts[i], parts[j] = parts[j], parts[i]
+		}
+	}
+	if digits > 0 && digits < len(parts) {
+		parts = parts[len(parts)-digits:]
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// dottedIP 宏 %{i} 的取值：IPv4 为点分十进制，IPv6 为点分隔的半字节
+func dottedIP(ip net.IP) string {
+	if v4 := ip.To4(); v4 != nil {
+		return v4.String()
+	}
+	const hexDigits = "0123456789abcdef"
+	nibbles := make([]string, 0, 32)
+	for _, b := range ip.To16() {
+		nibbles = append(nibbles, string(hexDigits[b>>4]), string(hexDigits[b&0x0f]))
+	}
+	return strings.Join(nibbles, ".")
+}
+
+// resultOf 获取错误对应的验证结果
+func resultOf(err error) Result {
+	if e, ok := err.(*spfError); ok {
+		return e.result
+	}
+	return ResultPermError
+}
+
+// isValidDomain 粗略检查域名格式
+func isValidDomain(domain string) bool {
+	if domain == "" || len(domain) > 253 || !strings.Contains(domain, ".") {
+		return false
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" || len(label) > 63 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,128 @@
+package mailauth
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"temp-mailbox-service/internal/infrastructure/dns"
+)
+
+// failingResolver 总是返回临时错误的解析器
+type failingResolver struct{}
+
+func (failingResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	return nil, errors.New("i/o timeout")
+}
+
+func (failingResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	return nil, errors.New("i/o timeout")
+}
+
+func (failingResolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	return nil, errors.New("i/o timeout")
+}
+
+func newSPFResolver() *dns.StaticResolver {
+	r := dns.NewStaticResolver()
+	r.TXT["example.com"] = []string{"google-site-verification=abc", "v=spf1 ip4:192.0.2.0/24 a:mail.example.com mx include:_spf.example.net ~all"}
+	r.TXT["_spf.example.net"] = []string{"v=spf1 ip6:2001:db8::/32 -all"}
+	r.IP["mail.example.com"] = []net.IP{net.ParseIP("198.51.100.10")}
+	r.MX["example.com"] = []*net.MX{{Host: "mx.example.com.", Pref: 10}}
+	r.IP["mx.example.com"] = []net.IP{net.ParseIP("198.51.100.20")}
+
+	r.TXT["strict.example"] = []string{"v=spf1 ip4:192.0.2.1 -all"}
+	r.TXT["redirect.example"] = []string{"v=spf1 redirect=strict.example"}
+	r.TXT["double.example"] = []string{"v=spf1 -all", "v=spf1 +all"}
+	r.TXT["syntax.example"] = []string{"v=spf1 ip4:not-an-ip -all"}
+	r.TXT["loop.example"] = []string{"v=spf1 include:loop.example -all"}
+	r.TXT["macro.example"] = []string{"v=spf1 exists:%{ir}.%{l1r-}.allow.macro.example -all"}
+	r.IP["1.2.0.192.alice.allow.macro.example"] = []net.IP{net.ParseIP("127.0.0.2")}
+	r.TXT["helo.example"] = []string{"v=spf1 a -all"}
+	r.IP["helo.example"] = []net.IP{net.ParseIP("203.0.113.5")}
+	return r
+}
+
+func TestCheckSPF(t *testing.T) {
+	v := NewVerifier(newSPFResolver(), time.Second)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		ip       string
+		helo     string
+		mailFrom string
+		want     Result
+	}{
+		{"ip4网段", "192.0.2.77", "client.example", "user@example.com", ResultPass},
+		{"a机制", "198.51.100.10", "client.example", "user@example.com", ResultPass},
+		{"mx机制", "198.51.100.20", "client.example", "user@example.com", ResultPass},
+		{"include中的ip6", "2001:db8::25", "client.example", "user@example.com", ResultPass},
+		{"未匹配时使用all", "203.0.113.1", "client.example", "user@example.com", ResultSoftFail},
+		{"严格拒绝", "192.0.2.2", "client.example", "user@strict.example", ResultFail},
+		{"redirect", "192.0.2.1", "client.example", "user@redirect.example", ResultPass},
+		{"没有记录", "192.0.2.1", "client.example", "user@none.example", ResultNone},
+		{"多条记录", "192.0.2.1", "client.example", "user@double.example", ResultPermError},
+		{"语法错误", "192.0.2.1", "client.example", "user@syntax.example", ResultPermError},
+		{"查询次数超限", "192.0.2.1", "client.example", "user@loop.example", ResultPermError},
+		{"宏展开", "192.0.2.1", "client.example", "alice-bob@macro.example", ResultPass},
+		{"宏展开不匹配", "192.0.2.9", "client.example", "alice-bob@macro.example", ResultFail},
+		{"空发件人使用HELO", "203.0.113.5", "helo.example", "", ResultPass},
+	}
+	for _, tt := range tests {
+		got := v.CheckSPF(ctx, net.ParseIP(tt.ip), tt.helo, tt.mailFrom)
+		if got.Result != tt.want {
+			t.Errorf("%s: 期望 %s，得到 %s（%s）", tt.name, tt.want, got.Result, got.Detail)
+		}
+	}
+
+	got := v.CheckSPF(ctx, net.ParseIP("192.0.2.1"), "client.example", "user@example.com")
+	if got.Domain != "example.com" || got.Detail == "" {
+		t.Errorf("结果应该包含域名和说明，得到: %+v", got)
+	}
+}
+
+func TestCheckSPF_TempError(t *testing.T) {
+	v := NewVerifier(failingResolver{}, time.Second)
+	got := v.CheckSPF(context.Background(), net.ParseIP("192.0.2.1"), "client.example", "user@example.com")
+	if got.Result != ResultTempError {
+		t.Errorf("DNS查询失败时应该返回 temperror，得到 %s", got.Result)
+	}
+}
+
+func TestCheckSPF_VoidLookups(t *testing.T) {
+	r := dns.NewStaticResolver()
+	r.TXT["void.example"] = []string{"v=spf1 a:a.void.example a:b.void.example a:c.void.example -all"}
+	v := NewVerifier(r, time.Second)
+
+	got := v.CheckSPF(context.Background(), net.ParseIP("192.0.2.1"), "client.example", "user@void.example")
+	if got.Result != ResultPermError {
+		t.Errorf("空结果查询超过上限时应该返回 permerror，得到 %s", got.Result)
+	}
+}
+
+func TestParseDualCIDR(t *testing.T) {
+	domain, cidr4, cidr6, err := parseDualCIDR(":mail.example.com/24//64")
+	if err != nil || domain != "mail.example.com" || cidr4 != 24 || cidr6 != 64 {
+		t.Errorf("解析结果错误: %q %d %d %v", domain, cidr4, cidr6, err)
+	}
+	domain, cidr4, cidr6, err = parseDualCIDR("/28")
+	if err != nil || domain != "" || cidr4 != 28 || cidr6 != 128 {
+		t.Errorf("解析结果错误: %q %d %d %v", domain, cidr4, cidr6, err)
+	}
+	if _, _, _, err := parseDualCIDR("/33"); err == nil {
+		t.Error("无效的前缀长度应该返回错误")
+	}
+}
+
+func TestDottedIP(t *testing.T) {
+	if got := dottedIP(net.ParseIP("192.0.2.1")); got != "192.0.2.1" {
+		t.Errorf("IPv4 地址格式错误: %s", got)
+	}
+	want := "2.0.0.1.0.d.b.8.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.1"
+	if got := dottedIP(net.ParseIP("2001:db8::1")); got != want {
+		t.Errorf("IPv6 地址格式错误: %s", got)
+	}
+}
@@ -0,0 +1,115 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+
+	"temp-mailbox-service/internal/infrastructure/config"
+)
+
+// Message 待发送的邮件
+type Message struct {
+	To      []string
+	Subject string
+	Body    string // 纯文本正文
+}
+
+// Sender 外发邮件接口
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+	// Forward 原样转发已有的邮件内容，信封发件人使用配置的发件地址
+	Forward(ctx context.Context, to []string, data []byte) error
+}
+
+// NewSender 根据配置创建外发邮件实现
+func NewSender(cfg *config.MailerConfig) (Sender, error) {
+	switch cfg.Driver {
+	case "", "log":
+		return NewLogSender(), nil
+	case "smtp":
+		return NewSMTPSender(cfg)
+	default:
+		return nil, fmt.Errorf("不支持的邮件发送驱动: %s", cfg.Driver)
+	}
+}
+
+// LogSender 只把邮件写入日志的发送实现，用于开发环境
+type LogSender struct{}
+
+// NewLogSender 创建日志发送实现
+func NewLogSender() *LogSender {
+	return &LogSender{}
+}
+
+// Send 将邮件内容写入日志
+func (s *LogSender) Send(ctx context.Context, msg *Message) error {
+	log.Printf("[mailer] To: %s\nSubject: %s\n\n%s", strings.Join(msg.To, ", "), msg.Subject, msg.Body)
+	return nil
+}
+
+// Forward 将转发信息写入日志
+func (s *LogSender) Forward(ctx context.Context, to []string, data []byte) error {
+	log.Printf("[mailer] 转发邮件 To: %s（%d字节）", strings.Join(to, ", "), len(data))
+	return nil
+}
+
+// buildMessage 生成 RFC 5322 格式的邮件内容
+func buildMessage(from *mail.Address, msg *Message, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	writeHeader := func(name, value string) {
+		buf.WriteString(name)
+		buf.WriteString(": ")
+		buf.WriteString(value)
+		buf.WriteString("\r\n")
+	}
+
+	to := make([]string, 0, len(msg.To))
+	for _, addr := range msg.To {
+		parsed, err := mail.ParseAddress(addr)
+		if err != nil {
+			return nil, fmt.Errorf("无效的收件人地址 %q: %w", addr, err)
+		}
+		to = append(to, parsed.String())
+	}
+
+	writeHeader("From", from.String())
+	writeHeader("To", strings.Join(to, ", "))
+	writeHeader("Subject", mime.BEncoding.Encode("UTF-8", msg.Subject))
+	writeHeader("Date", now.Format(time.RFC1123Z))
+	writeHeader("Message-ID", newMessageID(from.Address))
+	writeHeader("MIME-Version", "1.0")
+	writeHeader("Content-Type", "text/plain; charset=UTF-8")
+	writeHeader("Content-Transfer-Encoding", "quoted-printable")
+	buf.WriteString("\r\n")
+
+	body := strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n")
+	w := quotedprintable.NewWriter(&buf)
+	if _, err := w.Write([]byte(body)); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\r\n")
+	return buf.Bytes(), nil
+}
+
+// newMessageID 生成 Message-ID，域名部分取自发件地址
+func newMessageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 && at < len(from)-1 {
+		domain = from[at+1:]
+	}
+	b := make([]byte, 12)
+	rand.Read(b)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(b), domain)
+}
@@ -0,0 +1,140 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+
+	"temp-mailbox-service/internal/infrastructure/config"
+	"temp-mailbox-service/internal/infrastructure/mailparser"
+	"temp-mailbox-service/internal/infrastructure/smtp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureBackend 记录收到的邮件的SMTP后端，充当本地中继服务器
+type captureBackend struct {
+	mu        sync.Mutex
+	envelopes []*smtp.Envelope
+	messages  [][]byte
+}
+
+func (b *captureBackend) CheckRecipient(ctx context.Context, env *smtp.Envelope, rcpt string) error {
+	if rcpt == "reject@example.com" {
+		return smtp.ErrMailboxUnavailable
+	}
+	return nil
+}
+
+func (b *captureBackend) Deliver(ctx context.Context, env *smtp.Envelope, data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.envelopes = append(b.envelopes, env)
+	b.messages = append(b.messages, data)
+	return nil
+}
+
+// startRelay 在本地随机端口启动SMTP服务器
+func startRelay(t *testing.T, backend smtp.Backend) *config.MailerConfig {
+	t.Helper()
+	server := smtp.NewServer(&config.SMTPConfig{
+		Hostname:       "relay.test.local",
+		MaxMessageSize: 1024 * 1024,
+		MaxRecipients:  10,
+		ReadTimeout:    5,
+	}, backend)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.Serve(l)
+	t.Cleanup(func() { server.Close() })
+
+	host, port, _ := net.SplitHostPort(l.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	return &config.MailerConfig{
+		Driver:  "smtp",
+		Host:    host,
+		Port:    portNum,
+		From:    "临时邮箱 <no-reply@mail.test.local>",
+		Timeout: 5,
+	}
+}
+
+func TestSMTPSender_Send(t *testing.T) {
+	backend := &captureBackend{}
+	cfg := startRelay(t, backend)
+
+	sender, err := NewSender(cfg)
+	require.NoError(t, err)
+
+	err = sender.Send(context.Background(), &Message{
+		To:      []string{"user@example.com"},
+		Subject: "重置密码",
+		Body:    "请点击以下链接重置密码：\nhttps://example.com/reset-password?token=abc\n",
+	})
+	require.NoError(t, err)
+
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	require.Len(t, backend.messages, 1)
+	assert.Equal(t, "no-reply@mail.test.local", backend.envelopes[0].From)
+	assert.Equal(t, []string{"user@example.com"}, backend.envelopes[0].Recipients)
+
+	parsed, err := mailparser.Parse(backend.messages[0])
+	require.NoError(t, err)
+	assert.Equal(t, "重置密码", parsed.Subject)
+	assert.Contains(t, parsed.From, "no-reply@mail.test.local")
+	assert.Contains(t, parsed.Text, "https://example.com/reset-password?token=abc")
+	assert.NotEmpty(t, parsed.MessageID)
+}
+
+func TestSMTPSender_Forward(t *testing.T) {
+	backend := &captureBackend{}
+	cfg := startRelay(t, backend)
+
+	sender, err := NewSender(cfg)
+	require.NoError(t, err)
+
+	raw := []byte("From: alice@example.org\r\nTo: inbox@test.local\r\nSubject: hi\r\n\r\nhello\r\n")
+	require.NoError(t, sender.Forward(context.Background(), []string{"bob@example.com"}, raw))
+
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	require.Len(t, backend.messages, 1)
+	assert.Equal(t, "no-reply@mail.test.local", backend.envelopes[0].From)
+	assert.Equal(t, []string{"bob@example.com"}, backend.envelopes[0].Recipients)
+	// 中继服务器会在开头添加 Received 头，原有内容应该保持不变
+	assert.True(t, bytes.HasSuffix(backend.messages[0], raw), "转发的邮件内容应该保持不变")
+}
+
+func TestSMTPSender_RecipientRejected(t *testing.T) {
+	backend := &captureBackend{}
+	cfg := startRelay(t, backend)
+
+	sender, err := NewSMTPSender(cfg)
+	require.NoError(t, err)
+
+	err = sender.Send(context.Background(), &Message{
+		To:      []string{"reject@example.com"},
+		Subject: "hello",
+		Body:    "hello",
+	})
+	assert.Error(t, err)
+	assert.Empty(t, backend.messages)
+}
+
+func TestNewSender(t *testing.T) {
+	sender, err := NewSender(&config.MailerConfig{Driver: "log"})
+	require.NoError(t, err)
+	assert.IsType(t, &LogSender{}, sender)
+
+	_, err = NewSender(&config.MailerConfig{Driver: "sendmail"})
+	assert.Error(t, err)
+
+	_, err = NewSender(&config.MailerConfig{Driver: "smtp", From: "not an address"})
+	assert.Error(t, err)
+}
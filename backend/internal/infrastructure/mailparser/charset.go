@@ -0,0 +1,67 @@
+package mailparser
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// wordDecoder 支持多种字符集的RFC 2047编码字解码器
+var wordDecoder = &mime.WordDecoder{CharsetReader: charsetReader}
+
+// charsetReader 将指定字符集的输入转换为UTF-8
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	charset = strings.ToLower(strings.Trim(strings.TrimSpace(charset), `"`))
+	switch charset {
+	case "", "utf-8", "utf8", "us-ascii", "ascii":
+		return input, nil
+	case "gb2312", "gbk", "cp936", "x-gbk":
+		// 很多客户端声明为GB2312但实际使用GBK/GB18030扩展字符，统一按GB18030解码
+		return simplifiedchinese.GB18030.NewDecoder().Reader(input), nil
+	}
+
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, fmt.Errorf("不支持的字符集: %s", charset)
+	}
+	return enc.NewDecoder().Reader(input), nil
+}
+
+// decodeCharset 将字节内容按字符集解码为UTF-8字符串，失败时尽量保留原文
+func decodeCharset(data []byte, charset string) string {
+	reader, err := charsetReader(charset, strings.NewReader(string(data)))
+	if err != nil {
+		return toValidUTF8(data)
+	}
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		return toValidUTF8(data)
+	}
+	return toValidUTF8(decoded)
+}
+
+// decodeHeader 解码可能包含RFC 2047编码字的头部值
+func decodeHeader(value string) string {
+	decoded, err := wordDecoder.DecodeHeader(value)
+	if err != nil {
+		return toValidUTF8([]byte(value))
+	}
+	if !utf8.ValidString(decoded) {
+		// 部分客户端直接发送未编码的GBK头部
+		return decodeCharset([]byte(decoded), "gb18030")
+	}
+	return decoded
+}
+
+// toValidUTF8 替换非法的UTF-8字节，避免写入数据库失败
+func toValidUTF8(data []byte) string {
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	return strings.ToValidUTF8(string(data), "�")
+}
@@ -0,0 +1,269 @@
+package mailparser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+const (
+	// maxPartDepth 允许的最大multipart嵌套深度
+	maxPartDepth = 10
+	// maxParts 单封邮件允许的最大part数量
+	maxParts = 200
+)
+
+// Message 解析后的邮件
+type Message struct {
+	Header    mail.Header
+	Subject   string
+	From      string
+	To        string
+	Cc        string
+	ReplyTo   string
+	MessageID string
+	Date      *time.Time
+
+	Text        string
+	HTML        string
+	Attachments []*Attachment
+}
+
+// Attachment 解析出的附件
+type Attachment struct {
+	Filename    string
+	ContentType string
+	ContentID   string
+	Inline      bool
+	Data        []byte
+}
+
+// Parse 解析RFC 5322格式的原始邮件
+func Parse(raw []byte) (*Message, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("解析邮件头失败: %w", err)
+	}
+
+	parsed := &Message{
+		Header:    msg.Header,
+		Subject:   decodeHeader(msg.Header.Get("Subject")),
+		From:      decodeAddressList(msg.Header.Get("From")),
+		To:        decodeAddressList(msg.Header.Get("To")),
+		Cc:        decodeAddressList(msg.Header.Get("Cc")),
+		ReplyTo:   decodeAddressList(msg.Header.Get("Reply-To")),
+		MessageID: strings.Trim(strings.TrimSpace(msg.Header.Get("Message-ID")), "<>"),
+	}
+	if date, err := msg.Header.Date(); err == nil {
+		parsed.Date = &date
+	}
+
+	p := &parser{result: parsed}
+	if err := p.walk(textproto.MIMEHeader(msg.Header), msg.Body, 0); err != nil {
+		return nil, err
+	}
+	return parsed, nil
+}
+
+// parser 递归遍历MIME结构的解析器
+type parser struct {
+	result *Message
+	parts  int
+}
+
+// walk 处理单个MIME实体
+func (p *parser) walk(header textproto.MIMEHeader, body io.Reader, depth int) error {
+	p.parts++
+	if depth > maxPartDepth || p.parts > maxParts {
+		return fmt.Errorf("邮件结构过于复杂")
+	}
+
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		// 缺失或无法解析的Content-Type按RFC 2045默认为text/plain
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		boundary := params["boundary"]
+		if boundary == "" {
+			return p.addBody(header, "text/plain", params, body)
+		}
+		return p.walkMultipart(body, boundary, depth)
+	}
+
+	disposition, dispParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	filename := attachmentFilename(dispParams, params)
+
+	isAttachment := disposition == "attachment" || !isTextType(mediaType) ||
+		(disposition == "inline" && filename != "")
+	// 已有同类正文时，带文件名的文本部分视为附件
+	if !isAttachment && filename != "" &&
+		((mediaType == "text/plain" && p.result.Text != "") || (mediaType == "text/html" && p.result.HTML != "")) {
+		isAttachment = true
+	}
+
+	if isAttachment {
+		return p.addAttachment(header, mediaType, filename, disposition, body)
+	}
+	return p.addBody(header, mediaType, params, body)
+}
+
+// walkMultipart 遍历multipart的各个子部分
+func (p *parser) walkMultipart(body io.Reader, boundary string, depth int) error {
+	reader := multipart.NewReader(body, boundary)
+	for {
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			// 截断或格式错误的multipart，保留已解析部分
+			return nil
+		}
+		if err := p.walk(part.Header, part, depth+1); err != nil {
+			return err
+		}
+	}
+}
+
+// addBody 解码文本正文
+func (p *parser) addBody(header textproto.MIMEHeader, mediaType string, params map[string]string, body io.Reader) error {
+	data, err := decodeTransfer(header.Get("Content-Transfer-Encoding"), body)
+	if err != nil {
+		return fmt.Errorf("解码邮件正文失败: %w", err)
+	}
+	text := decodeCharset(data, params["charset"])
+
+	if mediaType == "text/html" {
+		if p.result.HTML == "" {
+			p.result.HTML = text
+		}
+		return nil
+	}
+	if p.result.Text == "" {
+		p.result.Text = text
+	} else {
+		p.result.Text += "\n" + text
+	}
+	return nil
+}
+
+// addAttachment 解码附件内容
+func (p *parser) addAttachment(header textproto.MIMEHeader, mediaType, filename, disposition string, body io.Reader) error {
+	data, err := decodeTransfer(header.Get("Content-Transfer-Encoding"), body)
+	if err != nil {
+		return fmt.Errorf("解码附件失败: %w", err)
+	}
+	if filename == "" {
+		filename = defaultFilename(mediaType, len(p.result.Attachments)+1)
+	}
+
+	contentID := strings.Trim(strings.TrimSpace(header.Get("Content-ID")), "<>")
+	p.result.Attachments = append(p.result.Attachments, &Attachment{
+		Filename:    filename,
+		ContentType: mediaType,
+		ContentID:   contentID,
+		// multipart/related中的内嵌资源通常只有Content-ID而没有Content-Disposition
+		Inline: disposition == "inline" || (disposition == "" && contentID != ""),
+		Data:   data,
+	})
+	return nil
+}
+
+// decodeTransfer 按Content-Transfer-Encoding解码内容
+func decodeTransfer(encoding string, body io.Reader) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return io.ReadAll(base64.NewDecoder(base64.StdEncoding, &base64Cleaner{r: bufio.NewReader(body)}))
+	case "quoted-printable":
+		data, err := io.ReadAll(quotedprintable.NewReader(body))
+		if err != nil && len(data) == 0 {
+			return nil, err
+		}
+		// 容忍不规范的QP编码，返回已解码部分
+		return data, nil
+	default:
+		return io.ReadAll(body)
+	}
+}
+
+// base64Cleaner 过滤base64内容中的空白和非法字符
+type base64Cleaner struct {
+	r *bufio.Reader
+}
+
+// Read 实现io.Reader
+func (c *base64Cleaner) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		b, err := c.r.ReadByte()
+		if err != nil {
+			if n > 0 {
+				return n, nil
+			}
+			return 0, err
+		}
+		if isBase64Char(b) {
+			p[n] = b
+			n++
+		}
+	}
+	return n, nil
+}
+
+// isBase64Char 检查是否为base64字符
+func isBase64Char(b byte) bool {
+	return (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9') ||
+		b == '+' || b == '/' || b == '='
+}
+
+// attachmentFilename 从Content-Disposition或Content-Type中获取文件名
+func attachmentFilename(dispParams, typeParams map[string]string) string {
+	name := dispParams["filename"]
+	if name == "" {
+		name = typeParams["name"]
+	}
+	if name == "" {
+		return ""
+	}
+	name = decodeHeader(name)
+	// 去掉路径部分，防止路径穿越
+	if idx := strings.LastIndexAny(name, `/\`); idx >= 0 {
+		name = name[idx+1:]
+	}
+	return name
+}
+
+// defaultFilename 为没有文件名的附件生成默认名称
+func defaultFilename(mediaType string, index int) string {
+	ext := ".bin"
+	if mediaType == "message/rfc822" {
+		ext = ".eml"
+	} else if exts, err := mime.ExtensionsByType(mediaType); err == nil && len(exts) > 0 {
+		ext = exts[0]
+	}
+	return fmt.Sprintf("attachment-%d%s", index, ext)
+}
+
+// decodeAddressList 解码地址列表头部
+func decodeAddressList(value string) string {
+	if value == "" {
+		return ""
+	}
+	return decodeHeader(value)
+}
+
+// isTextType 检查是否为可作为正文的文本类型
+func isTextType(mediaType string) bool {
+	return mediaType == "text/plain" || mediaType == "text/html"
+}
@@ -0,0 +1,182 @@
+package mailparser
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// crlf 将测试用例中的换行统一转换为CRLF
+func crlf(s string) []byte {
+	return []byte(strings.ReplaceAll(s, "\n", "\r\n"))
+}
+
+// gbkBase64 生成GBK编码后的base64字符串
+func gbkBase64(t *testing.T, s string) string {
+	t.Helper()
+	encoded, err := simplifiedchinese.GBK.NewEncoder().String(s)
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString([]byte(encoded))
+}
+
+func TestParse_PlainTextWithoutContentType(t *testing.T) {
+	raw := crlf(`From: Alice <alice@example.com>
+To: inbox@test.local
+Subject: Hello
+Date: Mon, 02 Jan 2006 15:04:05 +0000
+Message-ID: <abc@example.com>
+
+Hi there.
+`)
+
+	msg, err := Parse(raw)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello", msg.Subject)
+	assert.Equal(t, "Alice <alice@example.com>", msg.From)
+	assert.Equal(t, "abc@example.com", msg.MessageID)
+	require.NotNil(t, msg.Date)
+	assert.Equal(t, 2006, msg.Date.Year())
+	assert.Equal(t, "Hi there.\r\n", msg.Text)
+	assert.Empty(t, msg.HTML)
+	assert.Empty(t, msg.Attachments)
+}
+
+func TestParse_EncodedWordSubjects(t *testing.T) {
+	tests := []struct {
+		name    string
+		subject string
+		want    string
+	}{
+		{name: "UTF-8 Base64", subject: "=?UTF-8?B?5rWL6K+V6YKu5Lu2?=", want: "测试邮件"},
+		{name: "UTF-8 Q编码", subject: "=?utf-8?Q?caf=C3=A9?=", want: "café"},
+		{name: "GBK Base64", subject: "=?GBK?B?" + gbkBase64(t, "验证码通知") + "?=", want: "验证码通知"},
+		{name: "GB2312 Base64", subject: "=?gb2312?B?" + gbkBase64(t, "你好") + "?=", want: "你好"},
+		{name: "多个编码字", subject: "=?UTF-8?B?5L2g?= =?UTF-8?B?5aW9?= world", want: "你好 world"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := crlf("Subject: " + tt.subject + "\n\nbody\n")
+			msg, err := Parse(raw)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, msg.Subject)
+		})
+	}
+}
+
+func TestParse_MultipartAlternative(t *testing.T) {
+	raw := crlf(`From: sender@example.com
+Subject: alt
+MIME-Version: 1.0
+Content-Type: multipart/alternative; boundary="ALT"
+
+--ALT
+Content-Type: text/plain; charset=utf-8
+Content-Transfer-Encoding: quoted-printable
+
+caf=C3=A9 soft=
+break
+--ALT
+Content-Type: text/html; charset=utf-8
+Content-Transfer-Encoding: base64
+
+PHA+5L2g5aW9PC9wPg==
+--ALT--
+`)
+
+	msg, err := Parse(raw)
+	require.NoError(t, err)
+	assert.Equal(t, "café softbreak", strings.TrimSpace(msg.Text))
+	assert.Equal(t, "<p>你好</p>", msg.HTML)
+	assert.Empty(t, msg.Attachments)
+}
+
+func TestParse_MultipartMixedWithAttachments(t *testing.T) {
+	gbkBody := gbkBase64(t, "中文正文")
+	raw := crlf(`From: sender@example.com
+Subject: mixed
+MIME-Version: 1.0
+Content-Type: multipart/mixed; boundary="MIX"
+
+--MIX
+Content-Type: multipart/related; boundary="REL"
+
+--REL
+Content-Type: text/html; charset=gbk
+Content-Transfer-Encoding: base64
+
+` + gbkBody + `
+--REL
+Content-Type: image/png
+Content-Transfer-Encoding: base64
+Content-ID: <logo@example.com>
+
+iVBORw0KGgo=
+--REL--
+--MIX
+Content-Type: application/pdf; name="=?UTF-8?B?5oql5ZGKLnBkZg==?="
+Content-Disposition: attachment; filename="=?UTF-8?B?5oql5ZGKLnBkZg==?="
+Content-Transfer-Encoding: base64
+
+JVBERi0xLjQK
+--MIX
+Content-Type: text/plain; name="notes.txt"
+Content-Disposition: attachment; filename*=UTF-8''%E7%AC%94%E8%AE%B0.txt
+
+plain attachment
+--MIX--
+`)
+
+	msg, err := Parse(raw)
+	require.NoError(t, err)
+	assert.Equal(t, "中文正文", msg.HTML)
+	assert.Empty(t, msg.Text)
+	require.Len(t, msg.Attachments, 3)
+
+	inline := msg.Attachments[0]
+	assert.Equal(t, "image/png", inline.ContentType)
+	assert.Equal(t, "logo@example.com", inline.ContentID)
+	assert.True(t, inline.Inline)
+	assert.Equal(t, "\x89PNG\r\n\x1a\n", string(inline.Data))
+	assert.Equal(t, "attachment-1.png", inline.Filename)
+
+	pdf := msg.Attachments[1]
+	assert.Equal(t, "报告.pdf", pdf.Filename)
+	assert.Equal(t, "application/pdf", pdf.ContentType)
+	assert.False(t, pdf.Inline)
+	assert.Equal(t, "%PDF-1.4\n", string(pdf.Data))
+
+	txt := msg.Attachments[2]
+	assert.Equal(t, "笔记.txt", txt.Filename)
+	assert.Equal(t, "plain attachment", string(txt.Data))
+}
+
+func TestParse_FilenamePathIsStripped(t *testing.T) {
+	raw := crlf(`Content-Type: multipart/mixed; boundary=B
+
+--B
+Content-Type: text/plain
+
+body
+--B
+Content-Type: application/octet-stream
+Content-Disposition: attachment; filename="../../etc/passwd"
+
+data
+--B--
+`)
+
+	msg, err := Parse(raw)
+	require.NoError(t, err)
+	require.Len(t, msg.Attachments, 1)
+	assert.Equal(t, "passwd", msg.Attachments[0].Filename)
+}
+
+func TestParse_InvalidMessage(t *testing.T) {
+	_, err := Parse([]byte("no header separator and no colon"))
+	assert.Error(t, err)
+}
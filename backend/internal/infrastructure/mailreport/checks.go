@@ -0,0 +1,323 @@
+package mailreport
+
+import (
+	"fmt"
+	"math"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"temp-mailbox-service/internal/infrastructure/mailparser"
+
+	"golang.org/x/net/html"
+)
+
+// 邮件头检查状态
+const (
+	StatusOK        = "ok"
+	StatusMissing   = "missing"
+	StatusMalformed = "malformed"
+	StatusWarning   = "warning"
+)
+
+// HeaderCheck 单个邮件头的检查结果
+type HeaderCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Value  string `json:"value,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// ContentCheck 正文结构检查结果
+type ContentCheck struct {
+	HasText        bool     `json:"has_text"`
+	HasHTML        bool     `json:"has_html"`
+	TextLength     int      `json:"text_length"`      // 纯文本部分的字符数
+	HTMLTextLength int      `json:"html_text_length"` // HTML 可见文字的字符数
+	TextHTMLRatio  *float64 `json:"text_html_ratio"`  // 两者都存在时为纯文本与HTML可见文字的长度之比
+	ImageCount     int      `json:"image_count"`
+	LinkCount      int      `json:"link_count"`
+	Attachments    int      `json:"attachments"`
+}
+
+// SizeCheck 邮件大小检查结果
+type SizeCheck struct {
+	Bytes     int `json:"bytes"`
+	HTMLBytes int `json:"html_bytes"`
+}
+
+const (
+	// maxDateSkew 发信时间与接收时间允许的最大差距
+	maxDateSkew = 24 * time.Hour
+	// maxFutureDate 发信时间允许晚于接收时间的最大差距（时钟误差）
+	maxFutureDate = 15 * time.Minute
+	// htmlClipSize Gmail 截断HTML正文的大小
+	htmlClipSize = 102 * 1024
+	// maxMessageSize 超过该大小的邮件可能被部分服务商拒收
+	maxMessageSize = 10 * 1024 * 1024
+	// minImageText 含图片的HTML至少应包含的可见文字字符数
+	minImageText = 100
+)
+
+// messageIDPattern RFC 5322 msg-id：<id-left@id-right>
+var messageIDPattern = regexp.MustCompile(`^<[^<>@\s]+@[^<>@\s]+>$`)
+
+// checkHeaders 检查常用邮件头是否存在且格式正确
+func (r *Report) checkHeaders(header mail.Header) []HeaderCheck {
+	return []HeaderCheck{
+		r.checkFrom(header),
+		r.checkSubject(header),
+		r.checkDate(header),
+		r.checkMessageID(header),
+		r.checkListUnsubscribe(header),
+	}
+}
+
+func (r *Report) checkFrom(header mail.Header) HeaderCheck {
+	check := HeaderCheck{Name: "From", Value: header.Get("From")}
+	switch values := header["From"]; {
+	case len(values) == 0 || strings.TrimSpace(values[0]) == "":
+		check.Status, check.Detail = StatusMissing, "缺少发件人"
+		r.deduct("from_missing", 2.0, check.Detail)
+	case len(values) > 1:
+		check.Status, check.Detail = StatusMalformed, "存在多个 From 头"
+		r.deduct("from_malformed", 1.0, check.Detail)
+	default:
+		if _, err := mail.ParseAddressList(values[0]); err != nil {
+			check.Status, check.Detail = StatusMalformed, "发件人地址格式错误"
+			r.deduct("from_malformed", 1.0, check.Detail)
+		} else {
+			check.Status = StatusOK
+		}
+	}
+	return check
+}
+
+func (r *Report) checkSubject(header mail.Header) HeaderCheck {
+	check := HeaderCheck{Name: "Subject", Value: header.Get("Subject"), Status: StatusOK}
+	if strings.TrimSpace(check.Value) == "" {
+		check.Status, check.Detail = StatusMissing, "缺少邮件主题"
+		r.deduct("subject_missing", 0.5, check.Detail)
+	}
+	return check
+}
+
+func (r *Report) checkDate(header mail.Header) HeaderCheck {
+	check := HeaderCheck{Name: "Date", Value: header.Get("Date")}
+	values := header["Date"]
+	if len(values) == 0 || strings.TrimSpace(values[0]) == "" {
+		check.Status, check.Detail = StatusMissing, "缺少发信时间"
+		r.deduct("date_missing", 1.0, check.Detail)
+		return check
+	}
+	if len(values) > 1 {
+		check.Status, check.Detail = StatusMalformed, "存在多个 Date 头"
+		r.deduct("date_malformed", 1.0, check.Detail)
+		return check
+	}
+	date, err := mail.ParseDate(values[0])
+	if err != nil {
+		check.Status, check.Detail = StatusMalformed, "发信时间不符合 RFC 5322 格式"
+		r.deduct("date_malformed", 1.0, check.Detail)
+		return check
+	}
+
+	check.Status = StatusOK
+	received := r.receivedAt()
+	if received == nil {
+		return check
+	}
+	switch skew := received.Sub(date); {
+	case skew < -maxFutureDate:
+		check.Status, check.Detail = StatusWarning, fmt.Sprintf("发信时间比接收时间晚 %s", (-skew).Round(time.Second))
+		r.deduct("date_skew", 0.5, check.Detail)
+	case skew > maxDateSkew:
+		check.Status, check.Detail = StatusWarning, fmt.Sprintf("发信时间比接收时间早 %s", skew.Round(time.Second))
+		r.deduct("date_skew", 0.5, check.Detail)
+	}
+	return check
+}
+
+// receivedAt 最后一跳（本服务器）的接收时间
+func (r *Report) receivedAt() *time.Time {
+	for i := len(r.Hops) - 1; i >= 0; i-- {
+		if r.Hops[i].Time != nil {
+			return r.Hops[i].Time
+		}
+	}
+	return nil
+}
+
+func (r *Report) checkMessageID(header mail.Header) HeaderCheck {
+	check := HeaderCheck{Name: "Message-ID", Value: header.Get("Message-ID")}
+	values := header["Message-Id"]
+	switch {
+	case len(values) == 0 || strings.TrimSpace(values[0]) == "":
+		check.Status, check.Detail = StatusMissing, "缺少 Message-ID"
+		r.deduct("message_id_missing", 1.0, check.Detail)
+	case len(values) > 1:
+		check.Status, check.Detail = StatusMalformed, "存在多个 Message-ID 头"
+		r.deduct("message_id_malformed", 0.5, check.Detail)
+	case !messageIDPattern.MatchString(strings.TrimSpace(values[0])):
+		check.Status, check.Detail = StatusMalformed, "Message-ID 应为 <唯一标识@域名> 格式"
+		r.deduct("message_id_malformed", 0.5, check.Detail)
+	default:
+		check.Status = StatusOK
+	}
+	return check
+}
+
+// checkListUnsubscribe 检查退订头（RFC 2369），包含 HTTPS 链接时还需要一键退订头（RFC 8058）
+func (r *Report) checkListUnsubscribe(header mail.Header) HeaderCheck {
+	check := HeaderCheck{Name: "List-Unsubscribe", Value: header.Get("List-Unsubscribe")}
+	if strings.TrimSpace(check.Value) == "" {
+		check.Status, check.Detail = StatusMissing, "缺少退订链接，批量发送的邮件容易被标记为垃圾邮件"
+		r.deduct("list_unsubscribe_missing", 0.5, check.Detail)
+		return check
+	}
+
+	uris, ok := parseListUnsubscribe(check.Value)
+	if !ok {
+		check.Status, check.Detail = StatusMalformed, "退订头应为以逗号分隔的 <mailto:...> 或 <https://...> 列表"
+		r.deduct("list_unsubscribe_malformed", 0.5, check.Detail)
+		return check
+	}
+
+	check.Status = StatusOK
+	hasHTTPS := false
+	for _, uri := range uris {
+		if strings.HasPrefix(strings.ToLower(uri), "https:") {
+			hasHTTPS = true
+		}
+	}
+	if !hasHTTPS {
+		return check
+	}
+	post := strings.TrimSpace(header.Get("List-Unsubscribe-Post"))
+	switch {
+	case post == "":
+		check.Status, check.Detail = StatusWarning, "包含HTTPS退订链接但缺少 List-Unsubscribe-Post 一键退订头"
+		r.deduct("list_unsubscribe_post_missing", 0.25, check.Detail)
+	case post != "List-Unsubscribe=One-Click":
+		check.Status, check.Detail = StatusMalformed, "List-Unsubscribe-Post 的值应为 List-Unsubscribe=One-Click"
+		r.deduct("list_unsubscribe_post_malformed", 0.25, check.Detail)
+	}
+	return check
+}
+
+// parseListUnsubscribe 解析 <uri>, <uri> 列表，只接受 mailto、http 和 https
+func parseListUnsubscribe(value string) ([]string, bool) {
+	var uris []string
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if !strings.HasPrefix(part, "<") || !strings.HasSuffix(part, ">") {
+			return nil, false
+		}
+		uri := strings.TrimSpace(part[1 : len(part)-1])
+		lower := strings.ToLower(uri)
+		if !strings.HasPrefix(lower, "mailto:") && !strings.HasPrefix(lower, "https://") && !strings.HasPrefix(lower, "http://") {
+			return nil, false
+		}
+		uris = append(uris, uri)
+	}
+	return uris, len(uris) > 0
+}
+
+// checkContent 检查纯文本与HTML部分的平衡
+func (r *Report) checkContent(parsed *mailparser.Message) ContentCheck {
+	text := collapseSpace(parsed.Text)
+	check := ContentCheck{
+		HasText:     text != "",
+		HasHTML:     strings.TrimSpace(parsed.HTML) != "",
+		TextLength:  utf8.RuneCountInString(text),
+		Attachments: len(parsed.Attachments),
+	}
+	if check.HasHTML {
+		visible, images, links := inspectHTML(parsed.HTML)
+		check.HTMLTextLength = utf8.RuneCountInString(visible)
+		check.ImageCount, check.LinkCount = images, links
+	}
+
+	switch {
+	case !check.HasText && !check.HasHTML:
+		r.deduct("body_empty", 1.5, "邮件正文为空")
+	case !check.HasText:
+		r.deduct("text_missing", 1.0, "只有HTML部分，缺少纯文本部分")
+	case check.HasHTML && check.HTMLTextLength > 0:
+		ratio := float64(check.TextLength) / float64(check.HTMLTextLength)
+		ratio = math.Round(ratio*100) / 100
+		check.TextHTMLRatio = &ratio
+		if ratio < 0.3 || ratio > 3 {
+			r.deduct("text_html_mismatch", 0.5, "纯文本部分与HTML部分的内容差异较大")
+		}
+	}
+	if check.ImageCount > 0 && check.HTMLTextLength < minImageText {
+		r.deduct("image_heavy", 0.5, "HTML以图片为主，文字内容过少")
+	}
+	return check
+}
+
+// checkSize 检查邮件和HTML正文大小
+func (r *Report) checkSize(size, htmlSize int) SizeCheck {
+	if size > maxMessageSize {
+		r.deduct("message_too_large", 1.0, fmt.Sprintf("邮件大小 %d 字节，部分服务商可能拒收", size))
+	}
+	if htmlSize > htmlClipSize {
+		r.deduct("html_clipped", 0.5, "HTML正文超过102KB，Gmail 会截断显示")
+	}
+	return SizeCheck{Bytes: size, HTMLBytes: htmlSize}
+}
+
+// inspectHTML 提取HTML可见文字，并统计图片和链接数量
+func inspectHTML(s string) (string, int, int) {
+	var text strings.Builder
+	images, links := 0, 0
+	skip := 0
+
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		switch tt := z.Next(); tt {
+		case html.ErrorToken:
+			return collapseSpace(text.String()), images, links
+		case html.TextToken:
+			if skip == 0 {
+				text.Write(z.Text())
+				text.WriteByte(' ')
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch string(name) {
+			case "script", "style", "head", "title":
+				if tt == html.StartTagToken {
+					skip++
+				}
+			case "img":
+				images++
+			case "a":
+				for hasAttr {
+					var key []byte
+					key, _, hasAttr = z.TagAttr()
+					if string(key) == "href" {
+						links++
+						break
+					}
+				}
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "script", "style", "head", "title":
+				if skip > 0 {
+					skip--
+				}
+			}
+		}
+	}
+}
+
+// collapseSpace 合并连续空白并去除首尾空白
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
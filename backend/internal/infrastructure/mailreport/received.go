@@ -0,0 +1,151 @@
+package mailreport
+
+import (
+	"net"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Hop 投递链路中的一跳（来自 Received 头）
+type Hop struct {
+	Index int        `json:"index"` // 按时间顺序从1开始
+	From  string     `json:"from,omitempty"`
+	By    string     `json:"by,omitempty"`
+	With  string     `json:"with,omitempty"`
+	IP    string     `json:"ip,omitempty"`
+	Time  *time.Time `json:"time"`  // 时间格式错误时为 nil
+	Delay *float64   `json:"delay"` // 与上一跳的时间差（秒），无法计算时为 nil
+	Raw   string     `json:"raw"`
+}
+
+// bracketIP 匹配 [192.0.2.1] 或 [IPv6:2001:db8::1] 形式的地址
+var bracketIP = regexp.MustCompile(`\[(?:IPv6:)?([0-9A-Fa-f:.]+)\]`)
+
+// parseHops 解析 Received 头并按时间顺序（最早的在前）返回，同时计算每跳延迟和总耗时
+func parseHops(values []string) ([]Hop, *float64) {
+	hops := make([]Hop, 0, len(values))
+	// Received 头由每一跳添加在最上方，倒序遍历得到时间顺序
+	for i := len(values) - 1; i >= 0; i-- {
+		hop := parseReceived(values[i])
+		hop.Index = len(hops) + 1
+		hops = append(hops, hop)
+	}
+
+	var first, last, prev *time.Time
+	for i := range hops {
+		t := hops[i].Time
+		if t == nil {
+			continue
+		}
+		if prev != nil {
+			delay := t.Sub(*prev).Seconds()
+			hops[i].Delay = &delay
+		}
+		if first == nil {
+			first = t
+		}
+		last, prev = t, t
+	}
+
+	if first == nil || first == last {
+		return hops, nil
+	}
+	total := last.Sub(*first).Seconds()
+	return hops, &total
+}
+
+// parseReceived 解析单个 Received 头：from/by/with 子句和分号之后的时间
+func parseReceived(value string) Hop {
+	value = strings.Join(strings.Fields(value), " ")
+	hop := Hop{Raw: value}
+
+	clauses := value
+	if i := strings.LastIndex(value, ";"); i >= 0 {
+		clauses = value[:i]
+		if t, err := mail.ParseDate(strings.TrimSpace(value[i+1:])); err == nil {
+			hop.Time = &t
+		}
+	}
+
+	key := ""
+	for _, token := range receivedTokens(clauses) {
+		if strings.HasPrefix(token, "(") {
+			if key == "from" && hop.IP == "" {
+				hop.IP = findIP(token)
+			}
+			continue
+		}
+		switch lower := strings.ToLower(token); lower {
+		case "from", "by", "via", "with", "id", "for":
+			key = lower
+			continue
+		}
+
+		switch key {
+		case "from":
+			if hop.From == "" {
+				hop.From = token
+				if hop.IP == "" {
+					hop.IP = findIP(token)
+				}
+			}
+		case "by":
+			if hop.By == "" {
+				hop.By = token
+			}
+		case "with":
+			if hop.With == "" {
+				hop.With = token
+			}
+		}
+	}
+	return hop
+}
+
+// receivedTokens 按空白拆分子句，括号注释（可嵌套）作为一个整体
+func receivedTokens(s string) []string {
+	var tokens []string
+	var current strings.Builder
+	depth := 0
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+
+	for _, c := range s {
+		switch {
+		case c == '(':
+			if depth == 0 {
+				flush()
+			}
+			depth++
+			current.WriteRune(c)
+		case c == ')' && depth > 0:
+			depth--
+			current.WriteRune(c)
+			if depth == 0 {
+				flush()
+			}
+		case c == ' ' && depth == 0:
+			flush()
+		default:
+			current.WriteRune(c)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// findIP 提取方括号中的IP地址
+func findIP(s string) string {
+	for _, match := range bracketIP.FindAllStringSubmatch(s, -1) {
+		if ip := net.ParseIP(match[1]); ip != nil {
+			return ip.String()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,138 @@
+// Package mailreport 根据原始邮件生成投递质量报告，所有检查均在本地完成，不进行网络访问
+package mailreport
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/mail"
+	"time"
+
+	"temp-mailbox-service/internal/infrastructure/mailauth"
+	"temp-mailbox-service/internal/infrastructure/mailparser"
+)
+
+// MaxScore 报告满分
+const MaxScore = 10.0
+
+// Report 投递质量报告
+type Report struct {
+	Score          float64           `json:"score"`
+	MaxScore       float64           `json:"max_score"`
+	Deductions     []Deduction       `json:"deductions"`
+	Authentication *mailauth.Results `json:"authentication"` // 接收时未进行来源验证时为 nil
+	Hops           []Hop             `json:"hops"`
+	TotalDelay     *float64          `json:"total_delay"` // 从第一跳到最后一跳的耗时（秒），时间不足两个时为 nil
+	Headers        []HeaderCheck     `json:"headers"`
+	Content        ContentCheck      `json:"content"`
+	Size           SizeCheck         `json:"size"`
+}
+
+// Deduction 单项扣分
+type Deduction struct {
+	Rule   string  `json:"rule"`
+	Points float64 `json:"points"`
+	Reason string  `json:"reason"`
+}
+
+// Analyze 分析原始邮件，auth 为接收时保存的来源验证结果（可为 nil）
+func Analyze(raw []byte, auth *mailauth.Results) (*Report, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("解析邮件头失败: %w", err)
+	}
+
+	report := &Report{
+		MaxScore:       MaxScore,
+		Deductions:     []Deduction{},
+		Authentication: auth,
+	}
+	report.checkAuthentication(auth)
+
+	report.Hops, report.TotalDelay = parseHops(msg.Header["Received"])
+	report.checkHops()
+
+	report.Headers = report.checkHeaders(msg.Header)
+
+	parsed, err := mailparser.Parse(raw)
+	if err != nil {
+		// 正文结构错误时仍然输出其他检查结果
+		report.deduct("body_unparsable", 2.0, "邮件正文无法解析: "+err.Error())
+		parsed = &mailparser.Message{}
+	}
+	report.Content = report.checkContent(parsed)
+	report.Size = report.checkSize(len(raw), len(parsed.HTML))
+
+	total := 0.0
+	for _, d := range report.Deductions {
+		total += d.Points
+	}
+	report.Score = math.Max(0, math.Round((MaxScore-total)*100)/100)
+	return report, nil
+}
+
+// deduct 记录一项扣分
+func (r *Report) deduct(rule string, points float64, reason string) {
+	r.Deductions = append(r.Deductions, Deduction{Rule: rule, Points: points, Reason: reason})
+}
+
+// checkAuthentication 根据来源验证结果扣分
+func (r *Report) checkAuthentication(auth *mailauth.Results) {
+	if auth == nil {
+		return
+	}
+
+	switch auth.SPF.Result {
+	case mailauth.ResultPass:
+	case mailauth.ResultFail:
+		r.deduct("spf_fail", 1.5, "SPF验证失败，发信IP不在发件域名的授权列表中")
+	case mailauth.ResultSoftFail:
+		r.deduct("spf_softfail", 0.75, "SPF验证软失败（~all）")
+	case mailauth.ResultNone:
+		r.deduct("spf_none", 0.5, "发件域名没有SPF记录")
+	default:
+		r.deduct("spf_error", 0.5, fmt.Sprintf("SPF验证结果为 %s", auth.SPF.Result))
+	}
+
+	dkimPass, dkimFail := false, false
+	for _, d := range auth.DKIM {
+		switch d.Result {
+		case mailauth.ResultPass:
+			dkimPass = true
+		case mailauth.ResultFail, mailauth.ResultPermError:
+			dkimFail = true
+		}
+	}
+	switch {
+	case dkimPass:
+	case dkimFail:
+		r.deduct("dkim_fail", 1.5, "DKIM签名验证失败")
+	case len(auth.DKIM) == 0:
+		r.deduct("dkim_none", 1.0, "邮件没有DKIM签名")
+	default:
+		r.deduct("dkim_error", 0.5, "DKIM签名无法验证")
+	}
+
+	switch auth.DMARC.Result {
+	case mailauth.ResultPass:
+	case mailauth.ResultFail:
+		r.deduct("dmarc_fail", 1.5, "DMARC验证失败，SPF和DKIM均未与发件人域名对齐")
+	case mailauth.ResultNone:
+		r.deduct("dmarc_none", 0.5, "发件人域名没有DMARC记录")
+	default:
+		r.deduct("dmarc_error", 0.5, fmt.Sprintf("DMARC验证结果为 %s", auth.DMARC.Result))
+	}
+}
+
+// maxHopDelay 单跳延迟超过该值时扣分
+const maxHopDelay = 10 * time.Minute
+
+// checkHops 检查投递链路延迟
+func (r *Report) checkHops() {
+	for _, hop := range r.Hops {
+		if hop.Delay != nil && *hop.Delay > maxHopDelay.Seconds() {
+			r.deduct("hop_delay", 0.5, fmt.Sprintf("第 %d 跳耗时 %.0f 秒", hop.Index, *hop.Delay))
+			return
+		}
+	}
+}
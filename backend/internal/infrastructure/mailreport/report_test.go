@@ -0,0 +1,179 @@
+package mailreport
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"temp-mailbox-service/internal/infrastructure/mailauth"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// crlf 将测试用例中的换行统一转换为CRLF
+func crlf(s string) []byte {
+	return []byte(strings.ReplaceAll(s, "\n", "\r\n"))
+}
+
+const goodMessage = `Received: from mail.sender.example (mail.sender.example [192.0.2.10])
+	by mx.local with ESMTP;
+	Mon, 02 Jan 2006 15:06:05 +0000
+Received: from app-1.internal (app-1.internal [10.0.0.5])
+	by mail.sender.example (Postfix) with ESMTPS id ABC123
+	for <inbox@mx.local>; Mon, 02 Jan 2006 15:04:35 +0000 (UTC)
+From: Shop <noreply@sender.example>
+To: inbox@mx.local
+Subject: Your order has shipped
+Date: Mon, 02 Jan 2006 15:04:05 +0000
+Message-ID: <order-42@sender.example>
+List-Unsubscribe: <mailto:unsub@sender.example>, <https://sender.example/unsub/42>
+List-Unsubscribe-Post: List-Unsubscribe=One-Click
+MIME-Version: 1.0
+Content-Type: multipart/alternative; boundary="b1"
+
+--b1
+Content-Type: text/plain; charset=utf-8
+
+Hi Alice, your order 42 has shipped and will arrive on Friday. Track it online.
+--b1
+Content-Type: text/html; charset=utf-8
+
+<html><head><title>Order</title><style>p{color:red}</style></head>
+<body><p>Hi Alice, your order <b>42</b> has shipped and will arrive on Friday.</p>
+<p><a href="https://sender.example/track/42">Track it online.</a></p></body></html>
+--b1--
+`
+
+func passingAuth() *mailauth.Results {
+	return &mailauth.Results{
+		SPF:   mailauth.SPFResult{Result: mailauth.ResultPass, Domain: "sender.example"},
+		DKIM:  []mailauth.DKIMResult{{Result: mailauth.ResultPass, Domain: "sender.example"}},
+		DMARC: mailauth.DMARCResult{Result: mailauth.ResultPass, Domain: "sender.example"},
+	}
+}
+
+func ruleNames(r *Report) []string {
+	names := make([]string, 0, len(r.Deductions))
+	for _, d := range r.Deductions {
+		names = append(names, d.Rule)
+	}
+	return names
+}
+
+func TestAnalyze_WellFormedMessage(t *testing.T) {
+	report, err := Analyze(crlf(goodMessage), passingAuth())
+	require.NoError(t, err)
+
+	assert.Empty(t, report.Deductions)
+	assert.Equal(t, MaxScore, report.Score)
+	assert.NotNil(t, report.Authentication)
+
+	require.Len(t, report.Hops, 2)
+	first, last := report.Hops[0], report.Hops[1]
+	assert.Equal(t, 1, first.Index)
+	assert.Equal(t, "app-1.internal", first.From)
+	assert.Equal(t, "mail.sender.example", first.By)
+	assert.Equal(t, "ESMTPS", first.With)
+	assert.Equal(t, "10.0.0.5", first.IP)
+	assert.Nil(t, first.Delay)
+	assert.Equal(t, "192.0.2.10", last.IP)
+	assert.Equal(t, "mx.local", last.By)
+	require.NotNil(t, last.Delay)
+	assert.Equal(t, 90.0, *last.Delay)
+	require.NotNil(t, report.TotalDelay)
+	assert.Equal(t, 90.0, *report.TotalDelay)
+
+	for _, h := range report.Headers {
+		assert.Equal(t, StatusOK, h.Status, h.Name)
+	}
+
+	assert.True(t, report.Content.HasText)
+	assert.True(t, report.Content.HasHTML)
+	assert.Equal(t, 1, report.Content.LinkCount)
+	require.NotNil(t, report.Content.TextHTMLRatio)
+	assert.InDelta(t, 1.0, *report.Content.TextHTMLRatio, 0.1)
+	assert.Equal(t, len(crlf(goodMessage)), report.Size.Bytes)
+}
+
+func TestAnalyze_PoorMessage(t *testing.T) {
+	raw := crlf(`Received: from unknown ([203.0.113.9]) by mx.local with SMTP; Mon, 02 Jan 2006 16:00:00 +0000
+From: promo@spam.example
+Subject: 
+Message-ID: no-angle-brackets
+List-Unsubscribe: https://spam.example/unsub
+Content-Type: text/html
+
+<img src="https://spam.example/banner.png"><p>Buy now</p>
+`)
+	auth := &mailauth.Results{
+		SPF:   mailauth.SPFResult{Result: mailauth.ResultSoftFail},
+		DMARC: mailauth.DMARCResult{Result: mailauth.ResultFail},
+	}
+
+	report, err := Analyze(raw, auth)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{
+		"spf_softfail", "dkim_none", "dmarc_fail",
+		"subject_missing", "date_missing", "message_id_malformed", "list_unsubscribe_malformed",
+		"text_missing", "image_heavy",
+	}, ruleNames(report))
+	assert.Equal(t, 10-0.75-1.0-1.5-0.5-1.0-0.5-0.5-1.0-0.5, report.Score)
+	assert.Nil(t, report.TotalDelay)
+	assert.Equal(t, 1, report.Content.ImageCount)
+}
+
+func TestAnalyze_WithoutAuthentication(t *testing.T) {
+	report, err := Analyze(crlf(goodMessage), nil)
+	require.NoError(t, err)
+	assert.Nil(t, report.Authentication)
+	assert.Empty(t, report.Deductions, "未进行来源验证时不应该扣分")
+}
+
+func TestAnalyze_DateSkewAndOneClick(t *testing.T) {
+	raw := strings.Replace(goodMessage, "Date: Mon, 02 Jan 2006 15:04:05 +0000", "Date: Sat, 31 Dec 2005 09:00:00 +0000", 1)
+	raw = strings.Replace(raw, "List-Unsubscribe-Post: List-Unsubscribe=One-Click\n", "", 1)
+
+	report, err := Analyze(crlf(raw), passingAuth())
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"date_skew", "list_unsubscribe_post_missing"}, ruleNames(report))
+	assert.Equal(t, 9.25, report.Score)
+}
+
+func TestAnalyze_InvalidHeader(t *testing.T) {
+	_, err := Analyze([]byte("not a message"), nil)
+	assert.Error(t, err)
+}
+
+func TestParseReceived(t *testing.T) {
+	hop := parseReceived("from client.example (client.example [IPv6:2001:db8::25] (may be forged))\r\n\tby mx.local (Postfix) with ESMTPSA id 1; bogus date")
+	assert.Equal(t, "client.example", hop.From)
+	assert.Equal(t, "mx.local", hop.By)
+	assert.Equal(t, "ESMTPSA", hop.With)
+	assert.Equal(t, "2001:db8::25", hop.IP)
+	assert.Nil(t, hop.Time)
+
+	hop = parseReceived("from [198.51.100.7] by mx.local; Tue, 3 Jan 2006 01:02:03 -0700")
+	assert.Equal(t, "198.51.100.7", hop.IP)
+	require.NotNil(t, hop.Time)
+	assert.True(t, hop.Time.Equal(time.Date(2006, 1, 3, 8, 2, 3, 0, time.UTC)))
+}
+
+func TestParseListUnsubscribe(t *testing.T) {
+	uris, ok := parseListUnsubscribe("<mailto:a@example.com?subject=unsub>, <https://example.com/u>")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"mailto:a@example.com?subject=unsub", "https://example.com/u"}, uris)
+
+	for _, value := range []string{"mailto:a@example.com", "<ftp://example.com>", "<>", " , "} {
+		_, ok := parseListUnsubscribe(value)
+		assert.False(t, ok, value)
+	}
+}
+
+func TestInspectHTML(t *testing.T) {
+	text, images, links := inspectHTML(`<head><script>var x = 1;</script></head><p>Hello <a href="/a">there</a></p><img src="a.png"/><a name="x">anchor</a>`)
+	assert.Equal(t, "Hello there anchor", text)
+	assert.Equal(t, 1, images)
+	assert.Equal(t, 1, links)
+}
@@ -1,94 +1,20 @@
 package middleware
 
 import (
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
 
+	"temp-mailbox-service/internal/domain/user"
 	"temp-mailbox-service/internal/infrastructure/auth"
+	"temp-mailbox-service/internal/infrastructure/ratelimit"
 
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware 认证中间件
-func AuthMiddleware(jwtService auth.JWTService) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// 从请求头获取Authorization
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":   "缺少授权头",
-				"message": "Authorization header is required",
-			})
-			c.Abort()
-			return
-		}
-
-		// 提取Bearer token
-		token := auth.ExtractTokenFromHeader(authHeader)
-		if token == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":   "无效的授权格式",
-				"message": "Authorization header must be in format 'Bearer <token>'",
-			})
-			c.Abort()
-			return
-		}
-
-		// 验证访问令牌
-		claims, err := jwtService.ValidateAccessToken(token)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":   "令牌验证失败",
-				"message": err.Error(),
-			})
-			c.Abort()
-			return
-		}
-
-		// 将用户信息设置到上下文中
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
-		c.Set("email", claims.Email)
-		c.Set("jwt_claims", claims)
-
-		c.Next()
-	}
-}
-
-// OptionalAuthMiddleware 可选认证中间件（不强制要求认证）
-func OptionalAuthMiddleware(jwtService auth.JWTService) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.Next()
-			return
-		}
-
-		token := auth.ExtractTokenFromHeader(authHeader)
-		if token == "" {
-			c.Next()
-			return
-		}
-
-		claims, err := jwtService.ValidateAccessToken(token)
-		if err != nil {
-			// 可选认证失败时不阻止请求，但记录日志
-			c.Header("X-Auth-Warning", "Invalid token provided")
-			c.Next()
-			return
-		}
-
-		// 设置用户信息到上下文
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
-		c.Set("email", claims.Email)
-		c.Set("jwt_claims", claims)
-
-		c.Next()
-	}
-}
-
 // GetCurrentUserID 从上下文中获取当前用户ID
 func GetCurrentUserID(c *gin.Context) (uint, bool) {
 	userID, exists := c.Get("user_id")
@@ -132,8 +58,7 @@ func GetJWTClaims(c *gin.Context) (*auth.JWTClaims, bool) {
 // AdminMiddleware 管理员权限中间件
 func AdminMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 这里可以根据实际需求检查用户角色
-		// 目前简化处理，只检查是否已认证
+		// 需要在认证中间件之后使用
 		_, exists := c.Get("user_id")
 		if !exists {
 			c.JSON(http.StatusUnauthorized, gin.H{
@@ -144,8 +69,16 @@ func AdminMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// TODO: 在用户模型中添加角色字段后，可以在这里检查管理员权限
-		// 目前所有已认证用户都被视为管理员
+		// 检查管理员角色（来自令牌声明）
+		role, _ := GetRoleFromContext(c)
+		if role != user.RoleAdmin {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "需要管理员权限",
+				"message": "Admin role required",
+			})
+			c.Abort()
+			return
+		}
 
 		c.Next()
 	}
@@ -173,17 +106,16 @@ func RequestIDMiddleware() gin.HandlerFunc {
 	return gin.Logger()
 }
 
-// RateLimiterMiddleware 简单的速率限制中间件
-func RateLimiterMiddleware() gin.HandlerFunc {
+// RateLimiterMiddleware 按路由规则选择策略的限流中间件
+// 已认证的请求按用户限流，需要挂在认证中间件之后；其余请求按客户端IP限流
+func RateLimiterMiddleware(store ratelimit.Store, rules *ratelimit.Rules) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// TODO: 实现基于IP或用户的速率限制
-		// 目前暂时跳过
-		c.Next()
+		applyRateLimit(c, store, rules.Match(c.Request.Method, c.FullPath()))
 	}
 }
 
-// JWTAuth JWT认证中间件
-func JWTAuth(jwtService auth.JWTService) gin.HandlerFunc {
+// JWTAuth JWT认证中间件，revocations 不为空时拒绝已吊销的令牌
+func JWTAuth(jwtService auth.JWTService, revocations auth.RevocationChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 从Authorization头获取令牌
 		authHeader := c.GetHeader("Authorization")
@@ -218,10 +150,22 @@ func JWTAuth(jwtService auth.JWTService) gin.HandlerFunc {
 			return
 		}
 		
+		// 检查令牌是否已被吊销（退出登录等）
+		if revocations != nil && revocations.IsRevoked(c.Request.Context(), claims) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "令牌已被吊销",
+				"code":  "TOKEN_REVOKED",
+			})
+			c.Abort()
+			return
+		}
+		
 		// 将用户信息存储到上下文中
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("email", claims.Email)
+		c.Set("role", claims.Role)
+		c.Set("jwt_claims", claims)
 		
 		// 继续处理请求
 		c.Next()
@@ -270,48 +214,128 @@ func GetEmailFromContext(c *gin.Context) (string, bool) {
 	return "", false
 }
 
-// OptionalAuth 可选认证中间件（不强制要求认证）
-func OptionalAuth(jwtService auth.JWTService) gin.HandlerFunc {
+// RequireRole 角色验证中间件，用户角色属于 roles 之一时放行（需要在认证中间件之后使用）
+func RequireRole(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			// 没有认证头，继续处理但不设置用户信息
-			c.Next()
+		if _, exists := c.Get("user_id"); !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "未提供认证令牌",
+				"code":  "MISSING_TOKEN",
+			})
+			c.Abort()
 			return
 		}
 		
-		token := auth.ExtractTokenFromHeader(authHeader)
-		if token == "" {
-			// 令牌格式错误，继续处理但不设置用户信息
-			c.Next()
+		role, _ := GetRoleFromContext(c)
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+		
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "权限不足",
+			"code":  "FORBIDDEN",
+		})
+		c.Abort()
+	}
+}
+
+// GetRoleFromContext 从上下文中获取用户角色
+func GetRoleFromContext(c *gin.Context) (string, bool) {
+	role, exists := c.Get("role")
+	if !exists {
+		return "", false
+	}
+	
+	if r, ok := role.(string); ok {
+		return r, true
+	}
+	
+	return "", false
+}
+
+// JWTOrAPIKeyAuth 同时接受JWT和API密钥的认证中间件
+// 请求携带 Authorization: ApiKey <key> 或 X-API-Key 时按API密钥认证，否则按 JWTAuth 处理
+func JWTOrAPIKeyAuth(jwtService auth.JWTService, revocations auth.RevocationChecker, apiKeys auth.APIKeyAuthenticator) gin.HandlerFunc {
+	jwtAuth := JWTAuth(jwtService, revocations)
+	return func(c *gin.Context) {
+		key, ok := auth.ExtractAPIKey(c.GetHeader("Authorization"), c.GetHeader("X-API-Key"))
+		if !ok {
+			jwtAuth(c)
 			return
 		}
 		
-		claims, err := jwtService.ValidateAccessToken(token)
+		principal, err := apiKeys.AuthenticateAPIKey(c.Request.Context(), key, c.ClientIP())
+		if errors.Is(err, auth.ErrAPIKeyExpired) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "API密钥已过期",
+				"code":  "API_KEY_EXPIRED",
+			})
+			c.Abort()
+			return
+		}
 		if err != nil {
-			// 令牌无效，继续处理但不设置用户信息
-			c.Next()
+			if !errors.Is(err, auth.ErrAPIKeyInvalid) {
+				log.Printf("API密钥认证失败: %v", err)
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "API密钥无效",
+				"code":  "INVALID_API_KEY",
+			})
+			c.Abort()
 			return
 		}
 		
-		// 设置用户信息到上下文
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
-		c.Set("email", claims.Email)
+		// 将用户信息存储到上下文中
+		c.Set("user_id", principal.UserID)
+		c.Set("username", principal.Username)
+		c.Set("email", principal.Email)
+		c.Set("role", principal.Role)
+		c.Set("api_key", principal)
 		
 		c.Next()
 	}
 }
 
-// RequireRole 角色验证中间件（为将来的角色系统预留）
-func RequireRole(roles ...string) gin.HandlerFunc {
+// RequireAPIKeyScope API密钥授权范围检查中间件：GET/HEAD 请求需要 readScope，其余请求需要 writeScope
+// 使用JWT认证的请求不受限制（需要在 JWTOrAPIKeyAuth 之后使用）
+func RequireAPIKeyScope(readScope, writeScope string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 这里可以实现角色验证逻辑
-		// 目前只是一个占位符
+		principal, ok := GetAPIKeyPrincipal(c)
+		if !ok {
+			c.Next()
+			return
+		}
+		
+		scope := writeScope
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			scope = readScope
+		}
+		if !principal.HasScope(scope) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "API密钥缺少授权范围 " + scope,
+				"code":  "INSUFFICIENT_SCOPE",
+			})
+			c.Abort()
+			return
+		}
+		
 		c.Next()
 	}
 }
 
+// GetAPIKeyPrincipal 从上下文中获取API密钥身份，使用JWT认证时返回false
+func GetAPIKeyPrincipal(c *gin.Context) (*auth.APIKeyPrincipal, bool) {
+	value, exists := c.Get("api_key")
+	if !exists {
+		return nil, false
+	}
+	principal, ok := value.(*auth.APIKeyPrincipal)
+	return principal, ok
+}
+
 // CORS 跨域中间件
 func CORS() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -320,10 +344,10 @@ func CORS() gin.HandlerFunc {
 		
 		if origin != "" {
 			c.Header("Access-Control-Allow-Origin", origin)
-			c.Header("Access-Control-Allow-Methods", "GET,POST,PUT,DELETE,OPTIONS")
-			c.Header("Access-Control-Allow-Headers", "Content-Type,AccessToken,X-CSRF-Token,Authorization,Token,X-Requested-With")
+			c.Header("Access-Control-Allow-Methods", "GET,POST,PUT,PATCH,DELETE,OPTIONS")
+			c.Header("Access-Control-Allow-Headers", "Content-Type,AccessToken,X-CSRF-Token,Authorization,Token,X-Requested-With,X-API-Key")
 			c.Header("Access-Control-Allow-Credentials", "true")
-			c.Header("Access-Control-Expose-Headers", "Content-Length,Access-Control-Allow-Origin,Access-Control-Allow-Headers,Content-Type")
+			c.Header("Access-Control-Expose-Headers", "Content-Length,Access-Control-Allow-Origin,Access-Control-Allow-Headers,Content-Type,RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset,RateLimit-Policy,Retry-After")
 		}
 		
 		if method == "OPTIONS" {
@@ -335,13 +359,52 @@ func CORS() gin.HandlerFunc {
 	}
 }
 
-// RateLimit 限流中间件（简单版本）
-func RateLimit() gin.HandlerFunc {
+// RateLimit 使用固定策略的限流中间件
+func RateLimit(store ratelimit.Store, policy ratelimit.Policy) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 这里可以实现限流逻辑
-		// 目前只是一个占位符
+		applyRateLimit(c, store, policy)
+	}
+}
+
+// applyRateLimit 执行限流检查并设置 RateLimit-* 响应头，存储出错时放行
+func applyRateLimit(c *gin.Context, store ratelimit.Store, policy ratelimit.Policy) {
+	key := policy.Name + ":" + rateLimitKey(c)
+	result, err := store.Take(c.Request.Context(), key, policy, time.Now())
+	if err != nil {
+		log.Printf("限流检查失败 %s: %v", key, err)
 		c.Next()
+		return
 	}
+	
+	c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+	c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, int(policy.Window.Seconds())))
+	
+	if !result.Allowed {
+		c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": "请求过于频繁，请稍后再试",
+			"code":  "RATE_LIMITED",
+		})
+		c.Abort()
+		return
+	}
+	
+	c.Next()
+}
+
+// rateLimitKey 限流键：已认证时使用用户ID，否则使用客户端IP
+func rateLimitKey(c *gin.Context) string {
+	if userID, err := GetUserIDFromContext(c); err == nil {
+		return "user:" + strconv.FormatUint(uint64(userID), 10)
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// ceilSeconds 将时长向上取整为秒
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
 }
 
 // RequestID 请求ID中间件
@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"temp-mailbox-service/internal/domain/apikey"
+	"temp-mailbox-service/internal/infrastructure/auth"
+	"temp-mailbox-service/internal/infrastructure/config"
+	"temp-mailbox-service/internal/infrastructure/ratelimit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newRateLimitedRouter 按服务器的方式配置可信代理，登录接口每个客户端只允许一次请求
+func newRateLimitedRouter(t *testing.T, trustedProxies []string) *gin.Engine {
+	t.Helper()
+	r := gin.New()
+	require.NoError(t, r.SetTrustedProxies(trustedProxies))
+
+	cfg := &config.RateLimitConfig{
+		Login: config.RateLimitPolicy{Requests: 1, Window: 60},
+		Read:  config.RateLimitPolicy{Requests: 100, Window: 60},
+		Write: config.RateLimitPolicy{Requests: 100, Window: 60},
+	}
+	r.Use(RateLimiterMiddleware(ratelimit.NewMemoryStore(), ratelimit.NewRules(cfg)))
+	r.POST("/api/auth/login", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func postLogin(r *gin.Engine, remoteAddr, forwardedFor string) int {
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login", nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestRateLimiter_IgnoresSpoofedForwardedFor(t *testing.T) {
+	defaults, err := config.Load("")
+	require.NoError(t, err)
+	r := newRateLimitedRouter(t, defaults.Server.TrustedProxies)
+
+	assert.Equal(t, http.StatusOK, postLogin(r, "198.51.100.7:40000", "203.0.113.1"))
+	assert.Equal(t, http.StatusTooManyRequests, postLogin(r, "198.51.100.7:40001", "203.0.113.2"),
+		"未配置可信代理时伪造的 X-Forwarded-For 应与真实IP共用令牌桶")
+	assert.Equal(t, http.StatusOK, postLogin(r, "198.51.100.8:40000", ""))
+}
+
+func TestRateLimiter_TrustedProxyForwardedFor(t *testing.T) {
+	r := newRateLimitedRouter(t, []string{"10.0.0.0/8"})
+
+	assert.Equal(t, http.StatusOK, postLogin(r, "10.0.0.2:40000", "203.0.113.1"))
+	assert.Equal(t, http.StatusOK, postLogin(r, "10.0.0.2:40001", "203.0.113.2"), "可信代理转发的不同客户端应使用各自的令牌桶")
+	assert.Equal(t, http.StatusTooManyRequests, postLogin(r, "10.0.0.3:40000", "203.0.113.1"))
+
+	// 不可信来源伪造的请求头按连接地址计算
+	assert.Equal(t, http.StatusOK, postLogin(r, "198.51.100.7:40000", "203.0.113.3"))
+	assert.Equal(t, http.StatusTooManyRequests, postLogin(r, "198.51.100.7:40000", "203.0.113.4"))
+}
+
+// fakeAPIKeys 按密钥返回固定身份的API密钥校验实现
+type fakeAPIKeys map[string]*auth.APIKeyPrincipal
+
+func (f fakeAPIKeys) AuthenticateAPIKey(ctx context.Context, key, clientIP string) (*auth.APIKeyPrincipal, error) {
+	if principal, ok := f[key]; ok {
+		return principal, nil
+	}
+	return nil, auth.ErrAPIKeyInvalid
+}
+
+// newMailboxRouter 按服务器的方式组合 JWTOrAPIKeyAuth 和 RequireAPIKeyScope
+func newMailboxRouter(jwtService auth.JWTService, keys fakeAPIKeys) *gin.Engine {
+	r := gin.New()
+	group := r.Group("/api/mailboxes", JWTOrAPIKeyAuth(jwtService, nil, keys), RequireAPIKeyScope(apikey.ScopeMailboxRead, apikey.ScopeMailboxWrite))
+	handler := func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	}
+	group.GET("", handler)
+	group.POST("", handler)
+	group.DELETE("/:id", handler)
+	return r
+}
+
+func serve(r *gin.Engine, method, path string, header ...string) int {
+	req := httptest.NewRequest(method, path, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestRequireAPIKeyScope(t *testing.T) {
+	jwtService := auth.NewJWTService("test-secret", 15, 60, "test")
+	r := newMailboxRouter(jwtService, fakeAPIKeys{
+		"read":  {UserID: 1, Scopes: []string{apikey.ScopeMailboxRead}},
+		"write": {UserID: 1, Scopes: []string{apikey.ScopeMailboxWrite}},
+		"both":  {UserID: 1, Scopes: []string{apikey.ScopeMailboxRead, apikey.ScopeMailboxWrite}},
+		"none":  {UserID: 1},
+	})
+
+	tests := []struct {
+		name   string
+		key    string
+		method string
+		path   string
+		want   int
+	}{
+		{"只读密钥可以读取", "read", http.MethodGet, "/api/mailboxes", http.StatusOK},
+		{"只读密钥不能创建", "read", http.MethodPost, "/api/mailboxes", http.StatusForbidden},
+		{"只读密钥不能删除", "read", http.MethodDelete, "/api/mailboxes/1", http.StatusForbidden},
+		{"只写密钥不能读取", "write", http.MethodGet, "/api/mailboxes", http.StatusForbidden},
+		{"只写密钥可以删除", "write", http.MethodDelete, "/api/mailboxes/1", http.StatusOK},
+		{"读写密钥", "both", http.MethodPost, "/api/mailboxes", http.StatusOK},
+		{"没有授权范围的密钥", "none", http.MethodGet, "/api/mailboxes", http.StatusForbidden},
+		{"无效的密钥", "unknown", http.MethodGet, "/api/mailboxes", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, serve(r, tt.method, tt.path, "Authorization", "ApiKey "+tt.key))
+			assert.Equal(t, tt.want, serve(r, tt.method, tt.path, "X-API-Key", tt.key))
+		})
+	}
+
+	t.Run("JWT认证不受授权范围限制", func(t *testing.T) {
+		pair, err := jwtService.GenerateSessionTokens(auth.TokenSubject{UserID: 1, Username: "alice"}, "sid", 1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, serve(r, http.MethodPost, "/api/mailboxes", "Authorization", "Bearer "+pair.AccessToken))
+		assert.Equal(t, http.StatusUnauthorized, serve(r, http.MethodGet, "/api/mailboxes"))
+	})
+}
@@ -0,0 +1,186 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+)
+
+// jwksRefreshInterval 遇到未知 kid 时重新获取密钥集的最小间隔，避免被伪造令牌放大请求
+const jwksRefreshInterval = time.Minute
+
+// jsonWebKey JWKS 中的单个公钥
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// keySet 身份提供方签名公钥缓存，轮换密钥后按需刷新
+type keySet struct {
+	uri   string
+	fetch func(ctx context.Context, endpoint string, v interface{}) error
+
+	mu        sync.Mutex
+	keys      []publicKey
+	fetchedAt time.Time
+}
+
+// publicKey 解析后的公钥
+type publicKey struct {
+	kid string
+	alg string
+	key interface{}
+}
+
+// newKeySet 创建密钥集缓存
+func newKeySet(uri string, fetch func(ctx context.Context, endpoint string, v interface{}) error) *keySet {
+	return &keySet{uri: uri, fetch: fetch}
+}
+
+// get 查找与 kid 和签名算法匹配的公钥，找不到时刷新一次密钥集
+func (s *keySet) get(ctx context.Context, kid, alg string) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key := s.find(kid, alg); key != nil {
+		return key, nil
+	}
+	if !s.fetchedAt.IsZero() && time.Since(s.fetchedAt) < jwksRefreshInterval {
+		return nil, fmt.Errorf("未找到签名公钥 %q", kid)
+	}
+	if err := s.refresh(ctx); err != nil {
+		return nil, err
+	}
+	if key := s.find(kid, alg); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("未找到签名公钥 %q", kid)
+}
+
+// find 在已缓存的公钥中查找；令牌未指定 kid 且只有一个匹配的公钥时直接使用
+func (s *keySet) find(kid, alg string) interface{} {
+	var candidates []publicKey
+	for _, k := range s.keys {
+		if k.alg != "" && k.alg != alg {
+			continue
+		}
+		if !keyMatchesAlg(k.key, alg) {
+			continue
+		}
+		if kid != "" && k.kid == kid {
+			return k.key
+		}
+		candidates = append(candidates, k)
+	}
+	if kid == "" && len(candidates) == 1 {
+		return candidates[0].key
+	}
+	return nil
+}
+
+// refresh 重新获取密钥集，忽略无法解析或不用于签名的公钥
+func (s *keySet) refresh(ctx context.Context) error {
+	var doc struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	s.fetchedAt = time.Now()
+	if err := s.fetch(ctx, s.uri, &doc); err != nil {
+		return fmt.Errorf("获取签名公钥失败: %w", err)
+	}
+
+	keys := make([]publicKey, 0, len(doc.Keys))
+	for _, jwk := range doc.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue
+		}
+		keys = append(keys, publicKey{kid: jwk.Kid, alg: jwk.Alg, key: key})
+	}
+	s.keys = keys
+	return nil
+}
+
+// publicKey 将 JWK 转换为 RSA 或 ECDSA 公钥
+func (k *jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("无效的RSA指数")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("不支持的曲线 %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("公钥不在曲线上")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("不支持的密钥类型 %s", k.Kty)
+}
+
+// keyMatchesAlg 检查公钥类型与签名算法是否匹配
+func keyMatchesAlg(key interface{}, alg string) bool {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return strings.HasPrefix(alg, "RS")
+	case *ecdsa.PublicKey:
+		return strings.HasPrefix(alg, "ES")
+	}
+	return false
+}
+
+// decodeBigInt 解码 base64url 编码的大整数
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, fmt.Errorf("空的整数值")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
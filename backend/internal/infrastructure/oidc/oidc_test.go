@@ -0,0 +1,375 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"temp-mailbox-service/internal/infrastructure/config"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testClientID     = "test-client"
+	testClientSecret = "test-secret"
+	testRedirectURL  = "http://localhost:8080/api/auth/oidc/mock/callback"
+)
+
+// mockGrant 模拟授权服务器记录的授权码
+type mockGrant struct {
+	challenge   string
+	nonce       string
+	redirectURI string
+}
+
+// mockIssuer 本地模拟的 OpenID Connect 身份提供方
+type mockIssuer struct {
+	server *httptest.Server
+
+	mu           sync.Mutex
+	key          *rsa.PrivateKey
+	kid          string
+	grants       map[string]mockGrant
+	claims       jwt.MapClaims // 附加到 ID 令牌中的声明
+	jwksRequests int
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	m := &mockIssuer{
+		key:    key,
+		kid:    "key-1",
+		grants: make(map[string]mockGrant),
+		claims: jwt.MapClaims{
+			"sub":            "user-123",
+			"email":          "alice@example.com",
+			"email_verified": true,
+			"name":           "Alice",
+		},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(discoveryPath, m.discovery)
+	mux.HandleFunc("/authorize", m.authorize)
+	mux.HandleFunc("/token", m.token)
+	mux.HandleFunc("/jwks", m.jwks)
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func (m *mockIssuer) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                m.server.URL,
+		"authorization_endpoint":                m.server.URL + "/authorize",
+		"token_endpoint":                        m.server.URL + "/token",
+		"jwks_uri":                              m.server.URL + "/jwks",
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+// authorize 跳过用户交互，直接签发授权码并重定向回客户端
+func (m *mockIssuer) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != testClientID || q.Get("code_challenge_method") != "S256" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	m.mu.Lock()
+	code := "code-" + q.Get("state")
+	m.grants[code] = mockGrant{
+		challenge:   q.Get("code_challenge"),
+		nonce:       q.Get("nonce"),
+		redirectURI: q.Get("redirect_uri"),
+	}
+	m.mu.Unlock()
+
+	redirect, _ := url.Parse(q.Get("redirect_uri"))
+	values := redirect.Query()
+	values.Set("code", code)
+	values.Set("state", q.Get("state"))
+	redirect.RawQuery = values.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+// token 校验客户端凭据、授权码和 PKCE 校验码后签发 ID 令牌
+func (m *mockIssuer) token(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok || clientID != testClientID || clientSecret != testClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	m.mu.Lock()
+	grant, found := m.grants[r.PostFormValue("code")]
+	delete(m.grants, r.PostFormValue("code"))
+	m.mu.Unlock()
+	if !found || grant.redirectURI != r.PostFormValue("redirect_uri") {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+	if CodeChallengeS256(r.PostFormValue("code_verifier")) != grant.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     m.sign(jwt.MapClaims{"nonce": grant.nonce}),
+	})
+}
+
+func (m *mockIssuer) jwks(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.jwksRequests++
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": m.kid,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(m.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(m.key.E)).Bytes()),
+		}},
+	})
+}
+
+// sign 签发 ID 令牌，extra 覆盖默认声明
+func (m *mockIssuer) sign(extra jwt.MapClaims) string {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss": m.server.URL,
+		"aud": testClientID,
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+	for k, v := range m.claims {
+		claims[k] = v
+	}
+	for k, v := range extra {
+		claims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = m.kid
+	signed, _ := token.SignedString(m.key)
+	return signed
+}
+
+func (m *mockIssuer) provider() *Provider {
+	return NewProvider(config.OIDCProviderConfig{
+		Name:         "mock",
+		Issuer:       m.server.URL,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		RedirectURL:  testRedirectURL,
+	}, m.server.Client())
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// authorizeCode 模拟浏览器访问授权地址，返回重定向中的授权码
+func authorizeCode(t *testing.T, client *http.Client, authURL string) (code, state string) {
+	noRedirect := *client
+	noRedirect.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	resp, err := noRedirect.Get(authURL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func TestProvider_AuthorizationCodeFlow(t *testing.T) {
+	issuer := newMockIssuer(t)
+	provider := issuer.provider()
+	ctx := context.Background()
+
+	verifier, err := NewCodeVerifier()
+	require.NoError(t, err)
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", CodeChallengeS256(verifier))
+	require.NoError(t, err)
+
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "openid email profile", parsed.Query().Get("scope"))
+	assert.Equal(t, testRedirectURL, parsed.Query().Get("redirect_uri"))
+
+	code, state := authorizeCode(t, issuer.server.Client(), authURL)
+	assert.Equal(t, "state-1", state)
+
+	token, err := provider.Exchange(ctx, code, verifier)
+	require.NoError(t, err)
+
+	claims, err := provider.VerifyIDToken(ctx, token.IDToken, "nonce-1")
+	require.NoError(t, err)
+	assert.Equal(t, "user-123", claims.Subject)
+	assert.Equal(t, "alice@example.com", claims.Email)
+	assert.True(t, bool(claims.EmailVerified))
+	assert.Equal(t, "Alice", claims.Name)
+}
+
+func TestProvider_ExchangeRejectsWrongVerifier(t *testing.T) {
+	issuer := newMockIssuer(t)
+	provider := issuer.provider()
+	ctx := context.Background()
+
+	verifier, err := NewCodeVerifier()
+	require.NoError(t, err)
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", CodeChallengeS256(verifier))
+	require.NoError(t, err)
+	code, _ := authorizeCode(t, issuer.server.Client(), authURL)
+
+	other, err := NewCodeVerifier()
+	require.NoError(t, err)
+	_, err = provider.Exchange(ctx, code, other)
+	assert.ErrorContains(t, err, "invalid_grant")
+}
+
+func TestProvider_VerifyIDTokenRejectsInvalidTokens(t *testing.T) {
+	issuer := newMockIssuer(t)
+	provider := issuer.provider()
+	ctx := context.Background()
+
+	_, err := provider.VerifyIDToken(ctx, issuer.sign(jwt.MapClaims{"nonce": "n"}), "other")
+	assert.ErrorIs(t, err, ErrNonceMismatch)
+
+	invalid := map[string]string{
+		"受众不匹配":  issuer.sign(jwt.MapClaims{"nonce": "n", "aud": "other-client"}),
+		"签发方不匹配": issuer.sign(jwt.MapClaims{"nonce": "n", "iss": "https://evil.example.com"}),
+		"已过期":    issuer.sign(jwt.MapClaims{"nonce": "n", "exp": time.Now().Add(-time.Hour).Unix()}),
+		"azp不匹配": issuer.sign(jwt.MapClaims{"nonce": "n", "aud": []string{testClientID, "other"}, "azp": "other"}),
+		"缺少sub":  issuer.sign(jwt.MapClaims{"nonce": "n", "sub": ""}),
+	}
+	for name, token := range invalid {
+		_, err := provider.VerifyIDToken(ctx, token, "n")
+		assert.ErrorIs(t, err, ErrInvalidIDToken, name)
+	}
+
+	// 其他密钥签名的令牌
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	forged := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss": issuer.server.URL, "aud": testClientID, "sub": "user-123", "nonce": "n",
+		"iat": time.Now().Unix(), "exp": time.Now().Add(time.Hour).Unix(),
+	})
+	forged.Header["kid"] = issuer.kid
+	signed, err := forged.SignedString(otherKey)
+	require.NoError(t, err)
+	_, err = provider.VerifyIDToken(ctx, signed, "n")
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+
+	// 不接受 none 和 HMAC 算法
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{"sub": "x"}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	_, err = provider.VerifyIDToken(ctx, unsigned, "")
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+}
+
+func TestProvider_KeyRotation(t *testing.T) {
+	issuer := newMockIssuer(t)
+	provider := issuer.provider()
+	ctx := context.Background()
+
+	_, err := provider.VerifyIDToken(ctx, issuer.sign(jwt.MapClaims{"nonce": "n"}), "n")
+	require.NoError(t, err)
+	assert.Equal(t, 1, issuer.jwksRequests)
+
+	// 缓存命中时不重新获取
+	_, err = provider.VerifyIDToken(ctx, issuer.sign(jwt.MapClaims{"nonce": "n"}), "n")
+	require.NoError(t, err)
+	assert.Equal(t, 1, issuer.jwksRequests)
+
+	// 轮换密钥后遇到新的 kid 时重新获取（距上次获取不足刷新间隔时拒绝）
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	issuer.mu.Lock()
+	issuer.key, issuer.kid = newKey, "key-2"
+	issuer.mu.Unlock()
+
+	_, err = provider.VerifyIDToken(ctx, issuer.sign(jwt.MapClaims{"nonce": "n"}), "n")
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+
+	provider.keys.fetchedAt = time.Now().Add(-2 * jwksRefreshInterval)
+	_, err = provider.VerifyIDToken(ctx, issuer.sign(jwt.MapClaims{"nonce": "n"}), "n")
+	require.NoError(t, err)
+	assert.Equal(t, 2, issuer.jwksRequests)
+}
+
+func TestProvider_DiscoverRejectsIssuerMismatch(t *testing.T) {
+	issuer := newMockIssuer(t)
+	provider := NewProvider(config.OIDCProviderConfig{
+		Name:     "mock",
+		Issuer:   issuer.server.URL + "/tenant",
+		ClientID: testClientID,
+	}, issuer.server.Client())
+
+	_, err := provider.Discover(context.Background())
+	assert.Error(t, err)
+}
+
+func TestJSONWebKey_EC(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	jwk := &jsonWebKey{
+		Kty: "EC",
+		Crv: "P-256",
+		X:   base64.RawURLEncoding.EncodeToString(key.X.Bytes()),
+		Y:   base64.RawURLEncoding.EncodeToString(key.Y.Bytes()),
+	}
+	parsed, err := jwk.publicKey()
+	require.NoError(t, err)
+	assert.True(t, key.PublicKey.Equal(parsed))
+	assert.True(t, keyMatchesAlg(parsed, "ES256"))
+	assert.False(t, keyMatchesAlg(parsed, "RS256"))
+
+	jwk.Y = jwk.X
+	_, err = jwk.publicKey()
+	assert.Error(t, err)
+}
+
+func TestFlexBool(t *testing.T) {
+	var claims struct {
+		Verified flexBool `json:"email_verified"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"email_verified":"true"}`), &claims))
+	assert.True(t, bool(claims.Verified))
+	require.NoError(t, json.Unmarshal([]byte(`{"email_verified":false}`), &claims))
+	assert.False(t, bool(claims.Verified))
+	assert.Error(t, json.Unmarshal([]byte(`{"email_verified":"yes"}`), &claims))
+}
+
+func TestCodeChallengeS256(t *testing.T) {
+	// RFC 7636 附录 B 示例
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", CodeChallengeS256("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+
+	verifier, err := NewCodeVerifier()
+	require.NoError(t, err)
+	assert.Len(t, verifier, 43)
+}
@@ -0,0 +1,77 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"temp-mailbox-service/internal/domain/apikey"
+	"temp-mailbox-service/internal/infrastructure/database"
+
+	"gorm.io/gorm"
+)
+
+// apiKeyRepository API密钥仓储实现
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository 创建API密钥仓储实例
+func NewAPIKeyRepository() apikey.Repository {
+	return &apiKeyRepository{
+		db: database.GetDB(),
+	}
+}
+
+// Create 创建API密钥
+func (r *apiKeyRepository) Create(ctx context.Context, k *apikey.APIKey) error {
+	return r.db.WithContext(ctx).Create(k).Error
+}
+
+// GetByPrefix 按前缀获取未吊销的密钥
+func (r *apiKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*apikey.APIKey, error) {
+	var k apikey.APIKey
+	err := r.db.WithContext(ctx).Where("prefix = ? AND revoked_at IS NULL", prefix).First(&k).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &k, err
+}
+
+// ListActiveByUser 获取用户未吊销的密钥，按创建时间倒序
+func (r *apiKeyRepository) ListActiveByUser(ctx context.Context, userID uint) ([]*apikey.APIKey, error) {
+	var keys []*apikey.APIKey
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("created_at DESC, id DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+// CountActiveByUser 统计用户未吊销的密钥数量
+func (r *apiKeyRepository) CountActiveByUser(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&apikey.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// Revoke 吊销属于指定用户的密钥
+func (r *apiKeyRepository) Revoke(ctx context.Context, id, userID uint) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&apikey.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", &now)
+	return result.RowsAffected == 1, result.Error
+}
+
+// TouchLastUsed 更新最近使用时间和IP
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id uint, at time.Time, ip string) error {
+	return r.db.WithContext(ctx).Model(&apikey.APIKey{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"last_used_at": &at,
+			"last_used_ip": ip,
+		}).Error
+}
@@ -0,0 +1,106 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"temp-mailbox-service/internal/domain/maildomain"
+	"temp-mailbox-service/internal/infrastructure/database"
+
+	"gorm.io/gorm"
+)
+
+// domainRepository 托管域名仓储实现
+type domainRepository struct {
+	db *gorm.DB
+}
+
+// NewDomainRepository 创建托管域名仓储实例
+func NewDomainRepository() maildomain.Repository {
+	return &domainRepository{
+		db: database.GetDB(),
+	}
+}
+
+// Create 创建域名
+func (r *domainRepository) Create(ctx context.Context, d *maildomain.Domain) error {
+	return r.db.WithContext(ctx).Create(d).Error
+}
+
+// GetByID 根据ID获取域名
+func (r *domainRepository) GetByID(ctx context.Context, id uint) (*maildomain.Domain, error) {
+	var d maildomain.Domain
+	err := r.db.WithContext(ctx).First(&d, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &d, err
+}
+
+// GetByName 根据域名获取
+func (r *domainRepository) GetByName(ctx context.Context, name string) (*maildomain.Domain, error) {
+	var d maildomain.Domain
+	err := r.db.WithContext(ctx).Where("name = ?", name).First(&d).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &d, err
+}
+
+// Update 更新域名
+func (r *domainRepository) Update(ctx context.Context, d *maildomain.Domain) error {
+	return r.db.WithContext(ctx).Save(d).Error
+}
+
+// Delete 删除域名（物理删除，删除后可被重新添加）
+func (r *domainRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&maildomain.Domain{}, id).Error
+}
+
+// ListByUser 获取用户添加的域名列表
+func (r *domainRepository) ListByUser(ctx context.Context, userID uint, offset, limit int) ([]*maildomain.Domain, error) {
+	var domains []*maildomain.Domain
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Offset(offset).
+		Limit(limit).
+		Order("created_at DESC").
+		Find(&domains).Error
+	return domains, err
+}
+
+// CountByUser 获取用户添加的域名总数
+func (r *domainRepository) CountByUser(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&maildomain.Domain{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// ListVerified 获取所有已验证的域名（按添加顺序）
+func (r *domainRepository) ListVerified(ctx context.Context) ([]*maildomain.Domain, error) {
+	var domains []*maildomain.Domain
+	err := r.db.WithContext(ctx).
+		Where("status = ?", maildomain.StatusVerified).
+		Order("id ASC").
+		Find(&domains).Error
+	return domains, err
+}
+
+// ListUnverifiedBefore 获取指定时间之前添加、从未通过验证的用户域名
+func (r *domainRepository) ListUnverifiedBefore(ctx context.Context, before time.Time, limit int) ([]*maildomain.Domain, error) {
+	var domains []*maildomain.Domain
+	err := r.db.WithContext(ctx).
+		Where("user_id <> 0 AND verified_at IS NULL AND created_at < ?", before).
+		Order("id ASC").
+		Limit(limit).
+		Find(&domains).Error
+	return domains, err
+}
+
+// ExistsByName 检查域名是否已存在
+func (r *domainRepository) ExistsByName(ctx context.Context, name string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&maildomain.Domain{}).Where("name = ?", name).Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,67 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"temp-mailbox-service/internal/domain/job"
+	"temp-mailbox-service/internal/infrastructure/database"
+
+	"gorm.io/gorm"
+)
+
+// jobRepository 任务执行记录仓储实现
+type jobRepository struct {
+	db *gorm.DB
+}
+
+// NewJobRepository 创建任务执行记录仓储实例
+func NewJobRepository() job.Repository {
+	return &jobRepository{
+		db: database.GetDB(),
+	}
+}
+
+// Create 保存执行记录
+func (r *jobRepository) Create(ctx context.Context, run *job.Run) error {
+	return r.db.WithContext(ctx).Create(run).Error
+}
+
+// LatestByJob 获取任务最近一次执行记录
+func (r *jobRepository) LatestByJob(ctx context.Context, jobName string) (*job.Run, error) {
+	var run job.Run
+	err := r.db.WithContext(ctx).
+		Where("job_name = ?", jobName).
+		Order("started_at DESC, id DESC").
+		First(&run).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &run, err
+}
+
+// ListByJob 获取任务的执行记录（最近的在前）
+func (r *jobRepository) ListByJob(ctx context.Context, jobName string, offset, limit int) ([]*job.Run, error) {
+	var runs []*job.Run
+	err := r.db.WithContext(ctx).
+		Where("job_name = ?", jobName).
+		Offset(offset).
+		Limit(limit).
+		Order("started_at DESC, id DESC").
+		Find(&runs).Error
+	return runs, err
+}
+
+// CountByJob 获取任务的执行记录总数
+func (r *jobRepository) CountByJob(ctx context.Context, jobName string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&job.Run{}).Where("job_name = ?", jobName).Count(&count).Error
+	return count, err
+}
+
+// DeleteBefore 删除指定时间之前开始的执行记录，返回删除的数量
+func (r *jobRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("started_at < ?", before).Delete(&job.Run{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,149 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"temp-mailbox-service/internal/domain/lockout"
+	"temp-mailbox-service/internal/infrastructure/database"
+
+	"gorm.io/gorm"
+)
+
+// lockoutRepository 登录失败记录仓储实现
+type lockoutRepository struct {
+	db *gorm.DB
+}
+
+// NewLockoutRepository 创建登录失败记录仓储实例
+func NewLockoutRepository() lockout.Repository {
+	return &lockoutRepository{
+		db: database.GetDB(),
+	}
+}
+
+// Get 根据统计维度和值获取记录
+func (r *lockoutRepository) Get(ctx context.Context, kind lockout.Kind, value string) (*lockout.Record, error) {
+	var rec lockout.Record
+	err := r.db.WithContext(ctx).Where("kind = ? AND value = ?", kind, value).First(&rec).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &rec, err
+}
+
+// GetByID 根据ID获取记录
+func (r *lockoutRepository) GetByID(ctx context.Context, id uint) (*lockout.Record, error) {
+	var rec lockout.Record
+	err := r.db.WithContext(ctx).First(&rec, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &rec, err
+}
+
+// RecordFailure 原子地累加一次失败；首次失败早于 windowStart 或锁定已到期时重新计数
+func (r *lockoutRepository) RecordFailure(ctx context.Context, kind lockout.Kind, value string, now, windowStart time.Time) (*lockout.Record, error) {
+	var rec lockout.Record
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 窗口内且未处于已到期锁定的记录直接累加
+		result := tx.Model(&lockout.Record{}).
+			Where("kind = ? AND value = ? AND first_failure_at >= ?", kind, value, windowStart).
+			Where("locked_until IS NULL OR locked_until > ?", now).
+			Updates(map[string]interface{}{
+				"failures":        gorm.Expr("failures + 1"),
+				"last_failure_at": now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			// 记录不存在或需要重新计数
+			result = tx.Model(&lockout.Record{}).
+				Where("kind = ? AND value = ?", kind, value).
+				Updates(map[string]interface{}{
+					"failures":         1,
+					"first_failure_at": now,
+					"last_failure_at":  now,
+					"locked_until":     nil,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				rec = lockout.Record{
+					Kind:           kind,
+					Value:          value,
+					Failures:       1,
+					FirstFailureAt: now,
+					LastFailureAt:  now,
+				}
+				return tx.Create(&rec).Error
+			}
+		}
+
+		return tx.Where("kind = ? AND value = ?", kind, value).First(&rec).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// Lock 锁定记录直到指定时间
+func (r *lockoutRepository) Lock(ctx context.Context, id uint, until time.Time) error {
+	return r.db.WithContext(ctx).Model(&lockout.Record{}).
+		Where("id = ?", id).
+		Update("locked_until", until).Error
+}
+
+// Delete 删除指定维度和值的记录
+func (r *lockoutRepository) Delete(ctx context.Context, kind lockout.Kind, value string) error {
+	return r.db.WithContext(ctx).Where("kind = ? AND value = ?", kind, value).Delete(&lockout.Record{}).Error
+}
+
+// DeleteByID 根据ID删除记录
+func (r *lockoutRepository) DeleteByID(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&lockout.Record{}, id).Error
+}
+
+// List 按条件分页获取记录
+func (r *lockoutRepository) List(ctx context.Context, filter *lockout.Filter, now time.Time, offset, limit int) ([]*lockout.Record, error) {
+	var records []*lockout.Record
+	err := r.filterQuery(ctx, filter, now).
+		Offset(offset).
+		Limit(limit).
+		Order("last_failure_at DESC").
+		Find(&records).Error
+	return records, err
+}
+
+// Count 统计符合条件的记录数量
+func (r *lockoutRepository) Count(ctx context.Context, filter *lockout.Filter, now time.Time) (int64, error) {
+	var count int64
+	err := r.filterQuery(ctx, filter, now).Count(&count).Error
+	return count, err
+}
+
+// filterQuery 构建记录查询
+func (r *lockoutRepository) filterQuery(ctx context.Context, filter *lockout.Filter, now time.Time) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&lockout.Record{})
+	if filter.Kind != "" {
+		query = query.Where("kind = ?", filter.Kind)
+	}
+	if filter.Locked {
+		query = query.Where("locked_until > ?", now)
+	}
+	return query
+}
+
+// DeleteStaleBefore 删除最后一次失败早于 before 且未处于锁定状态的记录
+func (r *lockoutRepository) DeleteStaleBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("last_failure_at < ?", before).
+		Where("locked_until IS NULL OR locked_until < ?", before).
+		Delete(&lockout.Record{})
+	return result.RowsAffected, result.Error
+}
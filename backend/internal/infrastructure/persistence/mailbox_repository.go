@@ -0,0 +1,112 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"temp-mailbox-service/internal/domain/mailbox"
+	"temp-mailbox-service/internal/infrastructure/database"
+
+	"gorm.io/gorm"
+)
+
+// mailboxRepository 邮箱仓储实现
+type mailboxRepository struct {
+	db *gorm.DB
+}
+
+// NewMailboxRepository 创建邮箱仓储实例
+func NewMailboxRepository() mailbox.Repository {
+	return &mailboxRepository{
+		db: database.GetDB(),
+	}
+}
+
+// Create 创建邮箱
+func (r *mailboxRepository) Create(ctx context.Context, m *mailbox.Mailbox) error {
+	return r.db.WithContext(ctx).Create(m).Error
+}
+
+// GetByID 根据ID获取邮箱
+func (r *mailboxRepository) GetByID(ctx context.Context, id uint) (*mailbox.Mailbox, error) {
+	var m mailbox.Mailbox
+	err := r.db.WithContext(ctx).First(&m, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &m, err
+}
+
+// GetByAddress 根据完整地址获取邮箱
+func (r *mailboxRepository) GetByAddress(ctx context.Context, address string) (*mailbox.Mailbox, error) {
+	var m mailbox.Mailbox
+	err := r.db.WithContext(ctx).Where("address = ?", address).First(&m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &m, err
+}
+
+// Update 更新邮箱
+func (r *mailboxRepository) Update(ctx context.Context, m *mailbox.Mailbox) error {
+	return r.db.WithContext(ctx).Save(m).Error
+}
+
+// Delete 删除邮箱（软删除）
+func (r *mailboxRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&mailbox.Mailbox{}, id).Error
+}
+
+// ListByUser 获取用户的邮箱列表
+func (r *mailboxRepository) ListByUser(ctx context.Context, userID uint, offset, limit int) ([]*mailbox.Mailbox, error) {
+	var mailboxes []*mailbox.Mailbox
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Offset(offset).
+		Limit(limit).
+		Order("created_at DESC").
+		Find(&mailboxes).Error
+	return mailboxes, err
+}
+
+// CountByUser 获取用户的邮箱总数
+func (r *mailboxRepository) CountByUser(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&mailbox.Mailbox{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// ExistsByAddress 检查地址是否已被占用（包含已删除的邮箱，防止地址被重复使用）
+func (r *mailboxRepository) ExistsByAddress(ctx context.Context, address string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Unscoped().Model(&mailbox.Mailbox{}).Where("address = ?", address).Count(&count).Error
+	return count > 0, err
+}
+
+// CountActiveByDomain 统计指定域名下未过期的邮箱数量
+func (r *mailboxRepository) CountActiveByDomain(ctx context.Context, domain string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&mailbox.Mailbox{}).
+		Where("domain = ? AND status = ? AND expires_at > ?", domain, mailbox.StatusActive, time.Now()).
+		Count(&count).Error
+	return count, err
+}
+
+// UpdateExpiry 更新过期时间并恢复为有效状态
+func (r *mailboxRepository) UpdateExpiry(ctx context.Context, id uint, expiresAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&mailbox.Mailbox{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"expires_at": expiresAt,
+			"status":     mailbox.StatusActive,
+		}).Error
+}
+
+// MarkExpired 将指定时间之前到期的有效邮箱标记为过期，返回受影响的数量
+func (r *mailboxRepository) MarkExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&mailbox.Mailbox{}).
+		Where("status = ? AND expires_at <= ?", mailbox.StatusActive, before).
+		Update("status", mailbox.StatusExpired)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,120 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"temp-mailbox-service/internal/domain/mailfilter"
+	"temp-mailbox-service/internal/infrastructure/database"
+
+	"gorm.io/gorm"
+)
+
+// mailFilterRepository 过滤规则仓储实现
+type mailFilterRepository struct {
+	db *gorm.DB
+}
+
+// NewMailFilterRepository 创建过滤规则仓储实例
+func NewMailFilterRepository() mailfilter.Repository {
+	return &mailFilterRepository{
+		db: database.GetDB(),
+	}
+}
+
+// CreateRule 创建规则
+func (r *mailFilterRepository) CreateRule(ctx context.Context, rule *mailfilter.Rule) error {
+	return r.db.WithContext(ctx).Create(rule).Error
+}
+
+// UpdateRule 保存规则的全部字段
+func (r *mailFilterRepository) UpdateRule(ctx context.Context, rule *mailfilter.Rule) error {
+	return r.db.WithContext(ctx).Save(rule).Error
+}
+
+// DeleteRule 删除规则
+func (r *mailFilterRepository) DeleteRule(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&mailfilter.Rule{}, id).Error
+}
+
+// GetRule 根据ID获取规则
+func (r *mailFilterRepository) GetRule(ctx context.Context, id uint) (*mailfilter.Rule, error) {
+	var rule mailfilter.Rule
+	err := r.db.WithContext(ctx).First(&rule, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &rule, err
+}
+
+// ListRules 按优先级和创建顺序获取邮箱的全部规则
+func (r *mailFilterRepository) ListRules(ctx context.Context, mailboxID uint) ([]*mailfilter.Rule, error) {
+	var rules []*mailfilter.Rule
+	err := r.db.WithContext(ctx).
+		Where("mailbox_id = ?", mailboxID).
+		Order("priority ASC, id ASC").
+		Find(&rules).Error
+	return rules, err
+}
+
+// CountRules 统计邮箱的规则数量
+func (r *mailFilterRepository) CountRules(ctx context.Context, mailboxID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&mailfilter.Rule{}).
+		Where("mailbox_id = ?", mailboxID).
+		Count(&count).Error
+	return count, err
+}
+
+// GetScript 获取邮箱的 Sieve 脚本
+func (r *mailFilterRepository) GetScript(ctx context.Context, mailboxID uint) (*mailfilter.Script, error) {
+	var script mailfilter.Script
+	err := r.db.WithContext(ctx).Where("mailbox_id = ?", mailboxID).First(&script).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &script, err
+}
+
+// SaveScript 创建或更新 Sieve 脚本
+func (r *mailFilterRepository) SaveScript(ctx context.Context, script *mailfilter.Script) error {
+	return r.db.WithContext(ctx).Save(script).Error
+}
+
+// DeleteScript 删除邮箱的 Sieve 脚本
+func (r *mailFilterRepository) DeleteScript(ctx context.Context, mailboxID uint) error {
+	return r.db.WithContext(ctx).Where("mailbox_id = ?", mailboxID).Delete(&mailfilter.Script{}).Error
+}
+
+// CreateDecision 保存决定记录
+func (r *mailFilterRepository) CreateDecision(ctx context.Context, d *mailfilter.Decision) error {
+	return r.db.WithContext(ctx).Create(d).Error
+}
+
+// ListDecisions 获取邮箱的决定记录，按时间倒序
+func (r *mailFilterRepository) ListDecisions(ctx context.Context, mailboxID uint, offset, limit int) ([]*mailfilter.Decision, error) {
+	var decisions []*mailfilter.Decision
+	err := r.db.WithContext(ctx).
+		Where("mailbox_id = ?", mailboxID).
+		Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&decisions).Error
+	return decisions, err
+}
+
+// CountDecisions 统计邮箱的决定记录数量
+func (r *mailFilterRepository) CountDecisions(ctx context.Context, mailboxID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&mailfilter.Decision{}).
+		Where("mailbox_id = ?", mailboxID).
+		Count(&count).Error
+	return count, err
+}
+
+// DeleteDecisionsBefore 删除指定时间之前的决定记录
+func (r *mailFilterRepository) DeleteDecisionsBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("created_at < ?", before).Delete(&mailfilter.Decision{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,133 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"temp-mailbox-service/internal/domain/message"
+	"temp-mailbox-service/internal/infrastructure/database"
+
+	"gorm.io/gorm"
+)
+
+// messageRepository 邮件仓储实现
+type messageRepository struct {
+	db *gorm.DB
+}
+
+// NewMessageRepository 创建邮件仓储实例
+func NewMessageRepository() message.Repository {
+	return &messageRepository{
+		db: database.GetDB(),
+	}
+}
+
+// Create 保存邮件
+func (r *messageRepository) Create(ctx context.Context, msg *message.Message) error {
+	return r.db.WithContext(ctx).Create(msg).Error
+}
+
+// CreateBatch 在同一事务中保存多封邮件，任意一封失败时全部回滚
+func (r *messageRepository) CreateBatch(ctx context.Context, msgs []*message.Message) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, msg := range msgs {
+			if err := tx.Create(msg).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetByID 根据ID获取邮件（包含附件元数据）
+func (r *messageRepository) GetByID(ctx context.Context, id uint) (*message.Message, error) {
+	var msg message.Message
+	err := r.db.WithContext(ctx).Preload("Attachments").First(&msg, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &msg, err
+}
+
+// Delete 删除邮件（软删除）
+func (r *messageRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&message.Message{}, id).Error
+}
+
+// ListByMailbox 获取邮箱的邮件列表（不加载邮件头和正文）
+func (r *messageRepository) ListByMailbox(ctx context.Context, mailboxID uint, offset, limit int) ([]*message.Message, error) {
+	var messages []*message.Message
+	err := r.db.WithContext(ctx).
+		Omit("headers", "text_body", "html_body").
+		Where("mailbox_id = ?", mailboxID).
+		Offset(offset).
+		Limit(limit).
+		Order("received_at DESC").
+		Find(&messages).Error
+	return messages, err
+}
+
+// CountByMailbox 获取邮箱的邮件总数
+func (r *messageRepository) CountByMailbox(ctx context.Context, mailboxID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&message.Message{}).Where("mailbox_id = ?", mailboxID).Count(&count).Error
+	return count, err
+}
+
+// PurgeReceivedBefore 分批物理删除指定时间之前收到的邮件（包括已软删除的邮件）及其附件元数据
+func (r *messageRepository) PurgeReceivedBefore(ctx context.Context, before time.Time, batchSize int) (int64, error) {
+	var total int64
+	for {
+		var ids []uint
+		err := r.db.WithContext(ctx).Unscoped().Model(&message.Message{}).
+			Where("received_at < ?", before).
+			Limit(batchSize).
+			Pluck("id", &ids).Error
+		if err != nil {
+			return total, err
+		}
+		if len(ids) == 0 {
+			return total, nil
+		}
+
+		err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("message_id IN ?", ids).Delete(&message.Attachment{}).Error; err != nil {
+				return err
+			}
+			return tx.Unscoped().Where("id IN ?", ids).Delete(&message.Message{}).Error
+		})
+		if err != nil {
+			return total, err
+		}
+		total += int64(len(ids))
+	}
+}
+
+// StorageKeyInUse 检查对象存储键是否仍被附件或邮件原始内容引用
+func (r *messageRepository) StorageKeyInUse(ctx context.Context, key string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&message.Attachment{}).Where("storage_key = ?", key).Limit(1).Count(&count).Error
+	if err != nil || count > 0 {
+		return count > 0, err
+	}
+	err = r.db.WithContext(ctx).Unscoped().Model(&message.Message{}).Where("raw_key = ?", key).Limit(1).Count(&count).Error
+	return count > 0, err
+}
+
+// GetAttachment 根据ID获取附件元数据
+func (r *messageRepository) GetAttachment(ctx context.Context, id uint) (*message.Attachment, error) {
+	var attachment message.Attachment
+	err := r.db.WithContext(ctx).First(&attachment, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &attachment, err
+}
+
+// MarkRead 标记邮件为已读
+func (r *messageRepository) MarkRead(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&message.Message{}).
+		Where("id = ?", id).
+		Update("is_read", true).Error
+}
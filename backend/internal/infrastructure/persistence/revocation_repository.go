@@ -0,0 +1,45 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"temp-mailbox-service/internal/domain/session"
+	"temp-mailbox-service/internal/infrastructure/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// revocationRepository 令牌吊销记录仓储实现
+type revocationRepository struct {
+	db *gorm.DB
+}
+
+// NewRevocationRepository 创建令牌吊销记录仓储实例
+func NewRevocationRepository() session.RevocationRepository {
+	return &revocationRepository{
+		db: database.GetDB(),
+	}
+}
+
+// Create 保存吊销记录，同一令牌或会话重复吊销时忽略
+func (r *revocationRepository) Create(ctx context.Context, rev *session.Revocation) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(rev).Error
+}
+
+// ListActiveSince 获取 since 之后创建且尚未过期的记录
+func (r *revocationRepository) ListActiveSince(ctx context.Context, since, now time.Time) ([]*session.Revocation, error) {
+	var revocations []*session.Revocation
+	err := r.db.WithContext(ctx).
+		Where("created_at > ? AND expires_at > ?", since, now).
+		Order("id ASC").
+		Find(&revocations).Error
+	return revocations, err
+}
+
+// DeleteExpiredBefore 删除在指定时间之前过期的记录，返回删除的数量
+func (r *revocationRepository) DeleteExpiredBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&session.Revocation{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,92 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"temp-mailbox-service/internal/domain/session"
+	"temp-mailbox-service/internal/infrastructure/database"
+
+	"gorm.io/gorm"
+)
+
+// sessionRepository 登录会话仓储实现
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository 创建登录会话仓储实例
+func NewSessionRepository() session.Repository {
+	return &sessionRepository{
+		db: database.GetDB(),
+	}
+}
+
+// Create 创建会话
+func (r *sessionRepository) Create(ctx context.Context, s *session.Session) error {
+	return r.db.WithContext(ctx).Create(s).Error
+}
+
+// GetByFamilyID 根据令牌族ID获取会话
+func (r *sessionRepository) GetByFamilyID(ctx context.Context, familyID string) (*session.Session, error) {
+	var s session.Session
+	err := r.db.WithContext(ctx).Where("family_id = ?", familyID).First(&s).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &s, err
+}
+
+// GetByID 根据ID获取会话
+func (r *sessionRepository) GetByID(ctx context.Context, id uint) (*session.Session, error) {
+	var s session.Session
+	err := r.db.WithContext(ctx).First(&s, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &s, err
+}
+
+// Rotate 以比较并交换的方式轮换刷新令牌，并发刷新时只有一个请求能成功
+func (r *sessionRepository) Rotate(ctx context.Context, id uint, oldHash, newHash string, generation int, expiresAt time.Time, seenIP string) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&session.Session{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", id, oldHash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash": newHash,
+			"generation":         generation,
+			"rotated_at":         &now,
+			"expires_at":         expiresAt,
+			"last_seen_at":       now,
+			"last_seen_ip":       seenIP,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// Revoke 注销会话
+func (r *sessionRepository) Revoke(ctx context.Context, id uint, reason string) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Model(&session.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"revoked_at":    &now,
+			"revoke_reason": reason,
+		}).Error
+}
+
+// ListActiveByUser 获取用户未注销且未过期的会话
+func (r *sessionRepository) ListActiveByUser(ctx context.Context, userID uint) ([]*session.Session, error) {
+	var sessions []*session.Session
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("id DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// DeleteExpiredBefore 删除在指定时间之前过期的会话，返回删除的数量
+func (r *sessionRepository) DeleteExpiredBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&session.Session{})
+	return result.RowsAffected, result.Error
+}
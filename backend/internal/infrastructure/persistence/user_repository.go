@@ -3,6 +3,7 @@ package persistence
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 
 	"temp-mailbox-service/internal/domain/user"
@@ -58,6 +59,16 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (*u
 	return &u, err
 }
 
+// GetByIDWithDeleted 根据ID获取用户（包含已软删除的用户）
+func (r *userRepository) GetByIDWithDeleted(ctx context.Context, id uint) (*user.User, error) {
+	var u user.User
+	err := r.db.WithContext(ctx).Unscoped().First(&u, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &u, err
+}
+
 // Update 更新用户信息
 func (r *userRepository) Update(ctx context.Context, u *user.User) error {
 	return r.db.WithContext(ctx).Save(u).Error
@@ -86,6 +97,58 @@ func (r *userRepository) Count(ctx context.Context) (int64, error) {
 	return count, err
 }
 
+// CountByRole 获取指定角色的用户数量
+func (r *userRepository) CountByRole(ctx context.Context, role string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&user.User{}).Where("role = ?", role).Count(&count).Error
+	return count, err
+}
+
+// Search 按条件分页搜索用户
+func (r *userRepository) Search(ctx context.Context, filter *user.SearchFilter, offset, limit int) ([]*user.User, error) {
+	var users []*user.User
+	err := r.searchQuery(ctx, filter).
+		Offset(offset).
+		Limit(limit).
+		Order("created_at DESC").
+		Find(&users).Error
+	return users, err
+}
+
+// CountSearch 统计符合搜索条件的用户数量
+func (r *userRepository) CountSearch(ctx context.Context, filter *user.SearchFilter) (int64, error) {
+	var count int64
+	err := r.searchQuery(ctx, filter).Count(&count).Error
+	return count, err
+}
+
+// searchQuery 构建用户搜索查询
+func (r *userRepository) searchQuery(ctx context.Context, filter *user.SearchFilter) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&user.User{})
+	
+	switch filter.Status {
+	case user.StatusDeleted:
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	case user.StatusActive:
+		query = query.Where("is_active = ?", true)
+	case user.StatusInactive:
+		query = query.Where("is_active = ?", false)
+	}
+	
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	
+	if filter.Keyword != "" {
+		// 转义通配符，按字面匹配关键字
+		keyword := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(strings.ToLower(filter.Keyword))
+		pattern := "%" + keyword + "%"
+		query = query.Where(`(LOWER(email) LIKE ? ESCAPE '\' OR LOWER(username) LIKE ? ESCAPE '\')`, pattern, pattern)
+	}
+	
+	return query
+}
+
 // Exists 检查用户是否存在
 func (r *userRepository) Exists(ctx context.Context, id uint) (bool, error) {
 	var count int64
@@ -142,6 +205,143 @@ func (r *userRepository) ClearPasswordResetToken(ctx context.Context, id uint) e
 		}).Error
 }
 
+// GetByPasswordResetToken 根据密码重置令牌获取用户
+func (r *userRepository) GetByPasswordResetToken(ctx context.Context, token string) (*user.User, error) {
+	var u user.User
+	err := r.db.WithContext(ctx).Where("password_reset_token = ?", token).First(&u).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &u, err
+}
+
+// ResetPassword 使用密码重置令牌更新密码并清除令牌，令牌已被使用或替换时返回false
+func (r *userRepository) ResetPassword(ctx context.Context, id uint, token, hashedPassword string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&user.User{}).
+		Where("id = ? AND password_reset_token = ?", id, token).
+		Updates(map[string]interface{}{
+			"password":              hashedPassword,
+			"password_reset_token":  nil,
+			"password_reset_expiry": nil,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// MarkEmailVerified 标记用户邮箱已验证
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id uint) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Model(&user.User{}).
+		Where("id = ? AND email_verified = ?", id, false).
+		Updates(map[string]interface{}{
+			"email_verified":    true,
+			"email_verified_at": &now,
+		}).Error
+}
+
+// SetTOTPSecret 保存待确认的TOTP密钥，已启用两步验证时不会覆盖
+func (r *userRepository) SetTOTPSecret(ctx context.Context, id uint, encryptedSecret string) error {
+	return r.db.WithContext(ctx).Model(&user.User{}).
+		Where("id = ? AND totp_enabled = ?", id, false).
+		Update("totp_secret", encryptedSecret).Error
+}
+
+// EnableTOTP 启用两步验证并写入恢复码
+func (r *userRepository) EnableTOTP(ctx context.Context, id uint, counter int64, recoveryCodeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(&user.User{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{
+				"totp_enabled":      true,
+				"totp_enabled_at":   &now,
+				"totp_last_counter": counter,
+			}).Error; err != nil {
+			return err
+		}
+		return replaceRecoveryCodes(tx, id, recoveryCodeHashes)
+	})
+}
+
+// DisableTOTP 关闭两步验证并删除密钥和恢复码
+func (r *userRepository) DisableTOTP(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user.User{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{
+				"totp_secret":       "",
+				"totp_enabled":      false,
+				"totp_enabled_at":   nil,
+				"totp_last_counter": 0,
+			}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", id).Delete(&user.RecoveryCode{}).Error
+	})
+}
+
+// UseTOTPCounter 记录已使用的时间步，同一时间步或更早的验证码不能再次使用
+func (r *userRepository) UseTOTPCounter(ctx context.Context, id uint, counter int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&user.User{}).
+		Where("id = ? AND totp_last_counter < ?", id, counter).
+		Update("totp_last_counter", counter)
+	return result.RowsAffected == 1, result.Error
+}
+
+// ReplaceRecoveryCodes 用新的恢复码替换全部旧恢复码
+func (r *userRepository) ReplaceRecoveryCodes(ctx context.Context, id uint, codeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, id, codeHashes)
+	})
+}
+
+// UseRecoveryCode 将未使用的恢复码标记为已使用
+func (r *userRepository) UseRecoveryCode(ctx context.Context, id uint, codeHash string) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&user.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", id, codeHash).
+		Update("used_at", &now)
+	return result.RowsAffected == 1, result.Error
+}
+
+// replaceRecoveryCodes 在事务内删除旧恢复码并写入新恢复码
+func replaceRecoveryCodes(tx *gorm.DB, id uint, codeHashes []string) error {
+	if err := tx.Where("user_id = ?", id).Delete(&user.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	if len(codeHashes) == 0 {
+		return nil
+	}
+	codes := make([]*user.RecoveryCode, 0, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes = append(codes, &user.RecoveryCode{UserID: id, CodeHash: hash})
+	}
+	return tx.Create(&codes).Error
+}
+
+// GetIdentity 根据身份提供方和 subject 获取外部身份
+func (r *userRepository) GetIdentity(ctx context.Context, provider, subject string) (*user.Identity, error) {
+	var identity user.Identity
+	err := r.db.WithContext(ctx).
+		Where("provider = ? AND subject = ?", provider, subject).
+		First(&identity).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &identity, err
+}
+
+// CreateIdentity 关联外部身份
+func (r *userRepository) CreateIdentity(ctx context.Context, identity *user.Identity) error {
+	return r.db.WithContext(ctx).Create(identity).Error
+}
+
+// UpdateRole 更新用户角色
+func (r *userRepository) UpdateRole(ctx context.Context, id uint, role string) error {
+	return r.db.WithContext(ctx).Model(&user.User{}).
+		Where("id = ?", id).
+		Update("role", role).Error
+}
+
 // Activate 激活用户
 func (r *userRepository) Activate(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Model(&user.User{}).
@@ -154,4 +354,11 @@ func (r *userRepository) Deactivate(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Model(&user.User{}).
 		Where("id = ?", id).
 		Update("is_active", false).Error
+}
+
+// Restore 恢复已软删除的用户
+func (r *userRepository) Restore(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Unscoped().Model(&user.User{}).
+		Where("id = ?", id).
+		Update("deleted_at", nil).Error
 } 
\ No newline at end of file
@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval 清理已恢复满额的令牌桶的间隔
+const sweepInterval = time.Minute
+
+// memoryEntry 内存中的令牌桶及其策略
+type memoryEntry struct {
+	bucket bucket
+	policy Policy
+}
+
+// MemoryStore 基于内存的令牌桶存储（单实例部署使用）
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryEntry
+	lastSweep time.Time
+}
+
+// NewMemoryStore 创建内存令牌桶存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*memoryEntry),
+	}
+}
+
+// Take 从 key 对应的令牌桶中取出一个令牌
+func (s *MemoryStore) Take(ctx context.Context, key string, policy Policy, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	entry, ok := s.buckets[key]
+	if !ok {
+		entry = &memoryEntry{
+			bucket: bucket{tokens: float64(policy.Capacity()), updated: now},
+		}
+		s.buckets[key] = entry
+	}
+	entry.policy = policy
+	return entry.bucket.take(policy, now), nil
+}
+
+// Len 返回当前保存的令牌桶数量
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.buckets)
+}
+
+// sweep 删除已恢复满额的令牌桶，它们与新建的桶没有区别（调用方需持有锁）
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, entry := range s.buckets {
+		if entry.bucket.full(entry.policy, now) {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
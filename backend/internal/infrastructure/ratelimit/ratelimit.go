@@ -0,0 +1,141 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"temp-mailbox-service/internal/infrastructure/config"
+)
+
+// Policy 令牌桶限流策略：每 Window 补充 Limit 个令牌，桶容量为 Burst
+type Policy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+	Burst  int
+}
+
+// Capacity 桶容量，未设置 Burst 时等于 Limit
+func (p Policy) Capacity() int {
+	if p.Burst > 0 {
+		return p.Burst
+	}
+	return p.Limit
+}
+
+// interval 补充一个令牌所需的时间
+func (p Policy) interval() time.Duration {
+	return p.Window / time.Duration(p.Limit)
+}
+
+// Result 一次限流检查的结果
+type Result struct {
+	Allowed    bool
+	Limit      int           // 桶容量
+	Remaining  int           // 本次请求后剩余的令牌数
+	RetryAfter time.Duration // 被拒绝时需要等待的时间
+	ResetAfter time.Duration // 令牌桶恢复满额所需的时间
+}
+
+// Store 令牌桶状态存储接口，实现需要保证同一个 key 的 Take 是原子的
+type Store interface {
+	Take(ctx context.Context, key string, policy Policy, now time.Time) (Result, error)
+}
+
+// NewStore 根据配置创建令牌桶存储
+func NewStore(cfg *config.RateLimitConfig) (Store, error) {
+	switch cfg.Driver {
+	case "", "memory":
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("不支持的限流存储驱动: %s", cfg.Driver)
+	}
+}
+
+// Rules 按路由选择限流策略
+type Rules struct {
+	Routes map[string]Policy // 键为 "METHOD 路由模板"，例如 "POST /api/auth/login"
+	Read   Policy            // GET/HEAD/OPTIONS 请求的默认策略
+	Write  Policy            // 其他请求的默认策略
+}
+
+// NewRules 根据配置创建路由限流规则
+func NewRules(cfg *config.RateLimitConfig) *Rules {
+	auth := toPolicy("auth", cfg.Auth)
+	login := toPolicy("login", cfg.Login) // 两步验证与密码登录共用令牌桶
+	return &Rules{
+		Routes: map[string]Policy{
+			"POST /api/auth/login":                   login,
+			"POST /api/auth/mfa":                     login,
+			"POST /api/auth/register":                toPolicy("register", cfg.Register),
+			"POST /api/auth/forgot-password":         auth,
+			"POST /api/auth/reset-password":          auth,
+			"POST /api/auth/verify-email":            auth,
+			"POST /api/auth/resend-verification":     auth,
+			"GET /api/auth/oidc/:provider/authorize": auth,
+			"GET /api/auth/oidc/:provider/callback":  auth,
+		},
+		Read:  toPolicy("read", cfg.Read),
+		Write: toPolicy("write", cfg.Write),
+	}
+}
+
+// Match 返回请求对应的限流策略，route 为 gin 的路由模板（c.FullPath()）
+func (r *Rules) Match(method, route string) Policy {
+	if policy, ok := r.Routes[method+" "+route]; ok {
+		return policy
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return r.Read
+	default:
+		return r.Write
+	}
+}
+
+// toPolicy 将配置转换为限流策略
+func toPolicy(name string, cfg config.RateLimitPolicy) Policy {
+	return Policy{
+		Name:   name,
+		Limit:  cfg.Requests,
+		Window: time.Duration(cfg.Window) * time.Second,
+		Burst:  cfg.Burst,
+	}
+}
+
+// bucket 令牌桶状态
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// take 补充令牌后尝试取出一个令牌
+func (b *bucket) take(policy Policy, now time.Time) Result {
+	capacity := float64(policy.Capacity())
+	interval := policy.interval()
+
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+float64(elapsed)/float64(interval))
+		b.updated = now
+	}
+
+	result := Result{Limit: policy.Capacity()}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - b.tokens) * float64(interval))
+	}
+	result.Remaining = int(b.tokens)
+	result.ResetAfter = time.Duration((capacity - b.tokens) * float64(interval))
+	return result
+}
+
+// full 判断令牌桶在 now 时是否已恢复满额
+func (b *bucket) full(policy Policy, now time.Time) bool {
+	missing := float64(policy.Capacity()) - b.tokens
+	return now.Sub(b.updated) >= time.Duration(missing*float64(policy.interval()))
+}
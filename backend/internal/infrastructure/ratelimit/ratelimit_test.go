@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"temp-mailbox-service/internal/infrastructure/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_TokenBucket(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	policy := Policy{Name: "login", Limit: 2, Window: time.Minute, Burst: 3}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// 突发容量内全部放行
+	for i := 2; i >= 0; i-- {
+		result, err := store.Take(ctx, "ip:1.2.3.4", policy, now)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 3, result.Limit)
+		assert.Equal(t, i, result.Remaining)
+	}
+
+	// 令牌耗尽，每30秒补充一个
+	result, err := store.Take(ctx, "ip:1.2.3.4", policy, now.Add(10*time.Second))
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 20*time.Second, result.RetryAfter)
+	assert.Equal(t, 80*time.Second, result.ResetAfter)
+
+	// 不同的键互不影响
+	result, err = store.Take(ctx, "ip:5.6.7.8", policy, now.Add(10*time.Second))
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	result, err = store.Take(ctx, "ip:1.2.3.4", policy, now.Add(30*time.Second))
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	// 长时间空闲后最多恢复到桶容量
+	result, err = store.Take(ctx, "ip:1.2.3.4", policy, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Remaining)
+}
+
+func TestMemoryStore_Sweep(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	policy := Policy{Name: "read", Limit: 60, Window: time.Minute}
+	now := time.Now()
+
+	_, err := store.Take(ctx, "a", policy, now)
+	require.NoError(t, err)
+	_, err = store.Take(ctx, "b", policy, now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, store.Len())
+
+	// 超过清理间隔后，已恢复满额的桶被删除
+	_, err = store.Take(ctx, "c", policy, now.Add(2*sweepInterval))
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.Len())
+}
+
+func TestRules_Match(t *testing.T) {
+	cfg := &config.RateLimitConfig{
+		Login:    config.RateLimitPolicy{Requests: 5, Window: 60},
+		Register: config.RateLimitPolicy{Requests: 5, Window: 3600, Burst: 3},
+		Auth:     config.RateLimitPolicy{Requests: 10, Window: 600},
+		Read:     config.RateLimitPolicy{Requests: 300, Window: 60},
+		Write:    config.RateLimitPolicy{Requests: 60, Window: 60},
+	}
+	rules := NewRules(cfg)
+
+	assert.Equal(t, "login", rules.Match(http.MethodPost, "/api/auth/login").Name)
+	assert.Equal(t, "login", rules.Match(http.MethodPost, "/api/auth/mfa").Name)
+	assert.Equal(t, "register", rules.Match(http.MethodPost, "/api/auth/register").Name)
+	assert.Equal(t, "auth", rules.Match(http.MethodPost, "/api/auth/forgot-password").Name)
+	assert.Equal(t, "auth", rules.Match(http.MethodGet, "/api/auth/oidc/:provider/callback").Name)
+	assert.Equal(t, "read", rules.Match(http.MethodGet, "/api/mailboxes/:id").Name)
+	assert.Equal(t, "write", rules.Match(http.MethodDelete, "/api/mailboxes/:id").Name)
+	assert.Equal(t, 3, rules.Match(http.MethodPost, "/api/auth/register").Capacity())
+	assert.Equal(t, 5, rules.Match(http.MethodPost, "/api/auth/login").Capacity())
+}
@@ -0,0 +1,78 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustTime(t *testing.T, value string) time.Time {
+	tm, err := time.ParseInLocation("2006-01-02 15:04", value, time.UTC)
+	require.NoError(t, err)
+	return tm
+}
+
+func TestParse_Next(t *testing.T) {
+	cases := []struct {
+		spec string
+		from string
+		want string
+	}{
+		{"* * * * *", "2024-03-10 12:00", "2024-03-10 12:01"},
+		{"*/15 * * * *", "2024-03-10 12:07", "2024-03-10 12:15"},
+		{"30 3 * * *", "2024-03-10 03:30", "2024-03-11 03:30"},
+		{"0 9-17/4 * * *", "2024-03-10 10:00", "2024-03-10 13:00"},
+		{"0 0 1 * *", "2024-01-31 23:59", "2024-02-01 00:00"},
+		{"0 12 * * 1,3", "2024-03-10 12:00", "2024-03-11 12:00"}, // 周日 -> 周一
+		{"0 0 * * 7", "2024-03-10 12:00", "2024-03-17 00:00"},    // 7也表示周日
+		{"0 0 29 2 *", "2024-03-01 00:00", "2028-02-29 00:00"},
+		{"0 0 13 * 5", "2024-09-01 00:00", "2024-09-06 00:00"}, // 日和周同时受限时满足其一即可
+		{"@hourly", "2024-03-10 12:30", "2024-03-10 13:00"},
+		{"@daily", "2024-12-31 12:30", "2025-01-01 00:00"},
+	}
+
+	for _, tc := range cases {
+		schedule, err := Parse(tc.spec)
+		require.NoError(t, err, tc.spec)
+		assert.Equal(t, mustTime(t, tc.want), schedule.Next(mustTime(t, tc.from)), tc.spec)
+	}
+}
+
+func TestParse_Every(t *testing.T) {
+	schedule, err := Parse("@every 10m")
+	require.NoError(t, err)
+	from := mustTime(t, "2024-03-10 12:03")
+	assert.Equal(t, from.Add(10*time.Minute), schedule.Next(from))
+
+	_, err = Parse("@every 10ms")
+	assert.Error(t, err, "过短的间隔应该被拒绝")
+	_, err = Parse("@every soon")
+	assert.Error(t, err)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"@fortnightly",
+	} {
+		_, err := Parse(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestParse_Impossible(t *testing.T) {
+	schedule, err := Parse("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, schedule.Next(mustTime(t, "2024-01-01 00:00")).IsZero(), "永远无法匹配的表达式应该返回零值")
+}
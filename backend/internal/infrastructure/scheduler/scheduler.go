@@ -0,0 +1,291 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrJobNotFound 任务不存在
+	ErrJobNotFound = errors.New("scheduler: 任务不存在")
+	// ErrJobRunning 任务正在执行
+	ErrJobRunning = errors.New("scheduler: 任务正在执行")
+	// ErrStopped 调度器已停止
+	ErrStopped = errors.New("scheduler: 调度器已停止")
+)
+
+// Trigger 任务触发方式
+type Trigger string
+
+const (
+	// TriggerSchedule 按计划触发
+	TriggerSchedule Trigger = "schedule"
+	// TriggerManual 手动触发
+	TriggerManual Trigger = "manual"
+)
+
+// Func 任务函数，返回执行结果摘要
+type Func func(ctx context.Context) (string, error)
+
+// Job 定时任务
+type Job struct {
+	Name        string
+	Spec        string // 调度表达式，见 Parse
+	Description string
+	Run         Func
+}
+
+// Run 一次任务执行的记录
+type Run struct {
+	Job        string
+	Trigger    Trigger
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Result     string
+	Err        error
+}
+
+// Recorder 任务执行记录器，每次任务执行结束后调用
+type Recorder interface {
+	RecordRun(ctx context.Context, run *Run)
+}
+
+// JobInfo 任务状态
+type JobInfo struct {
+	Name        string
+	Spec        string
+	Description string
+	Running     bool
+	NextRun     time.Time
+}
+
+// entry 已注册的任务
+type entry struct {
+	job      Job
+	schedule Schedule
+	running  bool
+	next     time.Time
+}
+
+// Scheduler 进程内定时任务调度器
+//
+// 每个任务在独立的协程中按计划执行，同一任务不会并发执行（上一次未结束时跳过本次）。
+type Scheduler struct {
+	recorder Recorder
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	ctx     context.Context
+	cancel  context.CancelFunc
+	started bool
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// New 创建调度器，recorder可以为nil
+func New(recorder Recorder) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		recorder: recorder,
+		entries:  make(map[string]*entry),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Add 注册任务，必须在Start之前调用
+func (s *Scheduler) Add(job Job) error {
+	if job.Name == "" || job.Run == nil {
+		return fmt.Errorf("scheduler: 任务名称和函数不能为空")
+	}
+	schedule, err := Parse(job.Spec)
+	if err != nil {
+		return fmt.Errorf("scheduler: 任务 %s 的调度表达式无效: %w", job.Name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return fmt.Errorf("scheduler: 调度器已启动，无法注册任务 %s", job.Name)
+	}
+	if _, exists := s.entries[job.Name]; exists {
+		return fmt.Errorf("scheduler: 任务 %s 已存在", job.Name)
+	}
+	s.entries[job.Name] = &entry{job: job, schedule: schedule}
+	return nil
+}
+
+// Start 启动所有任务的调度
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started || s.stopped {
+		return
+	}
+	s.started = true
+
+	for _, e := range s.entries {
+		s.wg.Add(1)
+		go s.loop(e)
+	}
+}
+
+// Stop 停止调度并等待正在执行的任务结束，ctx到期时返回错误
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	s.stopped = true
+	s.mu.Unlock()
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("scheduler: 等待任务结束超时: %w", ctx.Err())
+	}
+}
+
+// Trigger 立即异步执行一次任务
+func (s *Scheduler) Trigger(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		return ErrStopped
+	}
+	e, ok := s.entries[name]
+	if !ok {
+		return ErrJobNotFound
+	}
+	if e.running {
+		return ErrJobRunning
+	}
+
+	e.running = true
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.execute(e, TriggerManual)
+	}()
+	return nil
+}
+
+// Jobs 获取所有任务的状态（按名称排序）
+func (s *Scheduler) Jobs() []JobInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	infos := make([]JobInfo, 0, len(s.entries))
+	for _, e := range s.entries {
+		infos = append(infos, s.info(e))
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// Job 获取单个任务的状态
+func (s *Scheduler) Job(name string) (JobInfo, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[name]
+	if !ok {
+		return JobInfo{}, false
+	}
+	return s.info(e), true
+}
+
+// info 生成任务状态，调用方需持有锁
+func (s *Scheduler) info(e *entry) JobInfo {
+	next := e.next
+	if next.IsZero() {
+		next = e.schedule.Next(time.Now())
+	}
+	return JobInfo{
+		Name:        e.job.Name,
+		Spec:        e.job.Spec,
+		Description: e.job.Description,
+		Running:     e.running,
+		NextRun:     next,
+	}
+}
+
+// loop 按计划循环执行任务
+func (s *Scheduler) loop(e *entry) {
+	defer s.wg.Done()
+
+	for {
+		next := e.schedule.Next(time.Now())
+		if next.IsZero() {
+			log.Printf("任务 %s 没有下一次执行时间，停止调度", e.job.Name)
+			return
+		}
+
+		s.mu.Lock()
+		e.next = next
+		s.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.mu.Lock()
+		if e.running {
+			s.mu.Unlock()
+			log.Printf("任务 %s 上一次执行尚未结束，跳过本次执行", e.job.Name)
+			continue
+		}
+		e.running = true
+		s.mu.Unlock()
+
+		s.execute(e, TriggerSchedule)
+	}
+}
+
+// execute 执行任务并记录结果，调用前需已将e.running置为true
+func (s *Scheduler) execute(e *entry, trigger Trigger) {
+	run := &Run{
+		Job:       e.job.Name,
+		Trigger:   trigger,
+		StartedAt: time.Now(),
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				run.Err = fmt.Errorf("任务异常: %v", r)
+			}
+		}()
+		run.Result, run.Err = e.job.Run(s.ctx)
+	}()
+	run.FinishedAt = time.Now()
+
+	s.mu.Lock()
+	e.running = false
+	s.mu.Unlock()
+
+	if run.Err != nil {
+		log.Printf("任务 %s 执行失败: %v", e.job.Name, run.Err)
+	}
+	if s.recorder != nil {
+		// 调度器停止时s.ctx已取消，记录执行结果使用独立的上下文
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		s.recorder.RecordRun(ctx, run)
+		cancel()
+	}
+}
@@ -0,0 +1,154 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRecorder 将执行记录保存在内存中
+type memoryRecorder struct {
+	mu   sync.Mutex
+	runs []*Run
+}
+
+func (r *memoryRecorder) RecordRun(ctx context.Context, run *Run) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs = append(r.runs, run)
+}
+
+func (r *memoryRecorder) snapshot() []*Run {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Run(nil), r.runs...)
+}
+
+func TestScheduler_ScheduledRuns(t *testing.T) {
+	recorder := &memoryRecorder{}
+	s := New(recorder)
+
+	var count int32
+	require.NoError(t, s.Add(Job{
+		Name: "tick",
+		Spec: "@every 1s",
+		Run: func(ctx context.Context) (string, error) {
+			atomic.AddInt32(&count, 1)
+			return "ok", nil
+		},
+	}))
+	s.Start()
+
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&count) >= 1 }, 3*time.Second, 20*time.Millisecond)
+	require.NoError(t, s.Stop(context.Background()))
+
+	runs := recorder.snapshot()
+	require.NotEmpty(t, runs)
+	assert.Equal(t, "tick", runs[0].Job)
+	assert.Equal(t, TriggerSchedule, runs[0].Trigger)
+	assert.Equal(t, "ok", runs[0].Result)
+	assert.NoError(t, runs[0].Err)
+	assert.False(t, runs[0].FinishedAt.Before(runs[0].StartedAt))
+}
+
+func TestScheduler_Trigger(t *testing.T) {
+	recorder := &memoryRecorder{}
+	s := New(recorder)
+
+	release := make(chan struct{})
+	require.NoError(t, s.Add(Job{
+		Name: "slow",
+		Spec: "@yearly",
+		Run: func(ctx context.Context) (string, error) {
+			<-release
+			return "", errors.New("failed")
+		},
+	}))
+	s.Start()
+
+	assert.ErrorIs(t, s.Trigger("missing"), ErrJobNotFound)
+	require.NoError(t, s.Trigger("slow"))
+	assert.ErrorIs(t, s.Trigger("slow"), ErrJobRunning, "同一任务不应该并发执行")
+
+	info, ok := s.Job("slow")
+	require.True(t, ok)
+	assert.True(t, info.Running)
+	assert.False(t, info.NextRun.IsZero())
+
+	close(release)
+	require.Eventually(t, func() bool { return len(recorder.snapshot()) == 1 }, time.Second, 10*time.Millisecond)
+
+	run := recorder.snapshot()[0]
+	assert.Equal(t, TriggerManual, run.Trigger)
+	assert.EqualError(t, run.Err, "failed")
+
+	require.NoError(t, s.Stop(context.Background()))
+	assert.ErrorIs(t, s.Trigger("slow"), ErrStopped)
+}
+
+func TestScheduler_PanicIsRecorded(t *testing.T) {
+	recorder := &memoryRecorder{}
+	s := New(recorder)
+	require.NoError(t, s.Add(Job{
+		Name: "panics",
+		Spec: "@daily",
+		Run: func(ctx context.Context) (string, error) {
+			panic("boom")
+		},
+	}))
+	s.Start()
+	require.NoError(t, s.Trigger("panics"))
+	require.NoError(t, s.Stop(context.Background()))
+
+	runs := recorder.snapshot()
+	require.Len(t, runs, 1)
+	assert.Contains(t, runs[0].Err.Error(), "boom")
+
+	info, _ := s.Job("panics")
+	assert.False(t, info.Running)
+}
+
+func TestScheduler_StopCancelsRunningJobs(t *testing.T) {
+	s := New(nil)
+	started := make(chan struct{})
+	require.NoError(t, s.Add(Job{
+		Name: "waits",
+		Spec: "@daily",
+		Run: func(ctx context.Context) (string, error) {
+			close(started)
+			<-ctx.Done()
+			return "", ctx.Err()
+		},
+	}))
+	s.Start()
+	require.NoError(t, s.Trigger("waits"))
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, s.Stop(ctx), "停止时应该取消任务上下文并等待任务结束")
+}
+
+func TestScheduler_Add(t *testing.T) {
+	s := New(nil)
+	noop := func(ctx context.Context) (string, error) { return "", nil }
+
+	require.NoError(t, s.Add(Job{Name: "a", Spec: "@hourly", Run: noop}))
+	assert.Error(t, s.Add(Job{Name: "a", Spec: "@hourly", Run: noop}), "重复的任务名称应该被拒绝")
+	assert.Error(t, s.Add(Job{Name: "b", Spec: "bad", Run: noop}))
+	assert.Error(t, s.Add(Job{Name: "", Spec: "@hourly", Run: noop}))
+
+	s.Start()
+	assert.Error(t, s.Add(Job{Name: "c", Spec: "@hourly", Run: noop}), "启动后不能注册任务")
+	require.NoError(t, s.Stop(context.Background()))
+
+	jobs := s.Jobs()
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "a", jobs[0].Name)
+}
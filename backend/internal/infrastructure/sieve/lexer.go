@@ -0,0 +1,255 @@
+package sieve
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tokenKind 词法单元类型
+type tokenKind int
+
+const (
+	tokenEOF        tokenKind = iota
+	tokenIdentifier           // if、header 等命令或测试名称
+	tokenTag                  // :is、:over 等标签
+	tokenNumber               // 数字，可带 K/M/G 后缀
+	tokenString               // 引号字符串或 text: 多行字符串
+	tokenSpecial              // [ ] ( ) , ; { }
+)
+
+// token 词法单元
+type token struct {
+	kind tokenKind
+	text string // 标识符和标签为小写名称，字符串为解码后的内容，特殊字符为字符本身
+	num  int64
+	line int
+}
+
+// describe 返回用于错误信息的词法单元描述
+func (t token) describe() string {
+	switch t.kind {
+	case tokenEOF:
+		return "脚本结尾"
+	case tokenTag:
+		return "标签 :" + t.text
+	case tokenNumber:
+		return fmt.Sprintf("数字 %d", t.num)
+	case tokenString:
+		return "字符串"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// lexer 按 RFC 5228 第 2 节的词法规则切分脚本
+type lexer struct {
+	src  string
+	pos  int
+	line int
+}
+
+// tokenize 将脚本切分为词法单元，最后一个单元为 tokenEOF
+func tokenize(src string) ([]token, error) {
+	l := &lexer{src: src, line: 1}
+	var tokens []token
+	for {
+		t, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+		if t.kind == tokenEOF {
+			return tokens, nil
+		}
+	}
+}
+
+// next 读取下一个词法单元，跳过空白和注释
+func (l *lexer) next() (token, error) {
+	if err := l.skipSpace(); err != nil {
+		return token{}, err
+	}
+	if l.pos >= len(l.src) {
+		return token{kind: tokenEOF, line: l.line}, nil
+	}
+
+	line := l.line
+	c := l.src[l.pos]
+	switch {
+	case strings.IndexByte("[](),;{}", c) >= 0:
+		l.pos++
+		return token{kind: tokenSpecial, text: string(c), line: line}, nil
+	case c == '"':
+		s, err := l.quotedString()
+		return token{kind: tokenString, text: s, line: line}, err
+	case c == ':':
+		l.pos++
+		name := l.identifier()
+		if name == "" {
+			return token{}, errorf(line, "冒号后缺少标签名称")
+		}
+		return token{kind: tokenTag, text: strings.ToLower(name), line: line}, nil
+	case isDigit(c):
+		return l.number()
+	case isIdentStart(c):
+		name := l.identifier()
+		if strings.EqualFold(name, "text") && l.pos < len(l.src) && l.src[l.pos] == ':' {
+			l.pos++
+			s, err := l.multiLine()
+			return token{kind: tokenString, text: s, line: line}, err
+		}
+		return token{kind: tokenIdentifier, text: strings.ToLower(name), line: line}, nil
+	}
+	return token{}, errorf(line, "无法识别的字符 %q", c)
+}
+
+// skipSpace 跳过空白、# 行注释和 /* */ 块注释
+func (l *lexer) skipSpace() error {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\n':
+			l.line++
+			l.pos++
+		case c == ' ' || c == '\t' || c == '\r':
+			l.pos++
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		case strings.HasPrefix(l.src[l.pos:], "/*"):
+			start := l.line
+			end := strings.Index(l.src[l.pos+2:], "*/")
+			if end < 0 {
+				return errorf(start, "块注释没有结束")
+			}
+			comment := l.src[l.pos : l.pos+2+end+2]
+			l.line += strings.Count(comment, "\n")
+			l.pos += len(comment)
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// identifier 读取标识符，不是标识符时返回空字符串
+func (l *lexer) identifier() string {
+	start := l.pos
+	if l.pos < len(l.src) && isIdentStart(l.src[l.pos]) {
+		l.pos++
+		for l.pos < len(l.src) && (isIdentStart(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.pos++
+		}
+	}
+	return l.src[start:l.pos]
+}
+
+// number 读取数字和可选的数量级后缀
+func (l *lexer) number() (token, error) {
+	line := l.line
+	var n int64
+	for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+		n = n*10 + int64(l.src[l.pos]-'0')
+		if n > 1<<32 {
+			return token{}, errorf(line, "数字过大")
+		}
+		l.pos++
+	}
+	if l.pos < len(l.src) {
+		switch l.src[l.pos] {
+		case 'k', 'K':
+			n <<= 10
+			l.pos++
+		case 'm', 'M':
+			n <<= 20
+			l.pos++
+		case 'g', 'G':
+			n <<= 30
+			l.pos++
+		}
+	}
+	if l.pos < len(l.src) && (isIdentStart(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+		return token{}, errorf(line, "数字格式错误")
+	}
+	return token{kind: tokenNumber, num: n, line: line}, nil
+}
+
+// quotedString 读取引号字符串，\" 和 \\ 为转义，其他反斜杠被忽略
+func (l *lexer) quotedString() (string, error) {
+	start := l.line
+	l.pos++
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch c {
+		case '"':
+			l.pos++
+			return b.String(), nil
+		case '\\':
+			l.pos++
+			if l.pos >= len(l.src) {
+				return "", errorf(start, "字符串没有结束")
+			}
+			c = l.src[l.pos]
+		case '\n':
+			l.line++
+		}
+		b.WriteByte(c)
+		l.pos++
+	}
+	return "", errorf(start, "字符串没有结束")
+}
+
+// multiLine 读取 text: 之后的多行字符串，以单独一行的 "." 结束，行首的 ".." 还原为 "."
+func (l *lexer) multiLine() (string, error) {
+	start := l.line
+	// text: 之后同一行只允许空白和注释
+	for l.pos < len(l.src) && (l.src[l.pos] == ' ' || l.src[l.pos] == '\t') {
+		l.pos++
+	}
+	if l.pos < len(l.src) && l.src[l.pos] == '#' {
+		for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+			l.pos++
+		}
+	}
+	if l.pos < len(l.src) && l.src[l.pos] == '\r' {
+		l.pos++
+	}
+	if l.pos >= len(l.src) || l.src[l.pos] != '\n' {
+		return "", errorf(start, "text: 之后必须换行")
+	}
+	l.pos++
+	l.line++
+
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		end := strings.IndexByte(l.src[l.pos:], '\n')
+		var line string
+		if end < 0 {
+			line = l.src[l.pos:]
+			l.pos = len(l.src)
+		} else {
+			line = l.src[l.pos : l.pos+end]
+			l.pos += end + 1
+			l.line++
+		}
+		line = strings.TrimSuffix(line, "\r")
+		if line == "." {
+			return b.String(), nil
+		}
+		if strings.HasPrefix(line, "..") {
+			line = line[1:]
+		}
+		b.WriteString(line)
+		b.WriteString("\r\n")
+	}
+	return "", errorf(start, "多行字符串没有以 \".\" 结束")
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
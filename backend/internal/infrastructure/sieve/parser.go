@@ -0,0 +1,556 @@
+package sieve
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+// maxNesting 代码块和测试的最大嵌套层数
+const maxNesting = 32
+
+// Error 脚本错误，包含出错的行号
+type Error struct {
+	Line    int
+	Message string
+}
+
+// Error 实现error接口
+func (e *Error) Error() string {
+	return fmt.Sprintf("第%d行: %s", e.Line, e.Message)
+}
+
+func errorf(line int, format string, args ...interface{}) *Error {
+	return &Error{Line: line, Message: fmt.Sprintf(format, args...)}
+}
+
+// node 语法树节点，命令和测试共用同一结构
+type node struct {
+	name     string
+	line     int
+	args     []argument
+	tests    []*node
+	testList bool // 测试以 ( ... ) 列表形式给出
+	block    []*node
+	hasBlock bool
+}
+
+// argument 命令或测试的参数
+type argument struct {
+	line   int
+	tag    string   // 标签参数的名称
+	strs   []string // 字符串或字符串列表
+	isList bool     // 以 [ ... ] 形式给出
+	num    int64
+	isNum  bool
+}
+
+func (a argument) isString() bool {
+	return a.tag == "" && !a.isNum
+}
+
+// parser 将词法单元组装为语法树
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) advance() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isSpecial(s string) bool {
+	t := p.peek()
+	return t.kind == tokenSpecial && t.text == s
+}
+
+func (p *parser) expectSpecial(s string) error {
+	t := p.advance()
+	if t.kind != tokenSpecial || t.text != s {
+		return errorf(t.line, "此处应为 %q，实际为%s", s, t.describe())
+	}
+	return nil
+}
+
+// commands 解析命令序列，直到 "}" 或脚本结尾
+func (p *parser) commands(depth int) ([]*node, error) {
+	if depth > maxNesting {
+		return nil, errorf(p.peek().line, "代码块嵌套过深")
+	}
+	var nodes []*node
+	for {
+		t := p.peek()
+		if t.kind == tokenEOF || (t.kind == tokenSpecial && t.text == "}") {
+			return nodes, nil
+		}
+		n, err := p.command(depth)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+}
+
+// command 解析单个命令：identifier arguments (";" / block)
+func (p *parser) command(depth int) (*node, error) {
+	t := p.advance()
+	if t.kind != tokenIdentifier {
+		return nil, errorf(t.line, "此处应为命令，实际为%s", t.describe())
+	}
+	n := &node{name: t.text, line: t.line}
+	if err := p.arguments(n, depth); err != nil {
+		return nil, err
+	}
+
+	if p.isSpecial(";") {
+		p.advance()
+		return n, nil
+	}
+	if !p.isSpecial("{") {
+		next := p.peek()
+		return nil, errorf(next.line, "命令 %s 之后应为 \";\" 或代码块，实际为%s", n.name, next.describe())
+	}
+	p.advance()
+	block, err := p.commands(depth + 1)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectSpecial("}"); err != nil {
+		return nil, err
+	}
+	n.block, n.hasBlock = block, true
+	return n, nil
+}
+
+// arguments 解析参数以及可选的单个测试或测试列表
+func (p *parser) arguments(n *node, depth int) error {
+	for {
+		t := p.peek()
+		switch {
+		case t.kind == tokenTag:
+			p.advance()
+			n.args = append(n.args, argument{line: t.line, tag: t.text})
+		case t.kind == tokenNumber:
+			p.advance()
+			n.args = append(n.args, argument{line: t.line, num: t.num, isNum: true})
+		case t.kind == tokenString:
+			p.advance()
+			n.args = append(n.args, argument{line: t.line, strs: []string{t.text}})
+		case t.kind == tokenSpecial && t.text == "[":
+			list, err := p.stringList()
+			if err != nil {
+				return err
+			}
+			n.args = append(n.args, list)
+		default:
+			return p.testArguments(n, depth)
+		}
+	}
+}
+
+// stringList 解析 [ "a", "b" ] 形式的字符串列表
+func (p *parser) stringList() (argument, error) {
+	open := p.advance()
+	list := argument{line: open.line, isList: true}
+	for {
+		t := p.advance()
+		if t.kind != tokenString {
+			return list, errorf(t.line, "字符串列表中应为字符串，实际为%s", t.describe())
+		}
+		list.strs = append(list.strs, t.text)
+		if p.isSpecial("]") {
+			p.advance()
+			return list, nil
+		}
+		if err := p.expectSpecial(","); err != nil {
+			return list, err
+		}
+	}
+}
+
+// testArguments 解析参数之后的单个测试或 ( ... ) 测试列表
+func (p *parser) testArguments(n *node, depth int) error {
+	if depth > maxNesting {
+		return errorf(p.peek().line, "测试嵌套过深")
+	}
+	if p.peek().kind == tokenIdentifier {
+		test, err := p.test(depth + 1)
+		if err != nil {
+			return err
+		}
+		n.tests = []*node{test}
+		return nil
+	}
+	if !p.isSpecial("(") {
+		return nil
+	}
+
+	p.advance()
+	n.testList = true
+	for {
+		test, err := p.test(depth + 1)
+		if err != nil {
+			return err
+		}
+		n.tests = append(n.tests, test)
+		if p.isSpecial(")") {
+			p.advance()
+			return nil
+		}
+		if err := p.expectSpecial(","); err != nil {
+			return err
+		}
+	}
+}
+
+// test 解析单个测试：identifier arguments
+func (p *parser) test(depth int) (*node, error) {
+	t := p.advance()
+	if t.kind != tokenIdentifier {
+		return nil, errorf(t.line, "此处应为测试，实际为%s", t.describe())
+	}
+	n := &node{name: t.text, line: t.line}
+	if err := p.arguments(n, depth); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// Parse 解析并校验脚本，错误信息包含出错的行号
+func Parse(src string) (*Script, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	nodes, err := p.commands(0)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, errorf(t.line, "多余的 \"}\"")
+	}
+
+	c := &compiler{requires: make(map[string]bool), uses: make(map[string]int)}
+	commands, err := c.commands(nodes, true)
+	if err != nil {
+		return nil, err
+	}
+	return &Script{commands: commands, uses: c.uses}, nil
+}
+
+// compiler 校验语法树并转换为可执行的命令
+type compiler struct {
+	requires map[string]bool
+	uses     map[string]int // 每种动作第一次出现的行号
+	started  bool           // 已出现 require 以外的命令
+}
+
+// commands 转换命令序列，topLevel 表示脚本顶层（只有顶层开头允许 require）
+func (c *compiler) commands(nodes []*node, topLevel bool) ([]command, error) {
+	var out []command
+	for i := 0; i < len(nodes); i++ {
+		n := nodes[i]
+		if n.name != "require" {
+			c.started = true
+		}
+
+		switch n.name {
+		case "require":
+			if !topLevel || c.started {
+				return nil, errorf(n.line, "require 必须位于脚本开头")
+			}
+			if err := c.require(n); err != nil {
+				return nil, err
+			}
+		case "if":
+			cmd, consumed, err := c.ifCommand(nodes[i:])
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, cmd)
+			i += consumed - 1
+		case "elsif", "else":
+			return nil, errorf(n.line, "%s 之前缺少 if", n.name)
+		case "stop", "keep", "discard":
+			if err := noArguments(n); err != nil {
+				return nil, err
+			}
+			if n.name == "stop" {
+				out = append(out, &stopCommand{})
+			} else {
+				out = append(out, c.use(&actionCommand{line: n.line, action: n.name}))
+			}
+		case "fileinto", "reject", "redirect":
+			cmd, err := c.action(n)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, cmd)
+		default:
+			return nil, errorf(n.line, "未知的命令 %s", n.name)
+		}
+	}
+	return out, nil
+}
+
+// require 校验扩展声明
+func (c *compiler) require(n *node) error {
+	if len(n.args) != 1 || !n.args[0].isString() || len(n.tests) > 0 || n.hasBlock {
+		return errorf(n.line, "require 需要一个字符串或字符串列表参数")
+	}
+	for _, ext := range n.args[0].strs {
+		if !extensions[ext] {
+			return errorf(n.line, "不支持的扩展 %q", ext)
+		}
+		c.requires[ext] = true
+	}
+	return nil
+}
+
+// ifCommand 转换 if 以及紧随其后的 elsif/else，返回消耗的节点数
+func (c *compiler) ifCommand(nodes []*node) (command, int, error) {
+	cmd := &ifCommand{}
+	consumed := 0
+	for consumed < len(nodes) {
+		n := nodes[consumed]
+		if consumed > 0 && n.name != "elsif" && n.name != "else" {
+			break
+		}
+		if !n.hasBlock {
+			return nil, 0, errorf(n.line, "%s 需要代码块", n.name)
+		}
+		if len(n.args) > 0 {
+			return nil, 0, errorf(n.line, "%s 不接受参数", n.name)
+		}
+		block, err := c.commands(n.block, false)
+		if err != nil {
+			return nil, 0, err
+		}
+		consumed++
+
+		if n.name == "else" {
+			if len(n.tests) > 0 {
+				return nil, 0, errorf(n.line, "else 不能带测试")
+			}
+			cmd.elseBlock = block
+			break
+		}
+		if len(n.tests) != 1 || n.testList {
+			return nil, 0, errorf(n.line, "%s 需要一个测试", n.name)
+		}
+		t, err := c.test(n.tests[0])
+		if err != nil {
+			return nil, 0, err
+		}
+		cmd.branches = append(cmd.branches, ifBranch{test: t, block: block})
+	}
+	return cmd, consumed, nil
+}
+
+// action 转换带一个字符串参数的动作
+func (c *compiler) action(n *node) (command, error) {
+	if n.name != "redirect" && !c.requires[n.name] {
+		return nil, errorf(n.line, "使用 %s 之前需要 require \"%s\"", n.name, n.name)
+	}
+	if len(n.args) != 1 || !n.args[0].isString() || n.args[0].isList || len(n.tests) > 0 || n.hasBlock {
+		return nil, errorf(n.line, "%s 需要一个字符串参数", n.name)
+	}
+	arg := n.args[0].strs[0]
+
+	switch n.name {
+	case "fileinto":
+		arg = strings.TrimSpace(arg)
+		if arg == "" || len([]rune(arg)) > maxFolderLength {
+			return nil, errorf(n.line, "文件夹名称不能为空且不能超过%d个字符", maxFolderLength)
+		}
+	case "redirect":
+		addr, err := mail.ParseAddress(arg)
+		if err != nil {
+			return nil, errorf(n.line, "无效的转发地址 %q", arg)
+		}
+		arg = addr.Address
+	}
+	return c.use(&actionCommand{line: n.line, action: n.name, arg: arg}), nil
+}
+
+// use 记录动作第一次出现的行号
+func (c *compiler) use(cmd *actionCommand) *actionCommand {
+	if _, ok := c.uses[cmd.action]; !ok {
+		c.uses[cmd.action] = cmd.line
+	}
+	return cmd
+}
+
+// noArguments 校验不带参数的命令
+func noArguments(n *node) error {
+	if len(n.args) > 0 || len(n.tests) > 0 || n.hasBlock {
+		return errorf(n.line, "%s 不接受参数", n.name)
+	}
+	return nil
+}
+
+// test 转换测试
+func (c *compiler) test(n *node) (test, error) {
+	switch n.name {
+	case "true", "false":
+		if len(n.args) > 0 || len(n.tests) > 0 {
+			return nil, errorf(n.line, "%s 不接受参数", n.name)
+		}
+		return constTest(n.name == "true"), nil
+	case "not":
+		if len(n.args) > 0 || len(n.tests) != 1 || n.testList {
+			return nil, errorf(n.line, "not 需要一个测试")
+		}
+		t, err := c.test(n.tests[0])
+		if err != nil {
+			return nil, err
+		}
+		return &notTest{test: t}, nil
+	case "allof", "anyof":
+		if len(n.args) > 0 || !n.testList {
+			return nil, errorf(n.line, "%s 需要测试列表", n.name)
+		}
+		tests := make([]test, 0, len(n.tests))
+		for _, child := range n.tests {
+			t, err := c.test(child)
+			if err != nil {
+				return nil, err
+			}
+			tests = append(tests, t)
+		}
+		return &listTest{all: n.name == "allof", tests: tests}, nil
+	case "exists":
+		if len(n.args) != 1 || !n.args[0].isString() || len(n.tests) > 0 {
+			return nil, errorf(n.line, "exists 需要一个邮件头名称列表")
+		}
+		if err := checkHeaderNames(n.line, n.args[0].strs); err != nil {
+			return nil, err
+		}
+		return &existsTest{names: n.args[0].strs}, nil
+	case "size":
+		return sizeTestOf(n)
+	case "header", "address", "envelope":
+		return c.matchTest(n)
+	}
+	return nil, errorf(n.line, "未知的测试 %s", n.name)
+}
+
+// sizeTestOf 转换 size :over/:under <数字>
+func sizeTestOf(n *node) (test, error) {
+	if len(n.args) != 2 || n.args[0].tag == "" || !n.args[1].isNum || len(n.tests) > 0 {
+		return nil, errorf(n.line, "size 的格式应为 size :over/:under <数字>")
+	}
+	switch n.args[0].tag {
+	case "over":
+		return &sizeTest{over: true, limit: n.args[1].num}, nil
+	case "under":
+		return &sizeTest{limit: n.args[1].num}, nil
+	}
+	return nil, errorf(n.line, "size 不支持标签 :%s", n.args[0].tag)
+}
+
+// matchTest 转换 header、address 和 envelope 测试：[标签...] <名称列表> <关键字列表>
+func (c *compiler) matchTest(n *node) (test, error) {
+	if n.name == "envelope" && !c.requires["envelope"] {
+		return nil, errorf(n.line, "使用 envelope 之前需要 require \"envelope\"")
+	}
+	if len(n.tests) > 0 {
+		return nil, errorf(n.line, "%s 不接受测试参数", n.name)
+	}
+
+	t := &matchTest{kind: n.name, comparator: comparatorCasemap, matchType: matchIs, part: partAll}
+	seen := make(map[string]bool)
+	args := n.args
+	for len(args) > 0 && args[0].tag != "" {
+		tag := args[0].tag
+		group := tagGroup(n.name, tag)
+		if group == "" {
+			return nil, errorf(args[0].line, "%s 不支持标签 :%s", n.name, tag)
+		}
+		if seen[group] {
+			return nil, errorf(args[0].line, "%s 重复指定了%s", n.name, group)
+		}
+		seen[group] = true
+
+		switch group {
+		case "比较器":
+			if len(args) < 2 || !args[1].isString() || args[1].isList {
+				return nil, errorf(args[0].line, ":comparator 之后需要比较器名称")
+			}
+			name := args[1].strs[0]
+			if name != comparatorCasemap && name != comparatorOctet {
+				return nil, errorf(args[1].line, "不支持的比较器 %q", name)
+			}
+			t.comparator = name
+			args = args[1:]
+		case "匹配方式":
+			t.matchType = tag
+		case "地址部分":
+			t.part = tag
+		}
+		args = args[1:]
+	}
+
+	if len(args) != 2 || !args[0].isString() || !args[1].isString() {
+		return nil, errorf(n.line, "%s 需要名称列表和关键字列表两个参数", n.name)
+	}
+	t.names, t.keys = args[0].strs, args[1].strs
+	if err := checkHeaderNames(n.line, t.names); err != nil {
+		return nil, err
+	}
+
+	for i, name := range t.names {
+		lower := strings.ToLower(name)
+		switch n.name {
+		case "address":
+			if !addressHeaders[lower] {
+				return nil, errorf(n.line, "邮件头 %s 不包含地址，不能用于 address 测试", name)
+			}
+		case "envelope":
+			if lower != "from" && lower != "to" {
+				return nil, errorf(n.line, "envelope 只支持 from 和 to")
+			}
+			t.names[i] = lower
+		}
+	}
+	return t, nil
+}
+
+// tagGroup 返回标签所属的类别，测试不支持该标签时返回空字符串
+func tagGroup(testName, tag string) string {
+	switch tag {
+	case "comparator":
+		return "比较器"
+	case matchIs, matchContains, matchMatches:
+		return "匹配方式"
+	case partAll, partLocalPart, partDomain:
+		if testName != "header" {
+			return "地址部分"
+		}
+	}
+	return ""
+}
+
+// checkHeaderNames 校验邮件头名称只包含可打印字符且不含冒号
+func checkHeaderNames(line int, names []string) error {
+	for _, name := range names {
+		if name == "" {
+			return errorf(line, "邮件头名称不能为空")
+		}
+		for i := 0; i < len(name); i++ {
+			if name[i] <= ' ' || name[i] >= 0x7f || name[i] == ':' {
+				return errorf(line, "无效的邮件头名称 %q", name)
+			}
+		}
+	}
+	return nil
+}
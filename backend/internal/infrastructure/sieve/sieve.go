@@ -0,0 +1,462 @@
+// Package sieve 实现 RFC 5228 Sieve 邮件过滤脚本的解析和执行，
+// 支持 fileinto、reject、envelope 扩展以及 i;octet、i;ascii-casemap 比较器
+package sieve
+
+import (
+	"mime"
+	"net/mail"
+	"net/textproto"
+	"strings"
+)
+
+// 动作类型
+const (
+	ActionKeep     = "keep"
+	ActionFileInto = "fileinto"
+	ActionRedirect = "redirect"
+	ActionDiscard  = "discard"
+	ActionReject   = "reject"
+)
+
+const (
+	// maxFolderLength fileinto 文件夹名称的最大长度
+	maxFolderLength = 100
+	// maxRedirects 单次执行允许的 redirect 数量
+	maxRedirects = 4
+)
+
+// 比较器
+const (
+	comparatorOctet   = "i;octet"
+	comparatorCasemap = "i;ascii-casemap"
+)
+
+// 匹配方式
+const (
+	matchIs       = "is"
+	matchContains = "contains"
+	matchMatches  = "matches"
+)
+
+// 地址部分
+const (
+	partAll       = "all"
+	partLocalPart = "localpart"
+	partDomain    = "domain"
+)
+
+// extensions 支持通过 require 声明的扩展
+var extensions = map[string]bool{
+	"fileinto":                        true,
+	"reject":                          true,
+	"envelope":                        true,
+	"comparator-" + comparatorOctet:   true,
+	"comparator-" + comparatorCasemap: true,
+}
+
+// addressHeaders 允许用于 address 测试的邮件头
+var addressHeaders = map[string]bool{
+	"from": true, "to": true, "cc": true, "bcc": true, "sender": true, "reply-to": true,
+	"resent-from": true, "resent-to": true, "resent-cc": true, "resent-bcc": true, "resent-sender": true,
+	"delivered-to": true, "return-path": true,
+}
+
+// Message 参与脚本执行的邮件信息
+type Message struct {
+	Header       map[string][]string // 原始邮件头，键为规范化的名称
+	Size         int
+	EnvelopeFrom string
+	EnvelopeTo   string
+}
+
+// Action 脚本执行产生的动作
+type Action struct {
+	Type     string `json:"type"`
+	Argument string `json:"argument,omitempty"` // 文件夹、转发地址或拒收原因
+	Line     int    `json:"line"`               // 产生动作的行号，隐式保留为0
+}
+
+// Result 脚本执行结果
+type Result struct {
+	Actions []Action `json:"actions"`
+}
+
+// Rejection 返回 reject 的原因，没有拒收时第二个返回值为 false
+func (r *Result) Rejection() (string, bool) {
+	for _, a := range r.Actions {
+		if a.Type == ActionReject {
+			return a.Argument, true
+		}
+	}
+	return "", false
+}
+
+// Folders 返回需要保存邮件的文件夹，空字符串表示收件箱
+func (r *Result) Folders() []string {
+	var folders []string
+	for _, a := range r.Actions {
+		switch a.Type {
+		case ActionKeep:
+			folders = append(folders, "")
+		case ActionFileInto:
+			folders = append(folders, a.Argument)
+		}
+	}
+	return folders
+}
+
+// Redirects 返回需要转发到的地址
+func (r *Result) Redirects() []string {
+	var addresses []string
+	for _, a := range r.Actions {
+		if a.Type == ActionRedirect {
+			addresses = append(addresses, a.Argument)
+		}
+	}
+	return addresses
+}
+
+// Script 已通过校验的脚本
+type Script struct {
+	commands []command
+	uses     map[string]int
+}
+
+// FirstUse 返回脚本中第一次使用指定动作的行号，未使用时返回0
+func (s *Script) FirstUse(action string) int {
+	return s.uses[action]
+}
+
+// Execute 对邮件执行脚本。返回错误时调用方应按 RFC 5228 2.10.6 执行隐式保留
+func (s *Script) Execute(m *Message) (*Result, error) {
+	e := &execution{msg: m, seen: make(map[Action]bool)}
+	if _, err := e.run(s.commands); err != nil {
+		return nil, err
+	}
+
+	hasReject, hasDelivery := false, false
+	for _, a := range e.actions {
+		switch a.Type {
+		case ActionReject:
+			hasReject = true
+		case ActionKeep, ActionFileInto, ActionRedirect:
+			hasDelivery = true
+		}
+	}
+	if hasReject && hasDelivery {
+		return nil, errorf(e.rejectLine, "reject 不能与 keep、fileinto 或 redirect 同时使用")
+	}
+
+	// 未执行 keep、fileinto、redirect、discard 或 reject 时保存到收件箱
+	if !e.cancelKeep {
+		e.add(Action{Type: ActionKeep})
+	}
+	return &Result{Actions: e.actions}, nil
+}
+
+// execution 单次执行的状态
+type execution struct {
+	msg        *Message
+	actions    []Action
+	seen       map[Action]bool
+	cancelKeep bool
+	redirects  int
+	rejectLine int
+}
+
+// add 记录动作，相同的动作只记录一次
+func (e *execution) add(a Action) {
+	key := Action{Type: a.Type, Argument: a.Argument}
+	if e.seen[key] {
+		return
+	}
+	e.seen[key] = true
+	e.actions = append(e.actions, a)
+}
+
+// run 依次执行命令，遇到 stop 时返回 true
+func (e *execution) run(commands []command) (bool, error) {
+	for _, cmd := range commands {
+		stop, err := cmd.execute(e)
+		if err != nil || stop {
+			return stop, err
+		}
+	}
+	return false, nil
+}
+
+// command 可执行的命令
+type command interface {
+	execute(e *execution) (bool, error)
+}
+
+// stopCommand stop
+type stopCommand struct{}
+
+func (stopCommand) execute(e *execution) (bool, error) {
+	return true, nil
+}
+
+// ifBranch if/elsif 分支
+type ifBranch struct {
+	test  test
+	block []command
+}
+
+// ifCommand if/elsif/else
+type ifCommand struct {
+	branches  []ifBranch
+	elseBlock []command
+}
+
+func (c *ifCommand) execute(e *execution) (bool, error) {
+	for _, branch := range c.branches {
+		if branch.test.eval(e.msg) {
+			return e.run(branch.block)
+		}
+	}
+	return e.run(c.elseBlock)
+}
+
+// actionCommand keep、discard、fileinto、reject、redirect
+type actionCommand struct {
+	line   int
+	action string
+	arg    string
+}
+
+func (c *actionCommand) execute(e *execution) (bool, error) {
+	if c.action != ActionKeep {
+		e.cancelKeep = true
+	}
+	switch c.action {
+	case ActionReject:
+		if e.rejectLine != 0 {
+			return false, errorf(c.line, "reject 只能执行一次")
+		}
+		e.rejectLine = c.line
+	case ActionRedirect:
+		if !e.seen[Action{Type: ActionRedirect, Argument: c.arg}] {
+			e.redirects++
+			if e.redirects > maxRedirects {
+				return false, errorf(c.line, "redirect 超过%d次", maxRedirects)
+			}
+		}
+	}
+	e.add(Action{Type: c.action, Argument: c.arg, Line: c.line})
+	return false, nil
+}
+
+// test 可求值的测试
+type test interface {
+	eval(m *Message) bool
+}
+
+// constTest true/false
+type constTest bool
+
+func (t constTest) eval(m *Message) bool {
+	return bool(t)
+}
+
+// notTest not
+type notTest struct {
+	test test
+}
+
+func (t *notTest) eval(m *Message) bool {
+	return !t.test.eval(m)
+}
+
+// listTest allof/anyof
+type listTest struct {
+	all   bool
+	tests []test
+}
+
+func (t *listTest) eval(m *Message) bool {
+	for _, child := range t.tests {
+		if child.eval(m) != t.all {
+			return !t.all
+		}
+	}
+	return t.all
+}
+
+// existsTest exists
+type existsTest struct {
+	names []string
+}
+
+func (t *existsTest) eval(m *Message) bool {
+	for _, name := range t.names {
+		if len(m.Header[textproto.CanonicalMIMEHeaderKey(name)]) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// sizeTest size :over/:under
+type sizeTest struct {
+	over  bool
+	limit int64
+}
+
+func (t *sizeTest) eval(m *Message) bool {
+	if t.over {
+		return int64(m.Size) > t.limit
+	}
+	return int64(m.Size) < t.limit
+}
+
+// matchTest header、address、envelope
+type matchTest struct {
+	kind       string
+	names      []string
+	keys       []string
+	comparator string
+	matchType  string
+	part       string
+}
+
+func (t *matchTest) eval(m *Message) bool {
+	for _, value := range t.values(m) {
+		for _, key := range t.keys {
+			if t.match(value, key) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// values 获取参与匹配的值
+func (t *matchTest) values(m *Message) []string {
+	var values []string
+	decoder := new(mime.WordDecoder)
+	for _, name := range t.names {
+		switch t.kind {
+		case "envelope":
+			address := m.EnvelopeFrom
+			if name == "to" {
+				address = m.EnvelopeTo
+			}
+			values = append(values, addressPart(address, t.part))
+		case "header":
+			for _, value := range m.Header[textproto.CanonicalMIMEHeaderKey(name)] {
+				if decoded, err := decoder.DecodeHeader(value); err == nil {
+					value = decoded
+				}
+				values = append(values, strings.TrimSpace(value))
+			}
+		case "address":
+			for _, value := range m.Header[textproto.CanonicalMIMEHeaderKey(name)] {
+				addresses, err := mail.ParseAddressList(value)
+				if err != nil {
+					continue
+				}
+				for _, addr := range addresses {
+					values = append(values, addressPart(addr.Address, t.part))
+				}
+			}
+		}
+	}
+	return values
+}
+
+// match 使用比较器和匹配方式比较值和关键字
+func (t *matchTest) match(value, key string) bool {
+	if t.comparator == comparatorCasemap {
+		value, key = asciiLower(value), asciiLower(key)
+	}
+	switch t.matchType {
+	case matchContains:
+		return strings.Contains(value, key)
+	case matchMatches:
+		return wildcardMatch(value, key)
+	}
+	return value == key
+}
+
+// addressPart 获取地址的指定部分
+func addressPart(address, part string) string {
+	at := strings.LastIndex(address, "@")
+	switch part {
+	case partLocalPart:
+		if at < 0 {
+			return address
+		}
+		return address[:at]
+	case partDomain:
+		if at < 0 {
+			return ""
+		}
+		return address[at+1:]
+	}
+	return address
+}
+
+// asciiLower 只转换 ASCII 字母的大小写（i;ascii-casemap）
+func asciiLower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c >= 'A' && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
+
+// wildcardMatch 按 :matches 规则匹配，* 匹配任意字符，? 匹配单个字符，\ 转义下一个字符
+func wildcardMatch(value, pattern string) bool {
+	type element struct {
+		r    rune
+		star bool
+		any  bool
+	}
+	var elements []element
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		switch runes[i] {
+		case '*':
+			elements = append(elements, element{star: true})
+		case '?':
+			elements = append(elements, element{any: true})
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+			}
+			elements = append(elements, element{r: runes[i]})
+		default:
+			elements = append(elements, element{r: runes[i]})
+		}
+	}
+
+	text := []rune(value)
+	ti, pi := 0, 0
+	starPi, starTi := -1, 0
+	for ti < len(text) {
+		if pi < len(elements) && !elements[pi].star && (elements[pi].any || elements[pi].r == text[ti]) {
+			ti++
+			pi++
+			continue
+		}
+		if pi < len(elements) && elements[pi].star {
+			starPi, starTi = pi, ti
+			pi++
+			continue
+		}
+		if starPi >= 0 {
+			pi = starPi + 1
+			starTi++
+			ti = starTi
+			continue
+		}
+		return false
+	}
+	for pi < len(elements) && elements[pi].star {
+		pi++
+	}
+	return pi == len(elements)
+}
@@ -0,0 +1,226 @@
+package sieve
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testMessage() *Message {
+	return &Message{
+		Header: map[string][]string{
+			"From":       {`"Alice" <Alice@Example.COM>`},
+			"To":         {"inbox@test.local, Bob <bob@other.example>"},
+			"Subject":    {"=?UTF-8?B?5L2g5aW9?= weekly Report"},
+			"List-Id":    {"<news.example.com>"},
+			"X-Priority": {"1"},
+		},
+		Size:         2048,
+		EnvelopeFrom: "bounce@mailer.example.com",
+		EnvelopeTo:   "inbox@test.local",
+	}
+}
+
+func run(t *testing.T, script string, m *Message) *Result {
+	t.Helper()
+	s, err := Parse(script)
+	require.NoError(t, err)
+	result, err := s.Execute(m)
+	require.NoError(t, err)
+	return result
+}
+
+func actionTypes(r *Result) []string {
+	var types []string
+	for _, a := range r.Actions {
+		types = append(types, a.Type+":"+a.Argument)
+	}
+	return types
+}
+
+func TestExecute_Tests(t *testing.T) {
+	tests := []struct {
+		name  string
+		test  string
+		match bool
+	}{
+		{"header默认is且不区分大小写", `header "x-priority" "1"`, true},
+		{"header解码编码字", `header :contains "subject" "你好"`, true},
+		{"header通配符", `header :matches "subject" "*week?y*"`, true},
+		{"header通配符转义", `header :matches "subject" "*\\*"`, false},
+		{"i;octet区分大小写", `header :comparator "i;octet" :contains "subject" "report"`, false},
+		{"多个关键字任一匹配", `header :is "x-priority" ["3", "1"]`, true},
+		{"不存在的邮件头", `header :contains "x-missing" ""`, false},
+		{"address域名", `address :domain "from" "example.com"`, true},
+		{"address本地部分", `address :localpart :is "to" "bob"`, true},
+		{"address完整地址", `address "from" "alice@example.com"`, true},
+		{"envelope发件人域名", `envelope :domain :matches "from" "*.example.com"`, true},
+		{"envelope收件人", `envelope "to" "other@test.local"`, false},
+		{"size超过", `size :over 1K`, true},
+		{"size小于", `size :under 2K`, false},
+		{"exists", `exists ["From", "List-Id"]`, true},
+		{"exists部分不存在", `exists ["From", "X-Missing"]`, false},
+		{"allof", `allof (true, header :contains "from" "alice")`, true},
+		{"anyof", `anyof (false, not true)`, false},
+		{"not", `not size :over 1M`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := "require [\"fileinto\", \"envelope\"];\nif " + tt.test + " {\n  fileinto \"Matched\";\n}\n"
+			result := run(t, script, testMessage())
+			if tt.match {
+				assert.Equal(t, []string{"fileinto:Matched"}, actionTypes(result))
+			} else {
+				assert.Equal(t, []string{"keep:"}, actionTypes(result))
+			}
+		})
+	}
+}
+
+func TestExecute_Actions(t *testing.T) {
+	t.Run("elsif和else", func(t *testing.T) {
+		script := `require "fileinto";
+if header :is "x-priority" "5" { fileinto "Low"; }
+elsif header :is "x-priority" "1" { fileinto "Urgent"; keep; }
+else { discard; }`
+		result := run(t, script, testMessage())
+		assert.Equal(t, []string{"fileinto:Urgent", "keep:"}, actionTypes(result))
+		assert.Equal(t, []string{"Urgent", ""}, result.Folders())
+		assert.Equal(t, 3, result.Actions[0].Line)
+	})
+
+	t.Run("stop之后的命令不执行", func(t *testing.T) {
+		result := run(t, "if true { stop; }\ndiscard;", testMessage())
+		assert.Equal(t, []string{"keep:"}, actionTypes(result))
+		assert.Zero(t, result.Actions[0].Line, "隐式保留的行号应该为0")
+	})
+
+	t.Run("discard取消隐式保留", func(t *testing.T) {
+		result := run(t, "discard;", testMessage())
+		assert.Equal(t, []string{"discard:"}, actionTypes(result))
+		assert.Empty(t, result.Folders())
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		result := run(t, "require \"reject\";\nreject text:\nNo thanks\n..dot\n.\n;", testMessage())
+		reason, ok := result.Rejection()
+		assert.True(t, ok)
+		assert.Equal(t, "No thanks\r\n.dot\r\n", reason)
+	})
+
+	t.Run("redirect去重", func(t *testing.T) {
+		result := run(t, `redirect "a@example.org"; redirect "A <a@example.org>"; redirect "b@example.org";`, testMessage())
+		assert.Equal(t, []string{"a@example.org", "b@example.org"}, result.Redirects())
+		assert.Empty(t, result.Folders(), "redirect 应该取消隐式保留")
+	})
+
+	t.Run("相同的fileinto只执行一次", func(t *testing.T) {
+		result := run(t, `require "fileinto"; fileinto "A"; fileinto "A"; keep; keep;`, testMessage())
+		assert.Equal(t, []string{"A", ""}, result.Folders())
+	})
+}
+
+func TestExecute_RuntimeErrors(t *testing.T) {
+	tests := map[string]string{
+		"reject与keep":  "require \"reject\";\nkeep;\nreject \"no\";",
+		"reject两次":     "require \"reject\";\nreject \"a\";\nreject \"b\";",
+		"redirect超过上限": `redirect "a@x.org"; redirect "b@x.org"; redirect "c@x.org"; redirect "d@x.org"; redirect "e@x.org";`,
+	}
+	for name, script := range tests {
+		s, err := Parse(script)
+		require.NoError(t, err, name)
+		_, err = s.Execute(testMessage())
+		var scriptErr *Error
+		if assert.ErrorAs(t, err, &scriptErr, name) {
+			assert.NotZero(t, scriptErr.Line, name)
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		line    int
+		message string
+	}{
+		{"未知命令", "keep;\n\nvacation \"x\";", 3, "未知的命令"},
+		{"缺少分号", "if true {\n  keep\n}", 3, "之后应为"},
+		{"命令后跟测试", "keep\ndiscard;", 1, "不接受参数"},
+		{"未声明扩展", "if true {\n  fileinto \"x\";\n}", 2, "require"},
+		{"不支持的扩展", "require [\"fileinto\", \"vacation\"];", 1, "不支持的扩展"},
+		{"require不在开头", "keep;\nrequire \"fileinto\";", 2, "开头"},
+		{"字符串未结束", "keep;\nredirect \"a@b.c;\n", 2, "字符串没有结束"},
+		{"块注释未结束", "/* a\n*/ keep; /*\n", 2, "块注释"},
+		{"else之前缺少if", "keep;\nelse { keep; }", 2, "缺少 if"},
+		{"if缺少测试", "if { keep; }", 1, "需要一个测试"},
+		{"未知测试", "if\n  body :contains \"x\" { keep; }", 2, "未知的测试"},
+		{"无效的转发地址", "redirect \"not an address\";", 1, "转发地址"},
+		{"address使用非地址头", "if address \"subject\" \"x\" { keep; }", 1, "不包含地址"},
+		{"envelope不支持的字段", "require \"envelope\";\nif envelope \"cc\" \"x\" { keep; }", 2, "from 和 to"},
+		{"不支持的比较器", "if header :comparator \"i;unicode\" \"a\" \"b\" { keep; }", 1, "比较器"},
+		{"重复的匹配方式", "if header :is :contains \"a\" \"b\" { keep; }", 1, "重复"},
+		{"header不支持地址部分", "if header :domain \"from\" \"b\" { keep; }", 1, "不支持标签"},
+		{"size格式错误", "if size 100 { keep; }", 1, "size"},
+		{"多余的右括号", "keep;\n}", 2, "多余"},
+		{"代码块未结束", "if true {\n keep;\n", 3, "\"}\""},
+		{"多行字符串未结束", "require \"reject\";\nreject text:\nabc\n", 2, "多行字符串"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.script)
+			var scriptErr *Error
+			require.ErrorAs(t, err, &scriptErr)
+			assert.Equal(t, tt.line, scriptErr.Line, scriptErr.Error())
+			assert.Contains(t, scriptErr.Error(), tt.message)
+			assert.True(t, strings.HasPrefix(scriptErr.Error(), "第"), "错误信息应该以行号开头")
+		})
+	}
+}
+
+func TestParse_Syntax(t *testing.T) {
+	script := `# 注释
+require ["fileinto", "reject", "envelope", "comparator-i;octet"];
+/* 多行
+   注释 */
+if anyof (header :contains ["Subject", "X-Tag"] ["[SPAM]", "viagra"],
+          size :over 5M) {
+    discard;
+    stop;
+} elsif address :all :comparator "i;octet" :is "From" "boss@example.com" {
+    fileinto "Important";
+}
+`
+	s, err := Parse(script)
+	require.NoError(t, err)
+	assert.Equal(t, 7, s.FirstUse(ActionDiscard))
+	assert.Equal(t, 10, s.FirstUse(ActionFileInto))
+	assert.Zero(t, s.FirstUse(ActionRedirect))
+
+	_, err = Parse("")
+	assert.NoError(t, err, "空脚本应该有效")
+}
+
+func TestWildcardMatch(t *testing.T) {
+	tests := []struct {
+		value, pattern string
+		want           bool
+	}{
+		{"hello", "hello", true},
+		{"hello", "h*o", true},
+		{"hello", "h?llo", true},
+		{"你好世界", "你?世*", true},
+		{"hello", "h*x", false},
+		{"", "*", true},
+		{"", "?", false},
+		{"a*b", `a\*b`, true},
+		{"axb", `a\*b`, false},
+		{"aaa", "*a*a*a*", true},
+		{"aa", "*a*a*a*", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, wildcardMatch(tt.value, tt.pattern), "%q ~ %q", tt.value, tt.pattern)
+	}
+}
@@ -0,0 +1,64 @@
+package smtp
+
+import (
+	"context"
+	"fmt"
+	"net"
+)
+
+// Envelope SMTP信封信息
+type Envelope struct {
+	RemoteAddr net.Addr
+	Helo       string
+	From       string
+	Recipients []string
+}
+
+// RemoteIP 获取连接方的IP地址
+func (e *Envelope) RemoteIP() net.IP {
+	if tcpAddr, ok := e.RemoteAddr.(*net.TCPAddr); ok {
+		return tcpAddr.IP
+	}
+	if e.RemoteAddr == nil {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(e.RemoteAddr.String())
+	if err != nil {
+		return nil
+	}
+	return net.ParseIP(host)
+}
+
+// Backend 邮件投递后端接口
+type Backend interface {
+	// CheckRecipient 在RCPT阶段校验收件人，返回错误时拒绝该收件人
+	CheckRecipient(ctx context.Context, env *Envelope, rcpt string) error
+	// Deliver 在DATA阶段投递完整邮件，返回错误时拒绝该邮件
+	Deliver(ctx context.Context, env *Envelope, data []byte) error
+}
+
+// Error 携带SMTP响应码的错误
+type Error struct {
+	Code         int
+	EnhancedCode string
+	Message      string
+}
+
+// Error 实现error接口
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s %s", e.Code, e.EnhancedCode, e.Message)
+}
+
+// 常用的SMTP错误
+var (
+	// ErrMailboxUnavailable 收件人邮箱不存在或不可用
+	ErrMailboxUnavailable = &Error{Code: 550, EnhancedCode: "5.1.1", Message: "Mailbox unavailable"}
+	// ErrRelayDenied 不接收非托管域名的邮件
+	ErrRelayDenied = &Error{Code: 550, EnhancedCode: "5.7.1", Message: "Relay access denied"}
+	// ErrMessageRejected 邮件被内容过滤规则拒收
+	ErrMessageRejected = &Error{Code: 550, EnhancedCode: "5.7.1", Message: "Message rejected as spam"}
+	// ErrRejectedByPolicy 邮件被收件人邮箱的过滤规则拒收
+	ErrRejectedByPolicy = &Error{Code: 550, EnhancedCode: "5.7.1", Message: "Rejected by recipient policy"}
+	// ErrTemporaryFailure 临时性处理失败
+	ErrTemporaryFailure = &Error{Code: 451, EnhancedCode: "4.3.0", Message: "Temporary local problem, try again later"}
+)
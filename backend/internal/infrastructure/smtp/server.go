@@ -0,0 +1,156 @@
+package smtp
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"temp-mailbox-service/internal/infrastructure/config"
+)
+
+// ErrServerClosed 服务器已关闭
+var ErrServerClosed = errors.New("smtp: 服务器已关闭")
+
+// Server SMTP接收服务器
+type Server struct {
+	hostname       string
+	maxMessageSize int
+	maxRecipients  int
+	readTimeout    time.Duration
+	backend        Backend
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// NewServer 创建SMTP服务器实例
+func NewServer(cfg *config.SMTPConfig, backend Backend) *Server {
+	hostname := cfg.Hostname
+	if hostname == "" {
+		hostname = "localhost"
+	}
+	readTimeout := time.Duration(cfg.ReadTimeout) * time.Second
+	if readTimeout <= 0 {
+		readTimeout = time.Minute
+	}
+	return &Server{
+		hostname:       hostname,
+		maxMessageSize: cfg.MaxMessageSize,
+		maxRecipients:  cfg.MaxRecipients,
+		readTimeout:    readTimeout,
+		backend:        backend,
+		conns:          make(map[net.Conn]struct{}),
+	}
+}
+
+// ListenAndServe 监听指定地址并开始处理连接
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("SMTP监听失败: %w", err)
+	}
+	return s.Serve(l)
+}
+
+// Serve 在给定的监听器上处理连接，直到服务器关闭
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		l.Close()
+		return ErrServerClosed
+	}
+	s.listener = l
+	s.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return ErrServerClosed
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			return fmt.Errorf("SMTP接受连接失败: %w", err)
+		}
+
+		if !s.trackConn(conn) {
+			conn.Close()
+			return ErrServerClosed
+		}
+
+		go func() {
+			defer s.wg.Done()
+			defer s.untrackConn(conn)
+			newSession(s, conn).serve()
+		}()
+	}
+}
+
+// Addr 获取监听地址（未启动时返回nil）
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// Close 关闭监听器和所有连接，并等待会话退出
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+// trackConn 登记活动连接
+func (s *Server) trackConn(conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.conns[conn] = struct{}{}
+	s.wg.Add(1)
+	return true
+}
+
+// untrackConn 注销活动连接
+func (s *Server) untrackConn(conn net.Conn) {
+	s.mu.Lock()
+	delete(s.conns, conn)
+	s.mu.Unlock()
+	conn.Close()
+}
+
+// logf 输出SMTP日志
+func (s *Server) logf(format string, args ...interface{}) {
+	log.Printf("[SMTP] "+format, args...)
+}
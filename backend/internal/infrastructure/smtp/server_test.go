@@ -0,0 +1,229 @@
+package smtp
+
+import (
+	"context"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+
+	"temp-mailbox-service/internal/infrastructure/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBackend 测试用投递后端
+type fakeBackend struct {
+	mu        sync.Mutex
+	accepted  map[string]bool
+	envelopes []*Envelope
+	messages  [][]byte
+}
+
+func (b *fakeBackend) CheckRecipient(ctx context.Context, env *Envelope, rcpt string) error {
+	if !b.accepted[strings.ToLower(rcpt)] {
+		return ErrMailboxUnavailable
+	}
+	return nil
+}
+
+func (b *fakeBackend) Deliver(ctx context.Context, env *Envelope, data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.envelopes = append(b.envelopes, env)
+	b.messages = append(b.messages, data)
+	return nil
+}
+
+// startTestServer 在本地随机端口启动测试服务器
+func startTestServer(t *testing.T, backend Backend, maxSize int) string {
+	t.Helper()
+	server := NewServer(&config.SMTPConfig{
+		Hostname:       "mx.test.local",
+		MaxMessageSize: maxSize,
+		MaxRecipients:  10,
+		ReadTimeout:    5,
+	}, backend)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.Serve(l)
+	t.Cleanup(func() { server.Close() })
+
+	return l.Addr().String()
+}
+
+func TestServer_DeliverToAcceptedRecipient(t *testing.T) {
+	backend := &fakeBackend{accepted: map[string]bool{"inbox@test.local": true}}
+	addr := startTestServer(t, backend, 1024*1024)
+
+	body := "From: sender@example.com\r\n" +
+		"To: inbox@test.local\r\n" +
+		"Subject: hello\r\n" +
+		"\r\n" +
+		"first line\r\n" +
+		".leading dot\r\n"
+	err := smtp.SendMail(addr, nil, "sender@example.com", []string{"inbox@test.local"}, []byte(body))
+	require.NoError(t, err)
+
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	require.Len(t, backend.messages, 1)
+
+	env := backend.envelopes[0]
+	assert.Equal(t, "sender@example.com", env.From)
+	assert.Equal(t, []string{"inbox@test.local"}, env.Recipients)
+	assert.Equal(t, "127.0.0.1", env.RemoteIP().String())
+
+	data := string(backend.messages[0])
+	assert.True(t, strings.HasPrefix(data, "Received: from "), "应该添加Received头")
+	assert.Contains(t, data, "by mx.test.local with ESMTP")
+	assert.Contains(t, data, "\r\n.leading dot\r\n", "点号转义应该被还原")
+	assert.Contains(t, data, "Subject: hello\r\n")
+}
+
+func TestServer_RejectUnknownRecipient(t *testing.T) {
+	backend := &fakeBackend{accepted: map[string]bool{}}
+	addr := startTestServer(t, backend, 1024*1024)
+
+	client, err := smtp.Dial(addr)
+	require.NoError(t, err)
+	defer client.Close()
+
+	require.NoError(t, client.Hello("client.example.com"))
+	require.NoError(t, client.Mail("sender@example.com"))
+
+	err = client.Rcpt("nobody@test.local")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "550")
+
+	// 没有有效收件人时不能发送DATA
+	_, err = client.Data()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "503")
+}
+
+func TestServer_CommandSequence(t *testing.T) {
+	backend := &fakeBackend{accepted: map[string]bool{"inbox@test.local": true}}
+	addr := startTestServer(t, backend, 1024*1024)
+
+	// 未HELO时不能MAIL
+	conn, err := textproto.Dial("tcp", addr)
+	require.NoError(t, err)
+	_, _, err = conn.ReadResponse(220)
+	require.NoError(t, err)
+	require.NoError(t, conn.PrintfLine("MAIL FROM:<sender@example.com>"))
+	code, _, err := conn.ReadResponse(250)
+	require.Error(t, err)
+	assert.Equal(t, 503, code)
+	conn.Close()
+
+	client, err := smtp.Dial(addr)
+	require.NoError(t, err)
+	defer client.Close()
+
+	require.NoError(t, client.Hello("client.example.com"))
+	ok, param := client.Extension("SIZE")
+	assert.True(t, ok)
+	assert.Equal(t, "1048576", param)
+
+	require.NoError(t, client.Mail("sender@example.com"))
+	require.NoError(t, client.Rcpt("INBOX@test.local"))
+	require.NoError(t, client.Reset())
+	require.NoError(t, client.Quit())
+}
+
+func TestServer_RejectsInvalidHelo(t *testing.T) {
+	backend := &fakeBackend{accepted: map[string]bool{"inbox@test.local": true}}
+	addr := startTestServer(t, backend, 1024*1024)
+
+	conn, err := textproto.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, _, err = conn.ReadResponse(220)
+	require.NoError(t, err)
+
+	// 夹带的裸CR会被写入 Received 头
+	require.NoError(t, conn.PrintfLine("EHLO evil\rX-Injected: yes"))
+	code, _, err := conn.ReadResponse(250)
+	require.Error(t, err)
+	assert.Equal(t, 501, code)
+
+	require.NoError(t, conn.PrintfLine("HELO [192.0.2.1]"))
+	_, _, err = conn.ReadResponse(250)
+	assert.NoError(t, err, "接受地址字面量")
+}
+
+func TestValidHeloArgument(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want bool
+	}{
+		{"client.example.com", true},
+		{"localhost", true},
+		{"mail_01.example.com.", true},
+		{"[192.0.2.1]", true},
+		{"[IPv6:2001:db8::1]", true},
+		{"[ipv6:2001:db8::1]", true},
+		{"[2001:db8::1]", false},
+		{"[IPv6:192.0.2.1]", false},
+		{"[192.0.2.1", false},
+		{"[not-an-ip]", false},
+		{"evil\rX-Injected: yes", false},
+		{"two words", false},
+		{"-leading.example.com", false},
+		{"double..dot.com", false},
+		{strings.Repeat("a", 256), false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, validHeloArgument(tt.arg), tt.arg)
+	}
+}
+
+func TestServer_MessageTooLarge(t *testing.T) {
+	backend := &fakeBackend{accepted: map[string]bool{"inbox@test.local": true}}
+	addr := startTestServer(t, backend, 64)
+
+	body := "Subject: big\r\n\r\n" + strings.Repeat("0123456789\r\n", 20)
+	err := smtp.SendMail(addr, nil, "sender@example.com", []string{"inbox@test.local"}, []byte(body))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "552")
+
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	assert.Empty(t, backend.messages)
+}
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   string
+		prefix string
+		want   string
+		params int
+		wantOK bool
+	}{
+		{name: "普通地址", args: "FROM:<a@b.com>", prefix: "FROM:", want: "a@b.com", wantOK: true},
+		{name: "带参数", args: "FROM:<a@b.com> SIZE=100 BODY=8BITMIME", prefix: "FROM:", want: "a@b.com", params: 2, wantOK: true},
+		{name: "空发件人", args: "FROM:<>", prefix: "FROM:", want: "", wantOK: true},
+		{name: "小写前缀和空格", args: "to: <a@b.com>", prefix: "TO:", want: "a@b.com", wantOK: true},
+		{name: "源路由", args: "TO:<@relay.com:a@b.com>", prefix: "TO:", want: "a@b.com", wantOK: true},
+		{name: "缺少尖括号", args: "TO:a@b.com", prefix: "TO:", wantOK: false},
+		{name: "前缀错误", args: "FROM:<a@b.com>", prefix: "TO:", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, params, ok := parsePath(tt.args, tt.prefix)
+			assert.Equal(t, tt.wantOK, ok)
+			if tt.wantOK {
+				assert.Equal(t, tt.want, got)
+				assert.Len(t, params, tt.params)
+			}
+		})
+	}
+}
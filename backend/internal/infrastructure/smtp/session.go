@@ -0,0 +1,414 @@
+package smtp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxCommandLength 命令行最大长度（RFC 5321 规定为512，这里适当放宽）
+	maxCommandLength = 4096
+	// maxBadCommands 连续错误命令上限，超过后断开连接
+	maxBadCommands = 10
+)
+
+// errLineTooLong 行长度超过限制
+var errLineTooLong = errors.New("smtp: 行长度超过限制")
+
+// heloDomainPattern HELO/EHLO 参数中的域名，允许下划线以兼容不规范的客户端
+var heloDomainPattern = regexp.MustCompile(`^[A-Za-z0-9_]([A-Za-z0-9_-]*[A-Za-z0-9_])?(\.[A-Za-z0-9_]([A-Za-z0-9_-]*[A-Za-z0-9_])?)*\.?$`)
+
+// session 单个SMTP连接会话
+type session struct {
+	server *Server
+	conn   net.Conn
+	reader *bufio.Reader
+	writer *bufio.Writer
+
+	helo        string
+	envelope    *Envelope
+	badCommands int
+}
+
+// newSession 创建会话
+func newSession(server *Server, conn net.Conn) *session {
+	return &session{
+		server: server,
+		conn:   conn,
+		reader: bufio.NewReaderSize(conn, maxCommandLength),
+		writer: bufio.NewWriter(conn),
+	}
+}
+
+// serve 处理会话的命令循环
+func (s *session) serve() {
+	s.reply(220, "%s ESMTP temp-mailbox-service", s.server.hostname)
+
+	for {
+		line, err := s.readLine()
+		if err != nil {
+			if errors.Is(err, errLineTooLong) {
+				s.reply(500, "5.5.2 Line too long")
+				continue
+			}
+			return
+		}
+
+		verb, args := parseCommand(line)
+		if quit := s.handle(verb, args); quit {
+			return
+		}
+		if s.badCommands >= maxBadCommands {
+			s.reply(421, "4.7.0 Too many errors, closing connection")
+			return
+		}
+	}
+}
+
+// handle 分发命令，返回true表示结束会话
+func (s *session) handle(verb, args string) bool {
+	switch verb {
+	case "HELO":
+		s.handleHelo(args, false)
+	case "EHLO":
+		s.handleHelo(args, true)
+	case "MAIL":
+		s.handleMail(args)
+	case "RCPT":
+		s.handleRcpt(args)
+	case "DATA":
+		s.handleData()
+	case "RSET":
+		s.envelope = nil
+		s.reply(250, "2.0.0 OK")
+	case "NOOP":
+		s.reply(250, "2.0.0 OK")
+	case "VRFY":
+		s.reply(252, "2.5.0 Cannot VRFY user, but will accept message")
+	case "QUIT":
+		s.reply(221, "2.0.0 Bye")
+		return true
+	default:
+		s.badCommands++
+		s.reply(500, "5.5.1 Unrecognized command")
+	}
+	return false
+}
+
+// handleHelo 处理HELO/EHLO
+func (s *session) handleHelo(args string, extended bool) {
+	domain := strings.TrimSpace(args)
+	if domain == "" {
+		s.badCommands++
+		s.reply(501, "5.5.4 Domain/address argument required")
+		return
+	}
+	// 参数会写入 Received 头，只接受域名或地址字面量
+	if !validHeloArgument(domain) {
+		s.badCommands++
+		s.reply(501, "5.5.4 Invalid domain or address literal")
+		return
+	}
+	s.helo = domain
+	s.envelope = nil
+
+	if !extended {
+		s.reply(250, "%s", s.server.hostname)
+		return
+	}
+	s.replyLines(250,
+		fmt.Sprintf("%s greets %s", s.server.hostname, domain),
+		"PIPELINING",
+		"8BITMIME",
+		"ENHANCEDSTATUSCODES",
+		fmt.Sprintf("SIZE %d", s.server.maxMessageSize),
+	)
+}
+
+// handleMail 处理MAIL FROM
+func (s *session) handleMail(args string) {
+	if s.helo == "" {
+		s.badCommands++
+		s.reply(503, "5.5.1 Send HELO/EHLO first")
+		return
+	}
+	if s.envelope != nil {
+		s.badCommands++
+		s.reply(503, "5.5.1 Sender already specified")
+		return
+	}
+
+	from, params, ok := parsePath(args, "FROM:")
+	if !ok {
+		s.badCommands++
+		s.reply(501, "5.5.4 Syntax: MAIL FROM:<address>")
+		return
+	}
+
+	for _, param := range params {
+		key, value, _ := strings.Cut(param, "=")
+		if strings.EqualFold(key, "SIZE") {
+			size, err := strconv.Atoi(value)
+			if err != nil {
+				s.reply(501, "5.5.4 Invalid SIZE parameter")
+				return
+			}
+			if size > s.server.maxMessageSize {
+				s.reply(552, "5.3.4 Message size exceeds fixed limit")
+				return
+			}
+		}
+	}
+
+	s.envelope = &Envelope{
+		RemoteAddr: s.conn.RemoteAddr(),
+		Helo:       s.helo,
+		From:       from,
+	}
+	s.reply(250, "2.1.0 OK")
+}
+
+// handleRcpt 处理RCPT TO
+func (s *session) handleRcpt(args string) {
+	if s.envelope == nil {
+		s.badCommands++
+		s.reply(503, "5.5.1 Need MAIL before RCPT")
+		return
+	}
+
+	rcpt, _, ok := parsePath(args, "TO:")
+	if !ok || rcpt == "" {
+		s.badCommands++
+		s.reply(501, "5.5.4 Syntax: RCPT TO:<address>")
+		return
+	}
+
+	if s.server.maxRecipients > 0 && len(s.envelope.Recipients) >= s.server.maxRecipients {
+		s.reply(452, "4.5.3 Too many recipients")
+		return
+	}
+
+	ctx, cancel := s.context()
+	defer cancel()
+	if err := s.server.backend.CheckRecipient(ctx, s.envelope, rcpt); err != nil {
+		s.replyError(err)
+		return
+	}
+
+	s.envelope.Recipients = append(s.envelope.Recipients, rcpt)
+	s.reply(250, "2.1.5 OK")
+}
+
+// handleData 处理DATA
+func (s *session) handleData() {
+	if s.envelope == nil || len(s.envelope.Recipients) == 0 {
+		s.badCommands++
+		s.reply(503, "5.5.1 Need RCPT before DATA")
+		return
+	}
+
+	s.reply(354, "End data with <CR><LF>.<CR><LF>")
+
+	body, err := s.readData()
+	envelope := s.envelope
+	s.envelope = nil
+	if err != nil {
+		if errors.Is(err, errMessageTooLarge) {
+			s.reply(552, "5.3.4 Message size exceeds fixed limit")
+			return
+		}
+		s.server.logf("读取邮件内容失败: %v", err)
+		return
+	}
+
+	data := append(s.receivedHeader(envelope), body...)
+
+	ctx, cancel := s.context()
+	defer cancel()
+	if err := s.server.backend.Deliver(ctx, envelope, data); err != nil {
+		s.replyError(err)
+		return
+	}
+
+	s.reply(250, "2.0.0 OK: queued")
+}
+
+// errMessageTooLarge 邮件超过大小限制
+var errMessageTooLarge = errors.New("smtp: 邮件超过大小限制")
+
+// readData 读取DATA内容，处理点号转义并统一行尾为CRLF
+func (s *session) readData() ([]byte, error) {
+	var buf bytes.Buffer
+	tooLarge := false
+	// midLine 表示上一次读取的是超长行的前半部分
+	midLine := false
+
+	for {
+		s.conn.SetReadDeadline(time.Now().Add(s.server.readTimeout))
+		line, err := s.reader.ReadSlice('\n')
+		partial := errors.Is(err, bufio.ErrBufferFull)
+		if err != nil && !partial {
+			if err == io.EOF {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+
+		content := line
+		if !partial {
+			content = bytes.TrimRight(line, "\r\n")
+		}
+		if !midLine {
+			if !partial && len(content) == 1 && content[0] == '.' {
+				break
+			}
+			if len(content) > 0 && content[0] == '.' {
+				content = content[1:]
+			}
+		}
+		midLine = partial
+
+		if tooLarge {
+			continue
+		}
+		buf.Write(content)
+		if !partial {
+			buf.WriteString("\r\n")
+		}
+		if buf.Len() > s.server.maxMessageSize {
+			tooLarge = true
+			buf.Reset()
+		}
+	}
+
+	if tooLarge {
+		return nil, errMessageTooLarge
+	}
+	return buf.Bytes(), nil
+}
+
+// receivedHeader 生成Received跟踪头
+func (s *session) receivedHeader(env *Envelope) []byte {
+	remote := "unknown"
+	if ip := env.RemoteIP(); ip != nil {
+		remote = ip.String()
+	}
+	header := fmt.Sprintf("Received: from %s ([%s])\r\n\tby %s with ESMTP;\r\n\t%s\r\n",
+		env.Helo, remote, s.server.hostname, time.Now().Format(time.RFC1123Z))
+	return []byte(header)
+}
+
+// readLine 读取一行命令
+func (s *session) readLine() (string, error) {
+	s.conn.SetReadDeadline(time.Now().Add(s.server.readTimeout))
+	line, err := s.reader.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		// 丢弃该行剩余部分
+		for errors.Is(err, bufio.ErrBufferFull) {
+			_, err = s.reader.ReadSlice('\n')
+		}
+		if err != nil {
+			return "", err
+		}
+		return "", errLineTooLong
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(line), "\r\n"), nil
+}
+
+// context 为后端调用创建带超时的上下文
+func (s *session) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), s.server.readTimeout)
+}
+
+// reply 发送单行响应
+func (s *session) reply(code int, format string, args ...interface{}) {
+	s.conn.SetWriteDeadline(time.Now().Add(s.server.readTimeout))
+	fmt.Fprintf(s.writer, "%d %s\r\n", code, fmt.Sprintf(format, args...))
+	s.writer.Flush()
+}
+
+// replyLines 发送多行响应
+func (s *session) replyLines(code int, lines ...string) {
+	s.conn.SetWriteDeadline(time.Now().Add(s.server.readTimeout))
+	for i, line := range lines {
+		sep := "-"
+		if i == len(lines)-1 {
+			sep = " "
+		}
+		fmt.Fprintf(s.writer, "%d%s%s\r\n", code, sep, line)
+	}
+	s.writer.Flush()
+}
+
+// replyError 将后端错误转换为SMTP响应
+func (s *session) replyError(err error) {
+	var smtpErr *Error
+	if !errors.As(err, &smtpErr) {
+		s.server.logf("后端处理失败: %v", err)
+		smtpErr = ErrTemporaryFailure
+	}
+	if smtpErr.EnhancedCode != "" {
+		s.reply(smtpErr.Code, "%s %s", smtpErr.EnhancedCode, smtpErr.Message)
+		return
+	}
+	s.reply(smtpErr.Code, "%s", smtpErr.Message)
+}
+
+// validHeloArgument 检查 HELO/EHLO 参数是否为域名或地址字面量（[IPv4] 或 [IPv6:地址]）
+func validHeloArgument(arg string) bool {
+	if len(arg) > 255 {
+		return false
+	}
+	if literal, ok := strings.CutPrefix(arg, "["); ok {
+		literal, ok = strings.CutSuffix(literal, "]")
+		if !ok {
+			return false
+		}
+		if len(literal) > 5 && strings.EqualFold(literal[:5], "IPv6:") {
+			ip := net.ParseIP(literal[5:])
+			return ip != nil && ip.To4() == nil
+		}
+		ip := net.ParseIP(literal)
+		return ip != nil && ip.To4() != nil && !strings.Contains(literal, ":")
+	}
+	return heloDomainPattern.MatchString(arg)
+}
+
+// parseCommand 拆分命令动词和参数
+func parseCommand(line string) (string, string) {
+	verb, args, _ := strings.Cut(strings.TrimSpace(line), " ")
+	return strings.ToUpper(verb), strings.TrimSpace(args)
+}
+
+// parsePath 解析 "FROM:<addr> PARAM=VALUE" 形式的参数
+func parsePath(args, prefix string) (string, []string, bool) {
+	if len(args) < len(prefix) || !strings.EqualFold(args[:len(prefix)], prefix) {
+		return "", nil, false
+	}
+	rest := strings.TrimSpace(args[len(prefix):])
+	if !strings.HasPrefix(rest, "<") {
+		return "", nil, false
+	}
+	end := strings.Index(rest, ">")
+	if end < 0 {
+		return "", nil, false
+	}
+	address := rest[1:end]
+	// 去掉源路由部分，如 <@a,@b:user@host>
+	if idx := strings.LastIndex(address, ":"); idx >= 0 && strings.HasPrefix(address, "@") {
+		address = address[idx+1:]
+	}
+	return address, strings.Fields(rest[end+1:]), true
+}
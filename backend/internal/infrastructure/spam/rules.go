@@ -0,0 +1,307 @@
+package spam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/textproto"
+	"regexp"
+	"strings"
+
+	"temp-mailbox-service/internal/infrastructure/dns"
+	"temp-mailbox-service/internal/infrastructure/mailauth"
+
+	"github.com/spf13/viper"
+)
+
+// RuleSet 规则文件内容，文件格式由扩展名决定（yaml、json、toml），例如：
+//
+//	tag_threshold: 5
+//	reject_threshold: 10
+//	rules:
+//	  - name: subject_all_caps
+//	    type: header
+//	    score: 1.5
+//	    header: Subject
+//	    pattern: '^[^a-z]{12,}$'
+//	  - name: dmarc_fail
+//	    type: auth
+//	    score: 4
+//	    check: dmarc
+//	    results: [fail]
+//	  - name: spamhaus
+//	    type: dnsbl
+//	    score: 6
+//	    zones: [zen.spamhaus.org]
+type RuleSet struct {
+	TagThreshold    float64      `mapstructure:"tag_threshold"`
+	RejectThreshold float64      `mapstructure:"reject_threshold"` // 为0时不拒收
+	Rules           []RuleConfig `mapstructure:"rules"`
+}
+
+// RuleConfig 单条规则配置，不同类型的规则使用不同的字段
+type RuleConfig struct {
+	Name  string  `mapstructure:"name"`
+	Type  string  `mapstructure:"type"` // header, body, urls, auth, dnsbl
+	Score float64 `mapstructure:"score"`
+
+	// header：邮件头的任一值匹配正则表达式（邮件头不存在时匹配空字符串）
+	Header  string `mapstructure:"header"`
+	Pattern string `mapstructure:"pattern"`
+
+	// body：正文包含至少 MinMatches 个关键词（不区分大小写）
+	Keywords   []string `mapstructure:"keywords"`
+	MinMatches int      `mapstructure:"min_matches"`
+
+	// urls：正文中不同链接的数量超过 MaxURLs
+	MaxURLs int `mapstructure:"max_urls"`
+
+	// auth：来源验证（spf、dkim、dmarc）结果属于 Results 之一
+	Check   string   `mapstructure:"check"`
+	Results []string `mapstructure:"results"`
+
+	// dnsbl：发信IP被任一黑名单收录
+	Zones []string `mapstructure:"zones"`
+}
+
+// LoadRules 读取规则文件
+func LoadRules(path string) (*RuleSet, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("读取规则文件失败: %w", err)
+	}
+
+	var set RuleSet
+	if err := v.Unmarshal(&set); err != nil {
+		return nil, fmt.Errorf("解析规则文件失败: %w", err)
+	}
+	if err := set.validate(); err != nil {
+		return nil, err
+	}
+	return &set, nil
+}
+
+// validate 校验阈值和规则名称
+func (s *RuleSet) validate() error {
+	if s.TagThreshold <= 0 {
+		return fmt.Errorf("标记阈值必须大于0")
+	}
+	if s.RejectThreshold < 0 || (s.RejectThreshold > 0 && s.RejectThreshold < s.TagThreshold) {
+		return fmt.Errorf("拒收阈值必须为0（不拒收）或不小于标记阈值")
+	}
+	names := make(map[string]bool)
+	for _, rule := range s.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("规则名称不能为空")
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("规则名称重复: %s", rule.Name)
+		}
+		names[rule.Name] = true
+	}
+	return nil
+}
+
+// newRule 根据规则类型创建规则
+func newRule(cfg *RuleConfig, resolver dns.Resolver) (Rule, error) {
+	switch cfg.Type {
+	case "header":
+		if cfg.Header == "" {
+			return nil, fmt.Errorf("规则 %s 缺少 header", cfg.Name)
+		}
+		pattern, err := regexp.Compile(cfg.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("规则 %s 的正则表达式无效: %w", cfg.Name, err)
+		}
+		return &headerRule{header: textproto.CanonicalMIMEHeaderKey(cfg.Header), pattern: pattern}, nil
+	case "body":
+		if len(cfg.Keywords) == 0 {
+			return nil, fmt.Errorf("规则 %s 缺少 keywords", cfg.Name)
+		}
+		keywords := make([]string, 0, len(cfg.Keywords))
+		for _, k := range cfg.Keywords {
+			keywords = append(keywords, strings.ToLower(k))
+		}
+		minMatches := cfg.MinMatches
+		if minMatches <= 0 {
+			minMatches = 1
+		}
+		return &bodyRule{keywords: keywords, minMatches: minMatches}, nil
+	case "urls":
+		if cfg.MaxURLs < 0 {
+			return nil, fmt.Errorf("规则 %s 的 max_urls 不能为负数", cfg.Name)
+		}
+		return &urlRule{max: cfg.MaxURLs}, nil
+	case "auth":
+		switch cfg.Check {
+		case "spf", "dkim", "dmarc":
+		default:
+			return nil, fmt.Errorf("规则 %s 的 check 必须为 spf、dkim 或 dmarc", cfg.Name)
+		}
+		if len(cfg.Results) == 0 {
+			return nil, fmt.Errorf("规则 %s 缺少 results", cfg.Name)
+		}
+		results := make(map[mailauth.Result]bool)
+		for _, r := range cfg.Results {
+			results[mailauth.Result(strings.ToLower(r))] = true
+		}
+		return &authRule{check: cfg.Check, results: results}, nil
+	case "dnsbl":
+		if len(cfg.Zones) == 0 {
+			return nil, fmt.Errorf("规则 %s 缺少 zones", cfg.Name)
+		}
+		return &dnsblRule{resolver: resolver, zones: cfg.Zones}, nil
+	default:
+		return nil, fmt.Errorf("规则 %s 的类型不支持: %s", cfg.Name, cfg.Type)
+	}
+}
+
+// headerRule 邮件头正则匹配
+type headerRule struct {
+	header  string
+	pattern *regexp.Regexp
+}
+
+func (r *headerRule) Check(ctx context.Context, m *Message) (bool, string, error) {
+	values := m.Header[r.header]
+	if len(values) == 0 {
+		values = []string{""}
+	}
+	decoder := new(mime.WordDecoder)
+	for _, value := range values {
+		if decoded, err := decoder.DecodeHeader(value); err == nil {
+			value = decoded
+		}
+		if r.pattern.MatchString(value) {
+			return true, fmt.Sprintf("%s 匹配 %s", r.header, r.pattern), nil
+		}
+	}
+	return false, "", nil
+}
+
+// bodyRule 正文关键词
+type bodyRule struct {
+	keywords   []string
+	minMatches int
+}
+
+func (r *bodyRule) Check(ctx context.Context, m *Message) (bool, string, error) {
+	body := strings.ToLower(m.Text + "\n" + m.HTML)
+	var matched []string
+	for _, k := range r.keywords {
+		if strings.Contains(body, k) {
+			matched = append(matched, k)
+		}
+	}
+	if len(matched) < r.minMatches {
+		return false, "", nil
+	}
+	return true, "包含关键词: " + strings.Join(matched, ", "), nil
+}
+
+// urlPattern 正文中的 http/https 链接
+var urlPattern = regexp.MustCompile(`(?i)https?://[^\s"'<>()]+`)
+
+// urlRule 链接数量
+type urlRule struct {
+	max int
+}
+
+func (r *urlRule) Check(ctx context.Context, m *Message) (bool, string, error) {
+	urls := make(map[string]bool)
+	for _, u := range urlPattern.FindAllString(m.Text+"\n"+m.HTML, -1) {
+		urls[strings.ToLower(u)] = true
+	}
+	if len(urls) <= r.max {
+		return false, "", nil
+	}
+	return true, fmt.Sprintf("包含 %d 个不同的链接", len(urls)), nil
+}
+
+// authRule 来源验证结果
+type authRule struct {
+	check   string
+	results map[mailauth.Result]bool
+}
+
+func (r *authRule) Check(ctx context.Context, m *Message) (bool, string, error) {
+	if m.Auth == nil {
+		return false, "", nil
+	}
+
+	var result mailauth.Result
+	switch r.check {
+	case "spf":
+		result = m.Auth.SPF.Result
+	case "dmarc":
+		result = m.Auth.DMARC.Result
+	case "dkim":
+		result = dkimResult(m.Auth.DKIM)
+	}
+	if !r.results[result] {
+		return false, "", nil
+	}
+	return true, fmt.Sprintf("%s=%s", r.check, result), nil
+}
+
+// dkimResult 多个签名中有一个通过即为通过，否则取第一个签名的结果
+func dkimResult(results []mailauth.DKIMResult) mailauth.Result {
+	if len(results) == 0 {
+		return mailauth.ResultNone
+	}
+	for _, r := range results {
+		if r.Result == mailauth.ResultPass {
+			return mailauth.ResultPass
+		}
+	}
+	return results[0].Result
+}
+
+// dnsblRule DNS黑名单查询
+type dnsblRule struct {
+	resolver dns.Resolver
+	zones    []string
+}
+
+func (r *dnsblRule) Check(ctx context.Context, m *Message) (bool, string, error) {
+	ip := m.RemoteIP
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() {
+		return false, "", nil
+	}
+
+	var lookupErr error
+	for _, zone := range r.zones {
+		addrs, err := r.resolver.LookupIP(ctx, "ip4", reverseIP(ip)+"."+strings.TrimSuffix(zone, "."))
+		if err != nil {
+			var dnsErr *net.DNSError
+			if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+				lookupErr = fmt.Errorf("查询 %s 失败: %w", zone, err)
+			}
+			continue
+		}
+		for _, addr := range addrs {
+			// 收录结果均在 127.0.0.0/8 内，127.255.255.0/24 为黑名单返回的错误码（如查询被拒绝）
+			if v4 := addr.To4(); v4 != nil && v4[0] == 127 && !(v4[1] == 255 && v4[2] == 255) {
+				return true, fmt.Sprintf("%s 被 %s 收录（%s）", ip, zone, addr), nil
+			}
+		}
+	}
+	return false, "", lookupErr
+}
+
+// reverseIP 按 DNSBL 查询格式反转IP地址：IPv4 按字节，IPv6 按半字节
+func reverseIP(ip net.IP) string {
+	if v4 := ip.To4(); v4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d", v4[3], v4[2], v4[1], v4[0])
+	}
+	const hexDigits = "0123456789abcdef"
+	ip = ip.To16()
+	parts := make([]string, 0, 32)
+	for i := len(ip) - 1; i >= 0; i-- {
+		parts = append(parts, string(hexDigits[ip[i]&0x0f]), string(hexDigits[ip[i]>>4]))
+	}
+	return strings.Join(parts, ".")
+}
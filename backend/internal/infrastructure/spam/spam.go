@@ -0,0 +1,128 @@
+// Package spam 收信垃圾邮件评分：按规则文件逐条检查邮件并累计得分，超过阈值时标记或拒收
+package spam
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/mail"
+	"time"
+
+	"temp-mailbox-service/internal/infrastructure/dns"
+	"temp-mailbox-service/internal/infrastructure/mailauth"
+)
+
+// 评分后的处理动作
+const (
+	ActionNone   = "none"
+	ActionTag    = "tag"
+	ActionReject = "reject"
+)
+
+// defaultTimeout 未配置时单封邮件评分的总时限
+const defaultTimeout = 10 * time.Second
+
+// Message 待评分的邮件
+type Message struct {
+	RemoteIP net.IP
+	Helo     string
+	MailFrom string
+	Header   mail.Header
+	Text     string
+	HTML     string
+	Auth     *mailauth.Results // 未进行来源验证时为 nil
+}
+
+// Rule 评分规则，命中时返回 true 和命中说明
+type Rule interface {
+	Check(ctx context.Context, m *Message) (bool, string, error)
+}
+
+// Hit 命中的规则
+type Hit struct {
+	Rule   string  `json:"rule"`
+	Score  float64 `json:"score"`
+	Detail string  `json:"detail,omitempty"`
+}
+
+// Result 评分结果
+type Result struct {
+	Score     float64   `json:"score"`
+	Action    string    `json:"action"`
+	Hits      []Hit     `json:"hits"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// scoredRule 带名称和分值的规则
+type scoredRule struct {
+	name  string
+	score float64
+	rule  Rule
+}
+
+// Engine 垃圾邮件评分引擎
+type Engine struct {
+	rules           []scoredRule
+	tagThreshold    float64
+	rejectThreshold float64
+	timeout         time.Duration
+}
+
+// NewEngine 根据规则集创建评分引擎，DNSBL 规则通过 resolver 查询
+func NewEngine(set *RuleSet, resolver dns.Resolver, timeout time.Duration) (*Engine, error) {
+	if err := set.validate(); err != nil {
+		return nil, err
+	}
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	e := &Engine{
+		tagThreshold:    set.TagThreshold,
+		rejectThreshold: set.RejectThreshold,
+		timeout:         timeout,
+	}
+	for i := range set.Rules {
+		cfg := &set.Rules[i]
+		rule, err := newRule(cfg, resolver)
+		if err != nil {
+			return nil, err
+		}
+		e.AddRule(cfg.Name, cfg.Score, rule)
+	}
+	return e, nil
+}
+
+// AddRule 添加自定义规则，命中时累加 score 分
+func (e *Engine) AddRule(name string, score float64, rule Rule) {
+	e.rules = append(e.rules, scoredRule{name: name, score: score, rule: rule})
+}
+
+// Evaluate 依次执行所有规则并根据总分决定处理动作，执行出错的规则视为未命中
+func (e *Engine) Evaluate(ctx context.Context, m *Message) *Result {
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	result := &Result{Hits: []Hit{}, CheckedAt: time.Now()}
+	for _, r := range e.rules {
+		hit, detail, err := r.rule.Check(ctx, m)
+		if err != nil {
+			log.Printf("垃圾邮件规则 %s 执行失败: %v", r.name, err)
+			continue
+		}
+		if hit {
+			result.Score += r.score
+			result.Hits = append(result.Hits, Hit{Rule: r.name, Score: r.score, Detail: detail})
+		}
+	}
+
+	switch {
+	case e.rejectThreshold > 0 && result.Score >= e.rejectThreshold:
+		result.Action = ActionReject
+	case result.Score >= e.tagThreshold:
+		result.Action = ActionTag
+	default:
+		result.Action = ActionNone
+	}
+	return result
+}
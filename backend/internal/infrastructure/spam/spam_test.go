@@ -0,0 +1,266 @@
+package spam
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"temp-mailbox-service/internal/infrastructure/dns"
+	"temp-mailbox-service/internal/infrastructure/mailauth"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingResolver 总是返回临时错误的解析器
+type failingResolver struct{}
+
+func (failingResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	return nil, errors.New("i/o timeout")
+}
+
+func (failingResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	return nil, errors.New("i/o timeout")
+}
+
+func (failingResolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	return nil, errors.New("i/o timeout")
+}
+
+func testMessage() *Message {
+	return &Message{
+		RemoteIP: net.ParseIP("192.0.2.99"),
+		Helo:     "client.example",
+		MailFrom: "promo@spam.example",
+		Header: mail.Header{
+			"Subject": {"=?utf-8?q?FREE_MONEY_NOW?="},
+			"From":    {"promo@spam.example"},
+		},
+		Text: "Win a free cruise! Visit https://spam.example/a and https://spam.example/b",
+		HTML: `<a href="https://spam.example/a">click</a> <a href="https://spam.example/c">here</a>`,
+		Auth: &mailauth.Results{
+			SPF:   mailauth.SPFResult{Result: mailauth.ResultSoftFail},
+			DKIM:  []mailauth.DKIMResult{{Result: mailauth.ResultFail}, {Result: mailauth.ResultPass}},
+			DMARC: mailauth.DMARCResult{Result: mailauth.ResultFail},
+		},
+	}
+}
+
+func newTestResolver() *dns.StaticResolver {
+	r := dns.NewStaticResolver()
+	r.IP["99.2.0.192.bl.example"] = []net.IP{net.ParseIP("127.0.0.2")}
+	r.IP["99.2.0.192.blocked.example"] = []net.IP{net.ParseIP("127.255.255.254")}
+	return r
+}
+
+func checkRule(t *testing.T, cfg RuleConfig, m *Message) (bool, string, error) {
+	t.Helper()
+	rule, err := newRule(&cfg, newTestResolver())
+	require.NoError(t, err)
+	return rule.Check(context.Background(), m)
+}
+
+func TestHeaderRule(t *testing.T) {
+	hit, detail, err := checkRule(t, RuleConfig{Name: "caps", Type: "header", Header: "subject", Pattern: `^[^a-z]+$`}, testMessage())
+	require.NoError(t, err)
+	assert.True(t, hit, "应该匹配解码后的主题")
+	assert.Contains(t, detail, "Subject")
+
+	hit, _, _ = checkRule(t, RuleConfig{Name: "no-date", Type: "header", Header: "Date", Pattern: `^$`}, testMessage())
+	assert.True(t, hit, "邮件头不存在时应该匹配空字符串")
+
+	hit, _, _ = checkRule(t, RuleConfig{Name: "from", Type: "header", Header: "From", Pattern: `@example\.com$`}, testMessage())
+	assert.False(t, hit)
+}
+
+func TestBodyRule(t *testing.T) {
+	hit, detail, err := checkRule(t, RuleConfig{Name: "kw", Type: "body", Keywords: []string{"FREE", "cruise", "casino"}, MinMatches: 2}, testMessage())
+	require.NoError(t, err)
+	assert.True(t, hit)
+	assert.Equal(t, "包含关键词: free, cruise", detail)
+
+	hit, _, _ = checkRule(t, RuleConfig{Name: "kw", Type: "body", Keywords: []string{"casino", "cruise"}, MinMatches: 2}, testMessage())
+	assert.False(t, hit)
+}
+
+func TestURLRule(t *testing.T) {
+	hit, detail, err := checkRule(t, RuleConfig{Name: "urls", Type: "urls", MaxURLs: 2}, testMessage())
+	require.NoError(t, err)
+	assert.True(t, hit)
+	assert.Equal(t, "包含 3 个不同的链接", detail)
+
+	hit, _, _ = checkRule(t, RuleConfig{Name: "urls", Type: "urls", MaxURLs: 3}, testMessage())
+	assert.False(t, hit)
+}
+
+func TestAuthRule(t *testing.T) {
+	tests := []struct {
+		check   string
+		results []string
+		want    bool
+	}{
+		{"spf", []string{"fail", "softfail"}, true},
+		{"spf", []string{"fail"}, false},
+		{"dkim", []string{"fail", "none"}, false}, // 有一个签名通过
+		{"dmarc", []string{"FAIL"}, true},
+	}
+	for _, tt := range tests {
+		hit, _, err := checkRule(t, RuleConfig{Name: "auth", Type: "auth", Check: tt.check, Results: tt.results}, testMessage())
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, hit, "%s %v", tt.check, tt.results)
+	}
+
+	m := testMessage()
+	m.Auth = nil
+	hit, _, _ := checkRule(t, RuleConfig{Name: "auth", Type: "auth", Check: "dmarc", Results: []string{"none"}}, m)
+	assert.False(t, hit, "未进行来源验证时不应该命中")
+}
+
+func TestDNSBLRule(t *testing.T) {
+	hit, detail, err := checkRule(t, RuleConfig{Name: "bl", Type: "dnsbl", Zones: []string{"clean.example", "bl.example."}}, testMessage())
+	require.NoError(t, err)
+	assert.True(t, hit)
+	assert.Contains(t, detail, "bl.example")
+
+	hit, _, err = checkRule(t, RuleConfig{Name: "bl", Type: "dnsbl", Zones: []string{"blocked.example"}}, testMessage())
+	assert.NoError(t, err)
+	assert.False(t, hit, "黑名单返回的错误码不应该视为收录")
+
+	m := testMessage()
+	m.RemoteIP = net.ParseIP("10.0.0.1")
+	rule := &dnsblRule{resolver: failingResolver{}, zones: []string{"bl.example"}}
+	hit, _, err = rule.Check(context.Background(), m)
+	assert.NoError(t, err, "内网地址不应该查询黑名单")
+	assert.False(t, hit)
+
+	_, _, err = rule.Check(context.Background(), testMessage())
+	assert.Error(t, err, "查询失败时应该返回错误")
+}
+
+func TestReverseIP(t *testing.T) {
+	assert.Equal(t, "1.2.0.192", reverseIP(net.ParseIP("192.0.2.1")))
+	assert.Equal(t, "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2", reverseIP(net.ParseIP("2001:db8::1")))
+}
+
+func TestNewRule_Invalid(t *testing.T) {
+	invalid := []RuleConfig{
+		{Name: "a", Type: "header", Pattern: "x"},
+		{Name: "b", Type: "header", Header: "Subject", Pattern: "("},
+		{Name: "c", Type: "body"},
+		{Name: "d", Type: "urls", MaxURLs: -1},
+		{Name: "e", Type: "auth", Check: "arc", Results: []string{"fail"}},
+		{Name: "f", Type: "auth", Check: "spf"},
+		{Name: "g", Type: "dnsbl"},
+		{Name: "h", Type: "bayes"},
+	}
+	for _, cfg := range invalid {
+		_, err := newRule(&cfg, nil)
+		assert.Error(t, err, cfg.Name)
+	}
+}
+
+func TestEngine_Evaluate(t *testing.T) {
+	set := &RuleSet{
+		TagThreshold:    3,
+		RejectThreshold: 8,
+		Rules: []RuleConfig{
+			{Name: "dmarc_fail", Type: "auth", Score: 2, Check: "dmarc", Results: []string{"fail"}},
+			{Name: "free", Type: "body", Score: 1.5, Keywords: []string{"free"}},
+			{Name: "listed", Type: "dnsbl", Score: 5, Zones: []string{"bl.example"}},
+		},
+	}
+	engine, err := NewEngine(set, newTestResolver(), time.Second)
+	require.NoError(t, err)
+
+	result := engine.Evaluate(context.Background(), testMessage())
+	assert.Equal(t, ActionReject, result.Action)
+	assert.Equal(t, 8.5, result.Score)
+	require.Len(t, result.Hits, 3)
+	assert.Equal(t, "dmarc_fail", result.Hits[0].Rule)
+	assert.Equal(t, 2.0, result.Hits[0].Score)
+
+	m := testMessage()
+	m.RemoteIP = net.ParseIP("198.51.100.1")
+	result = engine.Evaluate(context.Background(), m)
+	assert.Equal(t, ActionTag, result.Action)
+	assert.Equal(t, 3.5, result.Score)
+
+	m.Auth = nil
+	result = engine.Evaluate(context.Background(), m)
+	assert.Equal(t, ActionNone, result.Action)
+	assert.Len(t, result.Hits, 1)
+}
+
+func TestEngine_RuleErrorIsSkipped(t *testing.T) {
+	set := &RuleSet{
+		TagThreshold: 1,
+		Rules:        []RuleConfig{{Name: "listed", Type: "dnsbl", Score: 5, Zones: []string{"bl.example"}}},
+	}
+	engine, err := NewEngine(set, failingResolver{}, time.Second)
+	require.NoError(t, err)
+
+	engine.AddRule("custom", 1, ruleFunc(func(ctx context.Context, m *Message) (bool, string, error) {
+		return m.Helo == "client.example", "自定义规则", nil
+	}))
+
+	result := engine.Evaluate(context.Background(), testMessage())
+	assert.Equal(t, ActionTag, result.Action, "拒收阈值为0时不应该拒收")
+	require.Len(t, result.Hits, 1)
+	assert.Equal(t, "custom", result.Hits[0].Rule)
+}
+
+// ruleFunc 以函数实现 Rule 接口
+type ruleFunc func(ctx context.Context, m *Message) (bool, string, error)
+
+func (f ruleFunc) Check(ctx context.Context, m *Message) (bool, string, error) {
+	return f(ctx, m)
+}
+
+func TestLoadRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spam.yaml")
+	content := `tag_threshold: 4
+reject_threshold: 9
+rules:
+  - name: subject_caps
+    type: header
+    score: 1.5
+    header: Subject
+    pattern: '^[^a-z]+$'
+  - name: spamhaus
+    type: dnsbl
+    score: 6
+    zones: [zen.spamhaus.org]
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	set, err := LoadRules(path)
+	require.NoError(t, err)
+	assert.Equal(t, 4.0, set.TagThreshold)
+	assert.Equal(t, 9.0, set.RejectThreshold)
+	require.Len(t, set.Rules, 2)
+	assert.Equal(t, "Subject", set.Rules[0].Header)
+	assert.Equal(t, []string{"zen.spamhaus.org"}, set.Rules[1].Zones)
+
+	_, err = NewEngine(set, dns.NewStaticResolver(), 0)
+	assert.NoError(t, err)
+
+	_, err = LoadRules(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestRuleSet_Validate(t *testing.T) {
+	invalid := []RuleSet{
+		{TagThreshold: 0},
+		{TagThreshold: 5, RejectThreshold: 3},
+		{TagThreshold: 5, Rules: []RuleConfig{{Type: "urls"}}},
+		{TagThreshold: 5, Rules: []RuleConfig{{Name: "a", Type: "urls"}, {Name: "a", Type: "urls"}}},
+	}
+	for i := range invalid {
+		assert.Error(t, invalid[i].validate(), "%+v", invalid[i])
+	}
+}
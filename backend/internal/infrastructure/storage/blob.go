@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"temp-mailbox-service/internal/infrastructure/config"
+)
+
+// ErrNotFound 对象不存在
+var ErrNotFound = errors.New("storage: 对象不存在")
+
+// BlobStore 二进制对象存储接口（用于邮件附件等大对象）
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	Exists(ctx context.Context, key string) (bool, error)
+	List(ctx context.Context, prefix string) ([]string, error)
+
+	// Touch 将已存在对象的最后写入时间更新为当前时间，返回对象是否存在
+	Touch(ctx context.Context, key string) (bool, error)
+	// DeleteIfOlder 对象最后写入时间早于before时删除，返回是否已删除；
+	// 与Put、Touch互斥，刚写入或刚被复用的对象不会被删除
+	DeleteIfOlder(ctx context.Context, key string, before time.Time) (bool, error)
+}
+
+// NewBlobStore 根据配置创建对象存储实例
+func NewBlobStore(cfg *config.StorageConfig) (BlobStore, error) {
+	switch cfg.Driver {
+	case "local":
+		return NewLocalStore(cfg.Path)
+	case "memory":
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("不支持的存储驱动: %s", cfg.Driver)
+	}
+}
+
+// validateKey 校验对象键，禁止绝对路径和路径穿越
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("无效的对象键: %q", key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("无效的对象键: %q", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testBlobStore 对任意BlobStore实现执行通用测试
+func testBlobStore(t *testing.T, store BlobStore) {
+	ctx := context.Background()
+
+	require.NoError(t, store.Put(ctx, "attachments/ab/abcdef", []byte("hello")))
+	require.NoError(t, store.Put(ctx, "other/key", []byte("world")))
+
+	exists, err := store.Exists(ctx, "attachments/ab/abcdef")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	reader, err := store.Get(ctx, "attachments/ab/abcdef")
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	keys, err := store.List(ctx, "attachments/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"attachments/ab/abcdef"}, keys)
+
+	require.NoError(t, store.Delete(ctx, "attachments/ab/abcdef"))
+	require.NoError(t, store.Delete(ctx, "attachments/ab/abcdef"), "重复删除不应该报错")
+
+	_, err = store.Get(ctx, "attachments/ab/abcdef")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	touched, err := store.Touch(ctx, "attachments/ab/abcdef")
+	require.NoError(t, err)
+	assert.False(t, touched, "不存在的对象不能更新时间")
+
+	// 最后写入时间不早于before的对象不删除
+	deleted, err := store.DeleteIfOlder(ctx, "other/key", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.False(t, deleted)
+	deleted, err = store.DeleteIfOlder(ctx, "other/key", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	exists, err = store.Exists(ctx, "other/key")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	testBlobStore(t, store)
+}
+
+func TestLocalStore_TouchDelaysDelete(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	store, err := NewLocalStore(root)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put(ctx, "raw/ab/abcdef", []byte("hello")))
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(root, "raw", "ab", "abcdef"), old, old))
+
+	touched, err := store.Touch(ctx, "raw/ab/abcdef")
+	require.NoError(t, err)
+	assert.True(t, touched)
+
+	deleted, err := store.DeleteIfOlder(ctx, "raw/ab/abcdef", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.False(t, deleted, "刚被复用的对象不应被删除")
+}
+
+func TestMemoryStore(t *testing.T) {
+	testBlobStore(t, NewMemoryStore())
+}
+
+func TestValidateKey(t *testing.T) {
+	tests := []struct {
+		key     string
+		wantErr bool
+	}{
+		{key: "attachments/ab/abcdef", wantErr: false},
+		{key: "", wantErr: true},
+		{key: "/etc/passwd", wantErr: true},
+		{key: "../secret", wantErr: true},
+		{key: "a/../../b", wantErr: true},
+		{key: "a//b", wantErr: true},
+		{key: `a\b`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			err := validateKey(tt.key)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryStore 基于内存的对象存储（用于测试和开发环境）
+type memoryStore struct {
+	mu      sync.RWMutex
+	objects map[string]*memoryObject
+}
+
+// memoryObject 内存中的对象及其最后写入时间
+type memoryObject struct {
+	data      []byte
+	updatedAt time.Time
+}
+
+// NewMemoryStore 创建内存对象存储
+func NewMemoryStore() BlobStore {
+	return &memoryStore{
+		objects: make(map[string]*memoryObject),
+	}
+}
+
+// Put 写入对象
+func (s *memoryStore) Put(ctx context.Context, key string, data []byte) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = &memoryObject{data: append([]byte(nil), data...), updatedAt: time.Now()}
+	return nil
+}
+
+// Get 读取对象
+func (s *memoryStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	obj, ok := s.objects[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(obj.data)), nil
+}
+
+// Delete 删除对象
+func (s *memoryStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, key)
+	return nil
+}
+
+// Exists 检查对象是否存在
+func (s *memoryStore) Exists(ctx context.Context, key string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.objects[key]
+	return ok, nil
+}
+
+// Touch 更新对象的最后写入时间
+func (s *memoryStore) Touch(ctx context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.objects[key]
+	if ok {
+		obj.updatedAt = time.Now()
+	}
+	return ok, nil
+}
+
+// DeleteIfOlder 最后写入时间早于before时删除
+func (s *memoryStore) DeleteIfOlder(ctx context.Context, key string, before time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.objects[key]
+	if !ok || !obj.updatedAt.Before(before) {
+		return false, nil
+	}
+	delete(s.objects, key)
+	return true, nil
+}
+
+// List 列出指定前缀下的所有对象键
+func (s *memoryStore) List(ctx context.Context, prefix string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var keys []string
+	for key := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}